@@ -1,11 +1,18 @@
 package cmd
 
 import (
+	"context"
+	"fmt"
 	"log"
 	"net/http"
+	"strings"
+	"time"
 
 	"basic-service/interface/rest"
 	"basic-service/interface/sql"
+	"basic-service/pkg/jwtkey"
+	"basic-service/pkg/mailer"
+	"basic-service/pkg/phone"
 	"basic-service/usecase"
 
 	"github.com/spf13/cobra"
@@ -21,15 +28,128 @@ var appCmd = cobra.Command{
 		guestManager := sql.NewGuestManager(db)
 		publicTemplate := sql.NewPublicTemplateRepository(db)
 		userTemplate := sql.NewUserTemplateRepository(db)
+		templateMemberRepository := sql.NewTemplateMemberRepository(db)
 		userManager := sql.NewUserRepository(db)
+		tokenRepository := sql.NewTokenRepository(db)
+		userTokenRepository := sql.NewUserTokenRepository(db)
+		apiTokenRepository := sql.NewAPITokenRepository(db)
+		totpRepository := sql.NewTOTPRepository(db)
+		loginAttemptRepository := sql.NewLoginAttemptRepository(db)
+		auditor := usecase.NewAuditor(sql.NewAuditRepository(db))
+
+		keyOptions := make([]jwtkey.KeyOption, 0, len(systemConfig.Auth.Keys))
+		for _, v := range systemConfig.Auth.Keys {
+			keyOptions = append(keyOptions, jwtkey.KeyOption{
+				ID:             v.ID,
+				Algorithm:      v.Algorithm,
+				Secret:         v.Secret,
+				PrivateKeyFile: v.PrivateKeyFile,
+				PublicKeyFile:  v.PublicKeyFile,
+			})
+		}
+
+		keySet, err := jwtkey.Load(systemConfig.Auth.ActiveKey, keyOptions)
+		if err != nil {
+			return err
+		}
+
+		var mail mailer.Mailer
+		switch systemConfig.Mail.Driver {
+		case "smtp":
+			mail = mailer.NewSMTP(mailer.SMTPOption{
+				Host:     systemConfig.Mail.Host,
+				Port:     systemConfig.Mail.Port,
+				Username: systemConfig.Mail.Username,
+				Password: systemConfig.Mail.Password,
+				From:     systemConfig.Mail.From,
+			})
+		case "file", "":
+			dir := systemConfig.Mail.Dir
+			if dir == "" {
+				dir = "./tmp/mail"
+			}
+			mail = mailer.NewFile(dir, systemConfig.Mail.From)
+		default:
+			return fmt.Errorf("unknown mail driver: %s", systemConfig.Mail.Driver)
+		}
+
+		loginGuard, err := usecase.NewLoginGuard(loginAttemptRepository, auditor, mainOtel)
+		if err != nil {
+			return err
+		}
+		lockout := systemConfig.Auth.Lockout
+		if lockout.MaxFailures > 0 {
+			loginGuard.MaxEmailFailures = lockout.MaxFailures
+		}
+		if lockout.MaxIPFailures > 0 {
+			loginGuard.MaxIPFailures = lockout.MaxIPFailures
+		}
+		if lockout.Duration > 0 {
+			loginGuard.LockoutDuration = lockout.Duration
+		}
+
+		auth := usecase.NewAuth(userManager, tokenRepository, userTokenRepository, apiTokenRepository, totpRepository, keySet, mail, loginGuard, auditor)
+		auth.AppURL = strings.TrimSuffix(systemConfig.App.PublicURL, "/")
+		if systemConfig.Auth.AccessTokenTTL > 0 {
+			auth.AccessTokenTTL = systemConfig.Auth.AccessTokenTTL
+		}
+		if systemConfig.Auth.RefreshTokenTTL > 0 {
+			auth.RefreshTokenTTL = systemConfig.Auth.RefreshTokenTTL
+		}
+		if systemConfig.Auth.ImpersonationTTL > 0 {
+			auth.ImpersonationTTL = systemConfig.Auth.ImpersonationTTL
+		}
+		if systemConfig.Auth.TOTPIssuer != "" {
+			auth.TOTPIssuer = systemConfig.Auth.TOTPIssuer
+		}
+		auth.RequireAdminTOTP = systemConfig.Auth.RequireAdminTOTP
+		oidcOptions := make([]usecase.OIDCProviderOption, 0, len(systemConfig.OIDC.Providers))
+		for _, v := range systemConfig.OIDC.Providers {
+			oidcOptions = append(oidcOptions, usecase.OIDCProviderOption{
+				Name:         v.Name,
+				Issuer:       v.Issuer,
+				ClientID:     v.ClientID,
+				ClientSecret: v.ClientSecret,
+				RedirectURL:  v.RedirectURL,
+				Scopes:       v.Scopes,
+			})
+		}
+
+		oidcRepository := sql.NewOIDCRepository(db)
+		oidcLogin, err := usecase.NewOIDCLogin(auth, oidcRepository, oidcOptions)
+		if err != nil {
+			return err
+		}
 
-		auth := usecase.NewAuth(userManager, "secret")
 		publicTemplateUseCase := usecase.NewPublicTemplateUseCase(publicTemplate)
-		userTemplateCase := usecase.NewUserTemplate(userTemplate)
-		guestUsecase := usecase.NewGuestUsecase(guestManager)
-		userUsecase := usecase.NewUserUsecase(userManager)
+		userTemplateCase := usecase.NewUserTemplate(userTemplate, userManager, templateMemberRepository)
+		templateMembers := usecase.NewTemplateMembers(templateMemberRepository, userTemplate, userManager, mail)
+		templateMembers.AppURL = auth.AppURL
+		guestUsecase := usecase.NewGuestUsecase(guestManager, templateMemberRepository, userTemplate, sql.NewRSVPQuestionRepository(db))
+		// a generated key would break every link already sent on restart
+		guestUsecase.InvitationKey = []byte(systemConfig.Guest.InvitationSecret)
+		if len(guestUsecase.InvitationKey) < 32 {
+			return fmt.Errorf("guest.invitation_secret is required and must be at least 32 bytes")
+		}
+		guestUsecase.PhoneCountry = systemConfig.Guest.PhoneCountry
+		if guestUsecase.PhoneCountry != "" && !phone.Known(guestUsecase.PhoneCountry) {
+			return fmt.Errorf("guest.phone_country %q is not a supported country", guestUsecase.PhoneCountry)
+		}
+		account := usecase.NewAccount(auth, sql.NewAccountRepository(db), userTemplate, templateMemberRepository, guestManager)
+		if systemConfig.Account.DeletionGracePeriod > 0 {
+			account.GracePeriod = systemConfig.Account.DeletionGracePeriod
+		}
+		userUsecase := usecase.NewUserUsecase(userManager, tokenRepository, userTemplate, guestManager, loginGuard, auditor)
+
+		guestbook := usecase.NewGuestbook(guestManager, userTemplate, templateMemberRepository)
 
-		r := rest.SetupRouter(auth, publicTemplateUseCase, userTemplateCase, guestUsecase, userUsecase)
+		r := rest.SetupRouter(auth, oidcLogin, publicTemplateUseCase, userTemplateCase, templateMembers, account, guestUsecase, guestbook, userUsecase)
+
+		purgeInterval := systemConfig.Account.PurgeInterval
+		if purgeInterval <= 0 {
+			purgeInterval = time.Hour
+		}
+		go purgeExpired(account, tokenRepository, oidcRepository, purgeInterval)
 
 		log.Println("Server starting on :8085")
 		if err := http.ListenAndServe(":8085", r); err != nil {
@@ -40,6 +160,35 @@ var appCmd = cobra.Command{
 	},
 }
 
+// purgeExpired deletes the accounts whose deletion grace period is over, the
+// expired refresh tokens and revocations and the social logins that were never
+// finished, once at start and then every interval
+func purgeExpired(account *usecase.Account, tokens *sql.TokenRepository, oidcStates *sql.OIDCRepository, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		ctx := context.Background()
+
+		purged, err := account.PurgeDue(ctx, time.Now())
+		if err != nil {
+			log.Printf("account purge failed: %v", err)
+		} else if purged > 0 {
+			log.Printf("account purge: %d account(s) deleted", purged)
+		}
+
+		if err := tokens.DeleteExpired(ctx); err != nil {
+			log.Printf("token purge failed: %v", err)
+		}
+
+		if err := oidcStates.DeleteExpiredStates(ctx); err != nil {
+			log.Printf("oidc state purge failed: %v", err)
+		}
+
+		<-ticker.C
+	}
+}
+
 func init() {
 	rootCmd.AddCommand(&appCmd)
 }
@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"log"
+	"net/http"
+
+	"basic-service/pkg/mockidp"
+
+	"github.com/spf13/cobra"
+)
+
+var mockIDPOption mockidp.Option
+var mockIDPAddr string
+
+// MockIDP runs a local OpenID Connect provider to try the OIDC login without
+// a real identity provider, see pkg/mockidp.
+var MockIDP = cobra.Command{
+	Use:   "mock-idp",
+	Short: "Run a local OpenID Connect provider for development",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		server, err := mockidp.New(mockIDPOption)
+		if err != nil {
+			return err
+		}
+
+		log.Printf("Mock identity provider %s listening on %s", mockIDPOption.Issuer, mockIDPAddr)
+		return http.ListenAndServe(mockIDPAddr, server.Handler())
+	},
+}
+
+func init() {
+	MockIDP.Flags().StringVar(&mockIDPAddr, "addr", ":9096", "listen address")
+	MockIDP.Flags().StringVar(&mockIDPOption.Issuer, "issuer", "http://localhost:9096", "issuer URL, must match the address clients use")
+	MockIDP.Flags().StringVar(&mockIDPOption.ClientID, "client-id", "undangan", "accepted client id")
+	MockIDP.Flags().StringVar(&mockIDPOption.ClientSecret, "client-secret", "secret", "accepted client secret")
+	MockIDP.Flags().StringVar(&mockIDPOption.Email, "email", "guest@example.com", "email signed in when the request has no login_hint")
+	MockIDP.Flags().StringVar(&mockIDPOption.Name, "name", "", "name claim, defaults to the email local part")
+	MockIDP.Flags().BoolVar(&mockIDPOption.Unverified, "unverified", false, "report email_verified=false")
+
+	rootCmd.AddCommand(&MockIDP)
+}
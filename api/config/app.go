@@ -28,6 +28,83 @@ type RedisConfig struct {
 type AppConfig struct {
 	Timezone string `mapstructure:"timezone"`
 	Proxy    string `mapstructure:"proxy_url"`
+	// PublicURL is the web frontend base URL, used for links sent by email
+	PublicURL string `mapstructure:"public_url"`
+}
+
+// MailConfig ...
+type MailConfig struct {
+	// Driver is "smtp" or "file", file writes .eml files for local development
+	Driver   string `mapstructure:"driver"`
+	From     string `mapstructure:"from"`
+	Host     string `mapstructure:"host"`
+	Port     int    `mapstructure:"port"`
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+	Dir      string `mapstructure:"dir"`
+}
+
+// JWTKeyConfig ...
+type JWTKeyConfig struct {
+	ID             string `mapstructure:"kid"`
+	Algorithm      string `mapstructure:"alg"`
+	Secret         string `mapstructure:"secret"`
+	PrivateKeyFile string `mapstructure:"private_key_file"`
+	PublicKeyFile  string `mapstructure:"public_key_file"`
+}
+
+// AuthConfig ...
+type AuthConfig struct {
+	ActiveKey       string         `mapstructure:"active_kid"`
+	Keys            []JWTKeyConfig `mapstructure:"keys"`
+	AccessTokenTTL  time.Duration  `mapstructure:"access_token_ttl"`
+	RefreshTokenTTL time.Duration  `mapstructure:"refresh_token_ttl"`
+	Lockout         LockoutConfig  `mapstructure:"lockout"`
+	// TOTPIssuer is the name authenticator apps show next to the account
+	TOTPIssuer       string `mapstructure:"totp_issuer"`
+	RequireAdminTOTP bool   `mapstructure:"require_admin_2fa"`
+	// ImpersonationTTL is the longest an admin impersonation token can live
+	ImpersonationTTL time.Duration `mapstructure:"impersonation_ttl"`
+}
+
+// LockoutConfig ...
+type LockoutConfig struct {
+	MaxFailures   int           `mapstructure:"max_failures"`
+	MaxIPFailures int           `mapstructure:"max_ip_failures"`
+	Duration      time.Duration `mapstructure:"duration"`
+}
+
+// OIDCProviderConfig ...
+type OIDCProviderConfig struct {
+	Name         string   `mapstructure:"name"`
+	Issuer       string   `mapstructure:"issuer"`
+	ClientID     string   `mapstructure:"client_id"`
+	ClientSecret string   `mapstructure:"client_secret"`
+	RedirectURL  string   `mapstructure:"redirect_url"`
+	Scopes       []string `mapstructure:"scopes"`
+}
+
+// OIDCConfig ...
+type OIDCConfig struct {
+	Providers []OIDCProviderConfig `mapstructure:"providers"`
+}
+
+// AccountConfig ...
+type AccountConfig struct {
+	// DeletionGracePeriod is how long a deletion request can be cancelled
+	DeletionGracePeriod time.Duration `mapstructure:"deletion_grace_period"`
+	// PurgeInterval is how often accounts past their grace period and expired
+	// tokens and login records are deleted
+	PurgeInterval time.Duration `mapstructure:"purge_interval"`
+}
+
+// GuestConfig ...
+type GuestConfig struct {
+	// InvitationSecret signs the public invitation links of the guests
+	InvitationSecret string `mapstructure:"invitation_secret"`
+	// PhoneCountry is the ISO 3166 country of phone numbers written without
+	// a country code
+	PhoneCountry string `mapstructure:"phone_country"`
 }
 
 // NatsConfig ...
@@ -37,10 +114,15 @@ type NatsConfig struct {
 
 // Config ...
 type Config struct {
-	Otel     OtelConfig `mapstructure:"otel"`
-	DBSqlite Sqlite     `mapstructure:"sqlite"`
-	Nats     NatsConfig `mapstructure:"nats"`
-	App      AppConfig  `mapstructure:"app"`
+	Otel     OtelConfig    `mapstructure:"otel"`
+	DBSqlite Sqlite        `mapstructure:"sqlite"`
+	Nats     NatsConfig    `mapstructure:"nats"`
+	App      AppConfig     `mapstructure:"app"`
+	Auth     AuthConfig    `mapstructure:"auth"`
+	Mail     MailConfig    `mapstructure:"mail"`
+	OIDC     OIDCConfig    `mapstructure:"oidc"`
+	Account  AccountConfig `mapstructure:"account"`
+	Guest    GuestConfig   `mapstructure:"guest"`
 }
 
 // SetUpTimezone ...
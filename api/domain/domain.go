@@ -16,26 +16,28 @@ type UserClaim struct {
 }
 
 type SafeUser struct {
-	ID        string
-	Email     string
-	Name      string
-	Profile   string
-	Role      RoleType
-	IsActive  bool
-	CreatedAt time.Time
-	UpdatedAt time.Time
+	ID         string
+	Email      string
+	Name       string
+	Profile    string
+	Role       RoleType
+	IsActive   bool
+	VerifiedAt *time.Time
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
 }
 
 type User struct {
-	ID        string
-	Email     string
-	Password  string
-	Name      string
-	Profile   string
-	Role      RoleType
-	IsActive  bool
-	CreatedAt time.Time
-	UpdatedAt time.Time
+	ID         string
+	Email      string
+	Password   string
+	Name       string
+	Profile    string
+	Role       RoleType
+	IsActive   bool
+	VerifiedAt *time.Time
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
 }
 
 type PublicTemplate struct {
@@ -60,9 +62,9 @@ type MessageTemplate struct {
 
 type UserTemplate struct {
 	ID              string
-	UserID          string // reference to User ID
+	UserID          string // reference to the User ID that created it
 	BaseTemplateID  string // reference to PublicTemplate ID
-	State           int
+	State           TemplateState
 	Slug            string
 	URL             string
 	MessageTemplate []MessageTemplate
@@ -71,6 +73,88 @@ type UserTemplate struct {
 	CreatedAt       time.Time
 	UpdatedAt       time.Time
 	ExpireAt        time.Time
+	// Role is the membership of the requesting user, only set when listing
+	Role TemplateRole
+	// GuestbookApproveFirst holds new guestbook messages until a host
+	// approves them
+	GuestbookApproveFirst bool
+	// GuestbookBlockedWords hold the messages that contain one of them
+	GuestbookBlockedWords []string
+	// RSVPDeadline closes the RSVP form before ExpireAt, nil keeps it open
+	RSVPDeadline *time.Time
+}
+
+// Lifecycle returns the state of the template at now, a template that is not
+// archived is expired once ExpireAt has passed.
+func (t UserTemplate) Lifecycle(now time.Time) TemplateState {
+	if t.State != TemplateArchived && !t.ExpireAt.IsZero() && now.After(t.ExpireAt) {
+		return TemplateExpired
+	}
+	return t.State
+}
+
+// RSVPOpen reports whether guests can still answer the invitation at now.
+func (t UserTemplate) RSVPOpen(now time.Time) bool {
+	if t.Lifecycle(now) != TemplatePublished {
+		return false
+	}
+	return t.RSVPDeadline == nil || now.Before(*t.RSVPDeadline)
+}
+
+// TemplateState is the lifecycle of a user template. Guests can open a
+// published invitation or one with RSVP closed, only a published one accepts
+// RSVPs.
+type TemplateState int
+
+const (
+	TemplateDraft TemplateState = iota
+	TemplatePublished
+	TemplateRSVPClosed
+	TemplateArchived
+	// TemplateExpired is never stored, see UserTemplate.Lifecycle
+	TemplateExpired
+)
+
+var templateStateNames = map[TemplateState]string{
+	TemplateDraft:      "draft",
+	TemplatePublished:  "published",
+	TemplateRSVPClosed: "rsvp_closed",
+	TemplateArchived:   "archived",
+	TemplateExpired:    "expired",
+}
+
+// templateTransitions lists the states a template can be moved to from each
+// state, an expired template can only be archived.
+var templateTransitions = map[TemplateState][]TemplateState{
+	TemplateDraft:      {TemplatePublished, TemplateArchived},
+	TemplatePublished:  {TemplateDraft, TemplateRSVPClosed, TemplateArchived},
+	TemplateRSVPClosed: {TemplatePublished, TemplateArchived},
+	TemplateArchived:   {TemplateDraft},
+	TemplateExpired:    {TemplateArchived},
+}
+
+func (s TemplateState) String() string {
+	return templateStateNames[s]
+}
+
+// ParseTemplateState returns the state named name.
+func ParseTemplateState(name string) (TemplateState, bool) {
+	for state, v := range templateStateNames {
+		if v == name {
+			return state, true
+		}
+	}
+	return 0, false
+}
+
+// CanBecome reports whether a template in state s can be moved to next.
+func (s TemplateState) CanBecome(next TemplateState) bool {
+	for _, v := range templateTransitions[s] {
+		if v == next {
+			return true
+		}
+	}
+	return false
 }
 
 type Guest struct {
@@ -84,7 +168,236 @@ type Guest struct {
 	Address        string
 	Message        string
 	Attend         *bool
-	ViewAt         *time.Time
+	// AttendPerson is the headcount confirmed by the guest, at most Person
+	AttendPerson *int
+	// MessageStatus is the moderation state of Message on the guestbook
+	MessageStatus MessageStatus
+	MessagePinned bool
+	// MessageAt is when Message was last written
+	MessageAt *time.Time
+	// Answers maps the id of an RSVP question of the template to the answer
+	Answers   map[string]string
+	ViewAt    *time.Time
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	// InviteNonce is signed into the public invitation link of the guest, nil
+	// when the link was revoked
+	InviteNonce *string
+	// Members is the household of the guest. When there are members, Person
+	// is their number and AttendPerson the number of them who attend.
+	Members []GuestMember
+}
+
+// GuestMember is a person of a household invited as one guest, a plus one
+// can stay unnamed until the guest names them
+type GuestMember struct {
+	ID        string
+	GuestID   string
+	Name      string
+	PlusOne   bool
+	Attend    *bool
+	Position  int
+	CreatedAt time.Time
+}
+
+// MessageStatus is the moderation state of a guestbook message, only
+// approved messages are public
+type MessageStatus string
+
+const (
+	MessageApproved MessageStatus = "approved"
+	MessagePending  MessageStatus = "pending"
+	MessageHidden   MessageStatus = "hidden"
+)
+
+// RSVPQuestionType is the kind of answer an RSVP question expects
+type RSVPQuestionType string
+
+const (
+	RSVPQuestionChoice RSVPQuestionType = "choice"
+	RSVPQuestionText   RSVPQuestionType = "text"
+	RSVPQuestionNumber RSVPQuestionType = "number"
+)
+
+// RSVPQuestion is a question of the RSVP form of a user template, Options are
+// the answers of a choice question
+type RSVPQuestion struct {
+	ID             string
+	UserTemplateID string
+	Label          string
+	Type           RSVPQuestionType
+	Options        []string
+	Required       bool
+	Position       int
 	CreatedAt      time.Time
-	UpdatedAt      time.Time
+}
+
+type RefreshToken struct {
+	ID         string
+	UserID     string
+	FamilyID   string // shared by every token rotated from the same login
+	TokenHash  string
+	ReplacedBy *string
+	ExpiresAt  time.Time
+	RevokedAt  *time.Time
+	CreatedAt  time.Time
+}
+
+type UserTokenPurpose string
+
+const (
+	UserTokenPasswordReset     UserTokenPurpose = "password_reset"
+	UserTokenEmailVerification UserTokenPurpose = "email_verification"
+	UserTokenMFAChallenge      UserTokenPurpose = "mfa_challenge"
+)
+
+// UserToken is a single use token delivered by email
+type UserToken struct {
+	ID        string
+	UserID    string
+	Purpose   UserTokenPurpose
+	TokenHash string
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+// LoginAttempt counts consecutive failed logins for one key, either an email
+// or a client IP
+type LoginAttempt struct {
+	Key           string
+	Failures      int
+	LastFailureAt time.Time
+	LockedUntil   *time.Time
+}
+
+// AuditLog records a security relevant action
+type AuditLog struct {
+	ID         string
+	ActorID    string
+	Action     string
+	TargetType string
+	TargetID   string
+	IP         string
+	Metadata   map[string]any
+	CreatedAt  time.Time
+}
+
+// APIToken is a long lived personal access token, only its sha256 is stored
+type APIToken struct {
+	ID         string
+	UserID     string
+	Name       string
+	TokenHash  string
+	Prefix     string // first characters of the token, shown to recognise it
+	Scopes     []string
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+	RevokedAt  *time.Time
+	CreatedAt  time.Time
+}
+
+// UserTOTP is the TOTP second factor of a user
+type UserTOTP struct {
+	UserID    string
+	Secret    string
+	EnabledAt *time.Time // nil while enrollment is pending
+	LastStep  int64      // last accepted time step, a code is only valid once
+	CreatedAt time.Time
+}
+
+// OIDCState is a pending OpenID Connect login
+type OIDCState struct {
+	State        string
+	Provider     string
+	CodeVerifier string // PKCE verifier, only its S256 challenge leaves the server
+	Nonce        string
+	ExpiresAt    time.Time
+	CreatedAt    time.Time
+}
+
+// UserIdentity links an account of an external identity provider to a user
+type UserIdentity struct {
+	ID        string
+	UserID    string
+	Provider  string
+	Subject   string
+	Email     string
+	CreatedAt time.Time
+}
+
+// Session is one login of a user on a device, its ID is the refresh token
+// family shared by every token rotated from that login
+type Session struct {
+	ID         string
+	UserID     string
+	UserAgent  string
+	IP         string
+	CreatedAt  time.Time
+	LastSeenAt time.Time
+	RevokedAt  *time.Time
+}
+
+// TemplateRole is what a member can do on a user template, every role can do
+// what the roles below it can
+type TemplateRole string
+
+const (
+	TemplateRoleOwner  TemplateRole = "owner"
+	TemplateRoleEditor TemplateRole = "editor"
+	TemplateRoleViewer TemplateRole = "viewer"
+)
+
+// Allows reports whether r grants at least the rights of min.
+func (r TemplateRole) Allows(min TemplateRole) bool {
+	return r.rank() >= min.rank() && min.rank() > 0
+}
+
+// Valid reports whether r is a known role.
+func (r TemplateRole) Valid() bool {
+	return r.rank() > 0
+}
+
+func (r TemplateRole) rank() int {
+	switch r {
+	case TemplateRoleOwner:
+		return 3
+	case TemplateRoleEditor:
+		return 2
+	case TemplateRoleViewer:
+		return 1
+	}
+	return 0
+}
+
+// TemplateMember is a co-host of a user template, Name and Email come from
+// the user
+type TemplateMember struct {
+	ID             string
+	UserTemplateID string
+	UserID         string
+	Role           TemplateRole
+	Name           string
+	Email          string
+	CreatedAt      time.Time
+}
+
+// TemplateInvite is an emailed invitation to join a user template
+type TemplateInvite struct {
+	ID             string
+	UserTemplateID string
+	Email          string
+	Role           TemplateRole
+	TokenHash      string
+	InvitedBy      string
+	ExpiresAt      time.Time
+	AcceptedAt     *time.Time
+	CreatedAt      time.Time
+}
+
+// AccountDeletion is a pending request of a user to delete their account
+type AccountDeletion struct {
+	UserID      string
+	RequestedAt time.Time
+	DeleteAfter time.Time
 }
@@ -24,4 +24,10 @@ type Guests struct {
 	ViewAt         *time.Time
 	CreatedAt      time.Time
 	Attend         *bool
+	InviteNonce    *string
+	AttendPerson   *int32
+	RsvpAnswers    string
+	MessageStatus  string
+	MessagePinned  bool
+	MessageAt      *time.Time
 }
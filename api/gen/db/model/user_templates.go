@@ -12,16 +12,19 @@ import (
 )
 
 type UserTemplates struct {
-	ID              string `sql:"primary_key"`
-	UserID          string
-	BaseTemplateID  string
-	State           int32
-	Slug            string
-	URL             string
-	MessageTemplate string
-	Name            string
-	CoverImage      string
-	CreatedAt       time.Time
-	UpdatedAt       time.Time
-	ExpireAt        time.Time
+	ID                    string `sql:"primary_key"`
+	UserID                string
+	BaseTemplateID        string
+	State                 int32
+	Slug                  string
+	URL                   string
+	MessageTemplate       string
+	Name                  string
+	CoverImage            string
+	CreatedAt             time.Time
+	UpdatedAt             time.Time
+	ExpireAt              time.Time
+	GuestbookApproveFirst bool
+	GuestbookBlockedWords string
+	RsvpDeadline          *time.Time
 }
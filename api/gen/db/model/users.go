@@ -12,13 +12,14 @@ import (
 )
 
 type Users struct {
-	ID        string `sql:"primary_key"`
-	Email     string
-	Password  string
-	Name      string
-	Profile   string
-	Role      int32
-	IsActive  bool
-	CreatedAt time.Time
-	UpdatedAt time.Time
+	ID         string `sql:"primary_key"`
+	Email      string
+	Password   string
+	Name       string
+	Profile    string
+	Role       int32
+	IsActive   bool
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	VerifiedAt *time.Time
 }
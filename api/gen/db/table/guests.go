@@ -29,6 +29,12 @@ type guestsTable struct {
 	ViewAt         sqlite.ColumnTimestamp
 	CreatedAt      sqlite.ColumnTimestamp
 	Attend         sqlite.ColumnBool
+	InviteNonce    sqlite.ColumnString
+	AttendPerson   sqlite.ColumnInteger
+	RsvpAnswers    sqlite.ColumnString
+	MessageStatus  sqlite.ColumnString
+	MessagePinned  sqlite.ColumnBool
+	MessageAt      sqlite.ColumnTimestamp
 
 	AllColumns     sqlite.ColumnList
 	MutableColumns sqlite.ColumnList
@@ -82,9 +88,15 @@ func newGuestsTableImpl(schemaName, tableName, alias string) guestsTable {
 		ViewAtColumn         = sqlite.TimestampColumn("view_at")
 		CreatedAtColumn      = sqlite.TimestampColumn("created_at")
 		AttendColumn         = sqlite.BoolColumn("attend")
-		allColumns           = sqlite.ColumnList{IDColumn, UserTemplateIDColumn, NameColumn, GroupNameColumn, PersonColumn, TagsColumn, TelpColumn, AddressColumn, MessageColumn, ViewAtColumn, CreatedAtColumn, AttendColumn}
-		mutableColumns       = sqlite.ColumnList{UserTemplateIDColumn, NameColumn, GroupNameColumn, PersonColumn, TagsColumn, TelpColumn, AddressColumn, MessageColumn, ViewAtColumn, CreatedAtColumn, AttendColumn}
-		defaultColumns       = sqlite.ColumnList{NameColumn, GroupNameColumn, PersonColumn, TagsColumn, TelpColumn, AddressColumn, MessageColumn, CreatedAtColumn, AttendColumn}
+		InviteNonceColumn    = sqlite.StringColumn("invite_nonce")
+		AttendPersonColumn   = sqlite.IntegerColumn("attend_person")
+		RsvpAnswersColumn    = sqlite.StringColumn("rsvp_answers")
+		MessageStatusColumn  = sqlite.StringColumn("message_status")
+		MessagePinnedColumn  = sqlite.BoolColumn("message_pinned")
+		MessageAtColumn      = sqlite.TimestampColumn("message_at")
+		allColumns           = sqlite.ColumnList{IDColumn, UserTemplateIDColumn, NameColumn, GroupNameColumn, PersonColumn, TagsColumn, TelpColumn, AddressColumn, MessageColumn, ViewAtColumn, CreatedAtColumn, AttendColumn, InviteNonceColumn, AttendPersonColumn, RsvpAnswersColumn, MessageStatusColumn, MessagePinnedColumn, MessageAtColumn}
+		mutableColumns       = sqlite.ColumnList{UserTemplateIDColumn, NameColumn, GroupNameColumn, PersonColumn, TagsColumn, TelpColumn, AddressColumn, MessageColumn, ViewAtColumn, CreatedAtColumn, AttendColumn, InviteNonceColumn, AttendPersonColumn, RsvpAnswersColumn, MessageStatusColumn, MessagePinnedColumn, MessageAtColumn}
+		defaultColumns       = sqlite.ColumnList{NameColumn, GroupNameColumn, PersonColumn, TagsColumn, TelpColumn, AddressColumn, MessageColumn, CreatedAtColumn, AttendColumn, RsvpAnswersColumn, MessageStatusColumn, MessagePinnedColumn}
 	)
 
 	return guestsTable{
@@ -103,6 +115,12 @@ func newGuestsTableImpl(schemaName, tableName, alias string) guestsTable {
 		ViewAt:         ViewAtColumn,
 		CreatedAt:      CreatedAtColumn,
 		Attend:         AttendColumn,
+		InviteNonce:    InviteNonceColumn,
+		AttendPerson:   AttendPersonColumn,
+		RsvpAnswers:    RsvpAnswersColumn,
+		MessageStatus:  MessageStatusColumn,
+		MessagePinned:  MessagePinnedColumn,
+		MessageAt:      MessageAtColumn,
 
 		AllColumns:     allColumns,
 		MutableColumns: mutableColumns,
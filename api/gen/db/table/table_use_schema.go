@@ -10,8 +10,24 @@ package table
 // UseSchema sets a new schema name for all generated table SQL builder types. It is recommended to invoke
 // this method only once at the beginning of the program.
 func UseSchema(schema string) {
+	AccountDeletions = AccountDeletions.FromSchema(schema)
+	ApiTokens = ApiTokens.FromSchema(schema)
+	AuditLogs = AuditLogs.FromSchema(schema)
+	GuestMembers = GuestMembers.FromSchema(schema)
 	Guests = Guests.FromSchema(schema)
+	LoginAttempts = LoginAttempts.FromSchema(schema)
+	OidcStates = OidcStates.FromSchema(schema)
 	PublicTemplates = PublicTemplates.FromSchema(schema)
+	RefreshTokens = RefreshTokens.FromSchema(schema)
+	RevokedTokens = RevokedTokens.FromSchema(schema)
+	RsvpQuestions = RsvpQuestions.FromSchema(schema)
+	Sessions = Sessions.FromSchema(schema)
+	TemplateInvites = TemplateInvites.FromSchema(schema)
+	TemplateMembers = TemplateMembers.FromSchema(schema)
+	UserIdentities = UserIdentities.FromSchema(schema)
+	UserRecoveryCodes = UserRecoveryCodes.FromSchema(schema)
 	UserTemplates = UserTemplates.FromSchema(schema)
+	UserTokens = UserTokens.FromSchema(schema)
+	UserTotp = UserTotp.FromSchema(schema)
 	Users = Users.FromSchema(schema)
 }
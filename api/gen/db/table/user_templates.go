@@ -17,18 +17,21 @@ type userTemplatesTable struct {
 	sqlite.Table
 
 	// Columns
-	ID              sqlite.ColumnString
-	UserID          sqlite.ColumnString
-	BaseTemplateID  sqlite.ColumnString
-	State           sqlite.ColumnInteger
-	Slug            sqlite.ColumnString
-	URL             sqlite.ColumnString
-	MessageTemplate sqlite.ColumnString
-	Name            sqlite.ColumnString
-	CoverImage      sqlite.ColumnString
-	CreatedAt       sqlite.ColumnTimestamp
-	UpdatedAt       sqlite.ColumnTimestamp
-	ExpireAt        sqlite.ColumnTimestamp
+	ID                    sqlite.ColumnString
+	UserID                sqlite.ColumnString
+	BaseTemplateID        sqlite.ColumnString
+	State                 sqlite.ColumnInteger
+	Slug                  sqlite.ColumnString
+	URL                   sqlite.ColumnString
+	MessageTemplate       sqlite.ColumnString
+	Name                  sqlite.ColumnString
+	CoverImage            sqlite.ColumnString
+	CreatedAt             sqlite.ColumnTimestamp
+	UpdatedAt             sqlite.ColumnTimestamp
+	ExpireAt              sqlite.ColumnTimestamp
+	GuestbookApproveFirst sqlite.ColumnBool
+	GuestbookBlockedWords sqlite.ColumnString
+	RsvpDeadline          sqlite.ColumnTimestamp
 
 	AllColumns     sqlite.ColumnList
 	MutableColumns sqlite.ColumnList
@@ -70,39 +73,45 @@ func newUserTemplatesTable(schemaName, tableName, alias string) *UserTemplatesTa
 
 func newUserTemplatesTableImpl(schemaName, tableName, alias string) userTemplatesTable {
 	var (
-		IDColumn              = sqlite.StringColumn("id")
-		UserIDColumn          = sqlite.StringColumn("user_id")
-		BaseTemplateIDColumn  = sqlite.StringColumn("base_template_id")
-		StateColumn           = sqlite.IntegerColumn("state")
-		SlugColumn            = sqlite.StringColumn("slug")
-		URLColumn             = sqlite.StringColumn("url")
-		MessageTemplateColumn = sqlite.StringColumn("message_template")
-		NameColumn            = sqlite.StringColumn("name")
-		CoverImageColumn      = sqlite.StringColumn("cover_image")
-		CreatedAtColumn       = sqlite.TimestampColumn("created_at")
-		UpdatedAtColumn       = sqlite.TimestampColumn("updated_at")
-		ExpireAtColumn        = sqlite.TimestampColumn("expire_at")
-		allColumns            = sqlite.ColumnList{IDColumn, UserIDColumn, BaseTemplateIDColumn, StateColumn, SlugColumn, URLColumn, MessageTemplateColumn, NameColumn, CoverImageColumn, CreatedAtColumn, UpdatedAtColumn, ExpireAtColumn}
-		mutableColumns        = sqlite.ColumnList{UserIDColumn, BaseTemplateIDColumn, StateColumn, SlugColumn, URLColumn, MessageTemplateColumn, NameColumn, CoverImageColumn, CreatedAtColumn, UpdatedAtColumn, ExpireAtColumn}
-		defaultColumns        = sqlite.ColumnList{StateColumn, URLColumn, MessageTemplateColumn, NameColumn, CoverImageColumn, CreatedAtColumn, UpdatedAtColumn, ExpireAtColumn}
+		IDColumn                    = sqlite.StringColumn("id")
+		UserIDColumn                = sqlite.StringColumn("user_id")
+		BaseTemplateIDColumn        = sqlite.StringColumn("base_template_id")
+		StateColumn                 = sqlite.IntegerColumn("state")
+		SlugColumn                  = sqlite.StringColumn("slug")
+		URLColumn                   = sqlite.StringColumn("url")
+		MessageTemplateColumn       = sqlite.StringColumn("message_template")
+		NameColumn                  = sqlite.StringColumn("name")
+		CoverImageColumn            = sqlite.StringColumn("cover_image")
+		CreatedAtColumn             = sqlite.TimestampColumn("created_at")
+		UpdatedAtColumn             = sqlite.TimestampColumn("updated_at")
+		ExpireAtColumn              = sqlite.TimestampColumn("expire_at")
+		GuestbookApproveFirstColumn = sqlite.BoolColumn("guestbook_approve_first")
+		GuestbookBlockedWordsColumn = sqlite.StringColumn("guestbook_blocked_words")
+		RsvpDeadlineColumn          = sqlite.TimestampColumn("rsvp_deadline")
+		allColumns                  = sqlite.ColumnList{IDColumn, UserIDColumn, BaseTemplateIDColumn, StateColumn, SlugColumn, URLColumn, MessageTemplateColumn, NameColumn, CoverImageColumn, CreatedAtColumn, UpdatedAtColumn, ExpireAtColumn, GuestbookApproveFirstColumn, GuestbookBlockedWordsColumn, RsvpDeadlineColumn}
+		mutableColumns              = sqlite.ColumnList{UserIDColumn, BaseTemplateIDColumn, StateColumn, SlugColumn, URLColumn, MessageTemplateColumn, NameColumn, CoverImageColumn, CreatedAtColumn, UpdatedAtColumn, ExpireAtColumn, GuestbookApproveFirstColumn, GuestbookBlockedWordsColumn, RsvpDeadlineColumn}
+		defaultColumns              = sqlite.ColumnList{StateColumn, URLColumn, MessageTemplateColumn, NameColumn, CoverImageColumn, CreatedAtColumn, UpdatedAtColumn, ExpireAtColumn, GuestbookApproveFirstColumn, GuestbookBlockedWordsColumn}
 	)
 
 	return userTemplatesTable{
 		Table: sqlite.NewTable(schemaName, tableName, alias, allColumns...),
 
 		//Columns
-		ID:              IDColumn,
-		UserID:          UserIDColumn,
-		BaseTemplateID:  BaseTemplateIDColumn,
-		State:           StateColumn,
-		Slug:            SlugColumn,
-		URL:             URLColumn,
-		MessageTemplate: MessageTemplateColumn,
-		Name:            NameColumn,
-		CoverImage:      CoverImageColumn,
-		CreatedAt:       CreatedAtColumn,
-		UpdatedAt:       UpdatedAtColumn,
-		ExpireAt:        ExpireAtColumn,
+		ID:                    IDColumn,
+		UserID:                UserIDColumn,
+		BaseTemplateID:        BaseTemplateIDColumn,
+		State:                 StateColumn,
+		Slug:                  SlugColumn,
+		URL:                   URLColumn,
+		MessageTemplate:       MessageTemplateColumn,
+		Name:                  NameColumn,
+		CoverImage:            CoverImageColumn,
+		CreatedAt:             CreatedAtColumn,
+		UpdatedAt:             UpdatedAtColumn,
+		ExpireAt:              ExpireAtColumn,
+		GuestbookApproveFirst: GuestbookApproveFirstColumn,
+		GuestbookBlockedWords: GuestbookBlockedWordsColumn,
+		RsvpDeadline:          RsvpDeadlineColumn,
 
 		AllColumns:     allColumns,
 		MutableColumns: mutableColumns,
@@ -17,15 +17,16 @@ type usersTable struct {
 	sqlite.Table
 
 	// Columns
-	ID        sqlite.ColumnString
-	Email     sqlite.ColumnString
-	Password  sqlite.ColumnString
-	Name      sqlite.ColumnString
-	Profile   sqlite.ColumnString
-	Role      sqlite.ColumnInteger
-	IsActive  sqlite.ColumnBool
-	CreatedAt sqlite.ColumnTimestamp
-	UpdatedAt sqlite.ColumnTimestamp
+	ID         sqlite.ColumnString
+	Email      sqlite.ColumnString
+	Password   sqlite.ColumnString
+	Name       sqlite.ColumnString
+	Profile    sqlite.ColumnString
+	Role       sqlite.ColumnInteger
+	IsActive   sqlite.ColumnBool
+	CreatedAt  sqlite.ColumnTimestamp
+	UpdatedAt  sqlite.ColumnTimestamp
+	VerifiedAt sqlite.ColumnTimestamp
 
 	AllColumns     sqlite.ColumnList
 	MutableColumns sqlite.ColumnList
@@ -67,33 +68,35 @@ func newUsersTable(schemaName, tableName, alias string) *UsersTable {
 
 func newUsersTableImpl(schemaName, tableName, alias string) usersTable {
 	var (
-		IDColumn        = sqlite.StringColumn("id")
-		EmailColumn     = sqlite.StringColumn("email")
-		PasswordColumn  = sqlite.StringColumn("password")
-		NameColumn      = sqlite.StringColumn("name")
-		ProfileColumn   = sqlite.StringColumn("profile")
-		RoleColumn      = sqlite.IntegerColumn("role")
-		IsActiveColumn  = sqlite.BoolColumn("is_active")
-		CreatedAtColumn = sqlite.TimestampColumn("created_at")
-		UpdatedAtColumn = sqlite.TimestampColumn("updated_at")
-		allColumns      = sqlite.ColumnList{IDColumn, EmailColumn, PasswordColumn, NameColumn, ProfileColumn, RoleColumn, IsActiveColumn, CreatedAtColumn, UpdatedAtColumn}
-		mutableColumns  = sqlite.ColumnList{EmailColumn, PasswordColumn, NameColumn, ProfileColumn, RoleColumn, IsActiveColumn, CreatedAtColumn, UpdatedAtColumn}
-		defaultColumns  = sqlite.ColumnList{ProfileColumn, IsActiveColumn, CreatedAtColumn, UpdatedAtColumn}
+		IDColumn         = sqlite.StringColumn("id")
+		EmailColumn      = sqlite.StringColumn("email")
+		PasswordColumn   = sqlite.StringColumn("password")
+		NameColumn       = sqlite.StringColumn("name")
+		ProfileColumn    = sqlite.StringColumn("profile")
+		RoleColumn       = sqlite.IntegerColumn("role")
+		IsActiveColumn   = sqlite.BoolColumn("is_active")
+		CreatedAtColumn  = sqlite.TimestampColumn("created_at")
+		UpdatedAtColumn  = sqlite.TimestampColumn("updated_at")
+		VerifiedAtColumn = sqlite.TimestampColumn("verified_at")
+		allColumns       = sqlite.ColumnList{IDColumn, EmailColumn, PasswordColumn, NameColumn, ProfileColumn, RoleColumn, IsActiveColumn, CreatedAtColumn, UpdatedAtColumn, VerifiedAtColumn}
+		mutableColumns   = sqlite.ColumnList{EmailColumn, PasswordColumn, NameColumn, ProfileColumn, RoleColumn, IsActiveColumn, CreatedAtColumn, UpdatedAtColumn, VerifiedAtColumn}
+		defaultColumns   = sqlite.ColumnList{ProfileColumn, IsActiveColumn, CreatedAtColumn, UpdatedAtColumn}
 	)
 
 	return usersTable{
 		Table: sqlite.NewTable(schemaName, tableName, alias, allColumns...),
 
 		//Columns
-		ID:        IDColumn,
-		Email:     EmailColumn,
-		Password:  PasswordColumn,
-		Name:      NameColumn,
-		Profile:   ProfileColumn,
-		Role:      RoleColumn,
-		IsActive:  IsActiveColumn,
-		CreatedAt: CreatedAtColumn,
-		UpdatedAt: UpdatedAtColumn,
+		ID:         IDColumn,
+		Email:      EmailColumn,
+		Password:   PasswordColumn,
+		Name:       NameColumn,
+		Profile:    ProfileColumn,
+		Role:       RoleColumn,
+		IsActive:   IsActiveColumn,
+		CreatedAt:  CreatedAtColumn,
+		UpdatedAt:  UpdatedAtColumn,
+		VerifiedAt: VerifiedAtColumn,
 
 		AllColumns:     allColumns,
 		MutableColumns: mutableColumns,
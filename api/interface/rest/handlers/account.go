@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"basic-service/domain"
+	"basic-service/interface/rest/model"
+	"basic-service/usecase"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+)
+
+type Account struct {
+	validator *validator.Validate
+	cs        *usecase.Account
+}
+
+func NewAccount(cs *usecase.Account) *Account {
+	return &Account{
+		validator: validator.New(),
+		cs:        cs,
+	}
+}
+
+func toAccountDeletionModel(v domain.AccountDeletion) model.AccountDeletion {
+	return model.AccountDeletion{
+		RequestedAt: v.RequestedAt,
+		DeleteAfter: v.DeleteAfter,
+	}
+}
+
+// Export downloads a zip with the personal data of the current user
+func (h *Account) Export(w http.ResponseWriter, r *http.Request) {
+	// built in memory so a failure can still be reported as JSON
+	var buf bytes.Buffer
+	if err := h.cs.Export(r.Context(), &buf); err != nil {
+		renderError(w, r, errorStatus(err, http.StatusBadRequest), "Export account failed", err)
+		return
+	}
+
+	filename := fmt.Sprintf("account-export-%s.zip", time.Now().Format("20060102"))
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf.Bytes())
+}
+
+// RequestDeletion schedules the deletion of the current account
+func (h *Account) RequestDeletion(w http.ResponseWriter, r *http.Request) {
+	var req model.AccountDeletionRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		renderError(w, r, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		renderError(w, r, http.StatusBadRequest, "Validation failed", err)
+		return
+	}
+
+	deletion, err := h.cs.RequestDeletion(r.Context(), req.Password)
+	if err != nil {
+		renderError(w, r, errorStatus(err, http.StatusBadRequest), "Request account deletion failed", err)
+		return
+	}
+
+	render.Status(r, http.StatusAccepted)
+	render.JSON(w, r, toAccountDeletionModel(deletion))
+}
+
+func (h *Account) DeletionStatus(w http.ResponseWriter, r *http.Request) {
+	deletion, err := h.cs.DeletionStatus(r.Context())
+	if err != nil {
+		renderError(w, r, errorStatus(err, http.StatusBadRequest), "Get account deletion failed", err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, toAccountDeletionModel(deletion))
+}
+
+func (h *Account) CancelDeletion(w http.ResponseWriter, r *http.Request) {
+	if err := h.cs.CancelDeletion(r.Context()); err != nil {
+		renderError(w, r, errorStatus(err, http.StatusBadRequest), "Cancel account deletion failed", err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, map[string]interface{}{})
+}
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"basic-service/domain"
+	"basic-service/interface/rest/model"
+	"basic-service/interface/sql"
+	"basic-service/usecase"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/ggicci/httpin"
+	"github.com/go-chi/render"
+)
+
+func toAPITokenModel(v domain.APIToken) model.APIToken {
+	return model.APIToken{
+		Id:         v.ID,
+		Name:       v.Name,
+		Prefix:     v.Prefix,
+		Scopes:     v.Scopes,
+		ExpiresAt:  v.ExpiresAt,
+		LastUsedAt: v.LastUsedAt,
+		CreatedAt:  v.CreatedAt,
+	}
+}
+
+// CreateAPIToken issues a personal API token, the token is only shown once
+func (h *AuthHandler) CreateAPIToken(w http.ResponseWriter, r *http.Request) {
+	var req model.APITokenCreateRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		renderError(w, r, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		renderError(w, r, http.StatusBadRequest, "Validation failed", err)
+		return
+	}
+
+	scopes := make([]usecase.Scope, 0, len(req.Scopes))
+	for _, v := range req.Scopes {
+		scopes = append(scopes, usecase.Scope(v))
+	}
+
+	var expiresAt *time.Time
+	if req.ExpiresInDays > 0 {
+		v := time.Now().AddDate(0, 0, req.ExpiresInDays)
+		expiresAt = &v
+	}
+
+	raw, token, err := h.cs.CreateAPIToken(r.Context(), req.Name, scopes, expiresAt)
+	if err != nil {
+		renderError(w, r, errorStatus(err, http.StatusBadRequest), "Create API Token failed", err)
+		return
+	}
+
+	resp := toAPITokenModel(token)
+	resp.Token = raw
+
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, resp)
+}
+
+func (h *AuthHandler) ListAPITokens(w http.ResponseWriter, r *http.Request) {
+	tokens, err := h.cs.ListAPITokens(r.Context())
+	if err != nil {
+		renderError(w, r, http.StatusBadRequest, "List API Token failed", err)
+		return
+	}
+
+	resp := make([]model.APIToken, 0, len(tokens))
+	for _, v := range tokens {
+		resp = append(resp, toAPITokenModel(v))
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, resp)
+}
+
+func (h *AuthHandler) RevokeAPIToken(w http.ResponseWriter, r *http.Request) {
+	input := r.Context().Value(httpin.Input).(*model.IdentityRequest)
+
+	if err := h.cs.RevokeAPIToken(r.Context(), input.ID); err != nil {
+		status := errorStatus(err, http.StatusBadRequest)
+		if errors.Is(err, sql.ErrAPITokenNotFound) {
+			status = http.StatusNotFound
+		}
+		renderError(w, r, status, "Revoke API Token failed", err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, map[string]interface{}{})
+}
@@ -3,11 +3,15 @@ package handlers
 import (
 	"basic-service/domain"
 	"basic-service/interface/rest/model"
+	"basic-service/interface/sql"
 	"basic-service/usecase"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
 	"net/http"
 	"path"
+	"strconv"
 	"strings"
 	"time"
 
@@ -77,15 +81,71 @@ func (h *AuthHandler) Me(w http.ResponseWriter, r *http.Request) {
 	}
 
 	render.Status(r, http.StatusOK)
-	render.JSON(w, r, model.SafeUser{
-		CreatedAt: sf.CreatedAt,
-		Email:     sf.Email,
-		Id:        sf.ID,
-		IsActive:  sf.IsActive,
-		Name:      sf.Name,
-		Role:      model.UserRole(sf.Role),
-		UpdatedAt: time.Time{},
-	})
+	render.JSON(w, r, toSafeUserModel(sf))
+}
+
+// UpdateMe changes the name and optionally the profile picture of the current
+// user, the previous picture is removed once the update is stored
+func (h *AuthHandler) UpdateMe(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	input := r.Context().Value(httpin.Input).(*model.UpdateProfileRequest)
+	input.Name = strings.TrimSpace(input.Name)
+
+	if err := h.validator.Struct(input); err != nil {
+		renderError(w, r, http.StatusBadRequest, "Validation failed", err)
+		return
+	}
+
+	var profile string
+	if input.Profile != nil {
+		imageURL, err := h.upload.UploadImage(input.Profile)
+		if err != nil {
+			renderError(w, r, http.StatusBadRequest, "Image upload failed", err)
+			return
+		}
+		profile = path.Join("uploads", imageURL)
+	}
+
+	sf, previous, err := h.cs.UpdateProfile(ctx, input.Name, profile)
+	if err != nil {
+		if profile != "" {
+			h.upload.Remove(profile)
+		}
+		renderError(w, r, errorStatus(err, http.StatusBadRequest), "Update profile failed", err)
+		return
+	}
+
+	if profile != "" && previous != "" && previous != profile {
+		h.upload.Remove(previous)
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, toSafeUserModel(sf))
+}
+
+// ChangePassword changes the password of the current user. Every session is
+// revoked and a new token pair is returned for the caller
+func (h *AuthHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	var req model.ChangePasswordRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		renderError(w, r, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		renderError(w, r, http.StatusBadRequest, "Validation failed", err)
+		return
+	}
+
+	token, err := h.cs.ChangePassword(r.Context(), req.CurrentPassword, req.NewPassword)
+	if err != nil {
+		renderError(w, r, errorStatus(err, http.StatusBadRequest), "Change password failed", err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, loginResponse(token))
 }
 
 // Login handles user authentication
@@ -102,24 +162,205 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	token, err := h.cs.Login(r.Context(), req.Email, req.Password)
+	token, err := h.cs.Login(r.Context(), req.Email, req.Password, usecase.ClientFromContext(r.Context()).IP)
 	if err != nil {
-		renderError(w, r, http.StatusBadRequest, "Login failed", err)
+		var locked *usecase.LockedError
+		if errors.As(err, &locked) {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(locked.RetryAfter().Seconds()))))
+		}
+		renderError(w, r, errorStatus(err, http.StatusBadRequest), "Login failed", err)
 		return
 	}
 
 	render.Status(r, http.StatusOK)
-	render.JSON(w, r, model.LoginResponse{
-		Token: &token,
-	})
+	render.JSON(w, r, loginResponse(token))
+}
+
+// Refresh exchanges a refresh token for a new token pair
+func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	var req model.RefreshTokenRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		renderError(w, r, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		renderError(w, r, http.StatusBadRequest, "Validation failed", err)
+		return
+	}
+
+	token, err := h.cs.Refresh(r.Context(), req.RefreshToken)
+	if err != nil {
+		renderError(w, r, http.StatusUnauthorized, "Refresh failed", err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, loginResponse(token))
 }
 
 // Logout handles user logout
 func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	if err := h.cs.Logout(r.Context()); err != nil {
+		renderError(w, r, http.StatusBadRequest, "Logout failed", err)
+		return
+	}
+
 	render.Status(r, http.StatusOK)
 	render.JSON(w, r, map[string]string{"message": "Successfully logged out"})
 }
 
+// ForgotPassword emails a reset link, it always answers the same way so it
+// can't be used to find out which emails are registered
+func (h *AuthHandler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	var req model.ForgotPasswordRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		renderError(w, r, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	req.Email = strings.TrimSpace(req.Email)
+	if err := h.validator.Struct(req); err != nil {
+		renderError(w, r, http.StatusBadRequest, "Validation failed", err)
+		return
+	}
+
+	if err := h.cs.ForgotPassword(r.Context(), req.Email, usecase.ClientFromContext(r.Context()).IP); err != nil {
+		var locked *usecase.LockedError
+		if errors.As(err, &locked) {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(locked.RetryAfter().Seconds()))))
+		}
+		renderError(w, r, errorStatus(err, http.StatusInternalServerError), "Forgot password failed", err)
+		return
+	}
+
+	render.Status(r, http.StatusAccepted)
+	render.JSON(w, r, map[string]string{"message": "If the email is registered a reset link has been sent"})
+}
+
+// ResetPassword sets a new password with a token from ForgotPassword
+func (h *AuthHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	var req model.ResetPasswordRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		renderError(w, r, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		renderError(w, r, http.StatusBadRequest, "Validation failed", err)
+		return
+	}
+
+	if err := h.cs.ResetPassword(r.Context(), req.Token, req.Password); err != nil {
+		renderError(w, r, http.StatusBadRequest, "Reset password failed", err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, map[string]string{"message": "Password has been reset"})
+}
+
+// VerifyEmail confirms the email address with a token from the registration
+// email
+func (h *AuthHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	var req model.VerifyEmailRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		renderError(w, r, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		renderError(w, r, http.StatusBadRequest, "Validation failed", err)
+		return
+	}
+
+	if err := h.cs.VerifyEmail(r.Context(), req.Token); err != nil {
+		renderError(w, r, http.StatusBadRequest, "Verify email failed", err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, map[string]string{"message": "Email verified"})
+}
+
+// ResendVerification sends a new verification email to the current user
+func (h *AuthHandler) ResendVerification(w http.ResponseWriter, r *http.Request) {
+	if err := h.cs.ResendVerification(r.Context()); err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, usecase.ErrVerificationResendWait) {
+			status = http.StatusTooManyRequests
+		}
+		renderError(w, r, status, "Resend verification failed", err)
+		return
+	}
+
+	render.Status(r, http.StatusAccepted)
+	render.JSON(w, r, map[string]string{"message": "Verification email sent"})
+}
+
+// JWKS publishes the public keys used to verify access tokens
+func (h *AuthHandler) JWKS(w http.ResponseWriter, r *http.Request) {
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, h.cs.JWKS())
+}
+
+func loginResponse(token usecase.TokenPair) model.LoginResponse {
+	if token.MFAChallenge != "" {
+		return model.LoginResponse{
+			MFARequired:    true,
+			ChallengeToken: &token.MFAChallenge,
+			ExpiresAt:      &token.ExpiresAt,
+		}
+	}
+
+	return model.LoginResponse{
+		Token:            &token.AccessToken,
+		RefreshToken:     &token.RefreshToken,
+		ExpiresAt:        &token.ExpiresAt,
+		MFASetupRequired: token.MFASetupRequired,
+	}
+}
+
+// errorStatus maps well known usecase errors to their HTTP status, falling
+// back to status for everything else.
+func errorStatus(err error, status int) int {
+	switch {
+	case errors.Is(err, usecase.ErrForbidden),
+		errors.Is(err, usecase.ErrEmailNotVerified),
+		errors.Is(err, usecase.ErrTOTPRequired),
+		errors.Is(err, usecase.ErrCannotImpersonate),
+		errors.Is(err, usecase.ErrImpersonationReadOnly),
+		errors.Is(err, usecase.ErrInviteEmailMismatch):
+		return http.StatusForbidden
+	case errors.Is(err, sql.UserNotFoundErr),
+		errors.Is(err, sql.ErrUserTemplateNotFound),
+		errors.Is(err, sql.ErrTemplateMemberNotFound),
+		errors.Is(err, sql.ErrGuestNotFound),
+		errors.Is(err, sql.ErrRSVPQuestionNotFound),
+		errors.Is(err, usecase.ErrSessionNotFound),
+		errors.Is(err, usecase.ErrAccountDeletionNotFound),
+		errors.Is(err, usecase.ErrInvitationInvalid),
+		errors.Is(err, usecase.ErrTemplateNotPublished):
+		return http.StatusNotFound
+	case errors.Is(err, usecase.ErrAlreadyMember),
+		errors.Is(err, usecase.ErrLastOwner),
+		errors.Is(err, usecase.ErrTemplateTransition),
+		errors.Is(err, usecase.ErrRSVPClosed),
+		errors.Is(err, usecase.ErrRSVPQuestionTooMany):
+		return http.StatusConflict
+	case errors.Is(err, usecase.ErrInvitationExpired),
+		errors.Is(err, usecase.ErrTemplateArchived):
+		return http.StatusGone
+	case errors.Is(err, usecase.ErrTooManyAttempts):
+		return http.StatusTooManyRequests
+	}
+	return status
+}
+
 // renderError is a helper for consistent error responses
 func renderError(w http.ResponseWriter, r *http.Request, status int, message string, err error) {
 	resp := model.ErrorResponse{
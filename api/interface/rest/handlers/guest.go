@@ -3,8 +3,17 @@ package handlers
 import (
 	"basic-service/domain"
 	"basic-service/interface/rest/model"
+	"basic-service/interface/sql"
+	"basic-service/pkg/sheet"
 	"basic-service/usecase"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/ggicci/httpin"
 	"github.com/go-chi/render"
@@ -12,6 +21,13 @@ import (
 	"github.com/google/uuid"
 )
 
+// MaxGuestImportSize is the largest file Import accepts, MaxGuestImportBody
+// leaves room for the other fields of the form
+const (
+	MaxGuestImportSize = 5 << 20
+	MaxGuestImportBody = MaxGuestImportSize + 64<<10
+)
+
 type Guest struct {
 	validator *validator.Validate
 	cs        *usecase.GuestUsecase
@@ -24,18 +40,93 @@ func NewGuest(cs *usecase.GuestUsecase) *Guest {
 	}
 }
 
+// toGuestFilter reads the filters of a guest list, desc is the order when
+// none is asked for
+func toGuestFilter(userTemplateID string, f model.GuestFilterRequest, desc bool) sql.GuestFilter {
+	if f.Order != "" {
+		desc = f.Order == "desc"
+	}
+
+	return sql.GuestFilter{
+		UserTemplateID: userTemplateID,
+		Query:          strings.TrimSpace(f.Query),
+		Group:          f.Group,
+		Tag:            f.Tag,
+		Attend:         f.Attend,
+		Viewed:         f.Viewed,
+		SortBy:         f.Sort,
+		Desc:           desc,
+	}
+}
+
+func (h *Guest) toGuestModel(v domain.Guest) model.Guest {
+	k := model.Guest{
+		Address:         v.Address,
+		Attend:          v.Attend,
+		CreatedAt:       v.CreatedAt,
+		Group:           v.Group,
+		Id:              v.ID,
+		Message:         v.Message,
+		Name:            v.Name,
+		Person:          v.Person,
+		Tags:            v.Tags,
+		Telp:            v.Telp,
+		UpdatedAt:       v.UpdatedAt,
+		UserTemplateId:  v.UserTemplateID,
+		InvitationToken: h.cs.InvitationToken(v),
+		AttendPerson:    v.AttendPerson,
+		Answers:         v.Answers,
+		Members:         toGuestMemberModels(v.Members),
+	}
+
+	if v.ViewAt != nil && !v.ViewAt.IsZero() {
+		k.ViewAt = v.ViewAt
+	}
+
+	return k
+}
+
+func toGuestMemberModels(members []domain.GuestMember) []model.GuestMember {
+	if len(members) == 0 {
+		return nil
+	}
+
+	result := make([]model.GuestMember, 0, len(members))
+	for _, v := range members {
+		result = append(result, model.GuestMember{
+			Id:      v.ID,
+			Name:    v.Name,
+			PlusOne: v.PlusOne,
+			Attend:  v.Attend,
+		})
+	}
+	return result
+}
+
+func toGuestMembers(members []model.GuestMemberPayload) []domain.GuestMember {
+	result := make([]domain.GuestMember, 0, len(members))
+	for _, v := range members {
+		result = append(result, domain.GuestMember{
+			ID:      v.Id,
+			Name:    v.Name,
+			PlusOne: v.PlusOne,
+		})
+	}
+	return result
+}
+
 func (h *Guest) UpdateLastView(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	// Retrieve your data in one line of code!
-	input := r.Context().Value(httpin.Input).(*model.IdentityRequest)
+	input := r.Context().Value(httpin.Input).(*model.InvitationRequest)
 
 	if err := h.validator.Struct(input); err != nil {
 		renderError(w, r, http.StatusBadRequest, "Validation failed", err)
 		return
 	}
 
-	if err := h.cs.UpdateLastView(ctx, input.ID); err != nil {
-		renderError(w, r, http.StatusBadRequest, "Update Guest Last View failed", err)
+	if err := h.cs.UpdateLastView(ctx, input.Token); err != nil {
+		renderError(w, r, errorStatus(err, http.StatusBadRequest), "Update Guest Last View failed", err)
 		return
 	}
 
@@ -46,29 +137,42 @@ func (h *Guest) UpdateLastView(w http.ResponseWriter, r *http.Request) {
 func (h *Guest) GetGuest(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	// Retrieve your data in one line of code!
-	input := r.Context().Value(httpin.Input).(*model.IdentityRequest)
+	input := r.Context().Value(httpin.Input).(*model.InvitationRequest)
 
 	if err := h.validator.Struct(input); err != nil {
 		renderError(w, r, http.StatusBadRequest, "Validation failed", err)
 		return
 	}
 
-	guest, err := h.cs.GetGuest(ctx, input.ID)
+	data, err := h.cs.GetGuest(ctx, input.Token)
 	if err != nil {
-		renderError(w, r, http.StatusBadRequest, "Get Guest Message failed", err)
+		renderError(w, r, errorStatus(err, http.StatusBadRequest), "Get Guest Message failed", err)
 		return
 	}
+	guest, questions := data.Guest, data.Questions
+
+	result := model.SafeGuest{
+		Name:         guest.Name,
+		Group:        guest.Group,
+		Person:       guest.Person,
+		Address:      guest.Address,
+		Message:      guest.Message,
+		ViewAt:       guest.ViewAt,
+		Attend:       guest.Attend,
+		AttendPerson: guest.AttendPerson,
+		Answers:      guest.Answers,
+		Questions:    make([]model.RSVPQuestion, 0, len(questions)),
+		Slug:         data.Template.Slug,
+		RSVPOpen:     data.Template.RSVPOpen(time.Now()),
+		RSVPDeadline: data.Template.RSVPDeadline,
+		Members:      toGuestMemberModels(guest.Members),
+	}
+	for _, v := range questions {
+		result.Questions = append(result.Questions, toRSVPQuestionModel(v))
+	}
 
 	render.Status(r, http.StatusOK)
-	render.JSON(w, r, model.SafeGuest{
-		Name:    guest.Name,
-		Group:   guest.Group,
-		Person:  guest.Person,
-		Address: guest.Address,
-		Message: guest.Message,
-		ViewAt:  guest.ViewAt,
-		Attend:  guest.Attend,
-	})
+	render.JSON(w, r, result)
 }
 
 func (h *Guest) UpdateMessage(w http.ResponseWriter, r *http.Request) {
@@ -81,8 +185,18 @@ func (h *Guest) UpdateMessage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.cs.UpdateMessageAndLastView(ctx, input.Payload.ID, input.Payload.Message, input.Payload.Attend); err != nil {
-		renderError(w, r, http.StatusBadRequest, "update Guest Message failed", err)
+	rsvp := usecase.RSVP{
+		Attend:  input.Payload.Attend,
+		Person:  input.Payload.Person,
+		Message: input.Payload.Message,
+		Answers: input.Payload.Answers,
+	}
+	for _, v := range input.Payload.Members {
+		rsvp.Members = append(rsvp.Members, usecase.MemberRSVP{ID: v.Id, Attend: v.Attend, Name: v.Name})
+	}
+
+	if err := h.cs.SubmitRSVP(ctx, input.Token, rsvp); err != nil {
+		renderError(w, r, errorStatus(err, http.StatusBadRequest), "update Guest Message failed", err)
 		return
 	}
 
@@ -109,8 +223,9 @@ func (h *Guest) Create(w http.ResponseWriter, r *http.Request) {
 		Tags:           input.Payload.Tags,
 		Telp:           input.Payload.Telp,
 		Address:        input.Payload.Address,
+		Members:        toGuestMembers(input.Payload.Members),
 	}); err != nil {
-		renderError(w, r, http.StatusBadRequest, "Create Guest failed", err)
+		renderError(w, r, errorStatus(err, http.StatusBadRequest), "Create Guest failed", err)
 		return
 	}
 
@@ -121,35 +236,390 @@ func (h *Guest) Create(w http.ResponseWriter, r *http.Request) {
 func (h *Guest) List(w http.ResponseWriter, r *http.Request) {
 	input := r.Context().Value(httpin.Input).(*model.GuestListRequest)
 
-	data, err := h.cs.List(r.Context(), input.UserTemplateID, input.Page, input.Limit)
+	if err := h.validator.Struct(input); err != nil {
+		renderError(w, r, http.StatusBadRequest, "Validation failed", err)
+		return
+	}
+
+	data, err := h.cs.List(r.Context(), toGuestFilter(input.UserTemplateID, input.GuestFilterRequest, true), input.Page, input.Limit)
 	if err != nil {
-		renderError(w, r, http.StatusBadRequest, "get template list error", err)
+		renderError(w, r, errorStatus(err, http.StatusBadRequest), "get template list error", err)
 		return
 	}
 
-	result := model.GuestListResult{Total: int(data.Total)}
+	result := model.GuestListResult{Total: int(data.Total), Data: make([]model.Guest, 0, len(data.Data))}
 	for _, v := range data.Data {
-		k := model.Guest{
-			Address:        v.Address,
-			Attend:         v.Attend,
-			CreatedAt:      v.CreatedAt,
-			Group:          v.Group,
-			Id:             v.ID,
-			Message:        v.Message,
-			Name:           v.Name,
-			Person:         v.Person,
-			Tags:           v.Tags,
-			Telp:           v.Telp,
-			UpdatedAt:      v.UpdatedAt,
-			UserTemplateId: v.UserTemplateID,
-		}
+		result.Data = append(result.Data, h.toGuestModel(v))
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, result)
+}
+
+// Update changes the fields sent in the body, the rest of the guest is kept
+func (h *Guest) Update(w http.ResponseWriter, r *http.Request) {
+	input := r.Context().Value(httpin.Input).(*model.GuestUpdateRequest)
+
+	if err := h.validator.Struct(input); err != nil {
+		renderError(w, r, http.StatusBadRequest, "Validation failed", err)
+		return
+	}
+
+	guest, err := h.cs.Update(r.Context(), input.ID, sql.GuestUpdate{
+		Name:         input.Payload.Name,
+		Group:        input.Payload.Group,
+		Person:       input.Payload.Person,
+		Tags:         input.Payload.Tags,
+		Telp:         input.Payload.Telp,
+		Address:      input.Payload.Address,
+		Attend:       input.Payload.Attend,
+		AttendPerson: input.Payload.AttendPerson,
+	})
+	if err != nil {
+		renderError(w, r, errorStatus(err, http.StatusBadRequest), "Update Guest failed", err)
+		return
+	}
 
-		if v.ViewAt != nil && !v.ViewAt.IsZero() {
-			k.ViewAt = v.ViewAt
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, h.toGuestModel(*guest))
+}
+
+// SetMembers replaces the household of a guest
+func (h *Guest) SetMembers(w http.ResponseWriter, r *http.Request) {
+	input := r.Context().Value(httpin.Input).(*model.GuestMembersRequest)
+
+	if err := h.validator.Struct(input); err != nil {
+		renderError(w, r, http.StatusBadRequest, "Validation failed", err)
+		return
+	}
+
+	guest, err := h.cs.SetMembers(r.Context(), input.ID, toGuestMembers(input.Payload.Members))
+	if err != nil {
+		renderError(w, r, errorStatus(err, http.StatusBadRequest), "Update Guest Members failed", err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, h.toGuestModel(*guest))
+}
+
+func (h *Guest) Delete(w http.ResponseWriter, r *http.Request) {
+	input := r.Context().Value(httpin.Input).(*model.IdentityRequest)
+
+	if err := h.cs.Delete(r.Context(), input.ID); err != nil {
+		renderError(w, r, errorStatus(err, http.StatusBadRequest), "Delete Guest failed", err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, map[string]interface{}{})
+}
+
+// Duplicates lists the groups of guests of a template that look like the
+// same person
+func (h *Guest) Duplicates(w http.ResponseWriter, r *http.Request) {
+	input := r.Context().Value(httpin.Input).(*model.IdentityRequest)
+
+	data, err := h.cs.Duplicates(r.Context(), input.ID)
+	if err != nil {
+		renderError(w, r, errorStatus(err, http.StatusBadRequest), "Find duplicate guests failed", err)
+		return
+	}
+
+	result := model.GuestDuplicateList{Data: make([]model.GuestDuplicate, 0, len(data))}
+	for _, v := range data {
+		group := model.GuestDuplicate{Reasons: v.Reasons, Guests: make([]model.Guest, 0, len(v.Guests))}
+		for _, guest := range v.Guests {
+			group.Guests = append(group.Guests, h.toGuestModel(guest))
 		}
-		result.Data = append(result.Data, k)
+		result.Data = append(result.Data, group)
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, result)
+}
+
+// Merge folds duplicate guests into one
+func (h *Guest) Merge(w http.ResponseWriter, r *http.Request) {
+	input := r.Context().Value(httpin.Input).(*model.GuestMergeRequest)
+
+	if err := h.validator.Struct(input); err != nil {
+		renderError(w, r, http.StatusBadRequest, "Validation failed", err)
+		return
+	}
+
+	guest, err := h.cs.Merge(r.Context(), input.ID, input.Payload.GuestID, input.Payload.MergeIDs)
+	if err != nil {
+		renderError(w, r, errorStatus(err, http.StatusBadRequest), "Merge Guests failed", err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, h.toGuestModel(*guest))
+}
+
+func toInvitationModel(v usecase.Invitation) model.Invitation {
+	return model.Invitation{
+		GuestId:  v.GuestID,
+		Token:    v.Token,
+		ExpireAt: v.ExpireAt,
+		Revoked:  v.Revoked,
+	}
+}
+
+// GetInvitation returns the public link of a guest
+func (h *Guest) GetInvitation(w http.ResponseWriter, r *http.Request) {
+	input := r.Context().Value(httpin.Input).(*model.IdentityRequest)
+
+	data, err := h.cs.GetInvitation(r.Context(), input.ID)
+	if err != nil {
+		renderError(w, r, errorStatus(err, http.StatusBadRequest), "Get Invitation failed", err)
+		return
 	}
 
 	render.Status(r, http.StatusOK)
+	render.JSON(w, r, toInvitationModel(data))
+}
+
+// RegenerateInvitation gives a guest a new public link, the old one stops
+// working
+func (h *Guest) RegenerateInvitation(w http.ResponseWriter, r *http.Request) {
+	input := r.Context().Value(httpin.Input).(*model.IdentityRequest)
+
+	data, err := h.cs.RegenerateInvitation(r.Context(), input.ID)
+	if err != nil {
+		renderError(w, r, errorStatus(err, http.StatusBadRequest), "Regenerate Invitation failed", err)
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, toInvitationModel(data))
+}
+
+// RevokeInvitation disables the public link of a guest
+func (h *Guest) RevokeInvitation(w http.ResponseWriter, r *http.Request) {
+	input := r.Context().Value(httpin.Input).(*model.IdentityRequest)
+
+	data, err := h.cs.RevokeInvitation(r.Context(), input.ID)
+	if err != nil {
+		renderError(w, r, errorStatus(err, http.StatusBadRequest), "Revoke Invitation failed", err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, toInvitationModel(data))
+}
+
+// Import adds guests from a CSV or XLSX file, dry_run=true only reports what
+// would happen
+func (h *Guest) Import(w http.ResponseWriter, r *http.Request) {
+	input := r.Context().Value(httpin.Input).(*model.GuestImportRequest)
+
+	if err := h.validator.Struct(input); err != nil {
+		renderError(w, r, http.StatusBadRequest, "Validation failed", err)
+		return
+	}
+
+	if input.File == nil {
+		renderError(w, r, http.StatusBadRequest, "Validation failed", errors.New("file is required"))
+		return
+	}
+
+	if input.File.Size() > MaxGuestImportSize {
+		renderError(w, r, http.StatusRequestEntityTooLarge, "Import Guest failed", fmt.Errorf("file too large: %d bytes", input.File.Size()))
+		return
+	}
+
+	opt := usecase.GuestImportOptions{
+		DryRun:         input.DryRun,
+		UpdateExisting: input.OnDuplicate == "update",
+	}
+	if input.Mapping != "" {
+		if err := json.Unmarshal([]byte(input.Mapping), &opt.Mapping); err != nil {
+			renderError(w, r, http.StatusBadRequest, "Validation failed", fmt.Errorf("mapping: %w", err))
+			return
+		}
+	}
+
+	format, err := sheet.FormatOf(input.File.Filename())
+	if err != nil {
+		renderError(w, r, http.StatusBadRequest, "Import Guest failed", err)
+		return
+	}
+
+	file, err := input.File.OpenReceiveStream()
+	if err != nil {
+		renderError(w, r, http.StatusBadRequest, "Import Guest failed", err)
+		return
+	}
+	defer file.Close()
+
+	rows, err := sheet.Read(file, format)
+	if errors.Is(err, sheet.ErrTooLarge) {
+		renderError(w, r, http.StatusRequestEntityTooLarge, "Import Guest failed", err)
+		return
+	}
+	if err != nil {
+		renderError(w, r, http.StatusBadRequest, "Import Guest failed", err)
+		return
+	}
+
+	data, err := h.cs.Import(r.Context(), input.ID, rows, opt)
+	if err != nil {
+		renderError(w, r, errorStatus(err, http.StatusBadRequest), "Import Guest failed", err)
+		return
+	}
+
+	result := model.GuestImportResult{
+		DryRun:  data.DryRun,
+		Total:   data.Total,
+		Created: data.Created,
+		Updated: data.Updated,
+		Skipped: data.Skipped,
+		Errors:  make([]model.GuestImportRowError, 0, len(data.Errors)),
+	}
+	for _, v := range data.Errors {
+		result.Errors = append(result.Errors, model.GuestImportRowError{
+			Row:     v.Row,
+			Field:   v.Field,
+			Message: v.Message,
+		})
+	}
+
+	status := http.StatusOK
+	if !data.DryRun && (data.Created > 0 || data.Updated > 0) {
+		status = http.StatusCreated
+	}
+
+	render.Status(r, status)
 	render.JSON(w, r, result)
 }
+
+// guestExportColumns are the columns of the guest export, in order
+var guestExportColumns = []sheet.Column{
+	{Title: "No", Width: 10},
+	{Title: "Name", Width: 50},
+	{Title: "Group", Width: 28},
+	{Title: "Person", Width: 14},
+	{Title: "RSVP", Width: 20},
+	{Title: "Coming", Width: 16},
+	{Title: "Viewed", Width: 30},
+	{Title: "Message", Width: 64},
+	{Title: "Signature", Width: 45},
+}
+
+// rsvpLabel is the RSVP state of a guest as shown in exports
+func rsvpLabel(attend *bool) string {
+	switch {
+	case attend == nil:
+		return "Pending"
+	case *attend:
+		return "Attending"
+	}
+	return "Not attending"
+}
+
+// memberName is the name of a household member in exports, plus ones may
+// not have one yet
+func memberName(v domain.GuestMember) string {
+	if v.Name == "" {
+		return "Plus one"
+	}
+	return v.Name
+}
+
+// memberLabel lists the members of a household with their RSVP as shown in
+// exports
+func memberLabel(members []domain.GuestMember) string {
+	labels := make([]string, 0, len(members))
+	for _, v := range members {
+		labels = append(labels, fmt.Sprintf("%s (%s)", memberName(v), rsvpLabel(v.Attend)))
+	}
+	return strings.Join(labels, "; ")
+}
+
+// Export downloads the guests of a template as csv, xlsx or a printable pdf
+// attendance sheet, it takes the filters of the list oldest first
+func (h *Guest) Export(w http.ResponseWriter, r *http.Request) {
+	input := r.Context().Value(httpin.Input).(*model.GuestExportRequest)
+
+	if err := h.validator.Struct(input); err != nil {
+		renderError(w, r, http.StatusBadRequest, "Validation failed", err)
+		return
+	}
+
+	data, err := h.cs.Export(r.Context(), toGuestFilter(input.ID, input.GuestFilterRequest, false))
+	if err != nil {
+		renderError(w, r, errorStatus(err, http.StatusBadRequest), "Export Guest failed", err)
+		return
+	}
+	template, guests := data.Template, data.Guests
+
+	filename := fmt.Sprintf("guests-%s-%s.%s", template.Slug, time.Now().Format("20060102"), input.Format)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+
+	if input.Format == "pdf" {
+		rows := make([][]string, 0, len(guests))
+		for i, v := range guests {
+			viewAt, coming := "", ""
+			if v.ViewAt != nil {
+				viewAt = v.ViewAt.Format("2006-01-02 15:04")
+			}
+			if v.AttendPerson != nil {
+				coming = strconv.Itoa(*v.AttendPerson)
+			}
+			rows = append(rows, []string{
+				strconv.Itoa(i + 1), v.Name, v.Group, strconv.Itoa(v.Person),
+				rsvpLabel(v.Attend), coming, viewAt, v.Message, "",
+			})
+
+			// every member of a household signs on its own row
+			for _, m := range v.Members {
+				rows = append(rows, []string{"", "- " + memberName(m), "", "", rsvpLabel(m.Attend), "", "", "", ""})
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/pdf")
+		subtitle := fmt.Sprintf("%d guests, exported %s", len(guests), time.Now().Format("2006-01-02 15:04"))
+		if err := sheet.WritePDF(w, template.Name, subtitle, guestExportColumns, rows); err != nil {
+			log.Printf("export guests %s: %v", template.ID, err)
+		}
+		return
+	}
+
+	// every RSVP question is a column after the fixed ones, titled by its
+	// label
+	header := []any{"id", "name", "group", "person", "tags", "telp", "address", "attend", "attend_person", "members", "view_at", "message", "created_at"}
+	for _, q := range data.Questions {
+		header = append(header, q.Label)
+	}
+
+	rows := make([][]any, 0, len(guests)+1)
+	rows = append(rows, header)
+	for _, v := range guests {
+		var attend, attendPerson, viewAt any
+		if v.Attend != nil {
+			attend = *v.Attend
+		}
+		if v.AttendPerson != nil {
+			attendPerson = *v.AttendPerson
+		}
+		if v.ViewAt != nil {
+			viewAt = v.ViewAt.Format(time.RFC3339)
+		}
+		row := []any{
+			v.ID, v.Name, v.Group, v.Person, strings.Join(v.Tags, ", "), v.Telp, v.Address,
+			attend, attendPerson, memberLabel(v.Members), viewAt, v.Message, v.CreatedAt.Format(time.RFC3339),
+		}
+		for _, q := range data.Questions {
+			row = append(row, v.Answers[q.ID])
+		}
+		rows = append(rows, row)
+	}
+
+	format := sheet.Format(input.Format)
+	w.Header().Set("Content-Type", sheet.ContentType(format))
+	if err := sheet.Write(w, format, "Guests", rows); err != nil {
+		log.Printf("export guests %s: %v", template.ID, err)
+	}
+}
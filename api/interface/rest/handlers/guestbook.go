@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"basic-service/domain"
+	"basic-service/interface/rest/model"
+	"basic-service/usecase"
+	"net/http"
+
+	"github.com/ggicci/httpin"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+)
+
+type Guestbook struct {
+	validator *validator.Validate
+	cs        *usecase.Guestbook
+}
+
+func NewGuestbook(cs *usecase.Guestbook) *Guestbook {
+	return &Guestbook{
+		validator: validator.New(),
+		cs:        cs,
+	}
+}
+
+func toGuestbookEntryModel(v usecase.GuestbookEntry) model.GuestbookEntry {
+	return model.GuestbookEntry{
+		GuestId:   v.ID,
+		Name:      v.Name,
+		Message:   v.Message,
+		Status:    string(v.MessageStatus),
+		Pinned:    v.MessagePinned,
+		Flagged:   v.Flagged,
+		CreatedAt: v.MessageAt,
+	}
+}
+
+// Public returns the approved messages of a template, pinned first and then
+// newest first
+func (h *Guestbook) Public(w http.ResponseWriter, r *http.Request) {
+	input := r.Context().Value(httpin.Input).(*model.GuestbookRequest)
+
+	if err := h.validator.Struct(input); err != nil {
+		renderError(w, r, http.StatusBadRequest, "Validation failed", err)
+		return
+	}
+
+	data, err := h.cs.Public(r.Context(), input.Slug, input.Page, input.Limit)
+	if err != nil {
+		renderError(w, r, errorStatus(err, http.StatusBadRequest), "Get Guestbook failed", err)
+		return
+	}
+
+	result := model.GuestbookResult{Total: int(data.Total), Data: make([]model.GuestbookMessage, 0, len(data.Data))}
+	for _, v := range data.Data {
+		result.Data = append(result.Data, model.GuestbookMessage{
+			Name:      v.Name,
+			Message:   v.Message,
+			Pinned:    v.MessagePinned,
+			CreatedAt: v.MessageAt,
+		})
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, result)
+}
+
+// List returns the messages of a template in every state for moderation
+func (h *Guestbook) List(w http.ResponseWriter, r *http.Request) {
+	input := r.Context().Value(httpin.Input).(*model.GuestbookListRequest)
+
+	if err := h.validator.Struct(input); err != nil {
+		renderError(w, r, http.StatusBadRequest, "Validation failed", err)
+		return
+	}
+
+	data, err := h.cs.List(r.Context(), input.ID, domain.MessageStatus(input.Status), input.Page, input.Limit)
+	if err != nil {
+		renderError(w, r, errorStatus(err, http.StatusBadRequest), "List Guestbook failed", err)
+		return
+	}
+
+	result := model.GuestbookListResult{Total: int(data.Total), Data: make([]model.GuestbookEntry, 0, len(data.Data))}
+	for _, v := range data.Data {
+		result.Data = append(result.Data, toGuestbookEntryModel(v))
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, result)
+}
+
+// Moderate approves, holds or hides a message and pins or unpins it
+func (h *Guestbook) Moderate(w http.ResponseWriter, r *http.Request) {
+	input := r.Context().Value(httpin.Input).(*model.GuestbookModerateRequest)
+
+	if err := h.validator.Struct(input); err != nil {
+		renderError(w, r, http.StatusBadRequest, "Validation failed", err)
+		return
+	}
+
+	var status *domain.MessageStatus
+	if input.Payload.Status != nil {
+		v := domain.MessageStatus(*input.Payload.Status)
+		status = &v
+	}
+
+	data, err := h.cs.Moderate(r.Context(), input.ID, input.GuestID, status, input.Payload.Pinned)
+	if err != nil {
+		renderError(w, r, errorStatus(err, http.StatusBadRequest), "Moderate Guestbook failed", err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, toGuestbookEntryModel(data))
+}
+
+func (h *Guestbook) Settings(w http.ResponseWriter, r *http.Request) {
+	input := r.Context().Value(httpin.Input).(*model.IdentityRequest)
+
+	data, err := h.cs.Settings(r.Context(), input.ID)
+	if err != nil {
+		renderError(w, r, errorStatus(err, http.StatusBadRequest), "Get Guestbook Settings failed", err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, model.GuestbookSettings{ApproveFirst: data.ApproveFirst, BlockedWords: data.BlockedWords})
+}
+
+// UpdateSettings replaces the moderation settings, blocked words are stored
+// lower case
+func (h *Guestbook) UpdateSettings(w http.ResponseWriter, r *http.Request) {
+	input := r.Context().Value(httpin.Input).(*model.GuestbookSettingsRequest)
+
+	if err := h.validator.Struct(input); err != nil {
+		renderError(w, r, http.StatusBadRequest, "Validation failed", err)
+		return
+	}
+
+	data, err := h.cs.UpdateSettings(r.Context(), input.ID, usecase.GuestbookSettings{
+		ApproveFirst: input.Payload.ApproveFirst,
+		BlockedWords: input.Payload.BlockedWords,
+	})
+	if err != nil {
+		renderError(w, r, errorStatus(err, http.StatusBadRequest), "Update Guestbook Settings failed", err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, model.GuestbookSettings{ApproveFirst: data.ApproveFirst, BlockedWords: data.BlockedWords})
+}
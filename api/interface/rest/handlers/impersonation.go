@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"basic-service/interface/rest/model"
+	"net/http"
+	"time"
+
+	"github.com/ggicci/httpin"
+	"github.com/go-chi/render"
+)
+
+// Impersonate mints a short lived token acting as the user for support
+func (h *AuthHandler) Impersonate(w http.ResponseWriter, r *http.Request) {
+	input := r.Context().Value(httpin.Input).(*model.ImpersonateRequest)
+
+	if err := h.validator.Struct(input); err != nil {
+		renderError(w, r, http.StatusBadRequest, "Validation failed", err)
+		return
+	}
+
+	ttl := time.Duration(input.Payload.ExpiresInMinutes) * time.Minute
+
+	token, err := h.cs.Impersonate(r.Context(), input.ID, input.Payload.Reason, ttl, input.Payload.AllowDestructive)
+	if err != nil {
+		renderError(w, r, errorStatus(err, http.StatusBadRequest), "Impersonate User failed", err)
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, model.ImpersonateResponse{
+		ID:               token.ID,
+		Token:            token.AccessToken,
+		ExpiresAt:        token.ExpiresAt,
+		UserID:           token.UserID,
+		AllowDestructive: token.AllowDestructive,
+	})
+}
+
+// RevokeImpersonation ends an impersonation token before it expires
+func (h *AuthHandler) RevokeImpersonation(w http.ResponseWriter, r *http.Request) {
+	input := r.Context().Value(httpin.Input).(*model.ImpersonationTokenRequest)
+
+	if err := h.cs.RevokeImpersonation(r.Context(), input.ID, input.TokenID); err != nil {
+		renderError(w, r, errorStatus(err, http.StatusBadRequest), "Revoke Impersonation failed", err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, map[string]interface{}{})
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"basic-service/interface/rest/model"
+	"basic-service/interface/sql"
+	"basic-service/usecase"
+	"errors"
+	"net/http"
+
+	"github.com/ggicci/httpin"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+)
+
+type OIDCHandler struct {
+	validator *validator.Validate
+	cs        *usecase.OIDCLogin
+}
+
+func NewOIDCHandler(cs *usecase.OIDCLogin) *OIDCHandler {
+	return &OIDCHandler{
+		validator: validator.New(),
+		cs:        cs,
+	}
+}
+
+// Providers lists the identity providers a user can sign in with
+func (h *OIDCHandler) Providers(w http.ResponseWriter, r *http.Request) {
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, map[string]interface{}{"providers": h.cs.Providers()})
+}
+
+// Authorize returns the provider URL the frontend has to open
+func (h *OIDCHandler) Authorize(w http.ResponseWriter, r *http.Request) {
+	input := r.Context().Value(httpin.Input).(*model.OIDCProviderRequest)
+
+	authURL, err := h.cs.AuthorizationURL(r.Context(), input.Provider)
+	if err != nil {
+		renderError(w, r, oidcErrorStatus(err), "OIDC Authorize failed", err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, model.OIDCAuthorizeResponse{AuthorizationURL: authURL})
+}
+
+// Callback exchanges the code and state sent back by the provider for tokens
+func (h *OIDCHandler) Callback(w http.ResponseWriter, r *http.Request) {
+	input := r.Context().Value(httpin.Input).(*model.OIDCCallbackRequest)
+
+	if err := h.validator.Struct(input); err != nil {
+		renderError(w, r, http.StatusBadRequest, "Validation failed", err)
+		return
+	}
+
+	token, err := h.cs.Callback(r.Context(), input.Provider, input.Payload.Code, input.Payload.State)
+	if err != nil {
+		renderError(w, r, oidcErrorStatus(err), "OIDC Login failed", err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, loginResponse(token))
+}
+
+func oidcErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, usecase.ErrOIDCProviderNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, usecase.ErrOIDCUnavailable):
+		return http.StatusBadGateway
+	case errors.Is(err, sql.ErrOIDCStateInvalid), errors.Is(err, usecase.ErrOIDCInvalidToken),
+		errors.Is(err, usecase.ErrOIDCEmailNotVerified), errors.Is(err, usecase.ErrUserNotActive):
+		return http.StatusUnauthorized
+	}
+	return http.StatusBadRequest
+}
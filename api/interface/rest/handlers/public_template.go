@@ -53,7 +53,7 @@ func (h *PublicTemplate) Create(w http.ResponseWriter, r *http.Request) {
 		CoverImage:    path.Join("uploads", coverURL),
 		State:         input.State,
 	}); err != nil {
-		renderError(w, r, http.StatusBadRequest, "Create Public Template failed", err)
+		renderError(w, r, errorStatus(err, http.StatusBadRequest), "Create Public Template failed", err)
 		return
 	}
 
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"basic-service/domain"
+	"basic-service/interface/rest/model"
+	"net/http"
+
+	"github.com/ggicci/httpin"
+	"github.com/go-chi/render"
+)
+
+func toRSVPQuestionModel(v domain.RSVPQuestion) model.RSVPQuestion {
+	return model.RSVPQuestion{
+		Id:        v.ID,
+		Label:     v.Label,
+		Type:      string(v.Type),
+		Options:   v.Options,
+		Required:  v.Required,
+		Position:  v.Position,
+		CreatedAt: v.CreatedAt,
+	}
+}
+
+func toRSVPQuestion(templateID, id string, v model.RSVPQuestionPayload) domain.RSVPQuestion {
+	return domain.RSVPQuestion{
+		ID:             id,
+		UserTemplateID: templateID,
+		Label:          v.Label,
+		Type:           domain.RSVPQuestionType(v.Type),
+		Options:        v.Options,
+		Required:       v.Required,
+		Position:       v.Position,
+	}
+}
+
+// ListQuestions returns the RSVP questions of a template in the order they
+// are asked
+func (h *Guest) ListQuestions(w http.ResponseWriter, r *http.Request) {
+	input := r.Context().Value(httpin.Input).(*model.IdentityRequest)
+
+	questions, err := h.cs.Questions(r.Context(), input.ID)
+	if err != nil {
+		renderError(w, r, errorStatus(err, http.StatusBadRequest), "List RSVP Question failed", err)
+		return
+	}
+
+	result := make([]model.RSVPQuestion, 0, len(questions))
+	for _, v := range questions {
+		result = append(result, toRSVPQuestionModel(v))
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, result)
+}
+
+func (h *Guest) CreateQuestion(w http.ResponseWriter, r *http.Request) {
+	input := r.Context().Value(httpin.Input).(*model.RSVPQuestionCreateRequest)
+
+	if err := h.validator.Struct(input); err != nil {
+		renderError(w, r, http.StatusBadRequest, "Validation failed", err)
+		return
+	}
+
+	question, err := h.cs.CreateQuestion(r.Context(), toRSVPQuestion(input.ID, "", input.Payload))
+	if err != nil {
+		renderError(w, r, errorStatus(err, http.StatusBadRequest), "Create RSVP Question failed", err)
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, toRSVPQuestionModel(question))
+}
+
+func (h *Guest) UpdateQuestion(w http.ResponseWriter, r *http.Request) {
+	input := r.Context().Value(httpin.Input).(*model.RSVPQuestionUpdateRequest)
+
+	if err := h.validator.Struct(input); err != nil {
+		renderError(w, r, http.StatusBadRequest, "Validation failed", err)
+		return
+	}
+
+	question, err := h.cs.UpdateQuestion(r.Context(), toRSVPQuestion(input.ID, input.QuestionID, input.Payload))
+	if err != nil {
+		renderError(w, r, errorStatus(err, http.StatusBadRequest), "Update RSVP Question failed", err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, toRSVPQuestionModel(question))
+}
+
+// DeleteQuestion removes a question with the answers given to it
+func (h *Guest) DeleteQuestion(w http.ResponseWriter, r *http.Request) {
+	input := r.Context().Value(httpin.Input).(*model.RSVPQuestionRequest)
+
+	if err := h.cs.DeleteQuestion(r.Context(), input.ID, input.QuestionID); err != nil {
+		renderError(w, r, errorStatus(err, http.StatusBadRequest), "Delete RSVP Question failed", err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, map[string]interface{}{})
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"basic-service/domain"
+	"basic-service/interface/rest/model"
+	"net/http"
+
+	"github.com/ggicci/httpin"
+	"github.com/go-chi/render"
+)
+
+func toSessionModel(v domain.Session) model.Session {
+	return model.Session{
+		Id:         v.ID,
+		UserAgent:  v.UserAgent,
+		Ip:         v.IP,
+		CreatedAt:  v.CreatedAt,
+		LastSeenAt: v.LastSeenAt,
+	}
+}
+
+func (h *AuthHandler) ListSessions(w http.ResponseWriter, r *http.Request) {
+	sessions, err := h.cs.ListSessions(r.Context())
+	if err != nil {
+		renderError(w, r, http.StatusBadRequest, "List Session failed", err)
+		return
+	}
+
+	resp := make([]model.Session, 0, len(sessions))
+	for _, v := range sessions {
+		session := toSessionModel(v.Session)
+		session.Current = v.Current
+		resp = append(resp, session)
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, resp)
+}
+
+func (h *AuthHandler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	input := r.Context().Value(httpin.Input).(*model.IdentityRequest)
+
+	if err := h.cs.RevokeSession(r.Context(), input.ID); err != nil {
+		renderError(w, r, errorStatus(err, http.StatusBadRequest), "Revoke Session failed", err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, map[string]interface{}{})
+}
+
+// RevokeAllSessions signs out everywhere, except_current=true keeps the
+// session of the request
+func (h *AuthHandler) RevokeAllSessions(w http.ResponseWriter, r *http.Request) {
+	input := r.Context().Value(httpin.Input).(*model.RevokeSessionsRequest)
+
+	if err := h.cs.RevokeAllSessions(r.Context(), input.ExceptCurrent); err != nil {
+		renderError(w, r, errorStatus(err, http.StatusBadRequest), "Revoke Session failed", err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, map[string]interface{}{})
+}
+
+func (h *UserHandler) ListUserSessions(w http.ResponseWriter, r *http.Request) {
+	input := r.Context().Value(httpin.Input).(*model.IdentityRequest)
+
+	sessions, err := h.cs.ListSessions(r.Context(), input.ID)
+	if err != nil {
+		renderError(w, r, errorStatus(err, http.StatusBadRequest), "List User Session failed", err)
+		return
+	}
+
+	resp := make([]model.Session, 0, len(sessions))
+	for _, v := range sessions {
+		resp = append(resp, toSessionModel(v))
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, resp)
+}
+
+// RevokeUserSessions signs a user out everywhere
+func (h *UserHandler) RevokeUserSessions(w http.ResponseWriter, r *http.Request) {
+	input := r.Context().Value(httpin.Input).(*model.IdentityRequest)
+
+	if err := h.cs.RevokeSessions(r.Context(), input.ID, ""); err != nil {
+		renderError(w, r, errorStatus(err, http.StatusBadRequest), "Revoke User Session failed", err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, map[string]interface{}{})
+}
+
+func (h *UserHandler) RevokeUserSession(w http.ResponseWriter, r *http.Request) {
+	input := r.Context().Value(httpin.Input).(*model.UserSessionRequest)
+
+	if err := h.cs.RevokeSessions(r.Context(), input.ID, input.SessionID); err != nil {
+		renderError(w, r, errorStatus(err, http.StatusBadRequest), "Revoke User Session failed", err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, map[string]interface{}{})
+}
@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"basic-service/domain"
+	"basic-service/interface/rest/model"
+	"basic-service/usecase"
+	"encoding/json"
+	"net/http"
+
+	"github.com/ggicci/httpin"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+)
+
+type TemplateMember struct {
+	validator *validator.Validate
+	cs        *usecase.TemplateMembers
+}
+
+func NewTemplateMember(cs *usecase.TemplateMembers) *TemplateMember {
+	return &TemplateMember{
+		validator: validator.New(),
+		cs:        cs,
+	}
+}
+
+func toTemplateInviteModel(v domain.TemplateInvite) model.TemplateInvite {
+	return model.TemplateInvite{
+		Id:        v.ID,
+		Email:     v.Email,
+		Role:      string(v.Role),
+		InvitedBy: v.InvitedBy,
+		ExpiresAt: v.ExpiresAt,
+		CreatedAt: v.CreatedAt,
+	}
+}
+
+func (h *TemplateMember) List(w http.ResponseWriter, r *http.Request) {
+	input := r.Context().Value(httpin.Input).(*model.IdentityRequest)
+
+	members, err := h.cs.List(r.Context(), input.ID)
+	if err != nil {
+		renderError(w, r, errorStatus(err, http.StatusBadRequest), "List Template Member failed", err)
+		return
+	}
+
+	resp := make([]model.TemplateMember, 0, len(members))
+	for _, v := range members {
+		resp = append(resp, model.TemplateMember{
+			UserId:    v.UserID,
+			Name:      v.Name,
+			Email:     v.Email,
+			Role:      string(v.Role),
+			CreatedAt: v.CreatedAt,
+		})
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, resp)
+}
+
+func (h *TemplateMember) UpdateRole(w http.ResponseWriter, r *http.Request) {
+	input := r.Context().Value(httpin.Input).(*model.TemplateMemberRoleRequest)
+
+	if err := h.validator.Struct(input); err != nil {
+		renderError(w, r, http.StatusBadRequest, "Validation failed", err)
+		return
+	}
+
+	if err := h.cs.UpdateRole(r.Context(), input.ID, input.UserID, domain.TemplateRole(input.Payload.Role)); err != nil {
+		renderError(w, r, errorStatus(err, http.StatusBadRequest), "Update Template Member failed", err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, map[string]interface{}{})
+}
+
+// Remove takes a member off the template, members can remove themselves to
+// leave
+func (h *TemplateMember) Remove(w http.ResponseWriter, r *http.Request) {
+	input := r.Context().Value(httpin.Input).(*model.TemplateMemberRequest)
+
+	if err := h.cs.Remove(r.Context(), input.ID, input.UserID); err != nil {
+		renderError(w, r, errorStatus(err, http.StatusBadRequest), "Remove Template Member failed", err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, map[string]interface{}{})
+}
+
+func (h *TemplateMember) Invite(w http.ResponseWriter, r *http.Request) {
+	input := r.Context().Value(httpin.Input).(*model.TemplateInviteCreateRequest)
+
+	if err := h.validator.Struct(input); err != nil {
+		renderError(w, r, http.StatusBadRequest, "Validation failed", err)
+		return
+	}
+
+	invite, err := h.cs.Invite(r.Context(), input.ID, input.Payload.Email, domain.TemplateRole(input.Payload.Role))
+	if err != nil {
+		renderError(w, r, errorStatus(err, http.StatusBadRequest), "Invite Template Member failed", err)
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, toTemplateInviteModel(invite))
+}
+
+func (h *TemplateMember) ListInvites(w http.ResponseWriter, r *http.Request) {
+	input := r.Context().Value(httpin.Input).(*model.IdentityRequest)
+
+	invites, err := h.cs.ListInvites(r.Context(), input.ID)
+	if err != nil {
+		renderError(w, r, errorStatus(err, http.StatusBadRequest), "List Template Invite failed", err)
+		return
+	}
+
+	resp := make([]model.TemplateInvite, 0, len(invites))
+	for _, v := range invites {
+		resp = append(resp, toTemplateInviteModel(v))
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, resp)
+}
+
+func (h *TemplateMember) RevokeInvite(w http.ResponseWriter, r *http.Request) {
+	input := r.Context().Value(httpin.Input).(*model.TemplateInviteRequest)
+
+	if err := h.cs.RevokeInvite(r.Context(), input.ID, input.InviteID); err != nil {
+		renderError(w, r, errorStatus(err, http.StatusBadRequest), "Revoke Template Invite failed", err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, map[string]interface{}{})
+}
+
+// AcceptInvite joins the template of an emailed invitation
+func (h *TemplateMember) AcceptInvite(w http.ResponseWriter, r *http.Request) {
+	var req model.AcceptInviteRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		renderError(w, r, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		renderError(w, r, http.StatusBadRequest, "Validation failed", err)
+		return
+	}
+
+	templateID, err := h.cs.AcceptInvite(r.Context(), req.Token)
+	if err != nil {
+		renderError(w, r, errorStatus(err, http.StatusBadRequest), "Accept Template Invite failed", err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, map[string]interface{}{"user_template_id": templateID})
+}
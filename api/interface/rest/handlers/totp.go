@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"basic-service/interface/rest/model"
+	"basic-service/usecase"
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/render"
+)
+
+// VerifyMFA finishes a login that returned a challenge token
+func (h *AuthHandler) VerifyMFA(w http.ResponseWriter, r *http.Request) {
+	var req model.MFAVerifyRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		renderError(w, r, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		renderError(w, r, http.StatusBadRequest, "Validation failed", err)
+		return
+	}
+
+	token, err := h.cs.VerifyMFA(r.Context(), req.ChallengeToken, req.Code, usecase.ClientFromContext(r.Context()).IP)
+	if err != nil {
+		renderError(w, r, errorStatus(err, http.StatusUnauthorized), "Two-factor verification failed", err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, loginResponse(token))
+}
+
+func (h *AuthHandler) TOTPStatus(w http.ResponseWriter, r *http.Request) {
+	status, err := h.cs.TOTPStatus(r.Context())
+	if err != nil {
+		renderError(w, r, http.StatusBadRequest, "Get Two-factor Status failed", err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, model.TOTPStatusResponse{
+		Enabled:       status.Enabled,
+		Required:      status.Required,
+		RecoveryCodes: status.RecoveryCodes,
+	})
+}
+
+// SetupTOTP returns a new secret and its otpauth URI to show as a QR code
+func (h *AuthHandler) SetupTOTP(w http.ResponseWriter, r *http.Request) {
+	setup, err := h.cs.SetupTOTP(r.Context())
+	if err != nil {
+		renderError(w, r, errorStatus(err, http.StatusBadRequest), "Two-factor Setup failed", err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, model.TOTPSetupResponse{
+		Secret:     setup.Secret,
+		OtpauthURI: setup.URI,
+	})
+}
+
+// EnableTOTP confirms the setup, every session is signed out afterwards
+func (h *AuthHandler) EnableTOTP(w http.ResponseWriter, r *http.Request) {
+	var req model.TOTPCodeRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		renderError(w, r, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		renderError(w, r, http.StatusBadRequest, "Validation failed", err)
+		return
+	}
+
+	codes, err := h.cs.EnableTOTP(r.Context(), req.Code)
+	if err != nil {
+		renderError(w, r, errorStatus(err, http.StatusBadRequest), "Enable Two-factor failed", err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, model.RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+func (h *AuthHandler) DisableTOTP(w http.ResponseWriter, r *http.Request) {
+	var req model.TOTPDisableRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		renderError(w, r, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		renderError(w, r, http.StatusBadRequest, "Validation failed", err)
+		return
+	}
+
+	if err := h.cs.DisableTOTP(r.Context(), req.Password, req.Code); err != nil {
+		renderError(w, r, errorStatus(err, http.StatusBadRequest), "Disable Two-factor failed", err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, map[string]interface{}{})
+}
+
+func (h *AuthHandler) RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	var req model.TOTPCodeRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		renderError(w, r, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		renderError(w, r, http.StatusBadRequest, "Validation failed", err)
+		return
+	}
+
+	codes, err := h.cs.RegenerateRecoveryCodes(r.Context(), req.Code)
+	if err != nil {
+		renderError(w, r, errorStatus(err, http.StatusBadRequest), "Regenerate Recovery Codes failed", err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, model.RecoveryCodesResponse{RecoveryCodes: codes})
+}
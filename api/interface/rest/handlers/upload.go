@@ -158,3 +158,18 @@ func (h *UploadHandler) UploadImage(input *httpin.File) (string, error) {
 	publicURL := newFilename
 	return publicURL, nil
 }
+
+// Remove deletes a file previously returned by UploadImage, publicPath is the
+// stored "uploads/<name>" value. Missing files are ignored.
+func (h *UploadHandler) Remove(publicPath string) error {
+	name := filepath.Base(publicPath)
+	if name == "." || name == string(os.PathSeparator) || name == "uploads" {
+		return nil
+	}
+
+	if err := os.Remove(filepath.Join(h.UploadDir, name)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove upload: %w", err)
+	}
+
+	return nil
+}
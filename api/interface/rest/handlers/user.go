@@ -1,10 +1,11 @@
 package handlers
 
 import (
-	"fmt"
 	"net/http"
 
+	"basic-service/domain"
 	"basic-service/interface/rest/model"
+	"basic-service/interface/sql"
 	"basic-service/usecase"
 
 	"github.com/ggicci/httpin"
@@ -24,35 +25,47 @@ func NewUserHandler(cs *usecase.UserUsecase) *UserHandler {
 	}
 }
 
+func toSafeUserModel(v domain.SafeUser) model.SafeUser {
+	return model.SafeUser{
+		CreatedAt:  v.CreatedAt,
+		Email:      v.Email,
+		Id:         v.ID,
+		IsActive:   v.IsActive,
+		Name:       v.Name,
+		Profile:    v.Profile,
+		Role:       model.UserRole(v.Role),
+		VerifiedAt: v.VerifiedAt,
+		UpdatedAt:  v.UpdatedAt,
+	}
+}
+
 func (h *UserHandler) ListUser(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	// Retrieve your data in one line of code!
-	input := r.Context().Value(httpin.Input).(*model.PaginationRequest)
+	input := r.Context().Value(httpin.Input).(*model.UserListRequest)
 
 	if err := h.validator.Struct(input); err != nil {
 		renderError(w, r, http.StatusBadRequest, "Validation failed", err)
 		return
 	}
 
-	u, err := h.cs.List(ctx, input.Page, input.Limit)
+	u, err := h.cs.List(ctx, sql.UserFilter{
+		Query:       input.Query,
+		Role:        domain.RoleType(input.Role),
+		IsActive:    input.IsActive,
+		CreatedFrom: input.CreatedFrom,
+		CreatedTo:   input.CreatedTo,
+		SortBy:      input.Sort,
+		Desc:        input.Order != "asc",
+	}, input.Page, input.Limit)
 	if err != nil {
-		renderError(w, r, http.StatusBadRequest, "Get UserHandler Message failed", err)
+		renderError(w, r, errorStatus(err, http.StatusBadRequest), "Get UserHandler Message failed", err)
 		return
 	}
 
-	var response []model.SafeUser
-
+	response := make([]model.SafeUser, 0, len(u.Data))
 	for _, v := range u.Data {
-		fmt.Printf("User: %+v\n", v)
-		response = append(response, model.SafeUser{
-			CreatedAt: v.CreatedAt,
-			Email:     v.Email,
-			Id:        v.ID,
-			IsActive:  v.IsActive,
-			Name:      v.Name,
-			Role:      model.UserRole(v.Role),
-			UpdatedAt: v.UpdatedAt,
-		})
+		response = append(response, toSafeUserModel(v))
 	}
 
 	render.Status(r, http.StatusOK)
@@ -61,3 +74,78 @@ func (h *UserHandler) ListUser(w http.ResponseWriter, r *http.Request) {
 		"data":  response,
 	})
 }
+
+// GetUser returns a user with their templates and guest counts
+func (h *UserHandler) GetUser(w http.ResponseWriter, r *http.Request) {
+	input := r.Context().Value(httpin.Input).(*model.IdentityRequest)
+
+	detail, err := h.cs.Get(r.Context(), input.ID)
+	if err != nil {
+		renderError(w, r, errorStatus(err, http.StatusBadRequest), "Get User failed", err)
+		return
+	}
+
+	result := model.UserDetail{
+		SafeUser:   toSafeUserModel(detail.User),
+		GuestCount: detail.GuestCount,
+		Templates:  make([]model.UserTemplateStat, 0, len(detail.Templates)),
+	}
+	for _, v := range detail.Templates {
+		result.Templates = append(result.Templates, model.UserTemplateStat{
+			UserTemplate: toUserTemplateModel(v.Template),
+			GuestCount:   v.GuestCount,
+		})
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, result)
+}
+
+// ChangeUserState activates or deactivates a user
+func (h *UserHandler) ChangeUserState(w http.ResponseWriter, r *http.Request) {
+	input := r.Context().Value(httpin.Input).(*model.PatchUsersRequest)
+
+	if err := h.validator.Struct(input); err != nil {
+		renderError(w, r, http.StatusBadRequest, "Validation failed", err)
+		return
+	}
+
+	if err := h.cs.UpdateState(r.Context(), *input.Payload.UserId, *input.Payload.IsActive); err != nil {
+		renderError(w, r, errorStatus(err, http.StatusBadRequest), "Change User State failed", err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, map[string]interface{}{})
+}
+
+// ChangeUserRole promotes or demotes a user
+func (h *UserHandler) ChangeUserRole(w http.ResponseWriter, r *http.Request) {
+	input := r.Context().Value(httpin.Input).(*model.UserRoleRequest)
+
+	if err := h.validator.Struct(input); err != nil {
+		renderError(w, r, http.StatusBadRequest, "Validation failed", err)
+		return
+	}
+
+	if err := h.cs.ChangeRole(r.Context(), input.ID, domain.RoleType(input.Payload.Role)); err != nil {
+		renderError(w, r, errorStatus(err, http.StatusBadRequest), "Change User Role failed", err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, map[string]interface{}{})
+}
+
+// UnlockUser lifts a login lockout before it expires
+func (h *UserHandler) UnlockUser(w http.ResponseWriter, r *http.Request) {
+	input := r.Context().Value(httpin.Input).(*model.IdentityRequest)
+
+	if err := h.cs.Unlock(r.Context(), input.ID); err != nil {
+		renderError(w, r, errorStatus(err, http.StatusBadRequest), "Unlock User failed", err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, map[string]interface{}{})
+}
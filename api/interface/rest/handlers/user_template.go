@@ -6,6 +6,8 @@ import (
 	"basic-service/usecase"
 	"net/http"
 	"path"
+	"strings"
+	"time"
 
 	"github.com/ggicci/httpin"
 	"github.com/go-chi/render"
@@ -33,6 +35,11 @@ func (h *UserTemplate) Create(w http.ResponseWriter, r *http.Request) {
 	// Retrieve your data in one line of code!
 	input := r.Context().Value(httpin.Input).(*model.UserTemplateCreateRequest)
 
+	if err := h.cs.CanCreate(ctx); err != nil {
+		renderError(w, r, errorStatus(err, http.StatusBadRequest), "Create User Template failed", err)
+		return
+	}
+
 	err := h.upload.UploadTemplate(input.ZipFile, input.Slug)
 	if err != nil {
 		renderError(w, r, http.StatusBadRequest, "Template upload failed", err)
@@ -68,14 +75,15 @@ func (h *UserTemplate) Create(w http.ResponseWriter, r *http.Request) {
 		ID:              uuid.New().String(),
 		Name:            input.Name,
 		CoverImage:      path.Join("uploads", coverURL),
-		State:           1,
+		State:           domain.TemplatePublished,
 		Slug:            input.Slug,
 		BaseTemplateID:  input.BaseTemplateId,
 		URL:             input.URL,
 		MessageTemplate: messageTemplate,
 		ExpireAt:        input.ExpireAt,
+		RSVPDeadline:    input.RSVPDeadline,
 	}); err != nil {
-		renderError(w, r, http.StatusBadRequest, "Create User Template failed", err)
+		renderError(w, r, errorStatus(err, http.StatusBadRequest), "Create User Template failed", err)
 		return
 	}
 
@@ -88,35 +96,104 @@ func (h *UserTemplate) List(w http.ResponseWriter, r *http.Request) {
 
 	data, err := h.cs.List(r.Context(), input.Page, input.Limit, input.UserID)
 	if err != nil {
-		renderError(w, r, http.StatusBadRequest, "get template list error", err)
+		renderError(w, r, errorStatus(err, http.StatusBadRequest), "get template list error", err)
 		return
 	}
 
 	result := model.UserTemplateListResult{Total: int(data.Total)}
 	for _, v := range data.Data {
-		msgTemplate := make(map[string]model.MessageTemplate, 0)
-		for _, x := range v.MessageTemplate {
-			msgTemplate[x.Provider] = model.MessageTemplate{
-				Text:     x.Text,
-				Provider: x.Provider,
-			}
-		}
-		result.Data = append(result.Data, model.UserTemplate{
-			BaseTemplateId:  v.BaseTemplateID,
-			CoverImage:      v.CoverImage,
-			CreatedAt:       v.CreatedAt,
-			ExpireAt:        v.ExpireAt,
-			Id:              v.ID,
-			MessageTemplate: msgTemplate,
-			Name:            v.Name,
-			Slug:            v.Slug,
-			State:           v.State,
-			UpdatedAt:       v.UpdatedAt,
-			Url:             v.URL,
-			UserId:          v.UserID,
-		})
+		result.Data = append(result.Data, toUserTemplateModel(v))
 	}
 
 	render.Status(r, http.StatusOK)
 	render.JSON(w, r, result)
 }
+
+func toUserTemplateModel(v domain.UserTemplate) model.UserTemplate {
+	msgTemplate := make(map[string]model.MessageTemplate, 0)
+	for _, x := range v.MessageTemplate {
+		msgTemplate[x.Provider] = model.MessageTemplate{
+			Text:     x.Text,
+			Provider: x.Provider,
+		}
+	}
+
+	now := time.Now()
+	return model.UserTemplate{
+		BaseTemplateId:  v.BaseTemplateID,
+		CoverImage:      v.CoverImage,
+		CreatedAt:       v.CreatedAt,
+		ExpireAt:        v.ExpireAt,
+		Id:              v.ID,
+		MessageTemplate: msgTemplate,
+		Name:            v.Name,
+		Slug:            v.Slug,
+		State:           int(v.State),
+		UpdatedAt:       v.UpdatedAt,
+		Url:             v.URL,
+		UserId:          v.UserID,
+		Role:            string(v.Role),
+		Status:          v.Lifecycle(now).String(),
+		RSVPDeadline:    v.RSVPDeadline,
+		RSVPOpen:        v.RSVPOpen(now),
+	}
+}
+
+// SetState moves a template to another lifecycle state
+func (h *UserTemplate) SetState(w http.ResponseWriter, r *http.Request) {
+	input := r.Context().Value(httpin.Input).(*model.UserTemplateStateRequest)
+
+	if err := h.validator.Struct(input); err != nil {
+		renderError(w, r, http.StatusBadRequest, "Validation failed", err)
+		return
+	}
+
+	template, err := h.cs.SetState(r.Context(), input.ID, input.Payload.State)
+	if err != nil {
+		renderError(w, r, errorStatus(err, http.StatusBadRequest), "Change template state failed", err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, toUserTemplateModel(template))
+}
+
+// SetRSVPDeadline changes when the RSVP form of a template closes
+func (h *UserTemplate) SetRSVPDeadline(w http.ResponseWriter, r *http.Request) {
+	input := r.Context().Value(httpin.Input).(*model.RSVPDeadlineRequest)
+
+	if err := h.validator.Struct(input); err != nil {
+		renderError(w, r, http.StatusBadRequest, "Validation failed", err)
+		return
+	}
+
+	template, err := h.cs.SetRSVPDeadline(r.Context(), input.ID, input.Payload.RSVPDeadline)
+	if err != nil {
+		renderError(w, r, errorStatus(err, http.StatusBadRequest), "Change RSVP deadline failed", err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, toUserTemplateModel(template))
+}
+
+// Site serves the uploaded files of a template under /u/{slug}/, only while
+// guests can open the invitation.
+func (h *UserTemplate) Site(root http.FileSystem) http.Handler {
+	files := http.FileServer(root)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		slug, _, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+		if slug == "" {
+			renderError(w, r, http.StatusNotFound, "Invitation not found", nil)
+			return
+		}
+
+		if _, err := h.cs.Published(r.Context(), slug); err != nil {
+			renderError(w, r, errorStatus(err, http.StatusNotFound), "Invitation not available", err)
+			return
+		}
+
+		files.ServeHTTP(w, r)
+	})
+}
@@ -22,7 +22,7 @@ func AuthMiddleware(auth *usecase.Auth) func(http.Handler) http.Handler {
 				return
 			}
 
-			claims, err := auth.ValidateToken(tokenString)
+			claims, err := auth.ValidateToken(r.Context(), tokenString)
 			if err != nil {
 				http.Error(w, "Invalid token", http.StatusUnauthorized)
 				return
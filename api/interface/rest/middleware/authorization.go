@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"basic-service/domain"
+	"basic-service/interface/rest/model"
+	"basic-service/usecase"
+	"net/http"
+
+	"github.com/go-chi/render"
+)
+
+// RequireRole only lets requests through when the token role is one of roles.
+// It must be mounted after AuthMiddleware.
+func RequireRole(roles ...domain.RoleType) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, err := usecase.GetClaimFromContext(r.Context())
+			if err != nil || !claims.HasRole(roles...) {
+				forbidden(w, r)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequirePermission only lets requests through when the token role is granted
+// every permission. It must be mounted after AuthMiddleware.
+func RequirePermission(perms ...usecase.Permission) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, err := usecase.GetClaimFromContext(r.Context())
+			if err != nil || !claims.Can(perms...) {
+				forbidden(w, r)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// SessionOnly rejects personal API tokens, it guards account and admin
+// routes that a script should never reach. It must be mounted after
+// AuthMiddleware.
+func SessionOnly(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, err := usecase.GetClaimFromContext(r.Context())
+		if err != nil || claims.IsAPIToken() {
+			forbidden(w, r)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// AdminOnly marks a route as restricted to domain.RoleAdmin.
+var AdminOnly = RequireRole(domain.RoleAdmin)
+
+func forbidden(w http.ResponseWriter, r *http.Request) {
+	render.Status(r, http.StatusForbidden)
+	render.JSON(w, r, model.ErrorResponse{
+		Status:  http.StatusForbidden,
+		Message: "Forbidden",
+		Error:   usecase.ErrForbidden.Error(),
+	})
+}
@@ -0,0 +1,105 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"basic-service/domain"
+	"basic-service/usecase"
+)
+
+func TestAuthorization(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	tests := []struct {
+		name   string
+		mw     func(http.Handler) http.Handler
+		claims *usecase.Claims
+		want   int
+	}{
+		{"admin only without claims", AdminOnly, nil, http.StatusForbidden},
+		{"admin only as user", AdminOnly, &usecase.Claims{Role: domain.RoleUser}, http.StatusForbidden},
+		{"admin only as admin", AdminOnly, &usecase.Claims{Role: domain.RoleAdmin}, http.StatusNoContent},
+		{"permission as user", RequirePermission(usecase.PermPublicTemplateWrite), &usecase.Claims{Role: domain.RoleUser}, http.StatusForbidden},
+		{"permission as admin", RequirePermission(usecase.PermPublicTemplateWrite), &usecase.Claims{Role: domain.RoleAdmin}, http.StatusNoContent},
+		{"own permission as user", RequirePermission(usecase.PermGuestManageOwn), &usecase.Claims{Role: domain.RoleUser}, http.StatusNoContent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.claims != nil {
+				r = r.WithContext(context.WithValue(r.Context(), "claims", tt.claims))
+			}
+
+			w := httptest.NewRecorder()
+			tt.mw(ok).ServeHTTP(w, r)
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestSessionOnly(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	tests := []struct {
+		name   string
+		claims *usecase.Claims
+		want   int
+	}{
+		{"without claims", nil, http.StatusForbidden},
+		{"session", &usecase.Claims{UserID: "u1"}, http.StatusNoContent},
+		{"api token", &usecase.Claims{UserID: "u1", APITokenID: "t1"}, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		if tt.claims != nil {
+			r = r.WithContext(context.WithValue(r.Context(), "claims", tt.claims))
+		}
+
+		w := httptest.NewRecorder()
+		SessionOnly(ok).ServeHTTP(w, r)
+		if w.Code != tt.want {
+			t.Errorf("%s: status = %d, want %d", tt.name, w.Code, tt.want)
+		}
+	}
+}
+
+func TestNotImpersonated(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	tests := []struct {
+		name   string
+		claims *usecase.Claims
+		want   int
+	}{
+		{"without claims", nil, http.StatusForbidden},
+		{"session", &usecase.Claims{UserID: "u1"}, http.StatusNoContent},
+		{"impersonated", &usecase.Claims{UserID: "u1", ImpersonatorID: "admin"}, http.StatusForbidden},
+		{"impersonated destructive", &usecase.Claims{UserID: "u1", ImpersonatorID: "admin", AllowDestructive: true}, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		if tt.claims != nil {
+			r = r.WithContext(context.WithValue(r.Context(), "claims", tt.claims))
+		}
+
+		w := httptest.NewRecorder()
+		NotImpersonated(ok).ServeHTTP(w, r)
+		if w.Code != tt.want {
+			t.Errorf("%s: status = %d, want %d", tt.name, w.Code, tt.want)
+		}
+	}
+}
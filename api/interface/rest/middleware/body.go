@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"basic-service/interface/rest/model"
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/render"
+)
+
+// MaxBodySize caps the request body at limit bytes before a later middleware
+// reads it, such as httpin parsing a multipart form into memory. Bodies that
+// declare a larger size are refused right away, the others are cut off at
+// limit.
+func MaxBodySize(limit int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength > limit {
+				render.Status(r, http.StatusRequestEntityTooLarge)
+				render.JSON(w, r, model.ErrorResponse{
+					Status:  http.StatusRequestEntityTooLarge,
+					Message: "Request Entity Too Large",
+					Error:   fmt.Sprintf("request body is larger than %d bytes", limit),
+				})
+				return
+			}
+
+			r.Body = http.MaxBytesReader(w, r.Body, limit)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
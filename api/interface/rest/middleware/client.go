@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"basic-service/usecase"
+	"net"
+	"net/http"
+)
+
+// ClientInfo stores the peer address and user agent of the request for the
+// usecases, see usecase.ClientFromContext.
+func ClientInfo(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := usecase.WithClient(r.Context(), usecase.Client{
+			IP:        clientIP(r),
+			UserAgent: r.UserAgent(),
+		})
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// clientIP returns the address of the peer. Forwarded headers are ignored on
+// purpose, they are set by the client and would let it dodge the login limits.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"basic-service/domain"
+	"basic-service/usecase"
+	"log"
+	"net/http"
+
+	chiMiddleware "github.com/go-chi/chi/v5/middleware"
+)
+
+// Impersonation writes an audit entry for every request made with an
+// impersonation token, destructive usecases refuse the token themselves with
+// usecase.AuthorizeDestructive. It must be mounted after AuthMiddleware.
+func Impersonation(audit *usecase.Auditor) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, err := usecase.GetClaimFromContext(r.Context())
+			if err != nil || !claims.IsImpersonated() {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			entry := domain.AuditLog{
+				Action:     usecase.AuditImpersonationRequest,
+				TargetType: "user",
+				TargetID:   claims.UserID,
+				IP:         usecase.ClientFromContext(r.Context()).IP,
+				Metadata: map[string]any{
+					"method": r.Method,
+					"path":   r.URL.Path,
+				},
+			}
+
+			ww := chiMiddleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			next.ServeHTTP(ww, r)
+
+			entry.Metadata["status"] = ww.Status()
+			record(r, audit, entry)
+		})
+	}
+}
+
+// NotImpersonated rejects impersonation tokens, it guards credentials and
+// sessions of the account that support must never change.
+func NotImpersonated(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, err := usecase.GetClaimFromContext(r.Context())
+		if err != nil || claims.IsImpersonated() {
+			forbidden(w, r)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func record(r *http.Request, audit *usecase.Auditor, entry domain.AuditLog) {
+	if err := audit.Record(r.Context(), entry); err != nil {
+		log.Printf("impersonation audit failed: %s %s: %v", r.Method, r.URL.Path, err)
+	}
+}
@@ -26,31 +26,160 @@ type PaginationRequest struct {
 	Page  int `in:"query=page"`
 }
 
+type UserListRequest struct {
+	PaginationRequest
+	Query       string     `in:"query=q"`
+	Role        int        `in:"query=role"`
+	IsActive    *bool      `in:"query=is_active"`
+	CreatedFrom *time.Time `in:"query=created_from"`
+	CreatedTo   *time.Time `in:"query=created_to"`
+	Sort        string     `in:"query=sort" validate:"omitempty,oneof=created_at email name"`
+	Order       string     `in:"query=order" validate:"omitempty,oneof=asc desc"`
+}
+
+type UserRoleRequest struct {
+	ID      string `in:"path=id"`
+	Payload struct {
+		Role UserRole `json:"role" validate:"required,oneof=1 2"`
+	} `in:"body=json"`
+}
+
+type ImpersonateRequest struct {
+	ID      string `in:"path=id"`
+	Payload struct {
+		Reason           string `json:"reason" validate:"required,max=500"`
+		ExpiresInMinutes int    `json:"expires_in_minutes" validate:"min=0,max=1440"`
+		AllowDestructive bool   `json:"allow_destructive"`
+	} `in:"body=json"`
+}
+
+type ImpersonationTokenRequest struct {
+	ID      string `in:"path=id"`
+	TokenID string `in:"path=tid"`
+}
+
+// ImpersonateResponse defines model for ImpersonateResponse.
+type ImpersonateResponse struct {
+	ID               string    `json:"id"`
+	Token            string    `json:"token"`
+	ExpiresAt        time.Time `json:"expires_at"`
+	UserID           string    `json:"user_id"`
+	AllowDestructive bool      `json:"allow_destructive"`
+}
+
 type UserTemplateListRequest struct {
 	PaginationRequest
 	UserID string `in:"query=user_id"`
 }
 
 // ErrorResponse defines model for ErrorResponse.
+// TemplateMember defines model for TemplateMember.
+type TemplateMember struct {
+	UserId    string    `json:"user_id"`
+	Name      string    `json:"name"`
+	Email     string    `json:"email"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TemplateInvite defines model for TemplateInvite.
+type TemplateInvite struct {
+	Id        string    `json:"id"`
+	Email     string    `json:"email"`
+	Role      string    `json:"role"`
+	InvitedBy string    `json:"invited_by"`
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type TemplateInviteCreateRequest struct {
+	ID      string `in:"path=id"`
+	Payload struct {
+		Email string `json:"email" validate:"required,email"`
+		Role  string `json:"role" validate:"required,oneof=owner editor viewer"`
+	} `in:"body=json"`
+}
+
+type TemplateInviteRequest struct {
+	ID       string `in:"path=id"`
+	InviteID string `in:"path=invite_id"`
+}
+
+type TemplateMemberRequest struct {
+	ID     string `in:"path=id"`
+	UserID string `in:"path=user_id"`
+}
+
+type TemplateMemberRoleRequest struct {
+	ID      string `in:"path=id"`
+	UserID  string `in:"path=user_id"`
+	Payload struct {
+		Role string `json:"role" validate:"required,oneof=owner editor viewer"`
+	} `in:"body=json"`
+}
+
+type AcceptInviteRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
 type ErrorResponse struct {
 	Status  int    `json:"status"`
 	Error   string `json:"error,omitempty"`
 	Message string `json:"message,omitempty"`
 }
 
+// GuestFilterRequest narrows the guests of a template, shared by the list
+// and the export
+type GuestFilterRequest struct {
+	Query  string `in:"query=q"`
+	Group  string `in:"query=group"`
+	Tag    string `in:"query=tag"`
+	Attend string `in:"query=attend" validate:"omitempty,oneof=yes no pending"`
+	Viewed *bool  `in:"query=viewed"`
+	Sort   string `in:"query=sort" validate:"omitempty,oneof=created_at name group person attend view_at"`
+	Order  string `in:"query=order" validate:"omitempty,oneof=asc desc"`
+}
+
 type GuestListRequest struct {
 	PaginationRequest
-	UserTemplateID string `in:"query=user_template_id"`
+	GuestFilterRequest
+	UserTemplateID string `in:"query=user_template_id" validate:"required"`
 }
 
 type GuestUpdateMessageRequest struct {
+	InvitationRequest
 	Payload struct {
-		ID      string `json:"id,omitempty"`
-		Attend  bool   `json:"attend,omitempty"`
-		Message string `json:"message,omitempty"`
+		Attend bool `json:"attend,omitempty"`
+		// Person is the confirmed headcount, at most the invited persons
+		Person  int               `json:"person,omitempty" validate:"min=0"`
+		Message string            `json:"message,omitempty"`
+		Answers map[string]string `json:"answers,omitempty"`
+		// Members are the answers of a household, person is then ignored
+		Members []MemberRSVP `json:"members,omitempty" validate:"max=20,dive"`
 	} `in:"body=json" json:"payload,omitempty"` // use "body=xml" for XML formatted body
 }
 
+// MemberRSVP is the answer of a member of a household, name is only used
+// for plus ones
+type MemberRSVP struct {
+	Id     string `json:"id" validate:"required"`
+	Attend bool   `json:"attend"`
+	Name   string `json:"name,omitempty" validate:"max=255"`
+}
+
+// InvitationRequest reads the token of a public invitation link
+type InvitationRequest struct {
+	Token string `in:"path=token" validate:"required"`
+}
+
+// Invitation is the public link of a guest, token is empty when revoked
+type Invitation struct {
+	GuestId  string    `json:"guest_id"`
+	Token    string    `json:"token"`
+	ExpireAt time.Time `json:"expire_at"`
+	Revoked  bool      `json:"revoked"`
+}
+
 type IdentityRequest struct {
 	ID string `in:"path=id"`
 }
@@ -64,9 +193,228 @@ type GuestCreateRequest struct {
 		Person         int      `json:"person,omitempty"`
 		Tags           []string `json:"tags,omitempty"`
 		Telp           string   `json:"telp,omitempty"`
+		// Members make the guest a household, person is then their number
+		Members []GuestMemberPayload `json:"members,omitempty" validate:"max=20,dive"`
 	} `in:"body=json" json:"payload,omitempty"` // use "body=xml" for XML formatted body
 }
 
+// GuestMembersRequest replaces the household of a guest
+type GuestMembersRequest struct {
+	ID      string `in:"path=id" validate:"required"`
+	Payload struct {
+		Members []GuestMemberPayload `json:"members" validate:"max=20,dive"`
+	} `in:"body=json"`
+}
+
+// GuestMemberPayload is a member of a household, members sent with an id
+// keep their RSVP. A plus one may be left without a name.
+type GuestMemberPayload struct {
+	Id      string `json:"id,omitempty"`
+	Name    string `json:"name" validate:"max=255"`
+	PlusOne bool   `json:"plus_one,omitempty"`
+}
+
+// GuestMember is a member of a household, attend is unset until answered
+type GuestMember struct {
+	Id      string `json:"id"`
+	Name    string `json:"name"`
+	PlusOne bool   `json:"plus_one,omitempty"`
+	Attend  *bool  `json:"attend,omitempty"`
+}
+
+// GuestUpdateRequest only changes the fields that are sent
+type GuestUpdateRequest struct {
+	ID      string `in:"path=id" validate:"required"`
+	Payload struct {
+		Name    *string   `json:"name,omitempty" validate:"omitempty,min=1"`
+		Group   *string   `json:"group,omitempty"`
+		Person  *int      `json:"person,omitempty" validate:"omitempty,min=0"`
+		Tags    *[]string `json:"tags,omitempty"`
+		Telp    *string   `json:"telp,omitempty"`
+		Address *string   `json:"address,omitempty"`
+		Attend  *bool     `json:"attend,omitempty"`
+		// AttendPerson is the confirmed headcount, at most person
+		AttendPerson *int `json:"attend_person,omitempty" validate:"omitempty,min=0"`
+	} `in:"body=json" json:"payload,omitempty"`
+}
+
+// GuestbookRequest reads a page of the public guestbook of a template
+type GuestbookRequest struct {
+	PaginationRequest
+	Slug string `in:"path=slug" validate:"required"`
+}
+
+// GuestbookListRequest reads a page of the moderation list, status narrows
+// it to one state
+type GuestbookListRequest struct {
+	PaginationRequest
+	ID     string `in:"path=id" validate:"required"`
+	Status string `in:"query=status" validate:"omitempty,oneof=approved pending hidden"`
+}
+
+type GuestbookModerateRequest struct {
+	ID      string `in:"path=id" validate:"required"`
+	GuestID string `in:"path=guest_id" validate:"required"`
+	Payload struct {
+		Status *string `json:"status,omitempty" validate:"omitempty,oneof=approved pending hidden"`
+		Pinned *bool   `json:"pinned,omitempty"`
+	} `in:"body=json"`
+}
+
+type GuestbookSettingsRequest struct {
+	ID      string            `in:"path=id" validate:"required"`
+	Payload GuestbookSettings `in:"body=json"`
+}
+
+// GuestbookSettings are the moderation settings of a template, approve_first
+// holds new messages until a host approves them
+type GuestbookSettings struct {
+	ApproveFirst bool     `json:"approve_first"`
+	BlockedWords []string `json:"blocked_words" validate:"max=500,dive,max=100"`
+}
+
+type UserTemplateStateRequest struct {
+	ID      string            `in:"path=id" validate:"required"`
+	Payload UserTemplateState `in:"body=json"`
+}
+
+// UserTemplateState is the lifecycle state a template is moved to, expired
+// is reached when expire_at passes and cannot be set
+type UserTemplateState struct {
+	State string `json:"state" validate:"required,oneof=draft published rsvp_closed archived"`
+}
+
+type RSVPDeadlineRequest struct {
+	ID      string       `in:"path=id" validate:"required"`
+	Payload RSVPDeadline `in:"body=json"`
+}
+
+// RSVPDeadline closes the RSVP form of a template, null keeps it open until
+// the template expires
+type RSVPDeadline struct {
+	RSVPDeadline *time.Time `json:"rsvp_deadline"`
+}
+
+// GuestbookMessage is an approved message on the public guestbook
+type GuestbookMessage struct {
+	Name      string     `json:"name"`
+	Message   string     `json:"message"`
+	Pinned    bool       `json:"pinned,omitempty"`
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+}
+
+type GuestbookResult struct {
+	Total int                `json:"total"`
+	Data  []GuestbookMessage `json:"data"`
+}
+
+// GuestbookEntry is a message on the moderation list, flagged when it
+// contains a blocked word
+type GuestbookEntry struct {
+	GuestId   string     `json:"guest_id"`
+	Name      string     `json:"name"`
+	Message   string     `json:"message"`
+	Status    string     `json:"status"`
+	Pinned    bool       `json:"pinned"`
+	Flagged   bool       `json:"flagged"`
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+}
+
+type GuestbookListResult struct {
+	Total int              `json:"total"`
+	Data  []GuestbookEntry `json:"data"`
+}
+
+// RSVPQuestion defines model for RSVPQuestion.
+type RSVPQuestion struct {
+	Id        string    `json:"id"`
+	Label     string    `json:"label"`
+	Type      string    `json:"type"`
+	Options   []string  `json:"options"`
+	Required  bool      `json:"required"`
+	Position  int       `json:"position"`
+	CreatedAt time.Time `json:"created_at,omitempty"`
+}
+
+// RSVPQuestionPayload is a question of the RSVP form, options are the
+// answers of a choice question
+type RSVPQuestionPayload struct {
+	Label    string   `json:"label" validate:"required,max=255"`
+	Type     string   `json:"type" validate:"required,oneof=choice text number"`
+	Options  []string `json:"options,omitempty" validate:"max=50,dive,max=255"`
+	Required bool     `json:"required,omitempty"`
+	Position int      `json:"position,omitempty"`
+}
+
+type RSVPQuestionCreateRequest struct {
+	ID      string              `in:"path=id" validate:"required"`
+	Payload RSVPQuestionPayload `in:"body=json"`
+}
+
+type RSVPQuestionUpdateRequest struct {
+	ID         string              `in:"path=id" validate:"required"`
+	QuestionID string              `in:"path=question_id" validate:"required"`
+	Payload    RSVPQuestionPayload `in:"body=json"`
+}
+
+type RSVPQuestionRequest struct {
+	ID         string `in:"path=id" validate:"required"`
+	QuestionID string `in:"path=question_id" validate:"required"`
+}
+
+// GuestImportRequest is a CSV or XLSX file, mapping is a JSON object of guest
+// field to column header
+type GuestImportRequest struct {
+	ID          string       `in:"path=id" validate:"required"`
+	File        *httpin.File `in:"form=file"`
+	Mapping     string       `in:"form=mapping"`
+	DryRun      bool         `in:"form=dry_run"`
+	OnDuplicate string       `in:"form=on_duplicate" validate:"omitempty,oneof=skip update"`
+}
+
+type GuestExportRequest struct {
+	GuestFilterRequest
+	ID     string `in:"path=id" validate:"required"`
+	Format string `in:"query=format;default=csv" validate:"oneof=csv xlsx pdf"`
+}
+
+type GuestImportRowError struct {
+	Row     int    `json:"row"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+type GuestImportResult struct {
+	DryRun  bool                  `json:"dry_run"`
+	Total   int                   `json:"total"`
+	Created int                   `json:"created"`
+	Updated int                   `json:"updated"`
+	Skipped int                   `json:"skipped"`
+	Errors  []GuestImportRowError `json:"errors"`
+}
+
+type GuestMergeRequest struct {
+	ID      string     `in:"path=id" validate:"required"`
+	Payload GuestMerge `in:"body=json"`
+}
+
+// GuestMerge folds the guests of merge_ids into guest_id and deletes them
+type GuestMerge struct {
+	GuestID  string   `json:"guest_id" validate:"required"`
+	MergeIDs []string `json:"merge_ids" validate:"required,min=1,max=50,dive,required"`
+}
+
+// GuestDuplicate is a group of guests that look like the same person,
+// reasons are telp and name
+type GuestDuplicate struct {
+	Reasons []string `json:"reasons"`
+	Guests  []Guest  `json:"guests"`
+}
+
+type GuestDuplicateList struct {
+	Data []GuestDuplicate `json:"data"`
+}
+
 type GuestListResult struct {
 	Total int     `json:"total"`
 	Data  []Guest `json:"data"`
@@ -81,6 +429,17 @@ type SafeGuest struct {
 	Name    string     `json:"name,omitempty"`
 	ViewAt  *time.Time `json:"view_at,omitempty"`
 	Person  int        `json:"person,omitempty"`
+	// AttendPerson is the headcount the guest confirmed
+	AttendPerson *int              `json:"attend_person,omitempty"`
+	Answers      map[string]string `json:"answers,omitempty"`
+	Questions    []RSVPQuestion    `json:"questions"`
+	// Slug of the template, its guestbook is /public/guestbook/{slug}
+	Slug string `json:"slug"`
+	// RSVPOpen is false once the RSVP is closed or its deadline passed
+	RSVPOpen     bool       `json:"rsvp_open"`
+	RSVPDeadline *time.Time `json:"rsvp_deadline,omitempty"`
+	// Members of the household, each answers the RSVP
+	Members []GuestMember `json:"members,omitempty"`
 }
 
 // Guest defines model for Guest.
@@ -98,6 +457,13 @@ type Guest struct {
 	UpdatedAt      time.Time  `json:"updated_at,omitempty"`
 	UserTemplateId string     `json:"user_template_id,omitempty"`
 	ViewAt         *time.Time `json:"view_at,omitempty"`
+	// InvitationToken is the token of the public link, empty when revoked
+	InvitationToken string `json:"invitation_token,omitempty"`
+	// AttendPerson is the headcount the guest confirmed
+	AttendPerson *int              `json:"attend_person,omitempty"`
+	Answers      map[string]string `json:"answers,omitempty"`
+	// Members of the household, person and attend_person count them
+	Members []GuestMember `json:"members,omitempty"`
 }
 
 // LoginRequest defines model for LoginRequest.
@@ -108,7 +474,90 @@ type LoginRequest struct {
 
 // LoginResponse defines model for LoginResponse.
 type LoginResponse struct {
-	Token *string `json:"token,omitempty"`
+	Token        *string    `json:"token,omitempty"`
+	RefreshToken *string    `json:"refresh_token,omitempty"`
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"`
+	// MFARequired is set with ChallengeToken when the account has 2FA, the
+	// login is finished with POST /auth/login/2fa
+	MFARequired      bool    `json:"mfa_required,omitempty"`
+	ChallengeToken   *string `json:"challenge_token,omitempty"`
+	MFASetupRequired bool    `json:"mfa_setup_required,omitempty"`
+}
+
+// OIDCProviderRequest defines model for OIDCProviderRequest.
+type OIDCProviderRequest struct {
+	Provider string `in:"path=provider"`
+}
+
+// OIDCCallbackRequest defines model for OIDCCallbackRequest.
+type OIDCCallbackRequest struct {
+	Provider string `in:"path=provider"`
+	Payload  struct {
+		Code  string `json:"code" validate:"required"`
+		State string `json:"state" validate:"required"`
+	} `in:"body=json"`
+}
+
+// OIDCAuthorizeResponse defines model for OIDCAuthorizeResponse.
+type OIDCAuthorizeResponse struct {
+	AuthorizationURL string `json:"authorization_url"`
+}
+
+// MFAVerifyRequest defines model for MFAVerifyRequest.
+type MFAVerifyRequest struct {
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+	// Code is a TOTP code or a recovery code
+	Code string `json:"code" validate:"required"`
+}
+
+// TOTPCodeRequest defines model for TOTPCodeRequest.
+type TOTPCodeRequest struct {
+	Code string `json:"code" validate:"required"`
+}
+
+// TOTPDisableRequest defines model for TOTPDisableRequest.
+type TOTPDisableRequest struct {
+	Password string `json:"password" validate:"required"`
+	Code     string `json:"code" validate:"required"`
+}
+
+// TOTPSetupResponse defines model for TOTPSetupResponse.
+type TOTPSetupResponse struct {
+	Secret     string `json:"secret"`
+	OtpauthURI string `json:"otpauth_uri"`
+}
+
+// TOTPStatusResponse defines model for TOTPStatusResponse.
+type TOTPStatusResponse struct {
+	Enabled       bool  `json:"enabled"`
+	Required      bool  `json:"required"`
+	RecoveryCodes int64 `json:"recovery_codes_left"`
+}
+
+// RecoveryCodesResponse defines model for RecoveryCodesResponse.
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// ForgotPasswordRequest defines model for ForgotPasswordRequest.
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+// ResetPasswordRequest defines model for ResetPasswordRequest.
+type ResetPasswordRequest struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required,min=8"`
+}
+
+// VerifyEmailRequest defines model for VerifyEmailRequest.
+type VerifyEmailRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// RefreshTokenRequest defines model for RefreshTokenRequest.
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
 }
 
 // MessageTemplate defines model for MessageTemplate.
@@ -159,6 +608,7 @@ type UserTemplateCreateRequest struct {
 	MessageTemplate string       `in:"form=message_template"`
 	Name            string       `in:"form=name"`
 	ExpireAt        time.Time    `in:"form=expire_at"`
+	RSVPDeadline    *time.Time   `in:"form=rsvp_deadline"`
 }
 
 func (u *UserTemplateCreateRequest) GetMessageTemplate() ([]MessageTemplate, error) {
@@ -179,14 +629,77 @@ type RegisterUser struct {
 	Profile  *httpin.File `in:"form=profile"`
 }
 
+type UpdateProfileRequest struct {
+	Name    string       `in:"form=name" validate:"required,max=100"`
+	Profile *httpin.File `in:"form=profile"`
+}
+
+// ChangePasswordRequest defines model for ChangePasswordRequest.
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required,min=8,nefield=CurrentPassword"`
+}
+
+// APITokenCreateRequest defines model for APITokenCreateRequest.
+type APITokenCreateRequest struct {
+	Name          string   `json:"name" validate:"required,max=100"`
+	Scopes        []string `json:"scopes" validate:"required,min=1,dive,oneof=guests:read guests:write templates:read templates:write"`
+	ExpiresInDays int      `json:"expires_in_days" validate:"min=0,max=3650"`
+}
+
+// APIToken defines model for APIToken.
+type APIToken struct {
+	Id         string     `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	// Token is only returned when the token is created
+	Token string `json:"token,omitempty"`
+}
+
+// Session defines model for Session.
+type Session struct {
+	Id         string    `json:"id"`
+	UserAgent  string    `json:"user_agent"`
+	Ip         string    `json:"ip"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	Current    bool      `json:"current,omitempty"`
+}
+
+type RevokeSessionsRequest struct {
+	ExceptCurrent bool `in:"query=except_current"`
+}
+
+type UserSessionRequest struct {
+	ID        string `in:"path=id"`
+	SessionID string `in:"path=sid"`
+}
+
+// AccountDeletionRequest defines model for AccountDeletionRequest.
+type AccountDeletionRequest struct {
+	Password string `json:"password" validate:"required"`
+}
+
+// AccountDeletion defines model for AccountDeletion.
+type AccountDeletion struct {
+	RequestedAt time.Time `json:"requested_at"`
+	DeleteAfter time.Time `json:"delete_after"`
+}
+
 type SafeUser struct {
-	CreatedAt time.Time `json:"created_at,omitempty"`
-	Email     string    `json:"email,omitempty"`
-	Id        string    `json:"id,omitempty"`
-	IsActive  bool      `json:"is_active,omitempty"`
-	Name      string    `json:"name,omitempty"`
-	Role      UserRole  `json:"role,omitempty"`
-	UpdatedAt time.Time `json:"updated_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at,omitempty"`
+	Email      string     `json:"email,omitempty"`
+	Id         string     `json:"id,omitempty"`
+	IsActive   bool       `json:"is_active,omitempty"`
+	Name       string     `json:"name,omitempty"`
+	Profile    string     `json:"profile,omitempty"`
+	Role       UserRole   `json:"role,omitempty"`
+	VerifiedAt *time.Time `json:"verified_at,omitempty"`
+	UpdatedAt  time.Time  `json:"updated_at,omitempty"`
 }
 
 // User defines model for User.
@@ -223,6 +736,11 @@ type UserTemplate struct {
 	UpdatedAt       time.Time                  `json:"updated_at,omitempty"`
 	Url             string                     `json:"url,omitempty"`
 	UserId          string                     `json:"user_id,omitempty"`
+	Role            string                     `json:"role,omitempty"`
+	// Status is the lifecycle state by name, expired once expire_at passed
+	Status       string     `json:"status"`
+	RSVPDeadline *time.Time `json:"rsvp_deadline,omitempty"`
+	RSVPOpen     bool       `json:"rsvp_open"`
 }
 
 // PatchPublicGuestsIdJSONBody defines parameters for PatchPublicGuestsId.
@@ -232,6 +750,21 @@ type PatchPublicGuestsIdJSONBody struct {
 
 // PatchUsersJSONBody defines parameters for PatchUsers.
 type PatchUsersJSONBody struct {
-	IsActive *bool   `json:"is_active,omitempty"`
-	UserId   *string `json:"userId,omitempty"`
+	IsActive *bool   `json:"is_active,omitempty" validate:"required"`
+	UserId   *string `json:"userId,omitempty" validate:"required"`
+}
+
+type PatchUsersRequest struct {
+	Payload PatchUsersJSONBody `in:"body=json"`
+}
+
+type UserTemplateStat struct {
+	UserTemplate
+	GuestCount int64 `json:"guest_count"`
+}
+
+type UserDetail struct {
+	SafeUser
+	GuestCount int64              `json:"guest_count"`
+	Templates  []UserTemplateStat `json:"templates"`
 }
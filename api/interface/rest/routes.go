@@ -18,20 +18,25 @@ import (
 
 func SetupRouter(
 	authCase *usecase.Auth,
+	oidcCase *usecase.OIDCLogin,
 	publicTemplateCase *usecase.PublicTemplateUseCase,
 	userTemplateCase *usecase.UserTemplate,
+	templateMemberCase *usecase.TemplateMembers,
+	accountCase *usecase.Account,
 	guestCase *usecase.GuestUsecase,
+	guestbookCase *usecase.Guestbook,
 	userCase *usecase.UserUsecase,
 ) *chi.Mux {
 	r := chi.NewRouter()
 	// Middleware
 	r.Use(middleware.Logger)
 	r.Use(middleware.Recoverer)
+	r.Use(appMiddleware.ClientInfo)
 	r.Use(cors.Handler(cors.Options{
 		// AllowedOrigins:   []string{"https://foo.com"}, // Use this to allow specific origin hosts
 		AllowedOrigins: []string{"https://*", "http://*"},
 		// AllowOriginFunc:  func(r *http.Request, origin string) bool { return true },
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
 		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
 		ExposedHeaders:   []string{"Link"},
 		AllowCredentials: false,
@@ -43,47 +48,138 @@ func SetupRouter(
 		TemplateDir: "./public/template",
 	}
 	authHandler := handlers.NewAuthHandler(authCase, uploadHandler)
+	oidcHandler := handlers.NewOIDCHandler(oidcCase)
 	publicTemplateHandler := handlers.NewPublicTemplate(publicTemplateCase, uploadHandler)
 	userTemplateHandler := handlers.NewUserTemplate(userTemplateCase, uploadHandler)
+	templateMemberHandler := handlers.NewTemplateMember(templateMemberCase)
+	accountHandler := handlers.NewAccount(accountCase)
 	guestHandler := handlers.NewGuest(guestCase)
+	guestbookHandler := handlers.NewGuestbook(guestbookCase)
 	userHandler := handlers.NewUserHandler(userCase)
 
 	r.Handle("/uploads/*", http.StripPrefix("/uploads/", http.FileServer(http.Dir("./public/uploads"))))
-	r.Handle("/u/*", http.StripPrefix("/u/", http.FileServer(http.Dir("./public/template"))))
+	r.Handle("/u/*", http.StripPrefix("/u/", userTemplateHandler.Site(http.Dir("./public/template"))))
 
 	// Public routes
 	r.Group(func(r chi.Router) {
 		r.Post("/auth/login", authHandler.Login)
-		r.With(httpin.NewInput(model.IdentityRequest{})).Get("/public/guest/{id}", guestHandler.GetGuest)
-		r.With(httpin.NewInput(model.GuestUpdateMessageRequest{})).Post("/public/guest/message", guestHandler.UpdateMessage)
-		r.With(httpin.NewInput(model.IdentityRequest{})).Put("/public/guest/{id}", guestHandler.UpdateLastView)
+		r.Post("/auth/login/2fa", authHandler.VerifyMFA)
+		r.Get("/auth/oidc", oidcHandler.Providers)
+		r.With(httpin.NewInput(model.OIDCProviderRequest{})).Get("/auth/oidc/{provider}/authorize", oidcHandler.Authorize)
+		r.With(httpin.NewInput(model.OIDCCallbackRequest{})).Post("/auth/oidc/{provider}/callback", oidcHandler.Callback)
+		r.Post("/auth/refresh", authHandler.Refresh)
+		r.Get("/.well-known/jwks.json", authHandler.JWKS)
+		r.Post("/auth/password/forgot", authHandler.ForgotPassword)
+		r.Post("/auth/password/reset", authHandler.ResetPassword)
+		r.Post("/auth/verify-email", authHandler.VerifyEmail)
+		r.With(httpin.NewInput(model.InvitationRequest{})).Get("/public/guest/{token}", guestHandler.GetGuest)
+		r.With(httpin.NewInput(model.GuestUpdateMessageRequest{})).Post("/public/guest/{token}/message", guestHandler.UpdateMessage)
+		r.With(httpin.NewInput(model.InvitationRequest{})).Put("/public/guest/{token}", guestHandler.UpdateLastView)
+		r.With(httpin.NewInput(model.GuestbookRequest{})).Get("/public/guestbook/{slug}", guestbookHandler.Public)
 		r.With(httpin.NewInput(model.RegisterUser{})).Post("/auth/register", authHandler.Register)
 	})
 
 	// // Protected routes
 	r.Group(func(r chi.Router) {
-		// JWT verification
+		// JWT or personal API token verification
 		r.Use(appMiddleware.AuthMiddleware(authCase))
-		//
-		r.Get("/auth/me", authHandler.Me)
+		// audit trail for impersonation tokens
+		r.Use(appMiddleware.Impersonation(authCase.Audit))
+
+		// Account routes are not reachable with an API token
+		r.Group(func(r chi.Router) {
+			r.Use(appMiddleware.SessionOnly)
+
+			r.Get("/auth/me", authHandler.Me)
+			r.With(httpin.NewInput(model.UpdateProfileRequest{})).Put("/auth/me", authHandler.UpdateMe)
+			r.Post("/auth/logout", authHandler.Logout)
+
+			// Credentials and sessions stay out of reach of support
+			r.Group(func(r chi.Router) {
+				r.Use(appMiddleware.NotImpersonated)
+
+				r.Post("/auth/me/password", authHandler.ChangePassword)
+				r.Post("/auth/verify-email/resend", authHandler.ResendVerification)
+
+				r.Get("/auth/tokens", authHandler.ListAPITokens)
+				r.Post("/auth/tokens", authHandler.CreateAPIToken)
+				r.With(httpin.NewInput(model.IdentityRequest{})).Delete("/auth/tokens/{id}", authHandler.RevokeAPIToken)
+
+				r.Get("/auth/sessions", authHandler.ListSessions)
+				r.With(httpin.NewInput(model.RevokeSessionsRequest{})).Delete("/auth/sessions", authHandler.RevokeAllSessions)
+				r.With(httpin.NewInput(model.IdentityRequest{})).Delete("/auth/sessions/{id}", authHandler.RevokeSession)
+
+				r.Get("/auth/2fa", authHandler.TOTPStatus)
+				r.Post("/auth/2fa/setup", authHandler.SetupTOTP)
+				r.Post("/auth/2fa/enable", authHandler.EnableTOTP)
+				r.Post("/auth/2fa/disable", authHandler.DisableTOTP)
+				r.Post("/auth/2fa/recovery-codes", authHandler.RegenerateRecoveryCodes)
+
+				r.Get("/auth/me/export", accountHandler.Export)
+				r.Get("/auth/me/deletion", accountHandler.DeletionStatus)
+				r.Post("/auth/me/deletion", accountHandler.RequestDeletion)
+				r.Delete("/auth/me/deletion", accountHandler.CancelDeletion)
+			})
+		})
 
 		r.Route("/private/", func(r chi.Router) {
-			// // Public Template Manager
-			r.With(httpin.NewInput(model.PaginationRequest{})).Get("/public-templates", publicTemplateHandler.List)
-			r.With(httpin.NewInput(model.PublicTemplateCreateRequest{})).Post("/public-templates", publicTemplateHandler.Create)
+			// Routes in this block accept API tokens, RequirePermission
+			// checks the token scopes
+			r.With(appMiddleware.RequirePermission(usecase.PermUserTemplateRead), httpin.NewInput(model.UserTemplateListRequest{})).Get("/user-templates", userTemplateHandler.List)
+			r.With(appMiddleware.RequirePermission(usecase.PermUserTemplateWriteOwn), httpin.NewInput(model.UserTemplateCreateRequest{})).Post("/user-templates", userTemplateHandler.Create)
+			r.With(appMiddleware.RequirePermission(usecase.PermUserTemplateWriteOwn), httpin.NewInput(model.UserTemplateStateRequest{})).Put("/user-templates/{id}/state", userTemplateHandler.SetState)
+			r.With(appMiddleware.RequirePermission(usecase.PermUserTemplateWriteOwn), httpin.NewInput(model.RSVPDeadlineRequest{})).Put("/user-templates/{id}/rsvp-deadline", userTemplateHandler.SetRSVPDeadline)
 
-			r.With(httpin.NewInput(model.UserTemplateListRequest{})).Get("/user-templates", userTemplateHandler.List)
-			r.With(httpin.NewInput(model.UserTemplateCreateRequest{})).Post("/user-templates", userTemplateHandler.Create)
+			r.With(appMiddleware.RequirePermission(usecase.PermGuestRead), httpin.NewInput(model.GuestListRequest{})).Get("/guests", guestHandler.List)
+			r.With(appMiddleware.RequirePermission(usecase.PermGuestManageOwn), httpin.NewInput(model.GuestCreateRequest{})).Post("/guests", guestHandler.Create)
+			r.With(appMiddleware.RequirePermission(usecase.PermGuestManageOwn), httpin.NewInput(model.GuestUpdateRequest{})).Put("/guests/{id}", guestHandler.Update)
+			r.With(appMiddleware.RequirePermission(usecase.PermGuestManageOwn), httpin.NewInput(model.IdentityRequest{})).Delete("/guests/{id}", guestHandler.Delete)
+			r.With(appMiddleware.RequirePermission(usecase.PermGuestManageOwn), httpin.NewInput(model.GuestMembersRequest{})).Put("/guests/{id}/members", guestHandler.SetMembers)
+			r.With(appMiddleware.RequirePermission(usecase.PermGuestRead), httpin.NewInput(model.IdentityRequest{})).Get("/guests/{id}/invitation", guestHandler.GetInvitation)
+			r.With(appMiddleware.RequirePermission(usecase.PermGuestManageOwn), httpin.NewInput(model.IdentityRequest{})).Post("/guests/{id}/invitation", guestHandler.RegenerateInvitation)
+			r.With(appMiddleware.RequirePermission(usecase.PermGuestManageOwn), httpin.NewInput(model.IdentityRequest{})).Delete("/guests/{id}/invitation", guestHandler.RevokeInvitation)
+			r.With(appMiddleware.RequirePermission(usecase.PermGuestManageOwn), appMiddleware.MaxBodySize(handlers.MaxGuestImportBody), httpin.NewInput(model.GuestImportRequest{})).Post("/user-templates/{id}/guests/import", guestHandler.Import)
+			r.With(appMiddleware.RequirePermission(usecase.PermGuestRead), httpin.NewInput(model.GuestExportRequest{})).Get("/user-templates/{id}/guests/export", guestHandler.Export)
+			r.With(appMiddleware.RequirePermission(usecase.PermGuestRead), httpin.NewInput(model.IdentityRequest{})).Get("/user-templates/{id}/guests/duplicates", guestHandler.Duplicates)
+			r.With(appMiddleware.RequirePermission(usecase.PermGuestManageOwn), httpin.NewInput(model.GuestMergeRequest{})).Post("/user-templates/{id}/guests/merge", guestHandler.Merge)
+			r.With(appMiddleware.RequirePermission(usecase.PermUserTemplateRead), httpin.NewInput(model.IdentityRequest{})).Get("/user-templates/{id}/rsvp-questions", guestHandler.ListQuestions)
+			r.With(appMiddleware.RequirePermission(usecase.PermUserTemplateWriteOwn), httpin.NewInput(model.RSVPQuestionCreateRequest{})).Post("/user-templates/{id}/rsvp-questions", guestHandler.CreateQuestion)
+			r.With(appMiddleware.RequirePermission(usecase.PermUserTemplateWriteOwn), httpin.NewInput(model.RSVPQuestionUpdateRequest{})).Put("/user-templates/{id}/rsvp-questions/{question_id}", guestHandler.UpdateQuestion)
+			r.With(appMiddleware.RequirePermission(usecase.PermUserTemplateWriteOwn), httpin.NewInput(model.RSVPQuestionRequest{})).Delete("/user-templates/{id}/rsvp-questions/{question_id}", guestHandler.DeleteQuestion)
+			r.With(appMiddleware.RequirePermission(usecase.PermGuestRead), httpin.NewInput(model.GuestbookListRequest{})).Get("/user-templates/{id}/guestbook", guestbookHandler.List)
+			r.With(appMiddleware.RequirePermission(usecase.PermGuestManageOwn), httpin.NewInput(model.GuestbookModerateRequest{})).Put("/user-templates/{id}/guestbook/{guest_id}", guestbookHandler.Moderate)
+			r.With(appMiddleware.RequirePermission(usecase.PermGuestRead), httpin.NewInput(model.IdentityRequest{})).Get("/user-templates/{id}/guestbook/settings", guestbookHandler.Settings)
+			r.With(appMiddleware.RequirePermission(usecase.PermGuestManageOwn), httpin.NewInput(model.GuestbookSettingsRequest{})).Put("/user-templates/{id}/guestbook/settings", guestbookHandler.UpdateSettings)
 
-			r.With(httpin.NewInput(model.GuestListRequest{})).Get("/guests", guestHandler.List)
-			r.With(httpin.NewInput(model.GuestCreateRequest{})).Post("/guests", guestHandler.Create)
+			r.Group(func(r chi.Router) {
+				r.Use(appMiddleware.SessionOnly)
+
+				// // Public Template Manager
+				r.With(httpin.NewInput(model.PaginationRequest{})).Get("/public-templates", publicTemplateHandler.List)
+
+				// // Co-hosts of a User Template
+				r.With(httpin.NewInput(model.IdentityRequest{})).Get("/user-templates/{id}/members", templateMemberHandler.List)
+				r.With(httpin.NewInput(model.TemplateMemberRoleRequest{})).Put("/user-templates/{id}/members/{user_id}", templateMemberHandler.UpdateRole)
+				r.With(httpin.NewInput(model.TemplateMemberRequest{})).Delete("/user-templates/{id}/members/{user_id}", templateMemberHandler.Remove)
+				r.With(httpin.NewInput(model.IdentityRequest{})).Get("/user-templates/{id}/invites", templateMemberHandler.ListInvites)
+				r.With(httpin.NewInput(model.TemplateInviteCreateRequest{})).Post("/user-templates/{id}/invites", templateMemberHandler.Invite)
+				r.With(httpin.NewInput(model.TemplateInviteRequest{})).Delete("/user-templates/{id}/invites/{invite_id}", templateMemberHandler.RevokeInvite)
+				r.Post("/invites/accept", templateMemberHandler.AcceptInvite)
+				r.With(appMiddleware.RequirePermission(usecase.PermPublicTemplateWrite), httpin.NewInput(model.PublicTemplateCreateRequest{})).Post("/public-templates", publicTemplateHandler.Create)
+
+				// // User Manager
+				r.With(appMiddleware.AdminOnly, httpin.NewInput(model.UserListRequest{})).Get("/users", userHandler.ListUser)
+				r.With(appMiddleware.AdminOnly, httpin.NewInput(model.PatchUsersRequest{})).Patch("/users", userHandler.ChangeUserState)
+				r.With(appMiddleware.AdminOnly, httpin.NewInput(model.IdentityRequest{})).Get("/users/{id}", userHandler.GetUser)
+				r.With(appMiddleware.AdminOnly, httpin.NewInput(model.UserRoleRequest{})).Put("/users/{id}/role", userHandler.ChangeUserRole)
+				r.With(appMiddleware.AdminOnly, httpin.NewInput(model.IdentityRequest{})).Post("/users/{id}/unlock", userHandler.UnlockUser)
+				r.With(appMiddleware.AdminOnly, httpin.NewInput(model.IdentityRequest{})).Get("/users/{id}/sessions", userHandler.ListUserSessions)
+				r.With(appMiddleware.AdminOnly, httpin.NewInput(model.IdentityRequest{})).Delete("/users/{id}/sessions", userHandler.RevokeUserSessions)
+				r.With(appMiddleware.AdminOnly, httpin.NewInput(model.UserSessionRequest{})).Delete("/users/{id}/sessions/{sid}", userHandler.RevokeUserSession)
+				r.With(appMiddleware.AdminOnly, httpin.NewInput(model.ImpersonateRequest{})).Post("/users/{id}/impersonate", authHandler.Impersonate)
+				r.With(appMiddleware.AdminOnly, httpin.NewInput(model.ImpersonationTokenRequest{})).Delete("/users/{id}/impersonate/{tid}", authHandler.RevokeImpersonation)
+			})
 
-			// r.Delete("/guests/{id}", guestHandler.Delete)
-			// // User Manager
-			r.With(httpin.NewInput(model.PaginationRequest{})).Get("/users", userHandler.ListUser)
-			// r.Patch("/users", handlers.ChangeUserState)
-			// r.Get("/users/{id}", handlers.GetUser)
-			//
 			//r.Get("/public-templates/{id}", handlers.GetPublicTemplate)
 			// r.Put("/public-templates/{id}", handlers.UpdatePublicTemplate)
 			// r.Delete("/public-templates/{id}", handlers.DeletePublicTemplate)
@@ -92,9 +188,6 @@ func SetupRouter(
 			// r.Get("/user-templates/{id}", handlers.GetUserTemplate)
 			// r.Put("/user-templates/{id}", handlers.UpdateUserTemplate)
 			// r.Delete("/user-templates/{id}", handlers.DeleteUserTemplate)
-			//
-			// // Guest Manager
-			// r.Put("/guests/{id}", handlers.UpdateGuest)
 		})
 	})
 
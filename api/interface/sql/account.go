@@ -0,0 +1,194 @@
+package sql
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"basic-service/domain"
+	"basic-service/gen/db/model"
+	"basic-service/gen/db/table"
+
+	"braces.dev/errtrace"
+	"github.com/go-jet/jet/v2/qrm"
+	"github.com/go-jet/jet/v2/sqlite"
+)
+
+var ErrAccountDeletionNotFound = errors.New("account deletion not scheduled")
+
+type AccountRepository struct {
+	db *SQLite
+}
+
+func NewAccountRepository(db *SQLite) *AccountRepository {
+	return &AccountRepository{db: db}
+}
+
+// AccountPurge describes what goes with an account. DeleteTemplates are the
+// templates the user is the only owner of, TransferTemplates maps co-owned
+// templates created by the user to the owner that takes them over.
+type AccountPurge struct {
+	UserID            string
+	Email             string
+	DeleteTemplates   []string
+	TransferTemplates map[string]string
+}
+
+func toAccountDeletion(v model.AccountDeletions) domain.AccountDeletion {
+	return domain.AccountDeletion{
+		UserID:      v.UserID,
+		RequestedAt: v.RequestedAt,
+		DeleteAfter: v.DeleteAfter,
+	}
+}
+
+// ScheduleDeletion stores the deletion request, a new request replaces the
+// previous one.
+func (r *AccountRepository) ScheduleDeletion(ctx context.Context, deletion domain.AccountDeletion) error {
+	stmt := table.AccountDeletions.INSERT(
+		table.AccountDeletions.UserID,
+		table.AccountDeletions.RequestedAt,
+		table.AccountDeletions.DeleteAfter,
+	).VALUES(
+		sqlite.String(deletion.UserID),
+		sqlite.DATETIME(deletion.RequestedAt),
+		sqlite.DATETIME(deletion.DeleteAfter),
+	).ON_CONFLICT(table.AccountDeletions.UserID).DO_UPDATE(
+		sqlite.SET(
+			table.AccountDeletions.RequestedAt.SET(table.AccountDeletions.EXCLUDED.RequestedAt),
+			table.AccountDeletions.DeleteAfter.SET(table.AccountDeletions.EXCLUDED.DeleteAfter),
+		),
+	)
+
+	_, err := stmt.ExecContext(ctx, r.db.db)
+	return errtrace.Wrap(err)
+}
+
+func (r *AccountRepository) GetDeletion(ctx context.Context, userID string) (*domain.AccountDeletion, error) {
+	stmt := table.AccountDeletions.SELECT(
+		table.AccountDeletions.AllColumns,
+	).WHERE(
+		table.AccountDeletions.UserID.EQ(sqlite.String(userID)),
+	).LIMIT(1)
+
+	var dbDeletion model.AccountDeletions
+	if err := stmt.QueryContext(ctx, r.db.db, &dbDeletion); err != nil {
+		if errors.Is(err, qrm.ErrNoRows) {
+			return nil, errtrace.Wrap(ErrAccountDeletionNotFound)
+		}
+		return nil, errtrace.Wrap(err)
+	}
+
+	deletion := toAccountDeletion(dbDeletion)
+	return &deletion, nil
+}
+
+func (r *AccountRepository) CancelDeletion(ctx context.Context, userID string) error {
+	stmt := table.AccountDeletions.DELETE().
+		WHERE(table.AccountDeletions.UserID.EQ(sqlite.String(userID)))
+
+	result, err := stmt.ExecContext(ctx, r.db.db)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	if rowsAffected == 0 {
+		return errtrace.Wrap(ErrAccountDeletionNotFound)
+	}
+
+	return nil
+}
+
+// ListDueDeletions returns the deletions whose grace period ended before now.
+func (r *AccountRepository) ListDueDeletions(ctx context.Context, now time.Time) ([]domain.AccountDeletion, error) {
+	stmt := table.AccountDeletions.SELECT(
+		table.AccountDeletions.AllColumns,
+	).WHERE(
+		table.AccountDeletions.DeleteAfter.LT_EQ(sqlite.DATETIME(now)),
+	).ORDER_BY(
+		table.AccountDeletions.DeleteAfter.ASC(),
+	)
+
+	var dbDeletions []model.AccountDeletions
+	if err := stmt.QueryContext(ctx, r.db.db, &dbDeletions); err != nil && !errors.Is(err, qrm.ErrNoRows) {
+		return nil, errtrace.Wrap(err)
+	}
+
+	deletions := make([]domain.AccountDeletion, 0, len(dbDeletions))
+	for _, v := range dbDeletions {
+		deletions = append(deletions, toAccountDeletion(v))
+	}
+
+	return deletions, nil
+}
+
+// Purge deletes the user and every row that belongs to them in one
+// transaction. Audit logs are kept.
+func (r *AccountRepository) Purge(ctx context.Context, purge AccountPurge) error {
+	tx, err := r.db.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+	defer tx.Rollback()
+
+	userID := sqlite.String(purge.UserID)
+
+	var stmts []sqlite.Statement
+
+	if len(purge.DeleteTemplates) > 0 {
+		ids := make([]sqlite.Expression, 0, len(purge.DeleteTemplates))
+		for _, v := range purge.DeleteTemplates {
+			ids = append(ids, sqlite.String(v))
+		}
+
+		stmts = append(stmts,
+			table.GuestMembers.DELETE().WHERE(table.GuestMembers.UserTemplateID.IN(ids...)),
+			table.Guests.DELETE().WHERE(table.Guests.UserTemplateID.IN(ids...)),
+			table.TemplateInvites.DELETE().WHERE(table.TemplateInvites.UserTemplateID.IN(ids...)),
+			table.TemplateMembers.DELETE().WHERE(table.TemplateMembers.UserTemplateID.IN(ids...)),
+			table.RsvpQuestions.DELETE().WHERE(table.RsvpQuestions.UserTemplateID.IN(ids...)),
+			table.UserTemplates.DELETE().WHERE(table.UserTemplates.ID.IN(ids...)),
+		)
+	}
+
+	for templateID, ownerID := range purge.TransferTemplates {
+		stmts = append(stmts, table.UserTemplates.UPDATE().
+			SET(
+				table.UserTemplates.UserID.SET(sqlite.String(ownerID)),
+			).WHERE(
+			table.UserTemplates.ID.EQ(sqlite.String(templateID)),
+		))
+	}
+
+	stmts = append(stmts,
+		table.TemplateMembers.DELETE().WHERE(table.TemplateMembers.UserID.EQ(userID)),
+		table.TemplateInvites.DELETE().WHERE(
+			table.TemplateInvites.Email.EQ(sqlite.String(purge.Email)).
+				AND(table.TemplateInvites.AcceptedAt.IS_NULL()),
+		),
+		table.RefreshTokens.DELETE().WHERE(table.RefreshTokens.UserID.EQ(userID)),
+		table.Sessions.DELETE().WHERE(table.Sessions.UserID.EQ(userID)),
+		table.RevokedTokens.DELETE().WHERE(table.RevokedTokens.UserID.EQ(userID)),
+		table.UserTokens.DELETE().WHERE(table.UserTokens.UserID.EQ(userID)),
+		table.ApiTokens.DELETE().WHERE(table.ApiTokens.UserID.EQ(userID)),
+		table.UserTotp.DELETE().WHERE(table.UserTotp.UserID.EQ(userID)),
+		table.UserRecoveryCodes.DELETE().WHERE(table.UserRecoveryCodes.UserID.EQ(userID)),
+		table.UserIdentities.DELETE().WHERE(table.UserIdentities.UserID.EQ(userID)),
+		table.LoginAttempts.DELETE().WHERE(table.LoginAttempts.Key.EQ(sqlite.String("email:"+purge.Email))),
+		table.AccountDeletions.DELETE().WHERE(table.AccountDeletions.UserID.EQ(userID)),
+		table.Users.DELETE().WHERE(table.Users.ID.EQ(userID)),
+	)
+
+	for _, stmt := range stmts {
+		if _, err := stmt.ExecContext(ctx, tx); err != nil {
+			return errtrace.Wrap(err)
+		}
+	}
+
+	return errtrace.Wrap(tx.Commit())
+}
@@ -0,0 +1,158 @@
+package sql
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"basic-service/domain"
+	"basic-service/gen/db/model"
+	"basic-service/gen/db/table"
+
+	"braces.dev/errtrace"
+	"github.com/go-jet/jet/v2/qrm"
+	"github.com/go-jet/jet/v2/sqlite"
+)
+
+var ErrAPITokenNotFound = errors.New("api token not found")
+
+type APITokenRepository struct {
+	db *SQLite
+}
+
+func NewAPITokenRepository(db *SQLite) *APITokenRepository {
+	return &APITokenRepository{db: db}
+}
+
+func toAPIToken(v model.ApiTokens) domain.APIToken {
+	return domain.APIToken{
+		ID:         v.ID,
+		UserID:     v.UserID,
+		Name:       v.Name,
+		TokenHash:  v.TokenHash,
+		Prefix:     v.Prefix,
+		Scopes:     strings.Fields(v.Scopes),
+		ExpiresAt:  v.ExpiresAt,
+		LastUsedAt: v.LastUsedAt,
+		RevokedAt:  v.RevokedAt,
+		CreatedAt:  v.CreatedAt,
+	}
+}
+
+func (r *APITokenRepository) Create(ctx context.Context, token domain.APIToken) error {
+	expiresAt := sqlite.Expression(sqlite.NULL)
+	if token.ExpiresAt != nil {
+		expiresAt = sqlite.DATETIME(*token.ExpiresAt)
+	}
+
+	stmt := table.ApiTokens.INSERT(
+		table.ApiTokens.ID,
+		table.ApiTokens.UserID,
+		table.ApiTokens.Name,
+		table.ApiTokens.TokenHash,
+		table.ApiTokens.Prefix,
+		table.ApiTokens.Scopes,
+		table.ApiTokens.ExpiresAt,
+		table.ApiTokens.CreatedAt,
+	).VALUES(
+		sqlite.String(token.ID),
+		sqlite.String(token.UserID),
+		sqlite.String(token.Name),
+		sqlite.String(token.TokenHash),
+		sqlite.String(token.Prefix),
+		sqlite.String(strings.Join(token.Scopes, " ")),
+		expiresAt,
+		sqlite.DATETIME(token.CreatedAt),
+	)
+
+	_, err := stmt.ExecContext(ctx, r.db.db)
+	return errtrace.Wrap(err)
+}
+
+// GetByHash returns a token that is neither revoked nor expired.
+func (r *APITokenRepository) GetByHash(ctx context.Context, hash string) (*domain.APIToken, error) {
+	stmt := table.ApiTokens.SELECT(
+		table.ApiTokens.AllColumns,
+	).WHERE(
+		table.ApiTokens.TokenHash.EQ(sqlite.String(hash)).
+			AND(table.ApiTokens.RevokedAt.IS_NULL()).
+			AND(table.ApiTokens.ExpiresAt.IS_NULL().
+				OR(table.ApiTokens.ExpiresAt.GT(sqlite.DATETIME(time.Now())))),
+	).LIMIT(1)
+
+	var dbToken model.ApiTokens
+	if err := stmt.QueryContext(ctx, r.db.db, &dbToken); err != nil {
+		if errors.Is(err, qrm.ErrNoRows) {
+			return nil, errtrace.Wrap(ErrAPITokenNotFound)
+		}
+		return nil, errtrace.Wrap(err)
+	}
+
+	token := toAPIToken(dbToken)
+	return &token, nil
+}
+
+// ListByUser returns the tokens of the user that are not revoked, expired
+// tokens are kept so the user can see why a script stopped working.
+func (r *APITokenRepository) ListByUser(ctx context.Context, userID string) ([]domain.APIToken, error) {
+	stmt := table.ApiTokens.SELECT(
+		table.ApiTokens.AllColumns,
+	).WHERE(
+		table.ApiTokens.UserID.EQ(sqlite.String(userID)).
+			AND(table.ApiTokens.RevokedAt.IS_NULL()),
+	).ORDER_BY(
+		table.ApiTokens.CreatedAt.DESC(),
+	)
+
+	var dbTokens []model.ApiTokens
+	if err := stmt.QueryContext(ctx, r.db.db, &dbTokens); err != nil {
+		return nil, errtrace.Wrap(err)
+	}
+
+	tokens := make([]domain.APIToken, 0, len(dbTokens))
+	for _, v := range dbTokens {
+		tokens = append(tokens, toAPIToken(v))
+	}
+
+	return tokens, nil
+}
+
+func (r *APITokenRepository) Revoke(ctx context.Context, userID, id string) error {
+	stmt := table.ApiTokens.UPDATE().
+		SET(
+			table.ApiTokens.RevokedAt.SET(sqlite.DATETIME(time.Now())),
+		).WHERE(
+		table.ApiTokens.ID.EQ(sqlite.String(id)).
+			AND(table.ApiTokens.UserID.EQ(sqlite.String(userID))).
+			AND(table.ApiTokens.RevokedAt.IS_NULL()),
+	)
+
+	result, err := stmt.ExecContext(ctx, r.db.db)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	if rowsAffected == 0 {
+		return errtrace.Wrap(ErrAPITokenNotFound)
+	}
+
+	return nil
+}
+
+func (r *APITokenRepository) Touch(ctx context.Context, id string) error {
+	stmt := table.ApiTokens.UPDATE().
+		SET(
+			table.ApiTokens.LastUsedAt.SET(sqlite.DATETIME(time.Now())),
+		).WHERE(
+		table.ApiTokens.ID.EQ(sqlite.String(id)),
+	)
+
+	_, err := stmt.ExecContext(ctx, r.db.db)
+	return errtrace.Wrap(err)
+}
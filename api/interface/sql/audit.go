@@ -0,0 +1,53 @@
+package sql
+
+import (
+	"context"
+	"encoding/json"
+
+	"basic-service/domain"
+	"basic-service/gen/db/table"
+
+	"braces.dev/errtrace"
+	"github.com/go-jet/jet/v2/sqlite"
+)
+
+type AuditRepository struct {
+	db *SQLite
+}
+
+func NewAuditRepository(db *SQLite) *AuditRepository {
+	return &AuditRepository{db: db}
+}
+
+func (r *AuditRepository) Create(ctx context.Context, entry domain.AuditLog) error {
+	metadata, err := json.Marshal(entry.Metadata)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+	if entry.Metadata == nil {
+		metadata = []byte("{}")
+	}
+
+	stmt := table.AuditLogs.INSERT(
+		table.AuditLogs.ID,
+		table.AuditLogs.ActorID,
+		table.AuditLogs.Action,
+		table.AuditLogs.TargetType,
+		table.AuditLogs.TargetID,
+		table.AuditLogs.IP,
+		table.AuditLogs.Metadata,
+		table.AuditLogs.CreatedAt,
+	).VALUES(
+		sqlite.String(entry.ID),
+		sqlite.String(entry.ActorID),
+		sqlite.String(entry.Action),
+		sqlite.String(entry.TargetType),
+		sqlite.String(entry.TargetID),
+		sqlite.String(entry.IP),
+		sqlite.String(string(metadata)),
+		sqlite.DATETIME(entry.CreatedAt),
+	)
+
+	_, err = stmt.ExecContext(ctx, r.db.db)
+	return errtrace.Wrap(err)
+}
@@ -16,6 +16,8 @@ import (
 	"github.com/go-jet/jet/v2/sqlite"
 )
 
+var ErrGuestNotFound = errors.New("guest not found")
+
 type GuestManager struct {
 	db *SQLite
 }
@@ -24,13 +26,42 @@ func NewGuestManager(db *SQLite) *GuestManager {
 	return &GuestManager{db: db}
 }
 
+// Create adds a guest with its household members
 func (r *GuestManager) Create(ctx context.Context, guest domain.Guest) error {
-	tagsJSON, err := json.Marshal(guest.Tags)
+	stmt, err := insertGuest(guest)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	tx, err := r.db.db.BeginTx(ctx, nil)
 	if err != nil {
 		return errtrace.Wrap(err)
 	}
+	defer tx.Rollback()
 
-	stmt := table.Guests.INSERT(
+	if _, err := stmt.ExecContext(ctx, tx); err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	if err := replaceMembers(ctx, tx, guest); err != nil {
+		return err
+	}
+
+	return errtrace.Wrap(tx.Commit())
+}
+
+func insertGuest(guest domain.Guest) (sqlite.InsertStatement, error) {
+	tagsJSON, err := json.Marshal(guest.Tags)
+	if err != nil {
+		return nil, errtrace.Wrap(err)
+	}
+
+	answersJSON, err := marshalAnswers(guest.Answers)
+	if err != nil {
+		return nil, errtrace.Wrap(err)
+	}
+
+	return table.Guests.INSERT(
 		table.Guests.ID,
 		table.Guests.UserTemplateID,
 		table.Guests.Name,
@@ -43,6 +74,9 @@ func (r *GuestManager) Create(ctx context.Context, guest domain.Guest) error {
 		table.Guests.Attend,
 		table.Guests.ViewAt,
 		table.Guests.CreatedAt,
+		table.Guests.InviteNonce,
+		table.Guests.AttendPerson,
+		table.Guests.RsvpAnswers,
 	).VALUES(
 		guest.ID,
 		guest.UserTemplateID,
@@ -56,56 +90,217 @@ func (r *GuestManager) Create(ctx context.Context, guest domain.Guest) error {
 		guest.Attend,
 		guest.ViewAt,
 		time.Now(),
-	)
+		guest.InviteNonce,
+		guest.AttendPerson,
+		answersJSON,
+	), nil
+}
 
-	_, err = stmt.ExecContext(ctx, r.db.db)
-	return errtrace.Wrap(err)
+// marshalAnswers encodes the RSVP answers of a guest, no answers is {}
+func marshalAnswers(answers map[string]string) (string, error) {
+	if answers == nil {
+		answers = map[string]string{}
+	}
+
+	b, err := json.Marshal(answers)
+	return string(b), errtrace.Wrap(err)
 }
 
-func (r *GuestManager) List(ctx context.Context, userID, userTemplateID string, page, pageSize int) ([]domain.Guest, int64, error) {
-	var guests []model.Guests
+// GuestFilter narrows the guests of a user template, zero values are
+// ignored
+type GuestFilter struct {
+	UserTemplateID string
+	Query          string // matched against name, telp and address
+	Group          string
+	Tag            string // one of the tags
+	Attend         string // yes, no or pending
+	Viewed         *bool
+	// Guestbook keeps the guests who wrote a message, MessageStatus narrows
+	// them to one moderation state
+	Guestbook     bool
+	MessageStatus domain.MessageStatus
+	// PinnedFirst orders pinned messages before the sort column
+	PinnedFirst bool
+	SortBy      string // created_at, name, group, person, attend, view_at or message_at
+	Desc        bool
+	// Telp is Query read as the start of a phone number in E.164, the form
+	// telp is stored in, it is matched against telp as well
+	Telp string
+}
+
+var guestSortColumns = map[string]sqlite.Column{
+	"created_at": table.Guests.CreatedAt,
+	"name":       table.Guests.Name,
+	"group":      table.Guests.GroupName,
+	"person":     table.Guests.Person,
+	"attend":     table.Guests.Attend,
+	"view_at":    table.Guests.ViewAt,
+	"message_at": table.Guests.MessageAt,
+}
+
+func (f GuestFilter) condition() sqlite.BoolExpression {
+	cond := table.Guests.UserTemplateID.EQ(sqlite.String(f.UserTemplateID))
+
+	if f.Query != "" {
+		search := contains(table.Guests.Name, f.Query).
+			OR(contains(table.Guests.Telp, f.Query)).
+			OR(contains(table.Guests.Address, f.Query))
+		if f.Telp != "" {
+			search = search.OR(contains(table.Guests.Telp, f.Telp))
+		}
+		cond = cond.AND(search)
+	}
+	if f.Group != "" {
+		cond = cond.AND(table.Guests.GroupName.EQ(sqlite.String(f.Group)))
+	}
+	if f.Tag != "" {
+		// tags is a JSON array of strings
+		cond = cond.AND(sqlite.RawBool(
+			"EXISTS (SELECT 1 FROM json_each(guests.tags) WHERE json_each.value = #tag)",
+			sqlite.RawArgs{"#tag": f.Tag},
+		))
+	}
+	switch f.Attend {
+	case "yes":
+		cond = cond.AND(table.Guests.Attend.IS_TRUE())
+	case "no":
+		cond = cond.AND(table.Guests.Attend.IS_FALSE())
+	case "pending":
+		cond = cond.AND(table.Guests.Attend.IS_NULL())
+	}
+	if f.Viewed != nil {
+		if *f.Viewed {
+			cond = cond.AND(table.Guests.ViewAt.IS_NOT_NULL())
+		} else {
+			cond = cond.AND(table.Guests.ViewAt.IS_NULL())
+		}
+	}
+	if f.Guestbook {
+		cond = cond.AND(table.Guests.Message.NOT_EQ(sqlite.String("")))
+	}
+	if f.MessageStatus != "" {
+		cond = cond.AND(table.Guests.MessageStatus.EQ(sqlite.String(string(f.MessageStatus))))
+	}
+
+	return cond
+}
+
+// orderBy sorts by the filter's column, ties are broken by id so pages are
+// stable
+func (f GuestFilter) orderBy() []sqlite.OrderByClause {
+	column, ok := guestSortColumns[f.SortBy]
+	if !ok {
+		column = table.Guests.CreatedAt
+	}
+
+	var clauses []sqlite.OrderByClause
+	if f.PinnedFirst {
+		clauses = append(clauses, table.Guests.MessagePinned.DESC())
+	}
+
+	if f.Desc {
+		clauses = append(clauses, column.DESC())
+	} else {
+		clauses = append(clauses, column.ASC())
+	}
+
+	return append(clauses, table.Guests.ID.ASC())
+}
+
+// List returns a page of the guests matching filter and how many match in
+// total, access to the template is checked by the caller.
+func (r *GuestManager) List(ctx context.Context, filter GuestFilter, page, pageSize int) ([]domain.Guest, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 10 // Default page size
+	}
 
 	// Calculate offset based on page and pageSize
 	offset := (page - 1) * pageSize
 
-	// Modified query with JOIN and user_id check
 	stmt := sqlite.SELECT(
 		table.Guests.AllColumns,
 	).FROM(
-		table.Guests.INNER_JOIN(table.UserTemplates,
-			table.UserTemplates.ID.EQ(table.Guests.UserTemplateID)),
+		table.Guests,
 	).WHERE(
-		table.Guests.UserTemplateID.EQ(sqlite.String(userTemplateID)).
-			AND(table.UserTemplates.UserID.EQ(sqlite.String(userID))),
+		filter.condition(),
 	).ORDER_BY(
-		table.Guests.CreatedAt.DESC(),
+		filter.orderBy()...,
 	).LIMIT(
 		int64(pageSize),
 	).OFFSET(
 		int64(offset),
 	)
 
-	fmt.Println("SQL Query:", stmt.DebugSql())
+	var guests []model.Guests
+	if err := stmt.QueryContext(ctx, r.db.db, &guests); err != nil && !errors.Is(err, qrm.ErrNoRows) {
+		return nil, 0, errtrace.Wrap(err)
+	}
 
-	err := stmt.QueryContext(ctx, r.db.db, &guests)
+	// Get total count with the same filter
+	total, err := r.getFilteredCount(ctx, filter)
 	if err != nil {
-		if errors.Is(err, qrm.ErrNoRows) {
-			return []domain.Guest{}, 0, nil
-		}
 		return nil, 0, errtrace.Wrap(err)
 	}
 
-	// Get total count with the same user_id filter
-	total, err := r.getFilteredCount(ctx, userID, userTemplateID)
+	result, err := toGuests(guests)
 	if err != nil {
 		return nil, 0, errtrace.Wrap(err)
 	}
 
+	result, err = r.withMembers(ctx, result)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return result, total, nil
+}
+
+// ListAll returns every guest matching filter, oldest first unless filter
+// sorts otherwise. Access to the template is checked by the caller.
+func (r *GuestManager) ListAll(ctx context.Context, filter GuestFilter) ([]domain.Guest, error) {
+	stmt := sqlite.SELECT(
+		table.Guests.AllColumns,
+	).FROM(
+		table.Guests,
+	).WHERE(
+		filter.condition(),
+	).ORDER_BY(
+		filter.orderBy()...,
+	)
+
+	var guests []model.Guests
+	if err := stmt.QueryContext(ctx, r.db.db, &guests); err != nil && !errors.Is(err, qrm.ErrNoRows) {
+		return nil, errtrace.Wrap(err)
+	}
+
+	result, err := toGuests(guests)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.withMembers(ctx, result)
+}
+
+func toGuests(guests []model.Guests) ([]domain.Guest, error) {
 	result := make([]domain.Guest, 0, len(guests))
 	for _, g := range guests {
 		var tags []string
 		if err := json.Unmarshal([]byte(g.Tags), &tags); err != nil {
-			return nil, 0, errtrace.Wrap(err)
+			return nil, errtrace.Wrap(err)
+		}
+
+		answers := map[string]string{}
+		if err := json.Unmarshal([]byte(g.RsvpAnswers), &answers); err != nil {
+			return nil, errtrace.Wrap(err)
+		}
+
+		var attendPerson *int
+		if g.AttendPerson != nil {
+			v := int(*g.AttendPerson)
+			attendPerson = &v
 		}
 
 		result = append(result, domain.Guest{
@@ -119,40 +314,69 @@ func (r *GuestManager) List(ctx context.Context, userID, userTemplateID string,
 			Address:        g.Address,
 			Message:        g.Message,
 			Attend:         g.Attend,
+			AttendPerson:   attendPerson,
+			Answers:        answers,
+			MessageStatus:  domain.MessageStatus(g.MessageStatus),
+			MessagePinned:  g.MessagePinned,
+			MessageAt:      g.MessageAt,
 			ViewAt:         g.ViewAt,
 			CreatedAt:      g.CreatedAt,
+			InviteNonce:    g.InviteNonce,
 		})
 	}
 
-	return result, total, nil
+	return result, nil
 }
 
-// New helper function for filtered count
-func (r *GuestManager) getFilteredCount(ctx context.Context, userID, userTemplateID string) (int64, error) {
-	var count int64
-
+// getFilteredCount counts the guests matching filter
+func (r *GuestManager) getFilteredCount(ctx context.Context, filter GuestFilter) (int64, error) {
 	stmt := sqlite.SELECT(
 		sqlite.COUNT(sqlite.STAR).AS("total"),
 	).FROM(
-		table.Guests.
-			INNER_JOIN(table.UserTemplates,
-				table.UserTemplates.ID.EQ(table.Guests.UserTemplateID)),
+		table.Guests,
 	).WHERE(
-		table.Guests.UserTemplateID.EQ(sqlite.String(userTemplateID)).
-			AND(table.UserTemplates.UserID.EQ(sqlite.String(userID))),
+		filter.condition(),
 	)
 
 	var total struct {
 		Total int64
 	}
-
-	err := stmt.QueryContext(ctx, r.db.db, &total)
-	if err != nil {
+	if err := stmt.QueryContext(ctx, r.db.db, &total); err != nil {
 		return 0, errtrace.Wrap(err)
 	}
-	count = total.Total
 
-	return count, nil
+	return total.Total, nil
+}
+
+// CountByUser returns the number of guests of every user template userID is
+// a member of, keyed by user template ID
+func (r *GuestManager) CountByUser(ctx context.Context, userID string) (map[string]int64, error) {
+	stmt := sqlite.SELECT(
+		table.Guests.UserTemplateID.AS("user_template_id"),
+		sqlite.COUNT(table.Guests.ID).AS("total"),
+	).FROM(
+		table.Guests.INNER_JOIN(table.TemplateMembers,
+			table.TemplateMembers.UserTemplateID.EQ(table.Guests.UserTemplateID)),
+	).WHERE(
+		table.TemplateMembers.UserID.EQ(sqlite.String(userID)),
+	).GROUP_BY(
+		table.Guests.UserTemplateID,
+	)
+
+	var rows []struct {
+		UserTemplateID string
+		Total          int64
+	}
+	if err := stmt.QueryContext(ctx, r.db.db, &rows); err != nil && !errors.Is(err, qrm.ErrNoRows) {
+		return nil, errtrace.Wrap(err)
+	}
+
+	result := make(map[string]int64, len(rows))
+	for _, v := range rows {
+		result[v.UserTemplateID] = v.Total
+	}
+
+	return result, nil
 }
 
 func (r *GuestManager) Get(ctx context.Context, guestID string) (*domain.Guest, error) {
@@ -169,52 +393,100 @@ func (r *GuestManager) Get(ctx context.Context, guestID string) (*domain.Guest,
 	err := stmt.QueryContext(ctx, r.db.db, &guest)
 	if err != nil {
 		if errors.Is(err, qrm.ErrNoRows) {
-			return nil, nil
+			return nil, errtrace.Wrap(ErrGuestNotFound)
 		}
 		return nil, errtrace.Wrap(err)
 	}
 
-	var tags []string
-	if err := json.Unmarshal([]byte(guest.Tags), &tags); err != nil {
+	guests, err := toGuests([]model.Guests{guest})
+	if err != nil {
 		return nil, errtrace.Wrap(err)
 	}
 
-	return &domain.Guest{
-		ID:             guest.ID,
-		UserTemplateID: guest.UserTemplateID,
-		Name:           guest.Name,
-		Group:          guest.GroupName,
-		Person:         int(guest.Person),
-		Tags:           tags,
-		Telp:           guest.Telp,
-		Address:        guest.Address,
-		Message:        guest.Message,
-		Attend:         guest.Attend,
-		ViewAt:         guest.ViewAt,
-		CreatedAt:      guest.CreatedAt,
-	}, nil
+	guests, err = r.withMembers(ctx, guests)
+	if err != nil {
+		return nil, err
+	}
+
+	return &guests[0], nil
 }
 
-func (r *GuestManager) Update(ctx context.Context, guestID string, guest domain.Guest) error {
-	tagsJSON, err := json.Marshal(guest.Tags)
+// GetByInviteNonce returns the guest whose invitation link carries nonce.
+func (r *GuestManager) GetByInviteNonce(ctx context.Context, nonce string) (*domain.Guest, error) {
+	stmt := table.Guests.SELECT(
+		table.Guests.ID,
+	).WHERE(
+		table.Guests.InviteNonce.EQ(sqlite.String(nonce)),
+	).LIMIT(1)
+
+	var guest model.Guests
+	if err := stmt.QueryContext(ctx, r.db.db, &guest); err != nil {
+		if errors.Is(err, qrm.ErrNoRows) {
+			return nil, errtrace.Wrap(ErrGuestNotFound)
+		}
+		return nil, errtrace.Wrap(err)
+	}
+
+	return errtrace.Wrap2(r.Get(ctx, guest.ID))
+}
+
+// SetInviteNonce replaces the invitation nonce of a guest, nil revokes the
+// link.
+func (r *GuestManager) SetInviteNonce(ctx context.Context, guestID string, nonce *string) error {
+	value := sqlite.StringExp(sqlite.NULL)
+	if nonce != nil {
+		value = sqlite.String(*nonce)
+	}
+
+	stmt := table.Guests.UPDATE().
+		SET(
+			table.Guests.InviteNonce.SET(value),
+		).WHERE(
+		table.Guests.ID.EQ(sqlite.String(guestID)),
+	)
+
+	result, err := stmt.ExecContext(ctx, r.db.db)
 	if err != nil {
 		return errtrace.Wrap(err)
 	}
 
-	setList := []interface{}{
-		table.Guests.Name.SET(sqlite.String(guest.Name)),
-		table.Guests.GroupName.SET(sqlite.String(guest.Group)),
-		table.Guests.Person.SET(sqlite.Int(int64(guest.Person))),
-		table.Guests.Tags.SET(sqlite.String(string(tagsJSON))),
-		table.Guests.Telp.SET(sqlite.String(guest.Telp)),
-		table.Guests.Address.SET(sqlite.String(guest.Address)),
-		table.Guests.Message.SET(sqlite.String(guest.Message)),
-		table.Guests.ViewAt.SET(sqlite.DATETIME(guest.ViewAt)),
-		table.Guests.CreatedAt.SET(sqlite.DATETIME(guest.CreatedAt)),
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return errtrace.Wrap(err)
 	}
 
-	if guest.Attend != nil {
-		setList = append(setList, table.Guests.Attend.SET(sqlite.Bool(*guest.Attend)))
+	if rowsAffected == 0 {
+		return errtrace.Wrap(ErrGuestNotFound)
+	}
+
+	return nil
+}
+
+// GuestUpdate is a partial update of a guest, nil fields are left as they
+// are. Message and ViewAt are written by the guest and never change here.
+type GuestUpdate struct {
+	Name    *string
+	Group   *string
+	Person  *int
+	Tags    *[]string
+	Telp    *string
+	Address *string
+	Attend  *bool
+	// AttendPerson is the confirmed headcount, callers keep it within Person
+	AttendPerson *int
+	// MessageStatus and MessagePinned moderate the guestbook message
+	MessageStatus *domain.MessageStatus
+	MessagePinned *bool
+}
+
+func (r *GuestManager) Update(ctx context.Context, guestID string, guest GuestUpdate) error {
+	setList, err := guestUpdateSet(guest)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	if len(setList) == 0 {
+		return nil
 	}
 
 	stmt := table.Guests.UPDATE().
@@ -222,11 +494,164 @@ func (r *GuestManager) Update(ctx context.Context, guestID string, guest domain.
 		table.Guests.ID.EQ(sqlite.String(guestID)),
 	)
 
+	result, err := stmt.ExecContext(ctx, r.db.db)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	if rowsAffected == 0 {
+		return errtrace.Wrap(ErrGuestNotFound)
+	}
+
+	return nil
+}
+
+// guestUpdateSet returns the assignments of the non nil fields of guest
+func guestUpdateSet(guest GuestUpdate) ([]interface{}, error) {
+	var setList []interface{}
+
+	if guest.Name != nil {
+		setList = append(setList, table.Guests.Name.SET(sqlite.String(*guest.Name)))
+	}
+	if guest.Group != nil {
+		setList = append(setList, table.Guests.GroupName.SET(sqlite.String(*guest.Group)))
+	}
+	if guest.Person != nil {
+		setList = append(setList, table.Guests.Person.SET(sqlite.Int(int64(*guest.Person))))
+	}
+	if guest.Tags != nil {
+		tagsJSON, err := json.Marshal(*guest.Tags)
+		if err != nil {
+			return nil, errtrace.Wrap(err)
+		}
+		setList = append(setList, table.Guests.Tags.SET(sqlite.String(string(tagsJSON))))
+	}
+	if guest.Telp != nil {
+		setList = append(setList, table.Guests.Telp.SET(sqlite.String(*guest.Telp)))
+	}
+	if guest.Address != nil {
+		setList = append(setList, table.Guests.Address.SET(sqlite.String(*guest.Address)))
+	}
 	if guest.Attend != nil {
-		stmt = stmt.SET(table.Guests.Attend.SET(sqlite.Bool(*guest.Attend)))
+		setList = append(setList, table.Guests.Attend.SET(sqlite.Bool(*guest.Attend)))
+	}
+	if guest.AttendPerson != nil {
+		setList = append(setList, table.Guests.AttendPerson.SET(sqlite.Int(int64(*guest.AttendPerson))))
+	}
+	if guest.MessageStatus != nil {
+		setList = append(setList, table.Guests.MessageStatus.SET(sqlite.String(string(*guest.MessageStatus))))
+	}
+	if guest.MessagePinned != nil {
+		setList = append(setList, table.Guests.MessagePinned.SET(sqlite.Bool(*guest.MessagePinned)))
 	}
 
-	result, err := stmt.ExecContext(ctx, r.db.db)
+	return setList, nil
+}
+
+// Import creates and updates guests in one transaction, updates are keyed by
+// guest ID.
+func (r *GuestManager) Import(ctx context.Context, create []domain.Guest, update map[string]GuestUpdate) error {
+	tx, err := r.db.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+	defer tx.Rollback()
+
+	for _, guest := range create {
+		stmt, err := insertGuest(guest)
+		if err != nil {
+			return errtrace.Wrap(err)
+		}
+
+		if _, err := stmt.ExecContext(ctx, tx); err != nil {
+			return errtrace.Wrap(err)
+		}
+	}
+
+	for guestID, guest := range update {
+		setList, err := guestUpdateSet(guest)
+		if err != nil {
+			return errtrace.Wrap(err)
+		}
+
+		if len(setList) == 0 {
+			continue
+		}
+
+		stmt := table.Guests.UPDATE().
+			SET(setList[0], setList[1:]...).WHERE(
+			table.Guests.ID.EQ(sqlite.String(guestID)),
+		)
+
+		if _, err := stmt.ExecContext(ctx, tx); err != nil {
+			return errtrace.Wrap(err)
+		}
+	}
+
+	return errtrace.Wrap(tx.Commit())
+}
+
+// Merge writes guest and its household over the stored guest with its id and
+// deletes the guests of removeIDs in one transaction, they must be on the
+// same template.
+func (r *GuestManager) Merge(ctx context.Context, guest domain.Guest, removeIDs []string) error {
+	tagsJSON, err := json.Marshal(guest.Tags)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	answersJSON, err := marshalAnswers(guest.Answers)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	tx, err := r.db.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+	defer tx.Rollback()
+
+	update := table.Guests.UPDATE(
+		table.Guests.Name,
+		table.Guests.GroupName,
+		table.Guests.Person,
+		table.Guests.Tags,
+		table.Guests.Telp,
+		table.Guests.Address,
+		table.Guests.Message,
+		table.Guests.ViewAt,
+		table.Guests.Attend,
+		table.Guests.AttendPerson,
+		table.Guests.RsvpAnswers,
+		table.Guests.MessageStatus,
+		table.Guests.MessagePinned,
+		table.Guests.MessageAt,
+	).SET(
+		guest.Name,
+		guest.Group,
+		guest.Person,
+		string(tagsJSON),
+		guest.Telp,
+		guest.Address,
+		guest.Message,
+		guest.ViewAt,
+		guest.Attend,
+		guest.AttendPerson,
+		answersJSON,
+		string(guest.MessageStatus),
+		guest.MessagePinned,
+		guest.MessageAt,
+	).WHERE(
+		table.Guests.ID.EQ(sqlite.String(guest.ID)).
+			AND(table.Guests.UserTemplateID.EQ(sqlite.String(guest.UserTemplateID))),
+	)
+
+	result, err := update.ExecContext(ctx, tx)
 	if err != nil {
 		return errtrace.Wrap(err)
 	}
@@ -237,10 +662,47 @@ func (r *GuestManager) Update(ctx context.Context, guestID string, guest domain.
 	}
 
 	if rowsAffected == 0 {
-		return errtrace.Wrap(errors.New("no guest found with the given ID"))
+		return errtrace.Wrap(ErrGuestNotFound)
 	}
 
-	return nil
+	ids := make([]sqlite.Expression, 0, len(removeIDs))
+	for _, v := range removeIDs {
+		ids = append(ids, sqlite.String(v))
+	}
+
+	remove := table.Guests.DELETE().
+		WHERE(
+			table.Guests.ID.IN(ids...).
+				AND(table.Guests.UserTemplateID.EQ(sqlite.String(guest.UserTemplateID))),
+		)
+
+	result, err = remove.ExecContext(ctx, tx)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	rowsAffected, err = result.RowsAffected()
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	// a guest that is gone or on another template rolls the merge back
+	if rowsAffected != int64(len(removeIDs)) {
+		return errtrace.Wrap(ErrGuestNotFound)
+	}
+
+	members := table.GuestMembers.DELETE().
+		WHERE(table.GuestMembers.GuestID.IN(ids...))
+
+	if _, err := members.ExecContext(ctx, tx); err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	if err := replaceMembers(ctx, tx, guest); err != nil {
+		return err
+	}
+
+	return errtrace.Wrap(tx.Commit())
 }
 
 func (r *GuestManager) UpdateMessageAndLastView(ctx context.Context, guestID, message string, attend *bool) error {
@@ -262,8 +724,6 @@ func (r *GuestManager) UpdateMessageAndLastView(ctx context.Context, guestID, me
 		SET(setList[0], setList[1:]...).
 		WHERE(table.Guests.ID.EQ(sqlite.String(guestID)))
 
-	fmt.Println("SQL Update Query:", stmt.DebugSql())
-
 	// Execute the statement
 	result, err := stmt.ExecContext(ctx, r.db.db)
 	if err != nil {
@@ -283,11 +743,88 @@ func (r *GuestManager) UpdateMessageAndLastView(ctx context.Context, guestID, me
 	return nil
 }
 
+// GuestRSVP is the answer of a guest to the invitation
+type GuestRSVP struct {
+	Attend       bool
+	AttendPerson int
+	Message      string
+	Answers      map[string]string
+	// MessageStatus is the moderation state of a new message, empty when the
+	// message did not change
+	MessageStatus domain.MessageStatus
+	// Members is the household with the answer of each member, nil when the
+	// guest is not a household
+	Members []domain.GuestMember
+}
+
+// SaveRSVP stores the RSVP of a guest and marks the invitation as viewed.
+func (r *GuestManager) SaveRSVP(ctx context.Context, guest domain.Guest, rsvp GuestRSVP) error {
+	answersJSON, err := marshalAnswers(rsvp.Answers)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	now := time.Now()
+	setList := []interface{}{
+		table.Guests.AttendPerson.SET(sqlite.Int(int64(rsvp.AttendPerson))),
+		table.Guests.Message.SET(sqlite.String(rsvp.Message)),
+		table.Guests.RsvpAnswers.SET(sqlite.String(answersJSON)),
+		table.Guests.ViewAt.SET(sqlite.DATETIME(now)),
+	}
+	if rsvp.MessageStatus != "" {
+		setList = append(setList,
+			table.Guests.MessageStatus.SET(sqlite.String(string(rsvp.MessageStatus))),
+			table.Guests.MessageAt.SET(sqlite.DATETIME(now)),
+		)
+	}
+
+	stmt := table.Guests.UPDATE().
+		SET(table.Guests.Attend.SET(sqlite.Bool(rsvp.Attend)), setList...).WHERE(
+		table.Guests.ID.EQ(sqlite.String(guest.ID)),
+	)
+
+	tx, err := r.db.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+	defer tx.Rollback()
+
+	result, err := stmt.ExecContext(ctx, tx)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	if rowsAffected == 0 {
+		return errtrace.Wrap(ErrGuestNotFound)
+	}
+
+	if rsvp.Members != nil {
+		guest.Members = rsvp.Members
+		if err := replaceMembers(ctx, tx, guest); err != nil {
+			return err
+		}
+	}
+
+	return errtrace.Wrap(tx.Commit())
+}
+
+// Delete removes a guest with its household members
 func (r *GuestManager) Delete(ctx context.Context, guestID string) error {
+	tx, err := r.db.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+	defer tx.Rollback()
+
 	stmt := table.Guests.DELETE().
 		WHERE(table.Guests.ID.EQ(sqlite.String(guestID)))
 
-	result, err := stmt.ExecContext(ctx, r.db.db)
+	result, err := stmt.ExecContext(ctx, tx)
 	if err != nil {
 		return errtrace.Wrap(err)
 	}
@@ -298,8 +835,15 @@ func (r *GuestManager) Delete(ctx context.Context, guestID string) error {
 	}
 
 	if rowsAffected == 0 {
-		return errtrace.Wrap(errors.New("no guest found with the given ID"))
+		return errtrace.Wrap(ErrGuestNotFound)
 	}
 
-	return nil
+	members := table.GuestMembers.DELETE().
+		WHERE(table.GuestMembers.GuestID.EQ(sqlite.String(guestID)))
+
+	if _, err := members.ExecContext(ctx, tx); err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	return errtrace.Wrap(tx.Commit())
 }
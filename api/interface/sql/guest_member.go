@@ -0,0 +1,134 @@
+package sql
+
+import (
+	"context"
+	"errors"
+
+	"basic-service/domain"
+	"basic-service/gen/db/model"
+	"basic-service/gen/db/table"
+
+	"braces.dev/errtrace"
+	"github.com/go-jet/jet/v2/qrm"
+	"github.com/go-jet/jet/v2/sqlite"
+)
+
+// withMembers loads the household members of guests
+func (r *GuestManager) withMembers(ctx context.Context, guests []domain.Guest) ([]domain.Guest, error) {
+	if len(guests) == 0 {
+		return guests, nil
+	}
+
+	ids := make([]sqlite.Expression, 0, len(guests))
+	for _, v := range guests {
+		ids = append(ids, sqlite.String(v.ID))
+	}
+
+	stmt := table.GuestMembers.SELECT(
+		table.GuestMembers.AllColumns,
+	).WHERE(
+		table.GuestMembers.GuestID.IN(ids...),
+	).ORDER_BY(
+		table.GuestMembers.Position.ASC(),
+		table.GuestMembers.CreatedAt.ASC(),
+	)
+
+	var rows []model.GuestMembers
+	if err := stmt.QueryContext(ctx, r.db.db, &rows); err != nil && !errors.Is(err, qrm.ErrNoRows) {
+		return nil, errtrace.Wrap(err)
+	}
+
+	members := map[string][]domain.GuestMember{}
+	for _, v := range rows {
+		members[v.GuestID] = append(members[v.GuestID], domain.GuestMember{
+			ID:        v.ID,
+			GuestID:   v.GuestID,
+			Name:      v.Name,
+			PlusOne:   v.PlusOne,
+			Attend:    v.Attend,
+			Position:  int(v.Position),
+			CreatedAt: v.CreatedAt,
+		})
+	}
+
+	for i := range guests {
+		guests[i].Members = members[guests[i].ID]
+	}
+
+	return guests, nil
+}
+
+// replaceMembers writes guest.Members as the whole household of the guest
+func replaceMembers(ctx context.Context, db qrm.Executable, guest domain.Guest) error {
+	remove := table.GuestMembers.DELETE().
+		WHERE(table.GuestMembers.GuestID.EQ(sqlite.String(guest.ID)))
+
+	if _, err := remove.ExecContext(ctx, db); err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	if len(guest.Members) == 0 {
+		return nil
+	}
+
+	stmt := table.GuestMembers.INSERT(
+		table.GuestMembers.AllColumns,
+	)
+	for _, v := range guest.Members {
+		stmt = stmt.VALUES(
+			v.ID,
+			guest.ID,
+			guest.UserTemplateID,
+			v.Name,
+			v.PlusOne,
+			v.Attend,
+			v.Position,
+			v.CreatedAt,
+		)
+	}
+
+	_, err := stmt.ExecContext(ctx, db)
+	return errtrace.Wrap(err)
+}
+
+// SetMembers replaces the household of a guest and stores the headcount
+// derived from it, a nil Attend or AttendPerson is stored as not answered.
+func (r *GuestManager) SetMembers(ctx context.Context, guest domain.Guest) error {
+	tx, err := r.db.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+	defer tx.Rollback()
+
+	stmt := table.Guests.UPDATE(
+		table.Guests.Person,
+		table.Guests.Attend,
+		table.Guests.AttendPerson,
+	).SET(
+		guest.Person,
+		guest.Attend,
+		guest.AttendPerson,
+	).WHERE(
+		table.Guests.ID.EQ(sqlite.String(guest.ID)),
+	)
+
+	result, err := stmt.ExecContext(ctx, tx)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	if rowsAffected == 0 {
+		return errtrace.Wrap(ErrGuestNotFound)
+	}
+
+	if err := replaceMembers(ctx, tx, guest); err != nil {
+		return err
+	}
+
+	return errtrace.Wrap(tx.Commit())
+}
@@ -0,0 +1,119 @@
+package sql
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"basic-service/domain"
+	"basic-service/gen/db/model"
+	"basic-service/gen/db/table"
+
+	"braces.dev/errtrace"
+	"github.com/go-jet/jet/v2/qrm"
+	"github.com/go-jet/jet/v2/sqlite"
+)
+
+type LoginAttemptRepository struct {
+	db *SQLite
+}
+
+func NewLoginAttemptRepository(db *SQLite) *LoginAttemptRepository {
+	return &LoginAttemptRepository{db: db}
+}
+
+// Get returns the attempt counter of key, a zero counter is returned when the
+// key never failed.
+func (r *LoginAttemptRepository) Get(ctx context.Context, key string) (domain.LoginAttempt, error) {
+	stmt := table.LoginAttempts.SELECT(
+		table.LoginAttempts.AllColumns,
+	).WHERE(
+		table.LoginAttempts.Key.EQ(sqlite.String(key)),
+	).LIMIT(1)
+
+	var dbAttempt model.LoginAttempts
+	if err := stmt.QueryContext(ctx, r.db.db, &dbAttempt); err != nil {
+		if errors.Is(err, qrm.ErrNoRows) {
+			return domain.LoginAttempt{Key: key}, nil
+		}
+		return domain.LoginAttempt{}, errtrace.Wrap(err)
+	}
+
+	return domain.LoginAttempt{
+		Key:           dbAttempt.Key,
+		Failures:      int(dbAttempt.Failures),
+		LastFailureAt: dbAttempt.LastFailureAt,
+		LockedUntil:   dbAttempt.LockedUntil,
+	}, nil
+}
+
+// Fail counts a failure of key in one statement so concurrent failures are
+// all counted, failures before windowStart are forgotten. It returns the
+// counter after the failure.
+func (r *LoginAttemptRepository) Fail(ctx context.Context, key string, now, windowStart time.Time) (domain.LoginAttempt, error) {
+	failures := sqlite.IntExp(sqlite.CASE().
+		WHEN(table.LoginAttempts.LastFailureAt.LT(sqlite.DATETIME(windowStart))).
+		THEN(sqlite.Int(1)).
+		ELSE(table.LoginAttempts.Failures.ADD(sqlite.Int(1))))
+
+	stmt := table.LoginAttempts.INSERT(
+		table.LoginAttempts.Key,
+		table.LoginAttempts.Failures,
+		table.LoginAttempts.LastFailureAt,
+	).VALUES(
+		sqlite.String(key),
+		sqlite.Int(1),
+		sqlite.DATETIME(now),
+	).ON_CONFLICT(table.LoginAttempts.Key).DO_UPDATE(
+		sqlite.SET(
+			table.LoginAttempts.Failures.SET(failures),
+			table.LoginAttempts.LastFailureAt.SET(table.LoginAttempts.EXCLUDED.LastFailureAt),
+		),
+	).RETURNING(
+		table.LoginAttempts.AllColumns,
+	)
+
+	var dbAttempt model.LoginAttempts
+	if err := stmt.QueryContext(ctx, r.db.db, &dbAttempt); err != nil {
+		return domain.LoginAttempt{}, errtrace.Wrap(err)
+	}
+
+	return domain.LoginAttempt{
+		Key:           dbAttempt.Key,
+		Failures:      int(dbAttempt.Failures),
+		LastFailureAt: dbAttempt.LastFailureAt,
+		LockedUntil:   dbAttempt.LockedUntil,
+	}, nil
+}
+
+// Lock delays key until until, a later lock already set is kept. reset
+// starts the failures over, it is used for lockouts.
+func (r *LoginAttemptRepository) Lock(ctx context.Context, key string, until time.Time, reset bool) error {
+	columns := sqlite.ColumnList{table.LoginAttempts.LockedUntil}
+	values := []any{sqlite.DATETIME(until)}
+	if reset {
+		columns = append(columns, table.LoginAttempts.Failures)
+		values = append(values, sqlite.Int(0))
+	}
+
+	stmt := table.LoginAttempts.UPDATE(columns).SET(
+		values[0], values[1:]...,
+	).WHERE(
+		table.LoginAttempts.Key.EQ(sqlite.String(key)).AND(
+			table.LoginAttempts.LockedUntil.IS_NULL().
+				OR(table.LoginAttempts.LockedUntil.LT_EQ(sqlite.DATETIME(until))),
+		),
+	)
+
+	_, err := stmt.ExecContext(ctx, r.db.db)
+	return errtrace.Wrap(err)
+}
+
+// Reset forgets the failures of key, it is used after a successful login and
+// when an admin unlocks an account.
+func (r *LoginAttemptRepository) Reset(ctx context.Context, key string) error {
+	_, err := table.LoginAttempts.DELETE().
+		WHERE(table.LoginAttempts.Key.EQ(sqlite.String(key))).
+		ExecContext(ctx, r.db.db)
+	return errtrace.Wrap(err)
+}
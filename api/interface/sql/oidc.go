@@ -0,0 +1,148 @@
+package sql
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"basic-service/domain"
+	"basic-service/gen/db/model"
+	"basic-service/gen/db/table"
+
+	"braces.dev/errtrace"
+	"github.com/go-jet/jet/v2/qrm"
+	"github.com/go-jet/jet/v2/sqlite"
+)
+
+var (
+	ErrOIDCStateInvalid = errors.New("login state is invalid or expired")
+	ErrIdentityNotFound = errors.New("identity not found")
+)
+
+type OIDCRepository struct {
+	db *SQLite
+}
+
+func NewOIDCRepository(db *SQLite) *OIDCRepository {
+	return &OIDCRepository{db: db}
+}
+
+func (r *OIDCRepository) CreateState(ctx context.Context, state domain.OIDCState) error {
+	stmt := table.OidcStates.INSERT(
+		table.OidcStates.State,
+		table.OidcStates.Provider,
+		table.OidcStates.CodeVerifier,
+		table.OidcStates.Nonce,
+		table.OidcStates.ExpiresAt,
+		table.OidcStates.CreatedAt,
+	).VALUES(
+		sqlite.String(state.State),
+		sqlite.String(state.Provider),
+		sqlite.String(state.CodeVerifier),
+		sqlite.String(state.Nonce),
+		sqlite.DATETIME(state.ExpiresAt),
+		sqlite.DATETIME(state.CreatedAt),
+	)
+
+	_, err := stmt.ExecContext(ctx, r.db.db)
+	return errtrace.Wrap(err)
+}
+
+// ConsumeState deletes the state and returns it, a state can only be used by
+// one callback.
+func (r *OIDCRepository) ConsumeState(ctx context.Context, provider, state string) (*domain.OIDCState, error) {
+	stmt := table.OidcStates.SELECT(
+		table.OidcStates.AllColumns,
+	).WHERE(
+		table.OidcStates.State.EQ(sqlite.String(state)).
+			AND(table.OidcStates.Provider.EQ(sqlite.String(provider))),
+	).LIMIT(1)
+
+	var dbState model.OidcStates
+	if err := stmt.QueryContext(ctx, r.db.db, &dbState); err != nil {
+		if errors.Is(err, qrm.ErrNoRows) {
+			return nil, errtrace.Wrap(ErrOIDCStateInvalid)
+		}
+		return nil, errtrace.Wrap(err)
+	}
+
+	result, err := table.OidcStates.DELETE().
+		WHERE(table.OidcStates.State.EQ(sqlite.String(state))).
+		ExecContext(ctx, r.db.db)
+	if err != nil {
+		return nil, errtrace.Wrap(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, errtrace.Wrap(err)
+	}
+
+	if rowsAffected == 0 || time.Now().After(dbState.ExpiresAt) {
+		return nil, errtrace.Wrap(ErrOIDCStateInvalid)
+	}
+
+	return &domain.OIDCState{
+		State:        dbState.State,
+		Provider:     dbState.Provider,
+		CodeVerifier: dbState.CodeVerifier,
+		Nonce:        dbState.Nonce,
+		ExpiresAt:    dbState.ExpiresAt,
+		CreatedAt:    dbState.CreatedAt,
+	}, nil
+}
+
+// DeleteExpiredStates removes logins that were started but never finished.
+func (r *OIDCRepository) DeleteExpiredStates(ctx context.Context) error {
+	_, err := table.OidcStates.DELETE().
+		WHERE(table.OidcStates.ExpiresAt.LT(sqlite.DATETIME(time.Now()))).
+		ExecContext(ctx, r.db.db)
+	return errtrace.Wrap(err)
+}
+
+func (r *OIDCRepository) GetIdentity(ctx context.Context, provider, subject string) (*domain.UserIdentity, error) {
+	stmt := table.UserIdentities.SELECT(
+		table.UserIdentities.AllColumns,
+	).WHERE(
+		table.UserIdentities.Provider.EQ(sqlite.String(provider)).
+			AND(table.UserIdentities.Subject.EQ(sqlite.String(subject))),
+	).LIMIT(1)
+
+	var dbIdentity model.UserIdentities
+	if err := stmt.QueryContext(ctx, r.db.db, &dbIdentity); err != nil {
+		if errors.Is(err, qrm.ErrNoRows) {
+			return nil, errtrace.Wrap(ErrIdentityNotFound)
+		}
+		return nil, errtrace.Wrap(err)
+	}
+
+	return &domain.UserIdentity{
+		ID:        dbIdentity.ID,
+		UserID:    dbIdentity.UserID,
+		Provider:  dbIdentity.Provider,
+		Subject:   dbIdentity.Subject,
+		Email:     dbIdentity.Email,
+		CreatedAt: dbIdentity.CreatedAt,
+	}, nil
+}
+
+func (r *OIDCRepository) CreateIdentity(ctx context.Context, identity domain.UserIdentity) error {
+	stmt := table.UserIdentities.INSERT(
+		table.UserIdentities.ID,
+		table.UserIdentities.UserID,
+		table.UserIdentities.Provider,
+		table.UserIdentities.Subject,
+		table.UserIdentities.Email,
+		table.UserIdentities.CreatedAt,
+	).VALUES(
+		sqlite.String(identity.ID),
+		sqlite.String(identity.UserID),
+		sqlite.String(identity.Provider),
+		sqlite.String(identity.Subject),
+		sqlite.String(identity.Email),
+		sqlite.DATETIME(identity.CreatedAt),
+	)
+
+	_, err := stmt.ExecContext(ctx, r.db.db)
+	return errtrace.Wrap(err)
+}
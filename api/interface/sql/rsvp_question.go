@@ -0,0 +1,207 @@
+package sql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+
+	"basic-service/domain"
+	"basic-service/gen/db/model"
+	"basic-service/gen/db/table"
+
+	"braces.dev/errtrace"
+	"github.com/go-jet/jet/v2/qrm"
+	"github.com/go-jet/jet/v2/sqlite"
+)
+
+var ErrRSVPQuestionNotFound = errors.New("rsvp question not found")
+
+type RSVPQuestionRepository struct {
+	db *SQLite
+}
+
+func NewRSVPQuestionRepository(db *SQLite) *RSVPQuestionRepository {
+	return &RSVPQuestionRepository{db: db}
+}
+
+func toRSVPQuestion(v model.RsvpQuestions) (domain.RSVPQuestion, error) {
+	options := []string{}
+	if err := json.Unmarshal([]byte(v.Options), &options); err != nil {
+		return domain.RSVPQuestion{}, errtrace.Wrap(err)
+	}
+
+	return domain.RSVPQuestion{
+		ID:             v.ID,
+		UserTemplateID: v.UserTemplateID,
+		Label:          v.Label,
+		Type:           domain.RSVPQuestionType(v.Type),
+		Options:        options,
+		Required:       v.Required,
+		Position:       int(v.Position),
+		CreatedAt:      v.CreatedAt,
+	}, nil
+}
+
+// List returns the questions of a template in the order they are asked.
+func (r *RSVPQuestionRepository) List(ctx context.Context, templateID string) ([]domain.RSVPQuestion, error) {
+	stmt := table.RsvpQuestions.SELECT(
+		table.RsvpQuestions.AllColumns,
+	).WHERE(
+		table.RsvpQuestions.UserTemplateID.EQ(sqlite.String(templateID)),
+	).ORDER_BY(
+		table.RsvpQuestions.Position.ASC(),
+		table.RsvpQuestions.CreatedAt.ASC(),
+	)
+
+	var rows []model.RsvpQuestions
+	if err := stmt.QueryContext(ctx, r.db.db, &rows); err != nil && !errors.Is(err, qrm.ErrNoRows) {
+		return nil, errtrace.Wrap(err)
+	}
+
+	questions := make([]domain.RSVPQuestion, 0, len(rows))
+	for _, v := range rows {
+		question, err := toRSVPQuestion(v)
+		if err != nil {
+			return nil, err
+		}
+		questions = append(questions, question)
+	}
+
+	return questions, nil
+}
+
+func (r *RSVPQuestionRepository) Get(ctx context.Context, templateID, id string) (*domain.RSVPQuestion, error) {
+	stmt := table.RsvpQuestions.SELECT(
+		table.RsvpQuestions.AllColumns,
+	).WHERE(
+		table.RsvpQuestions.ID.EQ(sqlite.String(id)).
+			AND(table.RsvpQuestions.UserTemplateID.EQ(sqlite.String(templateID))),
+	).LIMIT(1)
+
+	var row model.RsvpQuestions
+	if err := stmt.QueryContext(ctx, r.db.db, &row); err != nil {
+		if errors.Is(err, qrm.ErrNoRows) {
+			return nil, errtrace.Wrap(ErrRSVPQuestionNotFound)
+		}
+		return nil, errtrace.Wrap(err)
+	}
+
+	question, err := toRSVPQuestion(row)
+	if err != nil {
+		return nil, err
+	}
+
+	return &question, nil
+}
+
+func (r *RSVPQuestionRepository) Create(ctx context.Context, question domain.RSVPQuestion) error {
+	options, err := json.Marshal(question.Options)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	stmt := table.RsvpQuestions.INSERT(
+		table.RsvpQuestions.ID,
+		table.RsvpQuestions.UserTemplateID,
+		table.RsvpQuestions.Label,
+		table.RsvpQuestions.Type,
+		table.RsvpQuestions.Options,
+		table.RsvpQuestions.Required,
+		table.RsvpQuestions.Position,
+		table.RsvpQuestions.CreatedAt,
+	).VALUES(
+		sqlite.String(question.ID),
+		sqlite.String(question.UserTemplateID),
+		sqlite.String(question.Label),
+		sqlite.String(string(question.Type)),
+		sqlite.String(string(options)),
+		sqlite.Bool(question.Required),
+		sqlite.Int(int64(question.Position)),
+		sqlite.DATETIME(question.CreatedAt),
+	)
+
+	_, err = stmt.ExecContext(ctx, r.db.db)
+	return errtrace.Wrap(err)
+}
+
+// Update replaces the question, its id and template never change.
+func (r *RSVPQuestionRepository) Update(ctx context.Context, question domain.RSVPQuestion) error {
+	options, err := json.Marshal(question.Options)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	stmt := table.RsvpQuestions.UPDATE().
+		SET(
+			table.RsvpQuestions.Label.SET(sqlite.String(question.Label)),
+			table.RsvpQuestions.Type.SET(sqlite.String(string(question.Type))),
+			table.RsvpQuestions.Options.SET(sqlite.String(string(options))),
+			table.RsvpQuestions.Required.SET(sqlite.Bool(question.Required)),
+			table.RsvpQuestions.Position.SET(sqlite.Int(int64(question.Position))),
+		).WHERE(
+		table.RsvpQuestions.ID.EQ(sqlite.String(question.ID)).
+			AND(table.RsvpQuestions.UserTemplateID.EQ(sqlite.String(question.UserTemplateID))),
+	)
+
+	result, err := stmt.ExecContext(ctx, r.db.db)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	if rowsAffected == 0 {
+		return errtrace.Wrap(ErrRSVPQuestionNotFound)
+	}
+
+	return nil
+}
+
+// Delete removes a question with the answers the guests gave to it.
+func (r *RSVPQuestionRepository) Delete(ctx context.Context, templateID, id string) error {
+	tx, err := r.db.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+	defer tx.Rollback()
+
+	stmt := table.RsvpQuestions.DELETE().
+		WHERE(
+			table.RsvpQuestions.ID.EQ(sqlite.String(id)).
+				AND(table.RsvpQuestions.UserTemplateID.EQ(sqlite.String(templateID))),
+		)
+
+	result, err := stmt.ExecContext(ctx, tx)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	if rowsAffected == 0 {
+		return errtrace.Wrap(ErrRSVPQuestionNotFound)
+	}
+
+	answers := table.Guests.UPDATE().
+		SET(
+			table.Guests.RsvpAnswers.SET(sqlite.StringExp(sqlite.Raw(
+				"json_remove(guests.rsvp_answers, #path)",
+				sqlite.RawArgs{"#path": "$." + strconv.Quote(id)},
+			))),
+		).WHERE(
+		table.Guests.UserTemplateID.EQ(sqlite.String(templateID)),
+	)
+
+	if _, err := answers.ExecContext(ctx, tx); err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	return errtrace.Wrap(tx.Commit())
+}
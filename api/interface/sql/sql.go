@@ -2,8 +2,10 @@ package sql
 
 import (
 	"database/sql"
+	"strings"
 
 	"braces.dev/errtrace"
+	"github.com/go-jet/jet/v2/sqlite"
 	_ "github.com/mattn/go-sqlite3"
 )
 
@@ -26,3 +28,13 @@ func getDB(sqlite *SQLite) *sql.DB {
 	}
 	return sqlite.db
 }
+
+// likeEscaper escapes the wildcards of LIKE, patterns use \ as escape
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// contains matches column against query as plain text anywhere in the
+// value, % and _ in query are not wildcards.
+func contains(column sqlite.ColumnString, query string) sqlite.BoolExpression {
+	pattern := sqlite.String("%" + likeEscaper.Replace(query) + "%")
+	return sqlite.BoolExp(sqlite.CustomExpression(column, sqlite.Token("LIKE"), pattern, sqlite.Token(`ESCAPE '\'`)))
+}
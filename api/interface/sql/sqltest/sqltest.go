@@ -0,0 +1,121 @@
+// Package sqltest opens throwaway SQLite databases for tests.
+package sqltest
+
+import (
+	stdsql "database/sql"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"testing"
+
+	"basic-service/interface/sql"
+)
+
+// baseSchema is the schema the files in ./migrations are applied on top of
+const baseSchema = `
+CREATE TABLE users (
+    id         TEXT PRIMARY KEY,
+    email      TEXT NOT NULL UNIQUE,
+    password   TEXT NOT NULL,
+    name       TEXT NOT NULL DEFAULT '',
+    profile    TEXT NOT NULL DEFAULT '',
+    role       INTEGER NOT NULL DEFAULT 2,
+    is_active  BOOLEAN NOT NULL DEFAULT 1,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE public_templates (
+    id             TEXT PRIMARY KEY,
+    name           TEXT NOT NULL DEFAULT '',
+    description    TEXT NOT NULL DEFAULT '',
+    price_interval TEXT NOT NULL DEFAULT '',
+    price          INTEGER NOT NULL DEFAULT 0,
+    type           TEXT NOT NULL DEFAULT '',
+    tags           TEXT NOT NULL DEFAULT '[]',
+    cover_image    TEXT NOT NULL DEFAULT '',
+    state          INTEGER NOT NULL DEFAULT 0,
+    created_at     DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at     DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE user_templates (
+    id               TEXT PRIMARY KEY,
+    user_id          TEXT NOT NULL,
+    base_template_id TEXT NOT NULL DEFAULT '',
+    state            INTEGER NOT NULL DEFAULT 0,
+    slug             TEXT NOT NULL DEFAULT '',
+    url              TEXT NOT NULL DEFAULT '',
+    message_template TEXT NOT NULL DEFAULT '',
+    name             TEXT NOT NULL DEFAULT '',
+    cover_image      TEXT NOT NULL DEFAULT '',
+    created_at       DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at       DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expire_at        DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE guests (
+    id               TEXT PRIMARY KEY,
+    user_template_id TEXT NOT NULL,
+    name             TEXT NOT NULL DEFAULT '',
+    group_name       TEXT NOT NULL DEFAULT '',
+    person           INTEGER NOT NULL DEFAULT 0,
+    tags             TEXT NOT NULL DEFAULT '[]',
+    telp             TEXT NOT NULL DEFAULT '',
+    address          TEXT NOT NULL DEFAULT '',
+    message          TEXT NOT NULL DEFAULT '',
+    view_at          DATETIME,
+    created_at       DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    attend           BOOLEAN
+);
+`
+
+// New returns a database with the base schema and every migration applied,
+// it lives in a directory removed when the test ends.
+func New(t testing.TB) *sql.SQLite {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "test.db")
+	if err := migrate(path); err != nil {
+		t.Fatalf("migrate test database: %v", err)
+	}
+
+	db, err := sql.NewSQLite(path)
+	if err != nil {
+		t.Fatalf("open test database: %v", err)
+	}
+
+	return db
+}
+
+func migrate(path string) error {
+	db, err := stdsql.Open("sqlite3", path)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if _, err := db.Exec(baseSchema); err != nil {
+		return err
+	}
+
+	_, file, _, _ := runtime.Caller(0)
+	files, err := filepath.Glob(filepath.Join(filepath.Dir(file), "..", "..", "..", "migrations", "*.sql"))
+	if err != nil {
+		return err
+	}
+	sort.Strings(files)
+
+	for _, f := range files {
+		query, err := os.ReadFile(f)
+		if err != nil {
+			return err
+		}
+		if _, err := db.Exec(string(query)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,371 @@
+package sql
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"basic-service/domain"
+	"basic-service/gen/db/model"
+	"basic-service/gen/db/table"
+
+	"braces.dev/errtrace"
+	"github.com/go-jet/jet/v2/qrm"
+	"github.com/go-jet/jet/v2/sqlite"
+)
+
+var (
+	ErrTemplateMemberNotFound = errors.New("template member not found")
+	ErrTemplateInviteInvalid  = errors.New("invitation is invalid or has expired")
+	ErrLastTemplateOwner      = errors.New("a template needs at least one owner")
+)
+
+type TemplateMemberRepository struct {
+	db *SQLite
+}
+
+func NewTemplateMemberRepository(db *SQLite) *TemplateMemberRepository {
+	return &TemplateMemberRepository{db: db}
+}
+
+type templateMemberRow struct {
+	model.TemplateMembers
+	Users model.Users
+}
+
+func toTemplateMember(v templateMemberRow) domain.TemplateMember {
+	return domain.TemplateMember{
+		ID:             v.TemplateMembers.ID,
+		UserTemplateID: v.UserTemplateID,
+		UserID:         v.UserID,
+		Role:           domain.TemplateRole(v.Role),
+		Name:           v.Users.Name,
+		Email:          v.Users.Email,
+		CreatedAt:      v.TemplateMembers.CreatedAt,
+	}
+}
+
+func toTemplateInvite(v model.TemplateInvites) domain.TemplateInvite {
+	return domain.TemplateInvite{
+		ID:             v.ID,
+		UserTemplateID: v.UserTemplateID,
+		Email:          v.Email,
+		Role:           domain.TemplateRole(v.Role),
+		TokenHash:      v.TokenHash,
+		InvitedBy:      v.InvitedBy,
+		ExpiresAt:      v.ExpiresAt,
+		AcceptedAt:     v.AcceptedAt,
+		CreatedAt:      v.CreatedAt,
+	}
+}
+
+func insertTemplateMember(member domain.TemplateMember) sqlite.InsertStatement {
+	return table.TemplateMembers.INSERT(
+		table.TemplateMembers.ID,
+		table.TemplateMembers.UserTemplateID,
+		table.TemplateMembers.UserID,
+		table.TemplateMembers.Role,
+		table.TemplateMembers.CreatedAt,
+	).VALUES(
+		sqlite.String(member.ID),
+		sqlite.String(member.UserTemplateID),
+		sqlite.String(member.UserID),
+		sqlite.String(string(member.Role)),
+		sqlite.DATETIME(member.CreatedAt),
+	)
+}
+
+// GetRole returns the role of the user on the template.
+func (r *TemplateMemberRepository) GetRole(ctx context.Context, templateID, userID string) (domain.TemplateRole, error) {
+	member, err := r.Get(ctx, templateID, userID)
+	if err != nil {
+		return "", errtrace.Wrap(err)
+	}
+
+	return member.Role, nil
+}
+
+func (r *TemplateMemberRepository) Get(ctx context.Context, templateID, userID string) (*domain.TemplateMember, error) {
+	stmt := sqlite.SELECT(
+		table.TemplateMembers.AllColumns,
+		table.Users.Name,
+		table.Users.Email,
+	).FROM(
+		table.TemplateMembers.INNER_JOIN(table.Users,
+			table.Users.ID.EQ(table.TemplateMembers.UserID)),
+	).WHERE(
+		table.TemplateMembers.UserTemplateID.EQ(sqlite.String(templateID)).
+			AND(table.TemplateMembers.UserID.EQ(sqlite.String(userID))),
+	).LIMIT(1)
+
+	var row templateMemberRow
+	if err := stmt.QueryContext(ctx, r.db.db, &row); err != nil {
+		if errors.Is(err, qrm.ErrNoRows) {
+			return nil, errtrace.Wrap(ErrTemplateMemberNotFound)
+		}
+		return nil, errtrace.Wrap(err)
+	}
+
+	member := toTemplateMember(row)
+	return &member, nil
+}
+
+// List returns the members of the template, owners first.
+func (r *TemplateMemberRepository) List(ctx context.Context, templateID string) ([]domain.TemplateMember, error) {
+	stmt := sqlite.SELECT(
+		table.TemplateMembers.AllColumns,
+		table.Users.Name,
+		table.Users.Email,
+	).FROM(
+		table.TemplateMembers.INNER_JOIN(table.Users,
+			table.Users.ID.EQ(table.TemplateMembers.UserID)),
+	).WHERE(
+		table.TemplateMembers.UserTemplateID.EQ(sqlite.String(templateID)),
+	).ORDER_BY(
+		sqlite.CASE(table.TemplateMembers.Role).
+			WHEN(sqlite.String(string(domain.TemplateRoleOwner))).THEN(sqlite.Int(0)).
+			WHEN(sqlite.String(string(domain.TemplateRoleEditor))).THEN(sqlite.Int(1)).
+			ELSE(sqlite.Int(2)),
+		table.TemplateMembers.CreatedAt.ASC(),
+	)
+
+	var rows []templateMemberRow
+	if err := stmt.QueryContext(ctx, r.db.db, &rows); err != nil && !errors.Is(err, qrm.ErrNoRows) {
+		return nil, errtrace.Wrap(err)
+	}
+
+	members := make([]domain.TemplateMember, 0, len(rows))
+	for _, v := range rows {
+		members = append(members, toTemplateMember(v))
+	}
+
+	return members, nil
+}
+
+// keepsOwner holds for the members of the template that can lose their
+// ownership: those who are not owners and any owner while there are others.
+// It is part of the statement changing the member so two owners stepping
+// down at once can't both pass it.
+func keepsOwner(templateID string) sqlite.BoolExpression {
+	owners := sqlite.SELECT(
+		sqlite.COUNT(table.TemplateMembers.ID),
+	).FROM(
+		table.TemplateMembers,
+	).WHERE(
+		table.TemplateMembers.UserTemplateID.EQ(sqlite.String(templateID)).
+			AND(table.TemplateMembers.Role.EQ(sqlite.String(string(domain.TemplateRoleOwner)))),
+	)
+
+	return table.TemplateMembers.Role.NOT_EQ(sqlite.String(string(domain.TemplateRoleOwner))).
+		OR(sqlite.IntExp(owners).GT(sqlite.Int(1)))
+}
+
+// UpdateRole changes the role of a member, it returns ErrLastTemplateOwner
+// instead of demoting the last owner.
+func (r *TemplateMemberRepository) UpdateRole(ctx context.Context, templateID, userID string, role domain.TemplateRole) error {
+	cond := table.TemplateMembers.UserTemplateID.EQ(sqlite.String(templateID)).
+		AND(table.TemplateMembers.UserID.EQ(sqlite.String(userID)))
+	if role != domain.TemplateRoleOwner {
+		cond = cond.AND(keepsOwner(templateID))
+	}
+
+	stmt := table.TemplateMembers.UPDATE().
+		SET(
+			table.TemplateMembers.Role.SET(sqlite.String(string(role))),
+		).WHERE(cond)
+
+	result, err := stmt.ExecContext(ctx, r.db.db)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	if rowsAffected == 0 {
+		return r.unchanged(ctx, templateID, userID)
+	}
+
+	return nil
+}
+
+// Remove takes a member off the template, it returns ErrLastTemplateOwner
+// instead of removing the last owner.
+func (r *TemplateMemberRepository) Remove(ctx context.Context, templateID, userID string) error {
+	stmt := table.TemplateMembers.DELETE().
+		WHERE(
+			table.TemplateMembers.UserTemplateID.EQ(sqlite.String(templateID)).
+				AND(table.TemplateMembers.UserID.EQ(sqlite.String(userID))).
+				AND(keepsOwner(templateID)),
+		)
+
+	result, err := stmt.ExecContext(ctx, r.db.db)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	if rowsAffected == 0 {
+		return r.unchanged(ctx, templateID, userID)
+	}
+
+	return nil
+}
+
+// unchanged tells why a member was not changed: either it is not a member or
+// it is the last owner
+func (r *TemplateMemberRepository) unchanged(ctx context.Context, templateID, userID string) error {
+	if _, err := r.Get(ctx, templateID, userID); err != nil {
+		return err
+	}
+	return errtrace.Wrap(ErrLastTemplateOwner)
+}
+
+func (r *TemplateMemberRepository) CreateInvite(ctx context.Context, invite domain.TemplateInvite) error {
+	stmt := table.TemplateInvites.INSERT(
+		table.TemplateInvites.ID,
+		table.TemplateInvites.UserTemplateID,
+		table.TemplateInvites.Email,
+		table.TemplateInvites.Role,
+		table.TemplateInvites.TokenHash,
+		table.TemplateInvites.InvitedBy,
+		table.TemplateInvites.ExpiresAt,
+		table.TemplateInvites.CreatedAt,
+	).VALUES(
+		sqlite.String(invite.ID),
+		sqlite.String(invite.UserTemplateID),
+		sqlite.String(invite.Email),
+		sqlite.String(string(invite.Role)),
+		sqlite.String(invite.TokenHash),
+		sqlite.String(invite.InvitedBy),
+		sqlite.DATETIME(invite.ExpiresAt),
+		sqlite.DATETIME(invite.CreatedAt),
+	)
+
+	_, err := stmt.ExecContext(ctx, r.db.db)
+	return errtrace.Wrap(err)
+}
+
+func pendingInvites() sqlite.BoolExpression {
+	return table.TemplateInvites.AcceptedAt.IS_NULL().
+		AND(table.TemplateInvites.ExpiresAt.GT(sqlite.DATETIME(time.Now())))
+}
+
+// GetInviteByHash returns a pending invitation.
+func (r *TemplateMemberRepository) GetInviteByHash(ctx context.Context, hash string) (*domain.TemplateInvite, error) {
+	stmt := table.TemplateInvites.SELECT(
+		table.TemplateInvites.AllColumns,
+	).WHERE(
+		table.TemplateInvites.TokenHash.EQ(sqlite.String(hash)).
+			AND(pendingInvites()),
+	).LIMIT(1)
+
+	var dbInvite model.TemplateInvites
+	if err := stmt.QueryContext(ctx, r.db.db, &dbInvite); err != nil {
+		if errors.Is(err, qrm.ErrNoRows) {
+			return nil, errtrace.Wrap(ErrTemplateInviteInvalid)
+		}
+		return nil, errtrace.Wrap(err)
+	}
+
+	invite := toTemplateInvite(dbInvite)
+	return &invite, nil
+}
+
+// ListInvites returns the pending invitations of the template.
+func (r *TemplateMemberRepository) ListInvites(ctx context.Context, templateID string) ([]domain.TemplateInvite, error) {
+	stmt := table.TemplateInvites.SELECT(
+		table.TemplateInvites.AllColumns,
+	).WHERE(
+		table.TemplateInvites.UserTemplateID.EQ(sqlite.String(templateID)).
+			AND(pendingInvites()),
+	).ORDER_BY(
+		table.TemplateInvites.CreatedAt.DESC(),
+	)
+
+	var dbInvites []model.TemplateInvites
+	if err := stmt.QueryContext(ctx, r.db.db, &dbInvites); err != nil && !errors.Is(err, qrm.ErrNoRows) {
+		return nil, errtrace.Wrap(err)
+	}
+
+	invites := make([]domain.TemplateInvite, 0, len(dbInvites))
+	for _, v := range dbInvites {
+		invites = append(invites, toTemplateInvite(v))
+	}
+
+	return invites, nil
+}
+
+// DeleteInvite withdraws a pending invitation of the template.
+func (r *TemplateMemberRepository) DeleteInvite(ctx context.Context, templateID, id string) error {
+	stmt := table.TemplateInvites.DELETE().
+		WHERE(
+			table.TemplateInvites.ID.EQ(sqlite.String(id)).
+				AND(table.TemplateInvites.UserTemplateID.EQ(sqlite.String(templateID))).
+				AND(table.TemplateInvites.AcceptedAt.IS_NULL()),
+		)
+
+	result, err := stmt.ExecContext(ctx, r.db.db)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	if rowsAffected == 0 {
+		return errtrace.Wrap(ErrTemplateInviteInvalid)
+	}
+
+	return nil
+}
+
+// AcceptInvite marks the invitation used and adds the member. A user that is
+// already a member keeps the current role.
+func (r *TemplateMemberRepository) AcceptInvite(ctx context.Context, inviteID string, member domain.TemplateMember) error {
+	tx, err := r.db.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+	defer tx.Rollback()
+
+	accept := table.TemplateInvites.UPDATE().
+		SET(
+			table.TemplateInvites.AcceptedAt.SET(sqlite.DATETIME(time.Now())),
+		).WHERE(
+		table.TemplateInvites.ID.EQ(sqlite.String(inviteID)).
+			AND(pendingInvites()),
+	)
+
+	result, err := accept.ExecContext(ctx, tx)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	if rowsAffected == 0 {
+		return errtrace.Wrap(ErrTemplateInviteInvalid)
+	}
+
+	insert := insertTemplateMember(member).
+		ON_CONFLICT(table.TemplateMembers.UserTemplateID, table.TemplateMembers.UserID).
+		DO_NOTHING()
+
+	if _, err := insert.ExecContext(ctx, tx); err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	return errtrace.Wrap(tx.Commit())
+}
@@ -0,0 +1,393 @@
+package sql
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"basic-service/domain"
+	"basic-service/gen/db/model"
+	"basic-service/gen/db/table"
+
+	"braces.dev/errtrace"
+	"github.com/go-jet/jet/v2/qrm"
+	"github.com/go-jet/jet/v2/sqlite"
+)
+
+var (
+	ErrRefreshTokenNotFound = errors.New("refresh token not found")
+	ErrSessionNotFound      = errors.New("session not found")
+)
+
+type TokenRepository struct {
+	db *SQLite
+}
+
+func NewTokenRepository(db *SQLite) *TokenRepository {
+	return &TokenRepository{db: db}
+}
+
+// CreateSession stores a new session together with its first refresh token.
+func (r *TokenRepository) CreateSession(ctx context.Context, session domain.Session, token domain.RefreshToken) error {
+	tx, err := r.db.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+	defer tx.Rollback()
+
+	insertSession := table.Sessions.INSERT(
+		table.Sessions.ID,
+		table.Sessions.UserID,
+		table.Sessions.UserAgent,
+		table.Sessions.IP,
+		table.Sessions.CreatedAt,
+		table.Sessions.LastSeenAt,
+	).VALUES(
+		sqlite.String(session.ID),
+		sqlite.String(session.UserID),
+		sqlite.String(session.UserAgent),
+		sqlite.String(session.IP),
+		sqlite.DATETIME(session.CreatedAt),
+		sqlite.DATETIME(session.LastSeenAt),
+	)
+
+	if _, err := insertSession.ExecContext(ctx, tx); err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	insertToken := table.RefreshTokens.INSERT(
+		table.RefreshTokens.ID,
+		table.RefreshTokens.UserID,
+		table.RefreshTokens.FamilyID,
+		table.RefreshTokens.TokenHash,
+		table.RefreshTokens.ExpiresAt,
+		table.RefreshTokens.CreatedAt,
+	).VALUES(
+		sqlite.String(token.ID),
+		sqlite.String(token.UserID),
+		sqlite.String(token.FamilyID),
+		sqlite.String(token.TokenHash),
+		sqlite.DATETIME(token.ExpiresAt),
+		sqlite.DATETIME(token.CreatedAt),
+	)
+
+	if _, err := insertToken.ExecContext(ctx, tx); err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	return errtrace.Wrap(tx.Commit())
+}
+
+func (r *TokenRepository) GetRefreshTokenByHash(ctx context.Context, hash string) (*domain.RefreshToken, error) {
+	stmt := table.RefreshTokens.SELECT(
+		table.RefreshTokens.AllColumns,
+	).WHERE(
+		table.RefreshTokens.TokenHash.EQ(sqlite.String(hash)),
+	).LIMIT(1)
+
+	var dbToken model.RefreshTokens
+	if err := stmt.QueryContext(ctx, r.db.db, &dbToken); err != nil {
+		if errors.Is(err, qrm.ErrNoRows) {
+			return nil, errtrace.Wrap(ErrRefreshTokenNotFound)
+		}
+		return nil, errtrace.Wrap(err)
+	}
+
+	return &domain.RefreshToken{
+		ID:         dbToken.ID,
+		UserID:     dbToken.UserID,
+		FamilyID:   dbToken.FamilyID,
+		TokenHash:  dbToken.TokenHash,
+		ReplacedBy: dbToken.ReplacedBy,
+		ExpiresAt:  dbToken.ExpiresAt,
+		RevokedAt:  dbToken.RevokedAt,
+		CreatedAt:  dbToken.CreatedAt,
+	}, nil
+}
+
+// RotateRefreshToken revokes the old token and stores its replacement in a
+// single transaction. It fails when the old token was already revoked, which
+// protects against two concurrent refreshes of the same token.
+func (r *TokenRepository) RotateRefreshToken(ctx context.Context, oldID string, next domain.RefreshToken) error {
+	tx, err := r.db.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+	defer tx.Rollback()
+
+	revoke := table.RefreshTokens.UPDATE().
+		SET(
+			table.RefreshTokens.RevokedAt.SET(sqlite.DATETIME(time.Now())),
+			table.RefreshTokens.ReplacedBy.SET(sqlite.String(next.ID)),
+		).WHERE(
+		table.RefreshTokens.ID.EQ(sqlite.String(oldID)).
+			AND(table.RefreshTokens.RevokedAt.IS_NULL()),
+	)
+
+	result, err := revoke.ExecContext(ctx, tx)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	if rowsAffected == 0 {
+		return errtrace.Wrap(ErrRefreshTokenNotFound)
+	}
+
+	insert := table.RefreshTokens.INSERT(
+		table.RefreshTokens.ID,
+		table.RefreshTokens.UserID,
+		table.RefreshTokens.FamilyID,
+		table.RefreshTokens.TokenHash,
+		table.RefreshTokens.ExpiresAt,
+		table.RefreshTokens.CreatedAt,
+	).VALUES(
+		sqlite.String(next.ID),
+		sqlite.String(next.UserID),
+		sqlite.String(next.FamilyID),
+		sqlite.String(next.TokenHash),
+		sqlite.DATETIME(next.ExpiresAt),
+		sqlite.DATETIME(next.CreatedAt),
+	)
+
+	if _, err := insert.ExecContext(ctx, tx); err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	return errtrace.Wrap(tx.Commit())
+}
+
+// RevokeFamily revokes every refresh token issued from the same login and
+// marks the session as signed out.
+func (r *TokenRepository) RevokeFamily(ctx context.Context, familyID string) error {
+	return errtrace.Wrap(r.revoke(ctx,
+		table.RefreshTokens.FamilyID.EQ(sqlite.String(familyID)),
+		table.Sessions.ID.EQ(sqlite.String(familyID)),
+	))
+}
+
+// RevokeUser revokes every refresh token of the user, which also invalidates
+// the access tokens issued for those sessions.
+func (r *TokenRepository) RevokeUser(ctx context.Context, userID string) error {
+	return errtrace.Wrap(r.revoke(ctx,
+		table.RefreshTokens.UserID.EQ(sqlite.String(userID)),
+		table.Sessions.UserID.EQ(sqlite.String(userID)),
+	))
+}
+
+// RevokeUserExcept is RevokeUser keeping one session signed in.
+func (r *TokenRepository) RevokeUserExcept(ctx context.Context, userID, familyID string) error {
+	return errtrace.Wrap(r.revoke(ctx,
+		table.RefreshTokens.UserID.EQ(sqlite.String(userID)).
+			AND(table.RefreshTokens.FamilyID.NOT_EQ(sqlite.String(familyID))),
+		table.Sessions.UserID.EQ(sqlite.String(userID)).
+			AND(table.Sessions.ID.NOT_EQ(sqlite.String(familyID))),
+	))
+}
+
+func (r *TokenRepository) revoke(ctx context.Context, tokens, sessions sqlite.BoolExpression) error {
+	tx, err := r.db.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+	defer tx.Rollback()
+
+	now := sqlite.DATETIME(time.Now())
+
+	revokeTokens := table.RefreshTokens.UPDATE().
+		SET(
+			table.RefreshTokens.RevokedAt.SET(now),
+		).WHERE(
+		tokens.AND(table.RefreshTokens.RevokedAt.IS_NULL()),
+	)
+
+	if _, err := revokeTokens.ExecContext(ctx, tx); err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	revokeSessions := table.Sessions.UPDATE().
+		SET(
+			table.Sessions.RevokedAt.SET(now),
+		).WHERE(
+		sessions.AND(table.Sessions.RevokedAt.IS_NULL()),
+	)
+
+	if _, err := revokeSessions.ExecContext(ctx, tx); err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	return errtrace.Wrap(tx.Commit())
+}
+
+// activeSessions matches sessions that still have a usable refresh token.
+func activeSessions() sqlite.BoolExpression {
+	return table.Sessions.RevokedAt.IS_NULL().AND(
+		table.Sessions.ID.IN(
+			table.RefreshTokens.SELECT(
+				table.RefreshTokens.FamilyID,
+			).WHERE(
+				table.RefreshTokens.RevokedAt.IS_NULL().
+					AND(table.RefreshTokens.ExpiresAt.GT(sqlite.DATETIME(time.Now()))),
+			),
+		),
+	)
+}
+
+// ListSessions returns the active sessions of the user, last seen first.
+func (r *TokenRepository) ListSessions(ctx context.Context, userID string) ([]domain.Session, error) {
+	stmt := table.Sessions.SELECT(
+		table.Sessions.AllColumns,
+	).WHERE(
+		table.Sessions.UserID.EQ(sqlite.String(userID)).
+			AND(activeSessions()),
+	).ORDER_BY(
+		table.Sessions.LastSeenAt.DESC(),
+	)
+
+	var dbSessions []model.Sessions
+	if err := stmt.QueryContext(ctx, r.db.db, &dbSessions); err != nil {
+		return nil, errtrace.Wrap(err)
+	}
+
+	sessions := make([]domain.Session, 0, len(dbSessions))
+	for _, v := range dbSessions {
+		sessions = append(sessions, domain.Session{
+			ID:         v.ID,
+			UserID:     v.UserID,
+			UserAgent:  v.UserAgent,
+			IP:         v.IP,
+			CreatedAt:  v.CreatedAt,
+			LastSeenAt: v.LastSeenAt,
+			RevokedAt:  v.RevokedAt,
+		})
+	}
+
+	return sessions, nil
+}
+
+// GetSession returns an active session of the user.
+func (r *TokenRepository) GetSession(ctx context.Context, userID, id string) (*domain.Session, error) {
+	stmt := table.Sessions.SELECT(
+		table.Sessions.AllColumns,
+	).WHERE(
+		table.Sessions.ID.EQ(sqlite.String(id)).
+			AND(table.Sessions.UserID.EQ(sqlite.String(userID))).
+			AND(activeSessions()),
+	).LIMIT(1)
+
+	var dbSession model.Sessions
+	if err := stmt.QueryContext(ctx, r.db.db, &dbSession); err != nil {
+		if errors.Is(err, qrm.ErrNoRows) {
+			return nil, errtrace.Wrap(ErrSessionNotFound)
+		}
+		return nil, errtrace.Wrap(err)
+	}
+
+	return &domain.Session{
+		ID:         dbSession.ID,
+		UserID:     dbSession.UserID,
+		UserAgent:  dbSession.UserAgent,
+		IP:         dbSession.IP,
+		CreatedAt:  dbSession.CreatedAt,
+		LastSeenAt: dbSession.LastSeenAt,
+		RevokedAt:  dbSession.RevokedAt,
+	}, nil
+}
+
+// TouchSession records activity on the session. It only writes when the
+// session was last seen before since, so busy clients don't cause a write per
+// request.
+func (r *TokenRepository) TouchSession(ctx context.Context, id, ip, userAgent string, since time.Time) error {
+	stmt := table.Sessions.UPDATE().
+		SET(
+			table.Sessions.LastSeenAt.SET(sqlite.DATETIME(time.Now())),
+			table.Sessions.IP.SET(sqlite.String(ip)),
+			table.Sessions.UserAgent.SET(sqlite.String(userAgent)),
+		).WHERE(
+		table.Sessions.ID.EQ(sqlite.String(id)).
+			AND(table.Sessions.LastSeenAt.LT(sqlite.DATETIME(since))),
+	)
+
+	_, err := stmt.ExecContext(ctx, r.db.db)
+	return errtrace.Wrap(err)
+}
+
+// IsFamilyActive reports whether the family still has a token that is neither
+// revoked nor expired.
+func (r *TokenRepository) IsFamilyActive(ctx context.Context, familyID string) (bool, error) {
+	stmt := sqlite.SELECT(
+		sqlite.COUNT(table.RefreshTokens.ID).AS("total"),
+	).FROM(
+		table.RefreshTokens,
+	).WHERE(
+		table.RefreshTokens.FamilyID.EQ(sqlite.String(familyID)).
+			AND(table.RefreshTokens.RevokedAt.IS_NULL()).
+			AND(table.RefreshTokens.ExpiresAt.GT(sqlite.DATETIME(time.Now()))),
+	)
+
+	var total struct {
+		Total int64
+	}
+	if err := stmt.QueryContext(ctx, r.db.db, &total); err != nil {
+		return false, errtrace.Wrap(err)
+	}
+
+	return total.Total > 0, nil
+}
+
+func (r *TokenRepository) RevokeJTI(ctx context.Context, jti, userID string, expiresAt time.Time) error {
+	stmt := table.RevokedTokens.INSERT(
+		table.RevokedTokens.Jti,
+		table.RevokedTokens.UserID,
+		table.RevokedTokens.ExpiresAt,
+		table.RevokedTokens.RevokedAt,
+	).VALUES(
+		sqlite.String(jti),
+		sqlite.String(userID),
+		sqlite.DATETIME(expiresAt),
+		sqlite.DATETIME(time.Now()),
+	).ON_CONFLICT(table.RevokedTokens.Jti).DO_NOTHING()
+
+	_, err := stmt.ExecContext(ctx, r.db.db)
+	return errtrace.Wrap(err)
+}
+
+func (r *TokenRepository) IsJTIRevoked(ctx context.Context, jti string) (bool, error) {
+	stmt := sqlite.SELECT(
+		sqlite.COUNT(table.RevokedTokens.Jti).AS("total"),
+	).FROM(
+		table.RevokedTokens,
+	).WHERE(
+		table.RevokedTokens.Jti.EQ(sqlite.String(jti)),
+	)
+
+	var total struct {
+		Total int64
+	}
+	if err := stmt.QueryContext(ctx, r.db.db, &total); err != nil {
+		return false, errtrace.Wrap(err)
+	}
+
+	return total.Total > 0, nil
+}
+
+// DeleteExpired removes refresh tokens and revoked ids that can no longer be
+// presented because they are past their expiry.
+func (r *TokenRepository) DeleteExpired(ctx context.Context) error {
+	now := sqlite.DATETIME(time.Now())
+
+	if _, err := table.RefreshTokens.DELETE().
+		WHERE(table.RefreshTokens.ExpiresAt.LT(now)).
+		ExecContext(ctx, r.db.db); err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	_, err := table.RevokedTokens.DELETE().
+		WHERE(table.RevokedTokens.ExpiresAt.LT(now)).
+		ExecContext(ctx, r.db.db)
+	return errtrace.Wrap(err)
+}
@@ -0,0 +1,270 @@
+package sql
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"basic-service/domain"
+	"basic-service/gen/db/model"
+	"basic-service/gen/db/table"
+
+	"braces.dev/errtrace"
+	"github.com/go-jet/jet/v2/qrm"
+	"github.com/go-jet/jet/v2/sqlite"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrTOTPNotFound        = errors.New("totp is not set up")
+	ErrTOTPStepUsed        = errors.New("totp code already used")
+	ErrRecoveryCodeInvalid = errors.New("recovery code is invalid or used")
+)
+
+type TOTPRepository struct {
+	db *SQLite
+}
+
+func NewTOTPRepository(db *SQLite) *TOTPRepository {
+	return &TOTPRepository{db: db}
+}
+
+func (r *TOTPRepository) Get(ctx context.Context, userID string) (*domain.UserTOTP, error) {
+	stmt := table.UserTotp.SELECT(
+		table.UserTotp.AllColumns,
+	).WHERE(
+		table.UserTotp.UserID.EQ(sqlite.String(userID)),
+	).LIMIT(1)
+
+	var dbTOTP model.UserTotp
+	if err := stmt.QueryContext(ctx, r.db.db, &dbTOTP); err != nil {
+		if errors.Is(err, qrm.ErrNoRows) {
+			return nil, errtrace.Wrap(ErrTOTPNotFound)
+		}
+		return nil, errtrace.Wrap(err)
+	}
+
+	return &domain.UserTOTP{
+		UserID:    dbTOTP.UserID,
+		Secret:    dbTOTP.Secret,
+		EnabledAt: dbTOTP.EnabledAt,
+		LastStep:  dbTOTP.LastStep,
+		CreatedAt: dbTOTP.CreatedAt,
+	}, nil
+}
+
+// IsEnabled reports whether the user confirmed their TOTP enrollment.
+func (r *TOTPRepository) IsEnabled(ctx context.Context, userID string) (bool, error) {
+	stmt := sqlite.SELECT(
+		sqlite.COUNT(table.UserTotp.UserID).AS("total"),
+	).FROM(
+		table.UserTotp,
+	).WHERE(
+		table.UserTotp.UserID.EQ(sqlite.String(userID)).
+			AND(table.UserTotp.EnabledAt.IS_NOT_NULL()),
+	)
+
+	var total struct {
+		Total int64
+	}
+	if err := stmt.QueryContext(ctx, r.db.db, &total); err != nil {
+		return false, errtrace.Wrap(err)
+	}
+
+	return total.Total > 0, nil
+}
+
+// SavePending stores a new secret that is not enabled yet, replacing an
+// earlier pending enrollment.
+func (r *TOTPRepository) SavePending(ctx context.Context, userID, secret string) error {
+	stmt := table.UserTotp.INSERT(
+		table.UserTotp.UserID,
+		table.UserTotp.Secret,
+		table.UserTotp.CreatedAt,
+	).VALUES(
+		sqlite.String(userID),
+		sqlite.String(secret),
+		sqlite.DATETIME(time.Now()),
+	).ON_CONFLICT(table.UserTotp.UserID).DO_UPDATE(
+		sqlite.SET(
+			table.UserTotp.Secret.SET(table.UserTotp.EXCLUDED.Secret),
+			table.UserTotp.LastStep.SET(sqlite.Int(0)),
+			table.UserTotp.CreatedAt.SET(table.UserTotp.EXCLUDED.CreatedAt),
+		).WHERE(
+			table.UserTotp.EnabledAt.IS_NULL(),
+		),
+	)
+
+	_, err := stmt.ExecContext(ctx, r.db.db)
+	return errtrace.Wrap(err)
+}
+
+// UseStep records step as the last accepted one. It fails with
+// ErrTOTPStepUsed when a code of that step or a later one was accepted.
+func (r *TOTPRepository) UseStep(ctx context.Context, userID string, step int64) error {
+	stmt := table.UserTotp.UPDATE().
+		SET(
+			table.UserTotp.LastStep.SET(sqlite.Int(step)),
+		).WHERE(
+		table.UserTotp.UserID.EQ(sqlite.String(userID)).
+			AND(table.UserTotp.LastStep.LT(sqlite.Int(step))),
+	)
+
+	result, err := stmt.ExecContext(ctx, r.db.db)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	if rowsAffected == 0 {
+		return errtrace.Wrap(ErrTOTPStepUsed)
+	}
+
+	return nil
+}
+
+// Enable confirms the enrollment and stores the recovery codes.
+func (r *TOTPRepository) Enable(ctx context.Context, userID string, codeHashes []string) error {
+	tx, err := r.db.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+	defer tx.Rollback()
+
+	enable := table.UserTotp.UPDATE().
+		SET(
+			table.UserTotp.EnabledAt.SET(sqlite.DATETIME(time.Now())),
+		).WHERE(
+		table.UserTotp.UserID.EQ(sqlite.String(userID)),
+	)
+
+	if _, err := enable.ExecContext(ctx, tx); err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	if err := replaceRecoveryCodes(ctx, tx, userID, codeHashes); err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	return errtrace.Wrap(tx.Commit())
+}
+
+// ReplaceRecoveryCodes drops the previous recovery codes of the user.
+func (r *TOTPRepository) ReplaceRecoveryCodes(ctx context.Context, userID string, codeHashes []string) error {
+	tx, err := r.db.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+	defer tx.Rollback()
+
+	if err := replaceRecoveryCodes(ctx, tx, userID, codeHashes); err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	return errtrace.Wrap(tx.Commit())
+}
+
+func replaceRecoveryCodes(ctx context.Context, db qrm.Executable, userID string, codeHashes []string) error {
+	if _, err := table.UserRecoveryCodes.DELETE().
+		WHERE(table.UserRecoveryCodes.UserID.EQ(sqlite.String(userID))).
+		ExecContext(ctx, db); err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	if len(codeHashes) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	stmt := table.UserRecoveryCodes.INSERT(
+		table.UserRecoveryCodes.ID,
+		table.UserRecoveryCodes.UserID,
+		table.UserRecoveryCodes.CodeHash,
+		table.UserRecoveryCodes.CreatedAt,
+	)
+	for _, v := range codeHashes {
+		stmt = stmt.VALUES(
+			sqlite.String(uuid.New().String()),
+			sqlite.String(userID),
+			sqlite.String(v),
+			sqlite.DATETIME(now),
+		)
+	}
+
+	_, err := stmt.ExecContext(ctx, db)
+	return errtrace.Wrap(err)
+}
+
+// UseRecoveryCode marks the matching unused code as used.
+func (r *TOTPRepository) UseRecoveryCode(ctx context.Context, userID, codeHash string) error {
+	stmt := table.UserRecoveryCodes.UPDATE().
+		SET(
+			table.UserRecoveryCodes.UsedAt.SET(sqlite.DATETIME(time.Now())),
+		).WHERE(
+		table.UserRecoveryCodes.UserID.EQ(sqlite.String(userID)).
+			AND(table.UserRecoveryCodes.CodeHash.EQ(sqlite.String(codeHash))).
+			AND(table.UserRecoveryCodes.UsedAt.IS_NULL()),
+	)
+
+	result, err := stmt.ExecContext(ctx, r.db.db)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	if rowsAffected == 0 {
+		return errtrace.Wrap(ErrRecoveryCodeInvalid)
+	}
+
+	return nil
+}
+
+// CountRecoveryCodes returns how many recovery codes are left.
+func (r *TOTPRepository) CountRecoveryCodes(ctx context.Context, userID string) (int64, error) {
+	stmt := sqlite.SELECT(
+		sqlite.COUNT(table.UserRecoveryCodes.ID).AS("total"),
+	).FROM(
+		table.UserRecoveryCodes,
+	).WHERE(
+		table.UserRecoveryCodes.UserID.EQ(sqlite.String(userID)).
+			AND(table.UserRecoveryCodes.UsedAt.IS_NULL()),
+	)
+
+	var total struct {
+		Total int64
+	}
+	if err := stmt.QueryContext(ctx, r.db.db, &total); err != nil {
+		return 0, errtrace.Wrap(err)
+	}
+
+	return total.Total, nil
+}
+
+// Delete removes the second factor and its recovery codes.
+func (r *TOTPRepository) Delete(ctx context.Context, userID string) error {
+	tx, err := r.db.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+	defer tx.Rollback()
+
+	if _, err := table.UserTotp.DELETE().
+		WHERE(table.UserTotp.UserID.EQ(sqlite.String(userID))).
+		ExecContext(ctx, tx); err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	if err := replaceRecoveryCodes(ctx, tx, userID, nil); err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	return errtrace.Wrap(tx.Commit())
+}
@@ -3,6 +3,7 @@ package sql
 import (
 	"context"
 	"errors"
+	"time"
 
 	"basic-service/domain"
 	"basic-service/gen/db/model"
@@ -68,6 +69,7 @@ func (r *UserRepository) ListByRole(ctx context.Context, role domain.RoleType, p
 		table.Users.CreatedAt,
 		table.Users.UpdatedAt,
 		table.Users.Profile,
+		table.Users.VerifiedAt,
 	).WHERE(
 		table.Users.Role.EQ(sqlite.Int32(int32(role))),
 	).ORDER_BY(
@@ -88,14 +90,138 @@ func (r *UserRepository) ListByRole(ctx context.Context, role domain.RoleType, p
 	users := []domain.User{}
 	for _, dbUser := range dbUsers {
 		users = append(users, domain.User{
-			ID:        dbUser.ID,
-			Email:     dbUser.Email,
-			Name:      dbUser.Name,
-			Role:      domain.RoleType(dbUser.Role),
-			IsActive:  dbUser.IsActive,
-			Profile:   dbUser.Profile,
-			CreatedAt: dbUser.CreatedAt,
-			UpdatedAt: dbUser.UpdatedAt,
+			ID:         dbUser.ID,
+			Email:      dbUser.Email,
+			Name:       dbUser.Name,
+			Role:       domain.RoleType(dbUser.Role),
+			IsActive:   dbUser.IsActive,
+			Profile:    dbUser.Profile,
+			VerifiedAt: dbUser.VerifiedAt,
+			CreatedAt:  dbUser.CreatedAt,
+			UpdatedAt:  dbUser.UpdatedAt,
+		})
+	}
+
+	return UserDataList{
+		Total: int(total.Total),
+		Data:  users,
+	}, nil
+}
+
+// UserFilter narrows Search, zero values are ignored
+type UserFilter struct {
+	Query       string // matched against email and name
+	Role        domain.RoleType
+	IsActive    *bool
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	SortBy      string // created_at, email or name
+	Desc        bool
+}
+
+var userSortColumns = map[string]sqlite.Column{
+	"created_at": table.Users.CreatedAt,
+	"email":      table.Users.Email,
+	"name":       table.Users.Name,
+}
+
+func (f UserFilter) condition() sqlite.BoolExpression {
+	cond := sqlite.Bool(true)
+
+	if f.Query != "" {
+		cond = cond.AND(contains(table.Users.Email, f.Query).OR(contains(table.Users.Name, f.Query)))
+	}
+	if f.Role != 0 {
+		cond = cond.AND(table.Users.Role.EQ(sqlite.Int32(int32(f.Role))))
+	}
+	if f.IsActive != nil {
+		cond = cond.AND(table.Users.IsActive.EQ(sqlite.Bool(*f.IsActive)))
+	}
+	if f.CreatedFrom != nil {
+		cond = cond.AND(table.Users.CreatedAt.GT_EQ(sqlite.DATETIME(*f.CreatedFrom)))
+	}
+	if f.CreatedTo != nil {
+		cond = cond.AND(table.Users.CreatedAt.LT(sqlite.DATETIME(*f.CreatedTo)))
+	}
+
+	return cond
+}
+
+func (f UserFilter) orderBy() sqlite.OrderByClause {
+	column, ok := userSortColumns[f.SortBy]
+	if !ok {
+		column = table.Users.CreatedAt
+	}
+
+	if f.Desc {
+		return column.DESC()
+	}
+	return column.ASC()
+}
+
+// Search lists users matching filter across every role
+func (r *UserRepository) Search(ctx context.Context, filter UserFilter, page, pageSize int) (UserDataList, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 10 // Default page size
+	}
+
+	countStmt := sqlite.SELECT(
+		sqlite.COUNT(table.Users.ID).AS("total"),
+	).FROM(
+		table.Users,
+	).WHERE(
+		filter.condition(),
+	)
+
+	var total struct {
+		Total int64
+	}
+	if err := countStmt.QueryContext(ctx, r.db.db, &total); err != nil {
+		return UserDataList{}, errtrace.Wrap(err)
+	}
+
+	offset := (page - 1) * pageSize
+	stmt := table.Users.SELECT(
+		table.Users.ID,
+		table.Users.Email,
+		table.Users.Name,
+		table.Users.Role,
+		table.Users.IsActive,
+		table.Users.CreatedAt,
+		table.Users.UpdatedAt,
+		table.Users.Profile,
+		table.Users.VerifiedAt,
+	).WHERE(
+		filter.condition(),
+	).ORDER_BY(
+		filter.orderBy(),
+		table.Users.ID.ASC(),
+	).LIMIT(
+		int64(pageSize),
+	).OFFSET(
+		int64(offset),
+	)
+
+	var dbUsers []model.Users
+	if err := stmt.QueryContext(ctx, r.db.db, &dbUsers); err != nil && !errors.Is(err, qrm.ErrNoRows) {
+		return UserDataList{}, errtrace.Wrap(err)
+	}
+
+	users := make([]domain.User, 0, len(dbUsers))
+	for _, dbUser := range dbUsers {
+		users = append(users, domain.User{
+			ID:         dbUser.ID,
+			Email:      dbUser.Email,
+			Name:       dbUser.Name,
+			Role:       domain.RoleType(dbUser.Role),
+			IsActive:   dbUser.IsActive,
+			Profile:    dbUser.Profile,
+			VerifiedAt: dbUser.VerifiedAt,
+			CreatedAt:  dbUser.CreatedAt,
+			UpdatedAt:  dbUser.UpdatedAt,
 		})
 	}
 
@@ -116,6 +242,7 @@ func (r *UserRepository) GetUserByID(ctx context.Context, id string) (*domain.Us
 		table.Users.CreatedAt,
 		table.Users.UpdatedAt,
 		table.Users.Profile,
+		table.Users.VerifiedAt,
 	).FROM(
 		table.Users,
 	).WHERE(
@@ -132,15 +259,16 @@ func (r *UserRepository) GetUserByID(ctx context.Context, id string) (*domain.Us
 	}
 
 	return &domain.User{
-		ID:        dbUser.ID,
-		Email:     dbUser.Email,
-		Profile:   dbUser.Profile,
-		Password:  dbUser.Password,
-		Name:      dbUser.Name,
-		Role:      domain.RoleType(dbUser.Role),
-		IsActive:  dbUser.IsActive,
-		CreatedAt: dbUser.CreatedAt,
-		UpdatedAt: dbUser.UpdatedAt,
+		ID:         dbUser.ID,
+		Email:      dbUser.Email,
+		Profile:    dbUser.Profile,
+		Password:   dbUser.Password,
+		Name:       dbUser.Name,
+		Role:       domain.RoleType(dbUser.Role),
+		IsActive:   dbUser.IsActive,
+		VerifiedAt: dbUser.VerifiedAt,
+		CreatedAt:  dbUser.CreatedAt,
+		UpdatedAt:  dbUser.UpdatedAt,
 	}, nil
 }
 
@@ -155,6 +283,7 @@ func (r *UserRepository) GetEmail(ctx context.Context, email string) (*domain.Us
 		table.Users.CreatedAt,
 		table.Users.UpdatedAt,
 		table.Users.Profile,
+		table.Users.VerifiedAt,
 	).FROM(
 		table.Users,
 	).WHERE(
@@ -171,15 +300,16 @@ func (r *UserRepository) GetEmail(ctx context.Context, email string) (*domain.Us
 	}
 
 	return &domain.User{
-		ID:        dbUser.ID,
-		Email:     dbUser.Email,
-		Password:  dbUser.Password,
-		Name:      dbUser.Name,
-		Role:      domain.RoleType(dbUser.Role),
-		IsActive:  dbUser.IsActive,
-		CreatedAt: dbUser.CreatedAt,
-		UpdatedAt: dbUser.UpdatedAt,
-		Profile:   dbUser.Profile,
+		ID:         dbUser.ID,
+		Email:      dbUser.Email,
+		Password:   dbUser.Password,
+		Name:       dbUser.Name,
+		Role:       domain.RoleType(dbUser.Role),
+		IsActive:   dbUser.IsActive,
+		CreatedAt:  dbUser.CreatedAt,
+		UpdatedAt:  dbUser.UpdatedAt,
+		Profile:    dbUser.Profile,
+		VerifiedAt: dbUser.VerifiedAt,
 	}, nil
 }
 
@@ -196,6 +326,32 @@ func (r *UserRepository) UpdateUserState(ctx context.Context, id string, isActiv
 	return errtrace.Wrap(err)
 }
 
+func (r *UserRepository) UpdateRole(ctx context.Context, id string, role domain.RoleType) error {
+	stmt := table.Users.UPDATE().
+		SET(
+			table.Users.Role.SET(sqlite.Int32(int32(role))),
+			table.Users.UpdatedAt.SET(sqlite.CURRENT_TIMESTAMP()),
+		).WHERE(
+		table.Users.ID.EQ(sqlite.String(id)),
+	)
+
+	result, err := stmt.ExecContext(ctx, r.db.db)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	if rowsAffected == 0 {
+		return errtrace.Wrap(UserNotFoundErr)
+	}
+
+	return nil
+}
+
 func (r *UserRepository) Create(ctx context.Context, user *domain.User) error {
 	// Check if user already exists
 	existingUser, err := r.GetEmail(ctx, user.Email)
@@ -256,6 +412,20 @@ func (r *UserRepository) UpdatePassword(ctx context.Context, id, password string
 	return errtrace.Wrap(err)
 }
 
+func (r *UserRepository) MarkVerified(ctx context.Context, id string) error {
+	stmt := table.Users.UPDATE().
+		SET(
+			table.Users.VerifiedAt.SET(sqlite.CURRENT_TIMESTAMP()),
+			table.Users.UpdatedAt.SET(sqlite.CURRENT_TIMESTAMP()),
+		).WHERE(
+		table.Users.ID.EQ(sqlite.String(id)).
+			AND(table.Users.VerifiedAt.IS_NULL()),
+	)
+
+	_, err := stmt.ExecContext(ctx, r.db.db)
+	return errtrace.Wrap(err)
+}
+
 func (r *UserRepository) Delete(ctx context.Context, id string) error {
 	stmt := table.Users.DELETE().
 		WHERE(table.Users.ID.EQ(sqlite.String(id)))
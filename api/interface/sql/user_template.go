@@ -8,8 +8,10 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"time"
 
 	"braces.dev/errtrace"
+	"github.com/go-jet/jet/v2/qrm"
 	"github.com/go-jet/jet/v2/sqlite"
 )
 
@@ -31,11 +33,15 @@ type UserTemplateList struct {
 	Data  []domain.UserTemplate
 }
 
-// Count returns the total number of user templates
-func (r *UserTemplateRepository) Count(ctx context.Context) (int64, error) {
+// Count returns the number of user templates the user is a member of
+func (r *UserTemplateRepository) Count(ctx context.Context, userID string) (int64, error) {
 	countStmt := sqlite.SELECT(
-		sqlite.COUNT(table.UserTemplates.ID).AS("total"),
-	).FROM(table.UserTemplates)
+		sqlite.COUNT(table.TemplateMembers.ID).AS("total"),
+	).FROM(
+		table.TemplateMembers,
+	).WHERE(
+		table.TemplateMembers.UserID.EQ(sqlite.String(userID)),
+	)
 
 	var total struct {
 		Total int64
@@ -47,30 +53,24 @@ func (r *UserTemplateRepository) Count(ctx context.Context) (int64, error) {
 	return total.Total, nil
 }
 
-// List returns a paginated list of user templates
+// List returns a paginated list of the user templates the user is a member
+// of, Role is the membership of the user
 func (r *UserTemplateRepository) List(ctx context.Context, userID string, offset, limit int) (UserTemplateList, error) {
 	// Get total count
-	total, err := r.Count(ctx)
+	total, err := r.Count(ctx, userID)
 	if err != nil {
 		return UserTemplateList{}, errtrace.Wrap(err)
 	}
 
 	// Get paginated templates
-	stmt := table.UserTemplates.SELECT(
-		table.UserTemplates.ID,
-		table.UserTemplates.UserID,
-		table.UserTemplates.BaseTemplateID,
-		table.UserTemplates.State,
-		table.UserTemplates.Slug,
-		table.UserTemplates.URL,
-		table.UserTemplates.MessageTemplate,
-		table.UserTemplates.Name,
-		table.UserTemplates.CoverImage,
-		table.UserTemplates.CreatedAt,
-		table.UserTemplates.UpdatedAt,
-		table.UserTemplates.ExpireAt,
+	stmt := sqlite.SELECT(
+		table.UserTemplates.AllColumns,
+		table.TemplateMembers.Role,
+	).FROM(
+		table.UserTemplates.INNER_JOIN(table.TemplateMembers,
+			table.TemplateMembers.UserTemplateID.EQ(table.UserTemplates.ID)),
 	).WHERE(
-		table.UserTemplates.UserID.EQ(sqlite.String(userID)),
+		table.TemplateMembers.UserID.EQ(sqlite.String(userID)),
 	).ORDER_BY(
 		table.UserTemplates.CreatedAt.DESC(),
 	).OFFSET(
@@ -79,17 +79,21 @@ func (r *UserTemplateRepository) List(ctx context.Context, userID string, offset
 		int64(limit),
 	)
 
-	var dbTemplates []model.UserTemplates
+	var dbTemplates []struct {
+		model.UserTemplates
+		TemplateMembers model.TemplateMembers
+	}
 	if err := stmt.QueryContext(ctx, r.db.db, &dbTemplates); err != nil {
 		return UserTemplateList{}, errtrace.Wrap(err)
 	}
 
 	templates := make([]domain.UserTemplate, 0, len(dbTemplates))
 	for _, dbTemplate := range dbTemplates {
-		template, err := r.mapToDomain(dbTemplate)
+		template, err := r.mapToDomain(dbTemplate.UserTemplates)
 		if err != nil {
 			return UserTemplateList{}, errtrace.Wrap(err)
 		}
+		template.Role = domain.TemplateRole(dbTemplate.TemplateMembers.Role)
 		templates = append(templates, template)
 	}
 
@@ -135,13 +139,24 @@ func (r *UserTemplateRepository) Exists(ctx context.Context, id string) (bool, e
 	return exists, nil
 }
 
-// Create adds a new user template
-func (r *UserTemplateRepository) Create(ctx context.Context, template domain.UserTemplate) error {
+// Create adds a new user template together with its first owner
+func (r *UserTemplateRepository) Create(ctx context.Context, template domain.UserTemplate, owner domain.TemplateMember) error {
 	msgTemplateStr, err := r.marshalMessageTemplate(template.MessageTemplate)
 	if err != nil {
 		return errtrace.Wrap(err)
 	}
 
+	blockedWordsJSON, err := marshalBlockedWords(template.GuestbookBlockedWords)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	tx, err := r.db.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+	defer tx.Rollback()
+
 	stmt := table.UserTemplates.INSERT(
 		table.UserTemplates.AllColumns,
 	).VALUES(
@@ -157,10 +172,20 @@ func (r *UserTemplateRepository) Create(ctx context.Context, template domain.Use
 		sqlite.DATETIME(template.CreatedAt),
 		sqlite.DATETIME(template.UpdatedAt),
 		sqlite.DATETIME(template.ExpireAt),
+		sqlite.Bool(template.GuestbookApproveFirst),
+		sqlite.String(blockedWordsJSON),
+		template.RSVPDeadline,
 	)
 
-	_, err = stmt.ExecContext(ctx, r.db.db)
-	return errtrace.Wrap(err)
+	if _, err := stmt.ExecContext(ctx, tx); err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	if _, err := insertTemplateMember(owner).ExecContext(ctx, tx); err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	return errtrace.Wrap(tx.Commit())
 }
 
 // Update modifies an existing user template
@@ -180,7 +205,6 @@ func (r *UserTemplateRepository) Update(ctx context.Context, templateID string,
 
 	stmt := table.UserTemplates.UPDATE().
 		SET(
-			table.UserTemplates.UserID.SET(sqlite.String(template.UserID)),
 			table.UserTemplates.BaseTemplateID.SET(sqlite.String(template.BaseTemplateID)),
 			table.UserTemplates.State.SET(sqlite.Int(int64(template.State))),
 			table.UserTemplates.Slug.SET(sqlite.String(template.Slug)),
@@ -198,7 +222,111 @@ func (r *UserTemplateRepository) Update(ctx context.Context, templateID string,
 	return errtrace.Wrap(err)
 }
 
-// Delete removes a user template
+// GetBySlug retrieves the user template published under slug
+func (r *UserTemplateRepository) GetBySlug(ctx context.Context, slug string) (domain.UserTemplate, error) {
+	stmt := table.UserTemplates.SELECT(
+		table.UserTemplates.AllColumns,
+	).WHERE(
+		table.UserTemplates.Slug.EQ(sqlite.String(slug)),
+	).ORDER_BY(
+		table.UserTemplates.CreatedAt.ASC(),
+	).LIMIT(1)
+
+	var dbTemplate model.UserTemplates
+	if err := stmt.QueryContext(ctx, r.db.db, &dbTemplate); err != nil {
+		if errors.Is(err, qrm.ErrNoRows) {
+			return domain.UserTemplate{}, errtrace.Wrap(ErrUserTemplateNotFound)
+		}
+		return domain.UserTemplate{}, errtrace.Wrap(err)
+	}
+
+	return r.mapToDomain(dbTemplate)
+}
+
+// UpdateGuestbook changes the guestbook moderation settings of a template
+func (r *UserTemplateRepository) UpdateGuestbook(ctx context.Context, templateID string, approveFirst bool, blockedWords []string) error {
+	blockedWordsJSON, err := marshalBlockedWords(blockedWords)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	stmt := table.UserTemplates.UPDATE().
+		SET(
+			table.UserTemplates.GuestbookApproveFirst.SET(sqlite.Bool(approveFirst)),
+			table.UserTemplates.GuestbookBlockedWords.SET(sqlite.String(blockedWordsJSON)),
+		).WHERE(
+		table.UserTemplates.ID.EQ(sqlite.String(templateID)),
+	)
+
+	result, err := stmt.ExecContext(ctx, r.db.db)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	if rowsAffected == 0 {
+		return errtrace.Wrap(ErrUserTemplateNotFound)
+	}
+
+	return nil
+}
+
+// UpdateState moves a template to another lifecycle state
+func (r *UserTemplateRepository) UpdateState(ctx context.Context, templateID string, state domain.TemplateState) error {
+	return r.updateLifecycle(ctx, templateID, table.UserTemplates.State.SET(sqlite.Int(int64(state))))
+}
+
+// UpdateRSVPDeadline changes when the RSVP form of a template closes, nil
+// keeps it open until the template expires.
+func (r *UserTemplateRepository) UpdateRSVPDeadline(ctx context.Context, templateID string, deadline *time.Time) error {
+	value := sqlite.TimestampExp(sqlite.NULL)
+	if deadline != nil {
+		value = sqlite.DATETIME(*deadline)
+	}
+
+	return r.updateLifecycle(ctx, templateID, table.UserTemplates.RsvpDeadline.SET(value))
+}
+
+func (r *UserTemplateRepository) updateLifecycle(ctx context.Context, templateID string, set sqlite.ColumnAssigment) error {
+	stmt := table.UserTemplates.UPDATE().
+		SET(
+			set,
+			table.UserTemplates.UpdatedAt.SET(sqlite.DATETIME(time.Now())),
+		).WHERE(
+		table.UserTemplates.ID.EQ(sqlite.String(templateID)),
+	)
+
+	result, err := stmt.ExecContext(ctx, r.db.db)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	if rowsAffected == 0 {
+		return errtrace.Wrap(ErrUserTemplateNotFound)
+	}
+
+	return nil
+}
+
+func marshalBlockedWords(words []string) (string, error) {
+	if words == nil {
+		words = []string{}
+	}
+
+	b, err := json.Marshal(words)
+	return string(b), errtrace.Wrap(err)
+}
+
+// Delete removes a user template with its members and invitations
 func (r *UserTemplateRepository) Delete(ctx context.Context, id string) error {
 	exists, err := r.Exists(ctx, id)
 	if err != nil {
@@ -208,11 +336,30 @@ func (r *UserTemplateRepository) Delete(ctx context.Context, id string) error {
 		return errtrace.Wrap(ErrUserTemplateNotFound)
 	}
 
-	stmt := table.UserTemplates.DELETE().
-		WHERE(table.UserTemplates.ID.EQ(sqlite.String(id)))
+	tx, err := r.db.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+	defer tx.Rollback()
+
+	stmts := []sqlite.DeleteStatement{
+		table.TemplateInvites.DELETE().
+			WHERE(table.TemplateInvites.UserTemplateID.EQ(sqlite.String(id))),
+		table.TemplateMembers.DELETE().
+			WHERE(table.TemplateMembers.UserTemplateID.EQ(sqlite.String(id))),
+		table.RsvpQuestions.DELETE().
+			WHERE(table.RsvpQuestions.UserTemplateID.EQ(sqlite.String(id))),
+		table.UserTemplates.DELETE().
+			WHERE(table.UserTemplates.ID.EQ(sqlite.String(id))),
+	}
 
-	_, err = stmt.ExecContext(ctx, r.db.db)
-	return errtrace.Wrap(err)
+	for _, stmt := range stmts {
+		if _, err := stmt.ExecContext(ctx, tx); err != nil {
+			return errtrace.Wrap(err)
+		}
+	}
+
+	return errtrace.Wrap(tx.Commit())
 }
 
 // Helper function to map database model to domain model
@@ -224,11 +371,18 @@ func (r *UserTemplateRepository) mapToDomain(dbTemplate model.UserTemplates) (do
 		}
 	}
 
+	blockedWords := []string{}
+	if dbTemplate.GuestbookBlockedWords != "" {
+		if err := json.Unmarshal([]byte(dbTemplate.GuestbookBlockedWords), &blockedWords); err != nil {
+			return domain.UserTemplate{}, errtrace.Wrap(err)
+		}
+	}
+
 	return domain.UserTemplate{
 		ID:              dbTemplate.ID,
 		UserID:          dbTemplate.UserID,
 		BaseTemplateID:  dbTemplate.BaseTemplateID,
-		State:           int(dbTemplate.State),
+		State:           domain.TemplateState(dbTemplate.State),
 		Slug:            dbTemplate.Slug,
 		URL:             dbTemplate.URL,
 		MessageTemplate: msgTemplates,
@@ -237,6 +391,10 @@ func (r *UserTemplateRepository) mapToDomain(dbTemplate model.UserTemplates) (do
 		CreatedAt:       dbTemplate.CreatedAt,
 		UpdatedAt:       dbTemplate.UpdatedAt,
 		ExpireAt:        dbTemplate.ExpireAt,
+
+		GuestbookApproveFirst: dbTemplate.GuestbookApproveFirst,
+		GuestbookBlockedWords: blockedWords,
+		RSVPDeadline:          dbTemplate.RsvpDeadline,
 	}, nil
 }
 
@@ -0,0 +1,151 @@
+package sql
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"basic-service/domain"
+	"basic-service/gen/db/model"
+	"basic-service/gen/db/table"
+
+	"braces.dev/errtrace"
+	"github.com/go-jet/jet/v2/qrm"
+	"github.com/go-jet/jet/v2/sqlite"
+)
+
+var ErrUserTokenInvalid = errors.New("token is invalid or expired")
+
+type UserTokenRepository struct {
+	db *SQLite
+}
+
+func NewUserTokenRepository(db *SQLite) *UserTokenRepository {
+	return &UserTokenRepository{db: db}
+}
+
+func (r *UserTokenRepository) Create(ctx context.Context, token domain.UserToken) error {
+	stmt := table.UserTokens.INSERT(
+		table.UserTokens.ID,
+		table.UserTokens.UserID,
+		table.UserTokens.Purpose,
+		table.UserTokens.TokenHash,
+		table.UserTokens.ExpiresAt,
+		table.UserTokens.CreatedAt,
+	).VALUES(
+		sqlite.String(token.ID),
+		sqlite.String(token.UserID),
+		sqlite.String(string(token.Purpose)),
+		sqlite.String(token.TokenHash),
+		sqlite.DATETIME(token.ExpiresAt),
+		sqlite.DATETIME(token.CreatedAt),
+	)
+
+	_, err := stmt.ExecContext(ctx, r.db.db)
+	return errtrace.Wrap(err)
+}
+
+// Get returns a token that is neither used nor expired without consuming it.
+func (r *UserTokenRepository) Get(ctx context.Context, purpose domain.UserTokenPurpose, hash string) (*domain.UserToken, error) {
+	stmt := table.UserTokens.SELECT(
+		table.UserTokens.AllColumns,
+	).WHERE(
+		table.UserTokens.TokenHash.EQ(sqlite.String(hash)).
+			AND(table.UserTokens.Purpose.EQ(sqlite.String(string(purpose)))),
+	).LIMIT(1)
+
+	var dbToken model.UserTokens
+	if err := stmt.QueryContext(ctx, r.db.db, &dbToken); err != nil {
+		if errors.Is(err, qrm.ErrNoRows) {
+			return nil, errtrace.Wrap(ErrUserTokenInvalid)
+		}
+		return nil, errtrace.Wrap(err)
+	}
+
+	if dbToken.UsedAt != nil || time.Now().After(dbToken.ExpiresAt) {
+		return nil, errtrace.Wrap(ErrUserTokenInvalid)
+	}
+
+	return &domain.UserToken{
+		ID:        dbToken.ID,
+		UserID:    dbToken.UserID,
+		Purpose:   domain.UserTokenPurpose(dbToken.Purpose),
+		TokenHash: dbToken.TokenHash,
+		ExpiresAt: dbToken.ExpiresAt,
+		UsedAt:    dbToken.UsedAt,
+		CreatedAt: dbToken.CreatedAt,
+	}, nil
+}
+
+// Consume marks the token as used and returns it. It fails with
+// ErrUserTokenInvalid when the token is unknown, used or expired.
+func (r *UserTokenRepository) Consume(ctx context.Context, purpose domain.UserTokenPurpose, hash string) (*domain.UserToken, error) {
+	token, err := r.Get(ctx, purpose, hash)
+	if err != nil {
+		return nil, errtrace.Wrap(err)
+	}
+
+	// guard against the same token being consumed twice concurrently
+	update := table.UserTokens.UPDATE().
+		SET(
+			table.UserTokens.UsedAt.SET(sqlite.DATETIME(time.Now())),
+		).WHERE(
+		table.UserTokens.ID.EQ(sqlite.String(token.ID)).
+			AND(table.UserTokens.UsedAt.IS_NULL()),
+	)
+
+	result, err := update.ExecContext(ctx, r.db.db)
+	if err != nil {
+		return nil, errtrace.Wrap(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, errtrace.Wrap(err)
+	}
+
+	if rowsAffected == 0 {
+		return nil, errtrace.Wrap(ErrUserTokenInvalid)
+	}
+
+	return token, nil
+}
+
+// Invalidate marks every unused token of the user for purpose as used, so
+// only the most recently issued one is valid.
+func (r *UserTokenRepository) Invalidate(ctx context.Context, userID string, purpose domain.UserTokenPurpose) error {
+	stmt := table.UserTokens.UPDATE().
+		SET(
+			table.UserTokens.UsedAt.SET(sqlite.DATETIME(time.Now())),
+		).WHERE(
+		table.UserTokens.UserID.EQ(sqlite.String(userID)).
+			AND(table.UserTokens.Purpose.EQ(sqlite.String(string(purpose)))).
+			AND(table.UserTokens.UsedAt.IS_NULL()),
+	)
+
+	_, err := stmt.ExecContext(ctx, r.db.db)
+	return errtrace.Wrap(err)
+}
+
+// LastIssuedAt returns when the newest token for purpose was created, nil
+// when the user never got one.
+func (r *UserTokenRepository) LastIssuedAt(ctx context.Context, userID string, purpose domain.UserTokenPurpose) (*time.Time, error) {
+	stmt := table.UserTokens.SELECT(
+		table.UserTokens.AllColumns,
+	).WHERE(
+		table.UserTokens.UserID.EQ(sqlite.String(userID)).
+			AND(table.UserTokens.Purpose.EQ(sqlite.String(string(purpose)))),
+	).ORDER_BY(
+		table.UserTokens.CreatedAt.DESC(),
+	).LIMIT(1)
+
+	var dbToken model.UserTokens
+	if err := stmt.QueryContext(ctx, r.db.db, &dbToken); err != nil {
+		if errors.Is(err, qrm.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, errtrace.Wrap(err)
+	}
+
+	return &dbToken.CreatedAt, nil
+}
@@ -0,0 +1,4 @@
+ALTER TABLE users ADD COLUMN verified_at DATETIME;
+
+-- accounts created before verification existed are trusted as is
+UPDATE users SET verified_at = created_at WHERE verified_at IS NULL;
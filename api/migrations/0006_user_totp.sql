@@ -0,0 +1,18 @@
+-- TOTP second factor, enabled_at stays NULL until the user confirms a code
+CREATE TABLE IF NOT EXISTS user_totp (
+    user_id    TEXT PRIMARY KEY,
+    secret     TEXT NOT NULL,
+    enabled_at DATETIME,
+    last_step  INTEGER NOT NULL DEFAULT 0,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS user_recovery_codes (
+    id         TEXT PRIMARY KEY,
+    user_id    TEXT NOT NULL,
+    code_hash  TEXT NOT NULL,
+    used_at    DATETIME,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_recovery_codes_user_id ON user_recovery_codes (user_id);
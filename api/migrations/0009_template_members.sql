@@ -0,0 +1,33 @@
+-- co-hosts of a user template, role is owner, editor or viewer
+CREATE TABLE IF NOT EXISTS template_members (
+    id               TEXT PRIMARY KEY,
+    user_template_id TEXT NOT NULL REFERENCES user_templates (id) ON DELETE CASCADE,
+    user_id          TEXT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    role             TEXT NOT NULL,
+    created_at       DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (user_template_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_template_members_user_id ON template_members (user_id);
+
+-- emailed invitations to join a user template, only the sha256 of the token
+-- is stored
+CREATE TABLE IF NOT EXISTS template_invites (
+    id               TEXT PRIMARY KEY,
+    user_template_id TEXT NOT NULL REFERENCES user_templates (id) ON DELETE CASCADE,
+    email            TEXT NOT NULL,
+    role             TEXT NOT NULL,
+    token_hash       TEXT NOT NULL UNIQUE,
+    invited_by       TEXT NOT NULL,
+    expires_at       DATETIME NOT NULL,
+    accepted_at      DATETIME,
+    created_at       DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_template_invites_user_template_id ON template_invites (user_template_id);
+
+-- the single owner of existing templates becomes their first member
+INSERT OR IGNORE INTO template_members (id, user_template_id, user_id, role, created_at)
+SELECT lower(hex(randomblob(16))), id, user_id, 'owner', COALESCE(created_at, CURRENT_TIMESTAMP)
+FROM user_templates
+WHERE user_id IS NOT NULL AND user_id != '';
@@ -0,0 +1,7 @@
+-- accounts scheduled for deletion, the account and its data are purged once
+-- delete_after has passed unless the user cancels before
+CREATE TABLE IF NOT EXISTS account_deletions (
+    user_id      TEXT PRIMARY KEY,
+    requested_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    delete_after DATETIME NOT NULL
+);
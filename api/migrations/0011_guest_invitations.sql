@@ -0,0 +1,7 @@
+-- invite_nonce identifies the public invitation link of a guest, the link
+-- carries it with an HMAC signature. NULL means the link was revoked.
+ALTER TABLE guests ADD COLUMN invite_nonce TEXT;
+
+UPDATE guests SET invite_nonce = lower(hex(randomblob(12))) WHERE invite_nonce IS NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS guests_invite_nonce ON guests (invite_nonce);
@@ -0,0 +1,19 @@
+-- attend_person is the headcount confirmed by the guest, at most person.
+-- rsvp_answers maps rsvp_questions.id to the answer of the guest.
+ALTER TABLE guests ADD COLUMN attend_person INTEGER;
+ALTER TABLE guests ADD COLUMN rsvp_answers TEXT NOT NULL DEFAULT '{}';
+
+-- questions asked on the RSVP form of a user template, type is choice, text
+-- or number, options is the JSON array of choices
+CREATE TABLE IF NOT EXISTS rsvp_questions (
+    id               TEXT PRIMARY KEY,
+    user_template_id TEXT NOT NULL REFERENCES user_templates (id) ON DELETE CASCADE,
+    label            TEXT NOT NULL,
+    type             TEXT NOT NULL,
+    options          TEXT NOT NULL DEFAULT '[]',
+    required         BOOLEAN NOT NULL DEFAULT 0,
+    position         INTEGER NOT NULL DEFAULT 0,
+    created_at       DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_rsvp_questions_user_template_id ON rsvp_questions (user_template_id);
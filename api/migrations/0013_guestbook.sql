@@ -0,0 +1,13 @@
+-- guestbook moderation of guests.message, message_status is approved,
+-- pending or hidden and message_at is when the message was last written
+ALTER TABLE guests ADD COLUMN message_status TEXT NOT NULL DEFAULT 'approved';
+ALTER TABLE guests ADD COLUMN message_pinned BOOLEAN NOT NULL DEFAULT 0;
+ALTER TABLE guests ADD COLUMN message_at DATETIME;
+UPDATE guests SET message_at = COALESCE(view_at, created_at) WHERE message != '' AND message_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_guests_guestbook ON guests (user_template_id, message_status, message_at);
+
+-- guestbook_approve_first holds new messages until they are approved,
+-- guestbook_blocked_words is a JSON array of words that hold a message
+ALTER TABLE user_templates ADD COLUMN guestbook_approve_first BOOLEAN NOT NULL DEFAULT 0;
+ALTER TABLE user_templates ADD COLUMN guestbook_blocked_words TEXT NOT NULL DEFAULT '[]';
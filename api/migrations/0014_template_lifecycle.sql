@@ -0,0 +1,6 @@
+-- user_templates.state is the lifecycle of the invitation: 0 draft,
+-- 1 published, 2 rsvp closed and 3 archived. Expired is not stored, a
+-- template is expired once expire_at has passed.
+-- rsvp_deadline closes the RSVP form before the invitation expires, NULL
+-- keeps it open until then
+ALTER TABLE user_templates ADD COLUMN rsvp_deadline DATETIME;
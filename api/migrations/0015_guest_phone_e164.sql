@@ -0,0 +1,11 @@
+-- guests.telp is stored in E.164. Numbers written before are rewritten as
+-- numbers of the default guest.phone_country, Indonesia: separators are
+-- dropped, 0812... and 62812... become +62812... Numbers with other
+-- characters are left as they are, the duplicate finder still reads them.
+UPDATE guests SET telp = replace(replace(replace(replace(replace(replace(telp, ' ', ''), '-', ''), '.', ''), '(', ''), ')', ''), '/', '')
+WHERE telp != '';
+
+UPDATE guests SET telp = '+' || substr(telp, 3) WHERE telp GLOB '00[1-9]*' AND telp NOT GLOB '*[^0-9]*';
+UPDATE guests SET telp = '+62' || substr(telp, 2) WHERE telp GLOB '0[1-9]*' AND telp NOT GLOB '*[^0-9]*';
+UPDATE guests SET telp = '+' || telp WHERE telp GLOB '62[1-9]*' AND telp NOT GLOB '*[^0-9]*';
+UPDATE guests SET telp = '+62' || telp WHERE telp GLOB '[1-9]*' AND telp NOT GLOB '*[^0-9]*';
@@ -0,0 +1,17 @@
+-- the named members of a guest invited as a household, a plus_one member may
+-- be unnamed until the guest names them. attend is the RSVP of the member,
+-- guests.person and guests.attend_person are kept as the number of members
+-- and of members who attend.
+CREATE TABLE IF NOT EXISTS guest_members (
+    id               TEXT PRIMARY KEY,
+    guest_id         TEXT NOT NULL REFERENCES guests (id) ON DELETE CASCADE,
+    user_template_id TEXT NOT NULL,
+    name             TEXT NOT NULL DEFAULT '',
+    plus_one         BOOLEAN NOT NULL DEFAULT 0,
+    attend           BOOLEAN,
+    position         INTEGER NOT NULL DEFAULT 0,
+    created_at       DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_guest_members_guest_id ON guest_members (guest_id, position);
+CREATE INDEX IF NOT EXISTS idx_guest_members_user_template_id ON guest_members (user_template_id);
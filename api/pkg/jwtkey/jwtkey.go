@@ -0,0 +1,240 @@
+package jwtkey
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+
+	"braces.dev/errtrace"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+	AlgEdDSA = "EdDSA"
+)
+
+var (
+	ErrNoSigningKey = errors.New("no active signing key configured")
+	ErrUnknownKey   = errors.New("unknown signing key id")
+)
+
+// KeyOption describes one key of the set. HS256 keys use Secret, RS256 and
+// EdDSA keys use PEM files. A key without PrivateKeyFile can only verify,
+// which is how retired keys are kept around during rotation.
+type KeyOption struct {
+	ID             string
+	Algorithm      string
+	Secret         string
+	PrivateKeyFile string
+	PublicKeyFile  string
+}
+
+type key struct {
+	id     string
+	method jwt.SigningMethod
+	sign   any
+	verify any
+}
+
+// KeySet signs tokens with the active key and verifies tokens signed with any
+// key of the set, selected by the "kid" header.
+type KeySet struct {
+	active string
+	keys   map[string]key
+}
+
+// Load builds a KeySet from options, active is the kid used for signing.
+func Load(active string, opts []KeyOption) (*KeySet, error) {
+	ks := &KeySet{active: active, keys: make(map[string]key, len(opts))}
+
+	for _, opt := range opts {
+		if opt.ID == "" {
+			return nil, errtrace.Wrap(errors.New("signing key id (kid) is required"))
+		}
+		if _, ok := ks.keys[opt.ID]; ok {
+			return nil, errtrace.Wrap(fmt.Errorf("duplicate signing key id: %s", opt.ID))
+		}
+
+		k, err := loadKey(opt)
+		if err != nil {
+			return nil, errtrace.Wrap(fmt.Errorf("load signing key %s: %w", opt.ID, err))
+		}
+		ks.keys[opt.ID] = k
+	}
+
+	signer, ok := ks.keys[active]
+	if !ok || signer.sign == nil {
+		return nil, errtrace.Wrap(ErrNoSigningKey)
+	}
+
+	return ks, nil
+}
+
+func loadKey(opt KeyOption) (key, error) {
+	k := key{id: opt.ID}
+
+	switch opt.Algorithm {
+	case AlgHS256, "":
+		if len(opt.Secret) < 32 {
+			return k, errtrace.Wrap(errors.New("HS256 secret must be at least 32 bytes"))
+		}
+		k.method = jwt.SigningMethodHS256
+		k.sign = []byte(opt.Secret)
+		k.verify = []byte(opt.Secret)
+	case AlgRS256:
+		k.method = jwt.SigningMethodRS256
+		if opt.PrivateKeyFile != "" {
+			pemBytes, err := os.ReadFile(opt.PrivateKeyFile)
+			if err != nil {
+				return k, errtrace.Wrap(err)
+			}
+			private, err := jwt.ParseRSAPrivateKeyFromPEM(pemBytes)
+			if err != nil {
+				return k, errtrace.Wrap(err)
+			}
+			k.sign = private
+			k.verify = &private.PublicKey
+		}
+		if opt.PublicKeyFile != "" {
+			pemBytes, err := os.ReadFile(opt.PublicKeyFile)
+			if err != nil {
+				return k, errtrace.Wrap(err)
+			}
+			public, err := jwt.ParseRSAPublicKeyFromPEM(pemBytes)
+			if err != nil {
+				return k, errtrace.Wrap(err)
+			}
+			k.verify = public
+		}
+	case AlgEdDSA:
+		k.method = jwt.SigningMethodEdDSA
+		if opt.PrivateKeyFile != "" {
+			pemBytes, err := os.ReadFile(opt.PrivateKeyFile)
+			if err != nil {
+				return k, errtrace.Wrap(err)
+			}
+			private, err := jwt.ParseEdPrivateKeyFromPEM(pemBytes)
+			if err != nil {
+				return k, errtrace.Wrap(err)
+			}
+			k.sign = private
+			k.verify = private.(ed25519.PrivateKey).Public()
+		}
+		if opt.PublicKeyFile != "" {
+			pemBytes, err := os.ReadFile(opt.PublicKeyFile)
+			if err != nil {
+				return k, errtrace.Wrap(err)
+			}
+			public, err := jwt.ParseEdPublicKeyFromPEM(pemBytes)
+			if err != nil {
+				return k, errtrace.Wrap(err)
+			}
+			k.verify = public
+		}
+	default:
+		return k, errtrace.Wrap(fmt.Errorf("unsupported algorithm: %s", opt.Algorithm))
+	}
+
+	if k.verify == nil {
+		return k, errtrace.Wrap(errors.New("private_key_file or public_key_file is required"))
+	}
+
+	return k, nil
+}
+
+// Sign signs claims with the active key and sets the "kid" header.
+func (ks *KeySet) Sign(claims jwt.Claims) (string, error) {
+	k := ks.keys[ks.active]
+
+	token := jwt.NewWithClaims(k.method, claims)
+	token.Header["kid"] = k.id
+
+	return errtrace.Wrap2(token.SignedString(k.sign))
+}
+
+// Parse verifies tokenString into claims. The key is chosen by "kid" and the
+// token algorithm must be the one configured for that key.
+func (ks *KeySet) Parse(tokenString string, claims jwt.Claims) (*jwt.Token, error) {
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+
+		k, ok := ks.keys[kid]
+		if !ok {
+			return nil, errtrace.Wrap(ErrUnknownKey)
+		}
+
+		if token.Method.Alg() != k.method.Alg() {
+			return nil, errtrace.Wrap(fmt.Errorf("unexpected signing method: %s", token.Method.Alg()))
+		}
+
+		return k.verify, nil
+	}, jwt.WithValidMethods([]string{AlgHS256, AlgRS256, AlgEdDSA}), jwt.WithExpirationRequired())
+
+	return token, errtrace.Wrap(err)
+}
+
+// JWK is a single JSON Web Key, only public parameters are ever exposed.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns the public keys of the set, HS256 secrets are never published.
+func (ks *KeySet) JWKS() JWKS {
+	result := JWKS{Keys: []JWK{}}
+
+	for _, k := range ks.keys {
+		if jwk, ok := publicJWK(k.id, k.method.Alg(), k.verify); ok {
+			result.Keys = append(result.Keys, jwk)
+		}
+	}
+
+	sort.Slice(result.Keys, func(i, j int) bool { return result.Keys[i].Kid < result.Keys[j].Kid })
+
+	return result
+}
+
+func publicJWK(kid, alg string, public crypto.PublicKey) (JWK, bool) {
+	enc := base64.RawURLEncoding
+
+	switch pub := public.(type) {
+	case *rsa.PublicKey:
+		return JWK{
+			Kty: "RSA",
+			Kid: kid,
+			Use: "sig",
+			Alg: alg,
+			N:   enc.EncodeToString(pub.N.Bytes()),
+			E:   enc.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}, true
+	case ed25519.PublicKey:
+		return JWK{
+			Kty: "OKP",
+			Kid: kid,
+			Use: "sig",
+			Alg: alg,
+			Crv: "Ed25519",
+			X:   enc.EncodeToString(pub),
+		}, true
+	}
+
+	return JWK{}, false
+}
@@ -0,0 +1,267 @@
+package jwtkey
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	secretA = "0123456789abcdef0123456789abcdef"
+	secretB = "fedcba9876543210fedcba9876543210"
+)
+
+// writePEM writes key as a PKCS8 private key and its PKIX public key to dir
+func writePEM(t *testing.T, dir, name string, private, public any) (string, string) {
+	t.Helper()
+
+	privateDER, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicDER, err := x509.MarshalPKIXPublicKey(public)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	privateFile := filepath.Join(dir, name+".key")
+	publicFile := filepath.Join(dir, name+".pub")
+	if err := os.WriteFile(privateFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(publicFile, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return privateFile, publicFile
+}
+
+// testKeys returns an HS256, an RS256 and a verify only EdDSA key, the
+// private keys are returned to forge tokens with.
+func testKeys(t *testing.T) ([]KeyOption, *rsa.PrivateKey, ed25519.PrivateKey) {
+	t.Helper()
+	dir := t.TempDir()
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaPrivate, _ := writePEM(t, dir, "rsa", rsaKey, &rsaKey.PublicKey)
+
+	edPublic, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, edPublicFile := writePEM(t, dir, "ed", edKey, edPublic)
+
+	opts := []KeyOption{
+		{ID: "hs", Algorithm: AlgHS256, Secret: secretA},
+		{ID: "rs", Algorithm: AlgRS256, PrivateKeyFile: rsaPrivate},
+		{ID: "ed", Algorithm: AlgEdDSA, PublicKeyFile: edPublicFile},
+	}
+
+	return opts, rsaKey, edKey
+}
+
+func testKeySet(t *testing.T, active string) (*KeySet, *rsa.PrivateKey, ed25519.PrivateKey) {
+	t.Helper()
+
+	opts, rsaKey, edKey := testKeys(t)
+	ks, err := Load(active, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return ks, rsaKey, edKey
+}
+
+func validClaims() jwt.RegisteredClaims {
+	return jwt.RegisteredClaims{
+		Subject:   "user",
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+	}
+}
+
+func forge(t *testing.T, method jwt.SigningMethod, kid string, claims jwt.Claims, key any) string {
+	t.Helper()
+
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func TestLoadHS256SecretLength(t *testing.T) {
+	tests := []struct {
+		name    string
+		secret  string
+		wantErr bool
+	}{
+		{"empty", "", true},
+		{"31 bytes", secretA[:31], true},
+		{"32 bytes", secretA, false},
+		{"64 bytes", secretA + secretB, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, alg := range []string{AlgHS256, ""} {
+				_, err := Load("k", []KeyOption{{ID: "k", Algorithm: alg, Secret: tt.secret}})
+				if (err != nil) != tt.wantErr {
+					t.Errorf("Load(alg %q) error = %v, want error %v", alg, err, tt.wantErr)
+				}
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name   string
+		active string
+		opts   []KeyOption
+		err    error
+	}{
+		{"missing kid", "", []KeyOption{{Secret: secretA}}, nil},
+		{"duplicate kid", "a", []KeyOption{{ID: "a", Secret: secretA}, {ID: "a", Secret: secretB}}, nil},
+		{"unsupported algorithm", "a", []KeyOption{{ID: "a", Algorithm: "none", Secret: secretA}}, nil},
+		{"no key files", "a", []KeyOption{{ID: "a", Algorithm: AlgRS256}}, nil},
+		{"unknown active key", "b", []KeyOption{{ID: "a", Secret: secretA}}, ErrNoSigningKey},
+		{"no keys", "a", nil, ErrNoSigningKey},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load(tt.active, tt.opts)
+			if err == nil {
+				t.Fatal("Load succeeded, want error")
+			}
+			if tt.err != nil && !errors.Is(err, tt.err) {
+				t.Errorf("Load error = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestLoadVerifyOnlyKeyCannotBeActive(t *testing.T) {
+	opts, _, _ := testKeys(t)
+
+	if _, err := Load("ed", opts); !errors.Is(err, ErrNoSigningKey) {
+		t.Errorf("Load error = %v, want %v", err, ErrNoSigningKey)
+	}
+}
+
+func TestSignParse(t *testing.T) {
+	for _, active := range []string{"hs", "rs"} {
+		t.Run(active, func(t *testing.T) {
+			ks, _, _ := testKeySet(t, active)
+
+			signed, err := ks.Sign(validClaims())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var claims jwt.RegisteredClaims
+			token, err := ks.Parse(signed, &claims)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if token.Header["kid"] != active || claims.Subject != "user" {
+				t.Errorf("Parse = kid %v subject %q, want kid %s subject user", token.Header["kid"], claims.Subject, active)
+			}
+		})
+	}
+}
+
+func TestParseVerifyOnlyKey(t *testing.T) {
+	ks, _, edKey := testKeySet(t, "hs")
+
+	var claims jwt.RegisteredClaims
+	if _, err := ks.Parse(forge(t, jwt.SigningMethodEdDSA, "ed", validClaims(), edKey), &claims); err != nil {
+		t.Errorf("Parse of a token signed by a retired key: %v", err)
+	}
+}
+
+func TestParseRejects(t *testing.T) {
+	ks, rsaKey, edKey := testKeySet(t, "hs")
+	_, otherEd, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	noExpiry := jwt.RegisteredClaims{Subject: "user"}
+	expired := jwt.RegisteredClaims{Subject: "user", ExpiresAt: jwt.NewNumericDate(time.Now().Add(-time.Minute))}
+	unsigned, err := jwt.NewWithClaims(jwt.SigningMethodNone, validClaims()).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		err   error
+	}{
+		{"missing kid", forge(t, jwt.SigningMethodHS256, "", validClaims(), []byte(secretA)), ErrUnknownKey},
+		{"unknown kid", forge(t, jwt.SigningMethodHS256, "other", validClaims(), []byte(secretA)), ErrUnknownKey},
+		{"wrong secret", forge(t, jwt.SigningMethodHS256, "hs", validClaims(), []byte(secretB)), jwt.ErrTokenSignatureInvalid},
+		{"RS256 token with an HS256 kid", forge(t, jwt.SigningMethodRS256, "hs", validClaims(), rsaKey), jwt.ErrTokenUnverifiable},
+		{"HS256 token with an RS256 kid", forge(t, jwt.SigningMethodHS256, "rs", validClaims(), []byte(secretA)), jwt.ErrTokenUnverifiable},
+		{"HS384 token with an HS256 kid", forge(t, jwt.SigningMethodHS384, "hs", validClaims(), []byte(secretA)), jwt.ErrTokenSignatureInvalid},
+		{"EdDSA token with an RS256 kid", forge(t, jwt.SigningMethodEdDSA, "rs", validClaims(), edKey), jwt.ErrTokenUnverifiable},
+		{"EdDSA token signed by another key", forge(t, jwt.SigningMethodEdDSA, "ed", validClaims(), otherEd), jwt.ErrTokenSignatureInvalid},
+		{"alg none", unsigned, jwt.ErrTokenSignatureInvalid},
+		{"no expiration", forge(t, jwt.SigningMethodHS256, "hs", noExpiry, []byte(secretA)), jwt.ErrTokenRequiredClaimMissing},
+		{"expired", forge(t, jwt.SigningMethodHS256, "hs", expired, []byte(secretA)), jwt.ErrTokenExpired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var claims jwt.RegisteredClaims
+			_, err := ks.Parse(tt.token, &claims)
+			if err == nil {
+				t.Fatal("Parse succeeded, want error")
+			}
+			if !errors.Is(err, tt.err) {
+				t.Errorf("Parse error = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestJWKS(t *testing.T) {
+	ks, _, _ := testKeySet(t, "hs")
+
+	jwks := ks.JWKS()
+	if len(jwks.Keys) != 2 {
+		t.Fatalf("JWKS has %d keys, want 2", len(jwks.Keys))
+	}
+
+	ed, rs := jwks.Keys[0], jwks.Keys[1]
+	if ed.Kid != "ed" || ed.Kty != "OKP" || ed.Crv != "Ed25519" || ed.X == "" {
+		t.Errorf("EdDSA JWK = %+v", ed)
+	}
+	if rs.Kid != "rs" || rs.Kty != "RSA" || rs.Alg != AlgRS256 || rs.E != "AQAB" || rs.N == "" {
+		t.Errorf("RS256 JWK = %+v", rs)
+	}
+	for _, k := range jwks.Keys {
+		if strings.Contains(k.N+k.X, secretA) || k.Kid == "hs" {
+			t.Errorf("JWKS exposes the HS256 key: %+v", k)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"braces.dev/errtrace"
+)
+
+// File writes every message as an .eml file into Dir and logs it, it is
+// meant for local development.
+type File struct {
+	Dir  string
+	From string
+}
+
+func NewFile(dir, from string) *File {
+	return &File{Dir: dir, From: from}
+}
+
+func (m *File) Send(ctx context.Context, msg Message) error {
+	if err := sanitize(msg); err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	if err := os.MkdirAll(m.Dir, 0o755); err != nil {
+		return errtrace.Wrap(fmt.Errorf("failed to create mail directory: %w", err))
+	}
+
+	filePath := filepath.Join(m.Dir, fmt.Sprintf("%d.eml", time.Now().UnixNano()))
+	if err := os.WriteFile(filePath, build(m.From, msg), 0o600); err != nil {
+		return errtrace.Wrap(fmt.Errorf("failed to write mail: %w", err))
+	}
+
+	log.Printf("mail to %s %q written to %s", msg.To, msg.Subject, filePath)
+	return nil
+}
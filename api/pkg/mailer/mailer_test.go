@@ -0,0 +1,47 @@
+package mailer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileSend(t *testing.T) {
+	dir := t.TempDir()
+	m := NewFile(dir, "noreply@example.com")
+
+	err := m.Send(context.Background(), Message{To: "a@example.com", Subject: "Hello", Body: "line 1\nline 2"})
+	if err != nil {
+		t.Fatalf("send: %v", err)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*.eml"))
+	if len(files) != 1 {
+		t.Fatalf("got %d files, want 1", len(files))
+	}
+
+	raw, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"From: noreply@example.com\r\n", "To: a@example.com\r\n", "Subject: Hello\r\n", "\r\n\r\nline 1\r\nline 2"} {
+		if !strings.Contains(string(raw), want) {
+			t.Errorf("message does not contain %q:\n%s", want, raw)
+		}
+	}
+}
+
+func TestSendRejectsHeaderInjection(t *testing.T) {
+	m := NewFile(t.TempDir(), "noreply@example.com")
+
+	for _, msg := range []Message{
+		{To: "a@example.com\r\nBcc: b@example.com", Subject: "Hello"},
+		{To: "a@example.com", Subject: "Hello\nBcc: b@example.com"},
+	} {
+		if err := m.Send(context.Background(), msg); err == nil {
+			t.Errorf("Send(%q, %q) succeeded", msg.To, msg.Subject)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+
+	"braces.dev/errtrace"
+)
+
+type SMTPOption struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+// SMTP sends through an SMTP relay, STARTTLS is used when the server offers
+// it and authentication only when a username is configured.
+type SMTP struct {
+	opt SMTPOption
+}
+
+func NewSMTP(opt SMTPOption) *SMTP {
+	if opt.Port == 0 {
+		opt.Port = 587
+	}
+	return &SMTP{opt: opt}
+}
+
+func (m *SMTP) Send(ctx context.Context, msg Message) error {
+	if err := sanitize(msg); err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	var auth smtp.Auth
+	if m.opt.Username != "" {
+		auth = smtp.PlainAuth("", m.opt.Username, m.opt.Password, m.opt.Host)
+	}
+
+	addr := net.JoinHostPort(m.opt.Host, strconv.Itoa(m.opt.Port))
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- smtp.SendMail(addr, auth, m.opt.From, []string{msg.To}, build(m.opt.From, msg))
+	}()
+
+	select {
+	case err := <-errCh:
+		if err != nil {
+			return errtrace.Wrap(fmt.Errorf("smtp send: %w", err))
+		}
+		return nil
+	case <-ctx.Done():
+		return errtrace.Wrap(ctx.Err())
+	}
+}
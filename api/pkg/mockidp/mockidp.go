@@ -0,0 +1,244 @@
+// Package mockidp is a minimal OpenID Connect provider for local development
+// and manual testing of the OIDC login. It approves every authorization
+// request without a login page, the signed in email comes from the
+// login_hint parameter or the configured default. Never expose it publicly.
+package mockidp
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"braces.dev/errtrace"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	keyID   = "mock"
+	codeTTL = time.Minute
+)
+
+type Option struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	// Email is used when the authorization request has no login_hint
+	Email string
+	Name  string
+	// Unverified makes the provider report email_verified=false
+	Unverified bool
+}
+
+type grant struct {
+	redirectURI string
+	challenge   string
+	nonce       string
+	email       string
+	expiresAt   time.Time
+}
+
+type Server struct {
+	opt   Option
+	key   *rsa.PrivateKey
+	mu    sync.Mutex
+	codes map[string]grant
+}
+
+func New(opt Option) (*Server, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, errtrace.Wrap(err)
+	}
+
+	opt.Issuer = strings.TrimSuffix(opt.Issuer, "/")
+
+	return &Server{opt: opt, key: key, codes: map[string]grant{}}, nil
+}
+
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", s.discovery)
+	mux.HandleFunc("GET /authorize", s.authorize)
+	mux.HandleFunc("POST /token", s.token)
+	mux.HandleFunc("GET /jwks", s.jwks)
+	return mux
+}
+
+func (s *Server) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"issuer":                                s.opt.Issuer,
+		"authorization_endpoint":                s.opt.Issuer + "/authorize",
+		"token_endpoint":                        s.opt.Issuer + "/token",
+		"jwks_uri":                              s.opt.Issuer + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"code_challenge_methods_supported":      []string{"S256"},
+		"scopes_supported":                      []string{"openid", "profile", "email"},
+	})
+}
+
+func (s *Server) authorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	if query.Get("client_id") != s.opt.ClientID {
+		writeError(w, "unauthorized_client", "unknown client_id")
+		return
+	}
+	if query.Get("response_type") != "code" {
+		writeError(w, "unsupported_response_type", "only code is supported")
+		return
+	}
+	if query.Get("code_challenge") == "" || query.Get("code_challenge_method") != "S256" {
+		writeError(w, "invalid_request", "PKCE with S256 is required")
+		return
+	}
+
+	redirectURI, err := url.Parse(query.Get("redirect_uri"))
+	if err != nil || redirectURI.Scheme == "" {
+		writeError(w, "invalid_request", "invalid redirect_uri")
+		return
+	}
+
+	email := query.Get("login_hint")
+	if email == "" {
+		email = s.opt.Email
+	}
+
+	code := randomString()
+	s.mu.Lock()
+	s.codes[code] = grant{
+		redirectURI: redirectURI.String(),
+		challenge:   query.Get("code_challenge"),
+		nonce:       query.Get("nonce"),
+		email:       email,
+		expiresAt:   time.Now().Add(codeTTL),
+	}
+	s.mu.Unlock()
+
+	values := redirectURI.Query()
+	values.Set("code", code)
+	values.Set("state", query.Get("state"))
+	redirectURI.RawQuery = values.Encode()
+
+	http.Redirect(w, r, redirectURI.String(), http.StatusFound)
+}
+
+func (s *Server) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeError(w, "invalid_request", err.Error())
+		return
+	}
+
+	clientID, clientSecret, ok := r.BasicAuth()
+	if !ok {
+		clientID, clientSecret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+	if clientID != s.opt.ClientID || subtle.ConstantTimeCompare([]byte(clientSecret), []byte(s.opt.ClientSecret)) != 1 {
+		writeError(w, "invalid_client", "invalid client credentials")
+		return
+	}
+
+	if r.PostForm.Get("grant_type") != "authorization_code" {
+		writeError(w, "unsupported_grant_type", "only authorization_code is supported")
+		return
+	}
+
+	code := r.PostForm.Get("code")
+	s.mu.Lock()
+	g, ok := s.codes[code]
+	delete(s.codes, code)
+	s.mu.Unlock()
+
+	if !ok || time.Now().After(g.expiresAt) || g.redirectURI != r.PostForm.Get("redirect_uri") {
+		writeError(w, "invalid_grant", "invalid code")
+		return
+	}
+
+	sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if base64.RawURLEncoding.EncodeToString(sum[:]) != g.challenge {
+		writeError(w, "invalid_grant", "code_verifier does not match")
+		return
+	}
+
+	idToken, err := s.idToken(g)
+	if err != nil {
+		writeError(w, "server_error", err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"access_token": randomString(),
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     idToken,
+	})
+}
+
+func (s *Server) idToken(g grant) (string, error) {
+	now := time.Now()
+	subject := sha256.Sum256([]byte(g.email))
+
+	name := s.opt.Name
+	if name == "" {
+		name, _, _ = strings.Cut(g.email, "@")
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":            s.opt.Issuer,
+		"sub":            hex.EncodeToString(subject[:8]),
+		"aud":            s.opt.ClientID,
+		"iat":            now.Unix(),
+		"exp":            now.Add(time.Hour).Unix(),
+		"nonce":          g.nonce,
+		"email":          g.email,
+		"email_verified": !s.opt.Unverified,
+		"name":           name,
+	})
+	token.Header["kid"] = keyID
+
+	return errtrace.Wrap2(token.SignedString(s.key))
+}
+
+func (s *Server) jwks(w http.ResponseWriter, r *http.Request) {
+	enc := base64.RawURLEncoding
+	writeJSON(w, http.StatusOK, map[string]any{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": keyID,
+			"use": "sig",
+			"alg": "RS256",
+			"n":   enc.EncodeToString(s.key.N.Bytes()),
+			"e":   enc.EncodeToString(big.NewInt(int64(s.key.E)).Bytes()),
+		}},
+	})
+}
+
+func randomString() string {
+	buf := make([]byte, 24)
+	_, _ = rand.Read(buf)
+	return base64.RawURLEncoding.EncodeToString(buf)
+}
+
+func writeError(w http.ResponseWriter, code, description string) {
+	writeJSON(w, http.StatusBadRequest, map[string]string{
+		"error":             code,
+		"error_description": description,
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
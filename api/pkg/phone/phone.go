@@ -0,0 +1,124 @@
+// Package phone normalizes phone numbers to E.164, numbers written without a
+// country code are read as numbers of a default country.
+package phone
+
+import (
+	"errors"
+	"strings"
+
+	"braces.dev/errtrace"
+)
+
+// DefaultCountry is used when no country is configured
+const DefaultCountry = "ID"
+
+var (
+	ErrInvalid        = errors.New("invalid phone number")
+	ErrUnknownCountry = errors.New("unknown phone country")
+)
+
+type country struct {
+	code  string // calling code
+	trunk string // prefix of national numbers, dropped in E.164
+}
+
+// countries are the ISO 3166 countries a default can be set to
+var countries = map[string]country{
+	"ID": {code: "62", trunk: "0"},
+	"MY": {code: "60", trunk: "0"},
+	"SG": {code: "65"},
+	"BN": {code: "673"},
+	"TL": {code: "670"},
+	"TH": {code: "66", trunk: "0"},
+	"PH": {code: "63", trunk: "0"},
+	"VN": {code: "84", trunk: "0"},
+	"AU": {code: "61", trunk: "0"},
+	"NL": {code: "31", trunk: "0"},
+	"GB": {code: "44", trunk: "0"},
+	"DE": {code: "49", trunk: "0"},
+	"SA": {code: "966", trunk: "0"},
+	"JP": {code: "81", trunk: "0"},
+	"KR": {code: "82", trunk: "0"},
+	"US": {code: "1", trunk: "1"},
+	"CA": {code: "1", trunk: "1"},
+}
+
+// Known reports whether country can be used as a default.
+func Known(country string) bool {
+	_, ok := countries[strings.ToUpper(country)]
+	return ok
+}
+
+// Normalize returns number in E.164. Spaces, dashes, dots, slashes and
+// parentheses are ignored, a number starting with + or 00 is international
+// and any other is a number of defaultCountry, with or without its trunk
+// prefix or calling code. An empty number stays empty.
+func Normalize(number, defaultCountry string) (string, error) {
+	if strings.TrimSpace(number) == "" {
+		if !Known(defaultCountry) {
+			return "", errtrace.Wrap(ErrUnknownCountry)
+		}
+		return "", nil
+	}
+
+	normalized, err := Prefix(number, defaultCountry)
+	if err != nil {
+		return "", err
+	}
+
+	// E.164 numbers have at most 15 digits, the shortest ones in use have 8
+	digits := strings.TrimPrefix(normalized, "+")
+	if len(digits) < 8 || len(digits) > 15 || digits[0] == '0' {
+		return "", errtrace.Wrap(ErrInvalid)
+	}
+
+	return normalized, nil
+}
+
+// Prefix reads number like Normalize without checking its length, so the
+// start of a number gives the start of its E.164 form. It is meant for
+// searching numbers stored by Normalize.
+func Prefix(number, defaultCountry string) (string, error) {
+	c, ok := countries[strings.ToUpper(defaultCountry)]
+	if !ok {
+		return "", errtrace.Wrap(ErrUnknownCountry)
+	}
+
+	number = strings.TrimSpace(number)
+	international := strings.HasPrefix(number, "+")
+	number = strings.TrimPrefix(number, "+")
+
+	var digits strings.Builder
+	for _, r := range number {
+		switch {
+		case r >= '0' && r <= '9':
+			digits.WriteRune(r)
+		case strings.ContainsRune(" -./()", r):
+		default:
+			return "", errtrace.Wrap(ErrInvalid)
+		}
+	}
+
+	national := digits.String()
+	if national == "" {
+		return "", errtrace.Wrap(ErrInvalid)
+	}
+
+	switch {
+	case international:
+	case strings.HasPrefix(national, "00"):
+		national = national[2:]
+	case c.trunk != "" && strings.HasPrefix(national, c.trunk):
+		national = c.code + national[len(c.trunk):]
+	case strings.HasPrefix(national, c.code):
+	default:
+		national = c.code + national
+	}
+
+	// only a 00 prefix
+	if national == "" {
+		return "", errtrace.Wrap(ErrInvalid)
+	}
+
+	return "+" + national, nil
+}
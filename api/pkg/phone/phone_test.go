@@ -0,0 +1,84 @@
+package phone
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		number, country string
+		want            string
+		err             error
+	}{
+		{"", "ID", "", nil},
+		{"   ", "ID", "", nil},
+		{"081234567890", "ID", "+6281234567890", nil},
+		{"0812-3456-7890", "ID", "+6281234567890", nil},
+		{"(0812) 3456.7890", "ID", "+6281234567890", nil},
+		{"6281234567890", "ID", "+6281234567890", nil},
+		{"81234567890", "ID", "+6281234567890", nil},
+		{"+62 812 3456 7890", "ID", "+6281234567890", nil},
+		{"0062 812 3456 7890", "ID", "+6281234567890", nil},
+		{"+31 6 12345678", "ID", "+31612345678", nil},
+		{"06 12345678", "nl", "+31612345678", nil},
+		{"8123 4567", "SG", "+6581234567", nil},
+		{"1 (415) 555-2671", "US", "+14155552671", nil},
+		{" 081234567890 ", "ID", "+6281234567890", nil},
+		{"0812abc", "ID", "", ErrInvalid},
+		{"+62 812", "ID", "", ErrInvalid},
+		{"+1234567890123456", "ID", "", ErrInvalid},
+		{"+0812345678", "ID", "", ErrInvalid},
+		{"081234567890", "XX", "", ErrUnknownCountry},
+		{"", "XX", "", ErrUnknownCountry},
+	}
+
+	for _, tt := range tests {
+		got, err := Normalize(tt.number, tt.country)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("Normalize(%q, %q) error = %v, want %v", tt.number, tt.country, err, tt.err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Normalize(%q, %q) = %q, want %q", tt.number, tt.country, got, tt.want)
+		}
+	}
+}
+
+func TestKnown(t *testing.T) {
+	for country, want := range map[string]bool{"ID": true, "id": true, "US": true, "XX": false, "": false} {
+		if got := Known(country); got != want {
+			t.Errorf("Known(%q) = %v, want %v", country, got, want)
+		}
+	}
+}
+
+func TestPrefix(t *testing.T) {
+	tests := []struct {
+		number, country string
+		want            string
+		err             error
+	}{
+		{"0812", "ID", "+62812", nil},
+		{"0812-34", "ID", "+6281234", nil},
+		{"62812", "ID", "+62812", nil},
+		{"812", "ID", "+62812", nil},
+		{"+31 6", "ID", "+316", nil},
+		{"081234567890", "ID", "+6281234567890", nil},
+		{"Budi", "ID", "", ErrInvalid},
+		{"-", "ID", "", ErrInvalid},
+		{"00", "ID", "", ErrInvalid},
+		{"0812", "XX", "", ErrUnknownCountry},
+	}
+
+	for _, tt := range tests {
+		got, err := Prefix(tt.number, tt.country)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("Prefix(%q, %q) error = %v, want %v", tt.number, tt.country, err, tt.err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Prefix(%q, %q) = %q, want %q", tt.number, tt.country, got, tt.want)
+		}
+	}
+}
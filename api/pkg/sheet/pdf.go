@@ -0,0 +1,78 @@
+package sheet
+
+import (
+	"fmt"
+	"io"
+
+	"braces.dev/errtrace"
+	"github.com/go-pdf/fpdf"
+)
+
+// Column is a column of a PDF table, Width is in millimetres
+type Column struct {
+	Title string
+	Width float64
+}
+
+// WritePDF writes rows as a printable landscape A4 table with the header
+// repeated on every page. Cells that do not fit are cut with "...".
+func WritePDF(w io.Writer, title, subtitle string, columns []Column, rows [][]string) error {
+	pdf := fpdf.New("L", "mm", "A4", "")
+	pdf.SetMargins(10, 10, 10)
+	pdf.SetAutoPageBreak(true, 15)
+	// core fonts are cp1252, names in other scripts come out as "?"
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+
+	pdf.SetHeaderFunc(func() {
+		if pdf.PageNo() == 1 {
+			pdf.SetFont("Helvetica", "B", 14)
+			pdf.CellFormat(0, 8, tr(title), "", 1, "L", false, 0, "")
+			if subtitle != "" {
+				pdf.SetFont("Helvetica", "", 9)
+				pdf.CellFormat(0, 6, tr(subtitle), "", 1, "L", false, 0, "")
+			}
+			pdf.Ln(2)
+		}
+
+		pdf.SetFont("Helvetica", "B", 9)
+		pdf.SetFillColor(230, 230, 230)
+		for _, c := range columns {
+			pdf.CellFormat(c.Width, 7, tr(c.Title), "1", 0, "L", true, 0, "")
+		}
+		pdf.Ln(-1)
+	})
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-12)
+		pdf.SetFont("Helvetica", "", 8)
+		pdf.CellFormat(0, 6, fmt.Sprintf("%d/{nb}", pdf.PageNo()), "", 0, "R", false, 0, "")
+	})
+	pdf.AliasNbPages("")
+	pdf.AddPage()
+
+	pdf.SetFont("Helvetica", "", 9)
+	for _, row := range rows {
+		for i, c := range columns {
+			var v string
+			if i < len(row) {
+				v = fit(pdf, tr(row[i]), c.Width-2)
+			}
+			pdf.CellFormat(c.Width, 8, v, "1", 0, "L", false, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+
+	return errtrace.Wrap(pdf.Output(w))
+}
+
+// fit cuts s so it is at most width wide in the current font, s is already
+// translated to one byte per character
+func fit(pdf *fpdf.Fpdf, s string, width float64) string {
+	if pdf.GetStringWidth(s) <= width {
+		return s
+	}
+
+	for len(s) > 0 && pdf.GetStringWidth(s+"...") > width {
+		s = s[:len(s)-1]
+	}
+	return s + "..."
+}
@@ -0,0 +1,128 @@
+// Package sheet reads the rows of CSV and XLSX spreadsheets.
+package sheet
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"braces.dev/errtrace"
+	"github.com/xuri/excelize/v2"
+)
+
+// Format is a spreadsheet file format
+type Format string
+
+const (
+	CSV  Format = "csv"
+	XLSX Format = "xlsx"
+)
+
+var (
+	ErrUnsupportedFormat = errors.New("unsupported spreadsheet format, use .csv or .xlsx")
+	ErrTooLarge          = errors.New("spreadsheet is too large once unzipped")
+)
+
+// An XLSX file is a zip archive, these keep a small upload from unzipping
+// into gigabytes. Worksheets above the XML limit are unzipped to a temporary
+// file instead of memory.
+const (
+	unzipSizeLimit    = 50 << 20
+	unzipXMLSizeLimit = 16 << 20
+)
+
+// FormatOf returns the format of a file from its name.
+func FormatOf(filename string) (Format, error) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		return CSV, nil
+	case ".xlsx":
+		return XLSX, nil
+	}
+	return "", errtrace.Wrap(ErrUnsupportedFormat)
+}
+
+// Read returns every row of the file, an XLSX file is read from its first
+// sheet. Cells are trimmed and trailing empty rows are dropped.
+func Read(r io.Reader, format Format) ([][]string, error) {
+	var rows [][]string
+
+	switch format {
+	case CSV:
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return nil, errtrace.Wrap(err)
+		}
+		// spreadsheet programs like to start CSV files with a BOM
+		data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+		cr := csv.NewReader(bytes.NewReader(data))
+		cr.FieldsPerRecord = -1
+		cr.Comma = detectDelimiter(data)
+
+		rows, err = cr.ReadAll()
+		if err != nil {
+			return nil, errtrace.Wrap(err)
+		}
+	case XLSX:
+		f, err := excelize.OpenReader(r, excelize.Options{
+			UnzipSizeLimit:    unzipSizeLimit,
+			UnzipXMLSizeLimit: unzipXMLSizeLimit,
+		})
+		// excelize doesn't export the error of an exceeded unzip limit
+		if err != nil && strings.HasPrefix(err.Error(), "unzip size exceeds") {
+			return nil, errtrace.Wrap(ErrTooLarge)
+		}
+		if err != nil {
+			return nil, errtrace.Wrap(err)
+		}
+		defer f.Close()
+
+		sheets := f.GetSheetList()
+		if len(sheets) == 0 {
+			return nil, nil
+		}
+
+		rows, err = f.GetRows(sheets[0])
+		if err != nil {
+			return nil, errtrace.Wrap(err)
+		}
+	default:
+		return nil, errtrace.Wrap(ErrUnsupportedFormat)
+	}
+
+	for i, row := range rows {
+		for j := range row {
+			row[j] = strings.TrimSpace(row[j])
+		}
+		rows[i] = row
+	}
+
+	for len(rows) > 0 && isEmpty(rows[len(rows)-1]) {
+		rows = rows[:len(rows)-1]
+	}
+
+	return rows, nil
+}
+
+// detectDelimiter picks ";" when the header line has more of them than
+// commas, which is what Excel writes in locales using a decimal comma.
+func detectDelimiter(data []byte) rune {
+	line, _, _ := bytes.Cut(data, []byte("\n"))
+	if bytes.Count(line, []byte(";")) > bytes.Count(line, []byte(",")) {
+		return ';'
+	}
+	return ','
+}
+
+func isEmpty(row []string) bool {
+	for _, v := range row {
+		if v != "" {
+			return false
+		}
+	}
+	return true
+}
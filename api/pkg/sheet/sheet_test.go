@@ -0,0 +1,152 @@
+package sheet
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func TestFormatOf(t *testing.T) {
+	tests := []struct {
+		name string
+		want Format
+		err  error
+	}{
+		{"guests.csv", CSV, nil},
+		{"Guests.XLSX", XLSX, nil},
+		{"guests.xls", "", ErrUnsupportedFormat},
+		{"guests", "", ErrUnsupportedFormat},
+	}
+
+	for _, tt := range tests {
+		got, err := FormatOf(tt.name)
+		if got != tt.want || !errors.Is(err, tt.err) {
+			t.Errorf("FormatOf(%q) = %q, %v, want %q, %v", tt.name, got, err, tt.want, tt.err)
+		}
+	}
+}
+
+func TestReadCSV(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want [][]string
+	}{
+		{
+			"comma",
+			"name,telp\nBudi, 0812 \n",
+			[][]string{{"name", "telp"}, {"Budi", "0812"}},
+		},
+		{
+			"semicolon",
+			"name;telp;tags\nBudi;0812;a,b\n",
+			[][]string{{"name", "telp", "tags"}, {"Budi", "0812", "a,b"}},
+		},
+		{
+			"bom",
+			"\xef\xbb\xbfname,telp\nBudi,0812\n",
+			[][]string{{"name", "telp"}, {"Budi", "0812"}},
+		},
+		{
+			"ragged rows and trailing empty rows",
+			"name,telp\nBudi\nSiti,0813,extra\n,\n",
+			[][]string{{"name", "telp"}, {"Budi"}, {"Siti", "0813", "extra"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Read(strings.NewReader(tt.data), CSV)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadXLSX(t *testing.T) {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	sheet := f.GetSheetName(0)
+	rows := [][]any{{"Name", "Person"}, {" Budi ", 2}, {"Siti", nil}}
+	for i, row := range rows {
+		cell, _ := excelize.CoordinatesToCellName(1, i+1)
+		if err := f.SetSheetRow(sheet, cell, &row); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := f.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := Read(&buf, XLSX)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := [][]string{{"Name", "Person"}, {"Budi", "2"}, {"Siti"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestReadInvalid(t *testing.T) {
+	if _, err := Read(strings.NewReader("name"), "ods"); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Errorf("unknown format: err = %v, want ErrUnsupportedFormat", err)
+	}
+	if _, err := Read(strings.NewReader("not a zip"), XLSX); err == nil {
+		t.Error("reading a broken xlsx file did not fail")
+	}
+	if _, err := Read(strings.NewReader("name\n\"unterminated\n"), CSV); err == nil {
+		t.Error("reading a broken csv file did not fail")
+	}
+}
+
+func TestReadXLSXTooLarge(t *testing.T) {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	var buf bytes.Buffer
+	if err := f.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	// re-zip the file with an entry that deflates to next to nothing
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var bomb bytes.Buffer
+	zw := zip.NewWriter(&bomb)
+	for _, file := range zr.File {
+		if err := zw.Copy(file); err != nil {
+			t.Fatal(err)
+		}
+	}
+	w, err := zw.Create("xl/media/padding.bin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write(make([]byte, unzipSizeLimit+1)); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Read(&bomb, XLSX); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("err = %v, want ErrTooLarge", err)
+	}
+}
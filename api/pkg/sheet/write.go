@@ -0,0 +1,94 @@
+package sheet
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+
+	"braces.dev/errtrace"
+	"github.com/xuri/excelize/v2"
+)
+
+// ContentType returns the MIME type of format
+func ContentType(format Format) string {
+	switch format {
+	case CSV:
+		return "text/csv; charset=utf-8"
+	case XLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "application/octet-stream"
+}
+
+// Neutralize keeps a CSV cell from running as a formula when the file is
+// opened in a spreadsheet: text starting with =, +, -, @, tab or carriage
+// return gets a leading '. Plain signed numbers such as +6281234 are kept.
+func Neutralize(s string) string {
+	if s == "" || !strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return s
+	}
+
+	if len(s) > 1 && (s[0] == '+' || s[0] == '-') && strings.Trim(s[1:], "0123456789") == "" {
+		return s
+	}
+
+	return "'" + s
+}
+
+// Write writes rows to w as CSV or as the only sheet of an XLSX file, the
+// first row is the header. Numbers stay numbers in XLSX, nil is an empty
+// cell. Text is neutralized in CSV, XLSX stores it as text cells that are
+// never evaluated.
+func Write(w io.Writer, format Format, name string, rows [][]any) error {
+	switch format {
+	case CSV:
+		cw := csv.NewWriter(w)
+		for _, row := range rows {
+			record := make([]string, len(row))
+			for i, v := range row {
+				switch v := v.(type) {
+				case nil:
+				case string:
+					record[i] = Neutralize(v)
+				default:
+					record[i] = fmt.Sprint(v)
+				}
+			}
+			if err := cw.Write(record); err != nil {
+				return errtrace.Wrap(err)
+			}
+		}
+		cw.Flush()
+		return errtrace.Wrap(cw.Error())
+	case XLSX:
+		f := excelize.NewFile()
+		defer f.Close()
+
+		if err := f.SetSheetName("Sheet1", name); err != nil {
+			return errtrace.Wrap(err)
+		}
+
+		sw, err := f.NewStreamWriter(name)
+		if err != nil {
+			return errtrace.Wrap(err)
+		}
+
+		for i, row := range rows {
+			cell, err := excelize.CoordinatesToCellName(1, i+1)
+			if err != nil {
+				return errtrace.Wrap(err)
+			}
+			if err := sw.SetRow(cell, row); err != nil {
+				return errtrace.Wrap(err)
+			}
+		}
+
+		if err := sw.Flush(); err != nil {
+			return errtrace.Wrap(err)
+		}
+
+		return errtrace.Wrap(f.Write(w))
+	}
+	return errtrace.Wrap(ErrUnsupportedFormat)
+}
@@ -0,0 +1,98 @@
+package sheet
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestWriteRoundTrip(t *testing.T) {
+	rows := [][]any{{"name", "person", "attend"}, {"Budi", 2, nil}, {"Siti, S.E.", 1, true}}
+	want := [][]string{{"name", "person", "attend"}, {"Budi", "2"}, {"Siti, S.E.", "1", "TRUE"}}
+
+	for _, format := range []Format{CSV, XLSX} {
+		t.Run(string(format), func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := Write(&buf, format, "Guests", rows); err != nil {
+				t.Fatalf("write: %v", err)
+			}
+
+			got, err := Read(&buf, format)
+			if err != nil {
+				t.Fatalf("read: %v", err)
+			}
+
+			// CSV has no types, booleans are written as Go prints them
+			expect := want
+			if format == CSV {
+				expect = [][]string{{"name", "person", "attend"}, {"Budi", "2", ""}, {"Siti, S.E.", "1", "true"}}
+			}
+			if !reflect.DeepEqual(got, expect) {
+				t.Errorf("got %q, want %q", got, expect)
+			}
+		})
+	}
+
+	// text is written as is to XLSX, where it is never evaluated
+	formula := [][]any{{"name"}, {"=HYPERLINK(\"x\")"}}
+	for format, want := range map[Format]string{CSV: "'=HYPERLINK(\"x\")", XLSX: "=HYPERLINK(\"x\")"} {
+		var buf bytes.Buffer
+		if err := Write(&buf, format, "Guests", formula); err != nil {
+			t.Fatalf("write %s: %v", format, err)
+		}
+		got, err := Read(&buf, format)
+		if err != nil {
+			t.Fatalf("read %s: %v", format, err)
+		}
+		if got[1][0] != want {
+			t.Errorf("%s formula cell = %q, want %q", format, got[1][0], want)
+		}
+	}
+
+	if err := Write(&bytes.Buffer{}, "ods", "Guests", rows); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Errorf("unknown format: err = %v, want ErrUnsupportedFormat", err)
+	}
+}
+
+func TestWritePDF(t *testing.T) {
+	columns := []Column{{Title: "Name", Width: 60}, {Title: "Group", Width: 40}}
+
+	rows := make([][]string, 100)
+	for i := range rows {
+		rows[i] = []string{"Guest with a name far too long to fit in its column of the table", "family"}
+	}
+
+	var buf bytes.Buffer
+	if err := WritePDF(&buf, "Guests", "Budi & Siti", columns, rows); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(buf.Bytes(), []byte("%PDF-")) {
+		t.Error("output is not a PDF")
+	}
+}
+
+func TestNeutralize(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"", ""},
+		{"Budi", "Budi"},
+		{"=HYPERLINK(\"x\")", "'=HYPERLINK(\"x\")"},
+		{"@SUM(A1)", "'@SUM(A1)"},
+		{"+cmd|' /C calc'!A0", "'+cmd|' /C calc'!A0"},
+		{"-2+3", "'-2+3"},
+		{"\t=1", "'\t=1"},
+		{"\r=1", "'\r=1"},
+		{"+6281234567", "+6281234567"},
+		{"-12", "-12"},
+		{"+", "'+"},
+		{"a=1", "a=1"},
+	}
+
+	for _, tt := range tests {
+		if got := Neutralize(tt.in); got != tt.want {
+			t.Errorf("Neutralize(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,586 @@
+package usecase
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"basic-service/domain"
+	"basic-service/interface/sql"
+	"basic-service/pkg/mailer"
+	"basic-service/pkg/sheet"
+
+	"braces.dev/errtrace"
+)
+
+const defaultDeletionGracePeriod = 14 * 24 * time.Hour
+
+const (
+	AuditAccountDeletionRequest = "account.deletion_request"
+	AuditAccountDeletionCancel  = "account.deletion_cancel"
+	AuditAccountDelete          = "account.delete"
+)
+
+var ErrAccountDeletionNotFound = errors.New("account deletion is not scheduled")
+
+// Account is the self-service side of an account: exporting the personal
+// data and deleting the account after a grace period.
+type Account struct {
+	auth      *Auth
+	repo      *sql.AccountRepository
+	templates *sql.UserTemplateRepository
+	members   *sql.TemplateMemberRepository
+	guests    *sql.GuestManager
+	// UploadDir and TemplateDir are where uploaded images and extracted
+	// templates are stored
+	UploadDir   string
+	TemplateDir string
+	// GracePeriod is how long a deletion request can be cancelled
+	GracePeriod time.Duration
+}
+
+func NewAccount(
+	auth *Auth,
+	repo *sql.AccountRepository,
+	templates *sql.UserTemplateRepository,
+	members *sql.TemplateMemberRepository,
+	guests *sql.GuestManager,
+) *Account {
+	return &Account{
+		auth:        auth,
+		repo:        repo,
+		templates:   templates,
+		members:     members,
+		guests:      guests,
+		UploadDir:   "./public/uploads",
+		TemplateDir: "./public/template",
+		GracePeriod: defaultDeletionGracePeriod,
+	}
+}
+
+// RequestDeletion schedules the deletion of the current account once the
+// grace period is over. The password is asked again and every other session
+// is signed out.
+func (a *Account) RequestDeletion(ctx context.Context, password string) (domain.AccountDeletion, error) {
+	claims, err := GetClaimFromContext(ctx)
+	if err != nil {
+		return domain.AccountDeletion{}, errtrace.Wrap(errors.New("invalid token claims"))
+	}
+
+	user, err := a.auth.UserManager.GetUserByID(ctx, claims.UserID)
+	if err != nil {
+		return domain.AccountDeletion{}, errtrace.Wrap(err)
+	}
+
+	if !a.auth.checkPasswordHash(password, user.Password) {
+		return domain.AccountDeletion{}, errtrace.Wrap(ErrInvalidCredentials)
+	}
+
+	now := time.Now()
+	deletion := domain.AccountDeletion{
+		UserID:      user.ID,
+		RequestedAt: now,
+		DeleteAfter: now.Add(a.GracePeriod),
+	}
+
+	if err := a.repo.ScheduleDeletion(ctx, deletion); err != nil {
+		return domain.AccountDeletion{}, errtrace.Wrap(err)
+	}
+
+	if err := a.auth.Tokens.RevokeUserExcept(ctx, user.ID, claims.SessionID); err != nil {
+		return domain.AccountDeletion{}, errtrace.Wrap(err)
+	}
+
+	if err := a.auth.Audit.Record(ctx, domain.AuditLog{
+		Action:     AuditAccountDeletionRequest,
+		TargetType: "user",
+		TargetID:   user.ID,
+		IP:         ClientFromContext(ctx).IP,
+		Metadata:   map[string]any{"delete_after": deletion.DeleteAfter},
+	}); err != nil {
+		return domain.AccountDeletion{}, errtrace.Wrap(err)
+	}
+
+	if err := a.auth.Mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Your account will be deleted",
+		Body: fmt.Sprintf("Hi %s,\n\nYour account and the invitations you own will be deleted on %s. "+
+			"Sign in and cancel the deletion before then if you change your mind.\n",
+			user.Name, deletion.DeleteAfter.Format(time.RFC1123)),
+	}); err != nil {
+		// the deletion is scheduled already, it shows in DeletionStatus
+		log.Printf("account deletion email for user %s: %v", user.ID, err)
+	}
+
+	return deletion, nil
+}
+
+// DeletionStatus returns the pending deletion of the current account.
+func (a *Account) DeletionStatus(ctx context.Context) (domain.AccountDeletion, error) {
+	claims, err := GetClaimFromContext(ctx)
+	if err != nil {
+		return domain.AccountDeletion{}, errtrace.Wrap(errors.New("invalid token claims"))
+	}
+
+	deletion, err := a.repo.GetDeletion(ctx, claims.UserID)
+	if err != nil {
+		if errors.Is(err, sql.ErrAccountDeletionNotFound) {
+			return domain.AccountDeletion{}, errtrace.Wrap(ErrAccountDeletionNotFound)
+		}
+		return domain.AccountDeletion{}, errtrace.Wrap(err)
+	}
+
+	return *deletion, nil
+}
+
+// CancelDeletion keeps the current account.
+func (a *Account) CancelDeletion(ctx context.Context) error {
+	claims, err := GetClaimFromContext(ctx)
+	if err != nil {
+		return errtrace.Wrap(errors.New("invalid token claims"))
+	}
+
+	if err := a.repo.CancelDeletion(ctx, claims.UserID); err != nil {
+		if errors.Is(err, sql.ErrAccountDeletionNotFound) {
+			return errtrace.Wrap(ErrAccountDeletionNotFound)
+		}
+		return errtrace.Wrap(err)
+	}
+
+	return errtrace.Wrap(a.auth.Audit.Record(ctx, domain.AuditLog{
+		Action:     AuditAccountDeletionCancel,
+		TargetType: "user",
+		TargetID:   claims.UserID,
+		IP:         ClientFromContext(ctx).IP,
+	}))
+}
+
+// PurgeDue deletes the accounts whose grace period ended before now and
+// returns how many were deleted. A failing account is logged and retried on
+// the next run.
+func (a *Account) PurgeDue(ctx context.Context, now time.Time) (int, error) {
+	deletions, err := a.repo.ListDueDeletions(ctx, now)
+	if err != nil {
+		return 0, errtrace.Wrap(err)
+	}
+
+	purged := 0
+	for _, v := range deletions {
+		if err := a.purge(ctx, v.UserID); err != nil {
+			log.Printf("account purge %s failed: %v", v.UserID, err)
+			continue
+		}
+		purged++
+	}
+
+	return purged, nil
+}
+
+// purge deletes a user with everything they own. Templates they are the only
+// owner of go with them, templates they created but share with another owner
+// are handed over to that owner.
+func (a *Account) purge(ctx context.Context, userID string) error {
+	user, err := a.auth.UserManager.GetUserByID(ctx, userID)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	templates, err := a.memberTemplates(ctx, userID)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	plan := sql.AccountPurge{
+		UserID:            user.ID,
+		Email:             strings.ToLower(user.Email),
+		TransferTemplates: map[string]string{},
+	}
+	var deleted []domain.UserTemplate
+
+	for _, template := range templates {
+		members, err := a.members.List(ctx, template.ID)
+		if err != nil {
+			return errtrace.Wrap(err)
+		}
+
+		var owner string
+		for _, m := range members {
+			if m.UserID != userID && m.Role == domain.TemplateRoleOwner {
+				owner = m.UserID
+				break
+			}
+		}
+
+		switch {
+		case owner == "":
+			plan.DeleteTemplates = append(plan.DeleteTemplates, template.ID)
+			deleted = append(deleted, template)
+		case template.UserID == userID:
+			plan.TransferTemplates[template.ID] = owner
+		}
+	}
+
+	if err := a.repo.Purge(ctx, plan); err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	if err := a.auth.Audit.Record(ctx, domain.AuditLog{
+		ActorID:    user.ID,
+		Action:     AuditAccountDelete,
+		TargetType: "user",
+		TargetID:   user.ID,
+		Metadata: map[string]any{
+			"deleted_templates":     plan.DeleteTemplates,
+			"transferred_templates": plan.TransferTemplates,
+		},
+	}); err != nil {
+		log.Printf("account purge %s audit failed: %v", user.ID, err)
+	}
+
+	// the rows are gone, leftover files are only logged
+	if name, ok := a.uploadPath(user.Profile); ok {
+		if err := os.Remove(name); err != nil && !os.IsNotExist(err) {
+			log.Printf("account purge %s: %v", user.ID, err)
+		}
+	}
+	for _, v := range deleted {
+		if name, ok := a.uploadPath(v.CoverImage); ok {
+			if err := os.Remove(name); err != nil && !os.IsNotExist(err) {
+				log.Printf("account purge %s: %v", user.ID, err)
+			}
+		}
+		if dir, ok := a.templatePath(v.Slug); ok {
+			if err := os.RemoveAll(dir); err != nil {
+				log.Printf("account purge %s: %v", user.ID, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// memberTemplates returns every template the user is a member of, with the
+// role of the user.
+func (a *Account) memberTemplates(ctx context.Context, userID string) ([]domain.UserTemplate, error) {
+	total, err := a.templates.Count(ctx, userID)
+	if err != nil {
+		return nil, errtrace.Wrap(err)
+	}
+
+	if total == 0 {
+		return []domain.UserTemplate{}, nil
+	}
+
+	list, err := a.templates.List(ctx, userID, 0, int(total))
+	if err != nil {
+		return nil, errtrace.Wrap(err)
+	}
+
+	return list.Data, nil
+}
+
+// uploadPath maps a stored "uploads/<name>" value to its file, only the base
+// name is used so a stored value cannot point outside UploadDir.
+func (a *Account) uploadPath(stored string) (string, bool) {
+	name := filepath.Base(stored)
+	if stored == "" || name == "." || name == string(os.PathSeparator) || name == "uploads" {
+		return "", false
+	}
+
+	return filepath.Join(a.UploadDir, name), true
+}
+
+func (a *Account) templatePath(slug string) (string, bool) {
+	name := filepath.Base(slug)
+	if slug == "" || name == "." || name == ".." || name == string(os.PathSeparator) {
+		return "", false
+	}
+
+	return filepath.Join(a.TemplateDir, name), true
+}
+
+type exportUser struct {
+	ID         string     `json:"id"`
+	Email      string     `json:"email"`
+	Name       string     `json:"name"`
+	Profile    string     `json:"profile"`
+	Role       string     `json:"role"`
+	VerifiedAt *time.Time `json:"verified_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+type exportSession struct {
+	ID         string    `json:"id"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+}
+
+type exportTemplate struct {
+	ID              string                   `json:"id"`
+	Name            string                   `json:"name"`
+	Slug            string                   `json:"slug"`
+	URL             string                   `json:"url"`
+	BaseTemplateID  string                   `json:"base_template_id"`
+	Role            string                   `json:"role"`
+	State           string                   `json:"state"`
+	CoverImage      string                   `json:"cover_image"`
+	MessageTemplate []domain.MessageTemplate `json:"message_template"`
+	CreatedAt       time.Time                `json:"created_at"`
+	UpdatedAt       time.Time                `json:"updated_at"`
+	ExpireAt        time.Time                `json:"expire_at"`
+	RSVPDeadline    *time.Time               `json:"rsvp_deadline"`
+}
+
+// Export writes a zip of the personal data of the current user to w:
+// user.json, sessions.json, templates.json and guests.csv, with the uploaded
+// images and the files of the templates the user owns under assets/.
+func (a *Account) Export(ctx context.Context, w io.Writer) error {
+	claims, err := GetClaimFromContext(ctx)
+	if err != nil {
+		return errtrace.Wrap(errors.New("invalid token claims"))
+	}
+
+	user, err := a.auth.UserManager.GetUserByID(ctx, claims.UserID)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	sessions, err := a.auth.Tokens.ListSessions(ctx, user.ID)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	templates, err := a.memberTemplates(ctx, user.ID)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	zw := zip.NewWriter(w)
+
+	role := "user"
+	if user.Role == domain.RoleAdmin {
+		role = "admin"
+	}
+	if err := writeZipJSON(zw, "user.json", exportUser{
+		ID:         user.ID,
+		Email:      user.Email,
+		Name:       user.Name,
+		Profile:    user.Profile,
+		Role:       role,
+		VerifiedAt: user.VerifiedAt,
+		CreatedAt:  user.CreatedAt,
+		UpdatedAt:  user.UpdatedAt,
+	}); err != nil {
+		return err
+	}
+
+	exportSessions := make([]exportSession, 0, len(sessions))
+	for _, v := range sessions {
+		exportSessions = append(exportSessions, exportSession{
+			ID:         v.ID,
+			UserAgent:  v.UserAgent,
+			IP:         v.IP,
+			CreatedAt:  v.CreatedAt,
+			LastSeenAt: v.LastSeenAt,
+		})
+	}
+	if err := writeZipJSON(zw, "sessions.json", exportSessions); err != nil {
+		return err
+	}
+
+	exportTemplates := make([]exportTemplate, 0, len(templates))
+	for _, v := range templates {
+		exportTemplates = append(exportTemplates, exportTemplate{
+			ID:              v.ID,
+			Name:            v.Name,
+			Slug:            v.Slug,
+			URL:             v.URL,
+			BaseTemplateID:  v.BaseTemplateID,
+			Role:            string(v.Role),
+			State:           v.Lifecycle(time.Now()).String(),
+			CoverImage:      v.CoverImage,
+			MessageTemplate: v.MessageTemplate,
+			CreatedAt:       v.CreatedAt,
+			UpdatedAt:       v.UpdatedAt,
+			ExpireAt:        v.ExpireAt,
+			RSVPDeadline:    v.RSVPDeadline,
+		})
+	}
+	if err := writeZipJSON(zw, "templates.json", exportTemplates); err != nil {
+		return err
+	}
+
+	if err := a.writeGuestsCSV(ctx, zw, templates); err != nil {
+		return err
+	}
+
+	if name, ok := a.uploadPath(user.Profile); ok {
+		if err := writeZipFile(zw, path.Join("assets", "profile", filepath.Base(name)), name); err != nil {
+			return err
+		}
+	}
+
+	for _, v := range templates {
+		if v.Role != domain.TemplateRoleOwner {
+			continue
+		}
+
+		if name, ok := a.uploadPath(v.CoverImage); ok {
+			if err := writeZipFile(zw, path.Join("assets", "covers", filepath.Base(name)), name); err != nil {
+				return err
+			}
+		}
+
+		if dir, ok := a.templatePath(v.Slug); ok {
+			if err := writeZipDir(zw, path.Join("assets", "templates", filepath.Base(dir)), dir); err != nil {
+				return err
+			}
+		}
+	}
+
+	return errtrace.Wrap(zw.Close())
+}
+
+func (a *Account) writeGuestsCSV(ctx context.Context, zw *zip.Writer, templates []domain.UserTemplate) error {
+	f, err := zw.Create("guests.csv")
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	cw := csv.NewWriter(f)
+	if err := cw.Write([]string{
+		"user_template_id", "id", "name", "group", "person", "tags",
+		"telp", "address", "message", "attend", "attend_person", "answers", "members", "view_at", "created_at",
+	}); err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	for _, template := range templates {
+		guests, err := a.guests.ListAll(ctx, sql.GuestFilter{UserTemplateID: template.ID})
+		if err != nil {
+			return errtrace.Wrap(err)
+		}
+
+		for _, g := range guests {
+			attend := ""
+			if g.Attend != nil {
+				attend = strconv.FormatBool(*g.Attend)
+			}
+			attendPerson := ""
+			if g.AttendPerson != nil {
+				attendPerson = strconv.Itoa(*g.AttendPerson)
+			}
+			viewAt := ""
+			if g.ViewAt != nil {
+				viewAt = g.ViewAt.Format(time.RFC3339)
+			}
+			answers, err := json.Marshal(g.Answers)
+			if err != nil {
+				return errtrace.Wrap(err)
+			}
+			members, err := json.Marshal(exportMembers(g.Members))
+			if err != nil {
+				return errtrace.Wrap(err)
+			}
+
+			// names, tags and messages are typed in by guests
+			if err := cw.Write([]string{
+				g.UserTemplateID, g.ID, sheet.Neutralize(g.Name), sheet.Neutralize(g.Group), strconv.Itoa(g.Person),
+				sheet.Neutralize(strings.Join(g.Tags, ";")), sheet.Neutralize(g.Telp), sheet.Neutralize(g.Address),
+				sheet.Neutralize(g.Message), attend, attendPerson, string(answers), string(members),
+				viewAt, g.CreatedAt.Format(time.RFC3339),
+			}); err != nil {
+				return errtrace.Wrap(err)
+			}
+		}
+	}
+
+	cw.Flush()
+	return errtrace.Wrap(cw.Error())
+}
+
+type exportMember struct {
+	Name    string `json:"name"`
+	PlusOne bool   `json:"plus_one"`
+	Attend  *bool  `json:"attend"`
+}
+
+// exportMembers is the household of a guest as written to guests.csv
+func exportMembers(members []domain.GuestMember) []exportMember {
+	result := make([]exportMember, 0, len(members))
+	for _, v := range members {
+		result = append(result, exportMember{Name: v.Name, PlusOne: v.PlusOne, Attend: v.Attend})
+	}
+	return result
+}
+
+func writeZipJSON(zw *zip.Writer, name string, v any) error {
+	f, err := zw.Create(name)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	return errtrace.Wrap(enc.Encode(v))
+}
+
+// writeZipFile copies the file src into the zip, a missing file is skipped.
+func writeZipFile(zw *zip.Writer, name, src string) error {
+	file, err := os.Open(src)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return errtrace.Wrap(err)
+	}
+	defer file.Close()
+
+	f, err := zw.Create(name)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	_, err = io.Copy(f, file)
+	return errtrace.Wrap(err)
+}
+
+// writeZipDir copies the regular files under dir into the zip below prefix,
+// a missing directory is skipped.
+func writeZipDir(zw *zip.Writer, prefix, dir string) error {
+	err := filepath.WalkDir(dir, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, name)
+		if err != nil {
+			return err
+		}
+
+		return writeZipFile(zw, path.Join(prefix, filepath.ToSlash(rel)), name)
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+
+	return errtrace.Wrap(err)
+}
@@ -0,0 +1,171 @@
+package usecase
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"basic-service/domain"
+	"basic-service/interface/sql"
+)
+
+func TestAccountDeletion(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "u1", "u1@example.com", domain.RoleUser)
+	other, _ := env.login(t, "u1@example.com")
+	current, ctx := env.login(t, "u1@example.com")
+	account := env.account()
+	bg := context.Background()
+
+	if _, err := account.RequestDeletion(ctx, "wrong"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("wrong password: err = %v, want ErrInvalidCredentials", err)
+	}
+
+	deletion, err := account.RequestDeletion(ctx, "password")
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	if deletion.DeleteAfter.Before(time.Now().Add(account.GracePeriod - time.Minute)) {
+		t.Errorf("deleted after %s, before the grace period", deletion.DeleteAfter)
+	}
+	if len(env.mail.messages("u1@example.com")) == 0 {
+		t.Error("no deletion notice sent")
+	}
+
+	// only the session that asked stays signed in
+	if _, err := env.auth.ValidateToken(bg, other.AccessToken); !errors.Is(err, ErrTokenRevoked) {
+		t.Errorf("other session: err = %v, want ErrTokenRevoked", err)
+	}
+	if _, err := env.auth.ValidateToken(bg, current.AccessToken); err != nil {
+		t.Errorf("current session: %v", err)
+	}
+
+	if _, err := account.DeletionStatus(ctx); err != nil {
+		t.Errorf("status: %v", err)
+	}
+	if err := account.CancelDeletion(ctx); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+	if _, err := account.DeletionStatus(ctx); !errors.Is(err, ErrAccountDeletionNotFound) {
+		t.Errorf("status after cancel: err = %v, want ErrAccountDeletionNotFound", err)
+	}
+	if err := account.CancelDeletion(ctx); !errors.Is(err, ErrAccountDeletionNotFound) {
+		t.Errorf("cancel twice: err = %v, want ErrAccountDeletionNotFound", err)
+	}
+
+	// a cancelled deletion is never purged
+	if n, err := account.PurgeDue(bg, time.Now().Add(2*account.GracePeriod)); err != nil || n != 0 {
+		t.Errorf("purge = %d, %v, want nothing purged", n, err)
+	}
+}
+
+func TestAccountDeletionMailFailure(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "u1", "u1@example.com", domain.RoleUser)
+	_, ctx := env.login(t, "u1@example.com")
+	account := env.account()
+	env.mail.err = errors.New("smtp down")
+
+	// the deletion is scheduled whether or not the notice arrives
+	if _, err := account.RequestDeletion(ctx, "password"); err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	if _, err := account.DeletionStatus(ctx); err != nil {
+		t.Errorf("status: %v", err)
+	}
+}
+
+func TestPurgeDue(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "u1", "u1@example.com", domain.RoleUser)
+	env.register(t, "u2", "u2@example.com", domain.RoleUser)
+	_, ctx := env.login(t, "u1@example.com")
+	env.createTemplate(t, ctx, "own")
+	env.createTemplate(t, ctx, "shared")
+	u2 := env.addMember(t, ctx, "shared", "u2@example.com", domain.TemplateRoleOwner)
+	env.createGuest(t, ctx, domain.Guest{ID: "g1", Name: "g1", UserTemplateID: "own"})
+	account := env.account()
+	bg := context.Background()
+
+	if _, err := account.RequestDeletion(ctx, "password"); err != nil {
+		t.Fatal(err)
+	}
+
+	if n, err := account.PurgeDue(bg, time.Now()); err != nil || n != 0 {
+		t.Errorf("purge during the grace period = %d, %v, want nothing purged", n, err)
+	}
+
+	n, err := account.PurgeDue(bg, time.Now().Add(account.GracePeriod+time.Hour))
+	if err != nil || n != 1 {
+		t.Fatalf("purge = %d, %v, want 1", n, err)
+	}
+
+	if _, err := env.auth.UserManager.GetUserByID(bg, "u1"); err == nil {
+		t.Error("user still exists")
+	}
+
+	list, err := env.templates().List(u2, 1, 10, "u2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Data) != 1 || list.Data[0].ID != "shared" || list.Data[0].UserID != "u2" {
+		t.Errorf("templates of u2 = %+v, want shared handed over", list.Data)
+	}
+
+	guests, err := env.guests().guestRepo.ListAll(bg, sql.GuestFilter{UserTemplateID: "own"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(guests) != 0 {
+		t.Errorf("%d guests of the deleted template left", len(guests))
+	}
+}
+
+func TestAccountExport(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "u1", "u1@example.com", domain.RoleUser)
+	_, ctx := env.login(t, "u1@example.com")
+	env.createTemplate(t, ctx, "t1")
+	env.createGuest(t, ctx, domain.Guest{ID: "g1", Name: "Budi", UserTemplateID: "t1"})
+
+	var buf bytes.Buffer
+	if err := env.account().Export(ctx, &buf); err != nil {
+		t.Fatalf("export: %v", err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files := map[string]string{}
+	for _, f := range zr.File {
+		r, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, _ := io.ReadAll(r)
+		r.Close()
+		files[f.Name] = string(b)
+	}
+
+	for _, name := range []string{"user.json", "sessions.json", "templates.json", "guests.csv"} {
+		if _, ok := files[name]; !ok {
+			t.Errorf("%s missing from the export", name)
+		}
+	}
+	if strings.Contains(files["user.json"], "$2a$") {
+		t.Error("user.json contains the password hash")
+	}
+	if !strings.Contains(files["user.json"], "u1@example.com") {
+		t.Error("user.json does not contain the email")
+	}
+	if !strings.Contains(files["guests.csv"], "Budi") {
+		t.Error("guests.csv does not contain the guest")
+	}
+}
@@ -0,0 +1,129 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"basic-service/domain"
+)
+
+func TestAPITokenScopes(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "u1", "u1@example.com", domain.RoleUser)
+	_, ctx := env.login(t, "u1@example.com")
+	bg := context.Background()
+
+	if _, _, err := env.auth.CreateAPIToken(ctx, "none", nil, nil); !errors.Is(err, ErrInvalidScope) {
+		t.Errorf("no scope: err = %v, want ErrInvalidScope", err)
+	}
+	if _, _, err := env.auth.CreateAPIToken(ctx, "unknown", []Scope{"users:manage"}, nil); !errors.Is(err, ErrInvalidScope) {
+		t.Errorf("unknown scope: err = %v, want ErrInvalidScope", err)
+	}
+
+	raw, token, err := env.auth.CreateAPIToken(ctx, "script", []Scope{ScopeGuestsRead, ScopeGuestsRead}, nil)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if !strings.HasPrefix(raw, apiTokenPrefix) || token.Prefix != raw[:apiTokenDisplayLen] || len(token.Scopes) != 1 {
+		t.Errorf("token = %+v", token)
+	}
+
+	claims, err := env.auth.ValidateToken(bg, raw)
+	if err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	if !claims.IsAPIToken() || claims.UserID != "u1" {
+		t.Fatalf("claims = %+v", claims)
+	}
+	if !claims.Can(PermGuestRead) {
+		t.Error("guests:read token cannot read guests")
+	}
+	if claims.Can(PermGuestManageOwn) || claims.Can(PermUserTemplateRead) {
+		t.Error("guests:read token can do more than reading guests")
+	}
+
+	// a token cannot mint more tokens
+	tokenCtx := context.WithValue(bg, "claims", claims)
+	if _, _, err := env.auth.CreateAPIToken(tokenCtx, "nested", []Scope{ScopeGuestsWrite}, nil); !errors.Is(err, ErrForbidden) {
+		t.Errorf("create with a token: err = %v, want ErrForbidden", err)
+	}
+}
+
+func TestAPITokenNeverExceedsRole(t *testing.T) {
+	claims := &Claims{UserID: "u1", Role: domain.RoleUser, APITokenID: "t1", Scopes: []Scope{ScopeGuestsWrite, ScopeTemplatesWrite}}
+
+	if claims.Can(PermUserList) {
+		t.Error("token of a user can list users")
+	}
+	if !claims.Can(PermGuestManageOwn, PermUserTemplateWriteOwn) {
+		t.Error("token cannot use its scopes")
+	}
+}
+
+func TestAPITokenRevokeAndExpiry(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "u1", "u1@example.com", domain.RoleUser)
+	env.register(t, "u2", "u2@example.com", domain.RoleUser)
+	_, ctx := env.login(t, "u1@example.com")
+	_, otherCtx := env.login(t, "u2@example.com")
+	bg := context.Background()
+
+	raw, token, err := env.auth.CreateAPIToken(ctx, "script", []Scope{ScopeGuestsRead}, nil)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	past := time.Now().Add(-time.Hour)
+	expired, _, err := env.auth.CreateAPIToken(ctx, "expired", []Scope{ScopeGuestsRead}, &past)
+	if err != nil {
+		t.Fatalf("create expired: %v", err)
+	}
+	if _, err := env.auth.ValidateToken(bg, expired); !errors.Is(err, ErrInvalidAPIToken) {
+		t.Errorf("expired: err = %v, want ErrInvalidAPIToken", err)
+	}
+
+	// revoking someone else's token does nothing
+	_ = env.auth.RevokeAPIToken(otherCtx, token.ID)
+	if _, err := env.auth.ValidateToken(bg, raw); err != nil {
+		t.Fatalf("revoked by another user: %v", err)
+	}
+
+	if err := env.auth.RevokeAPIToken(ctx, token.ID); err != nil {
+		t.Fatalf("revoke: %v", err)
+	}
+	if _, err := env.auth.ValidateToken(bg, raw); !errors.Is(err, ErrInvalidAPIToken) {
+		t.Errorf("revoked: err = %v, want ErrInvalidAPIToken", err)
+	}
+
+	tokens, err := env.auth.ListAPITokens(ctx)
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	// revoked tokens are no longer listed
+	if len(tokens) != 1 || tokens[0].Name != "expired" {
+		t.Errorf("listed %+v, want the expired token", tokens)
+	}
+}
+
+func TestAPITokenOfInactiveUser(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "admin", "admin@example.com", domain.RoleAdmin)
+	env.register(t, "u1", "u1@example.com", domain.RoleUser)
+	_, ctx := env.login(t, "u1@example.com")
+	_, adminCtx := env.login(t, "admin@example.com")
+
+	raw, _, err := env.auth.CreateAPIToken(ctx, "script", []Scope{ScopeGuestsRead}, nil)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	if err := env.users().UpdateState(adminCtx, "u1", false); err != nil {
+		t.Fatalf("deactivate: %v", err)
+	}
+	if _, err := env.auth.ValidateToken(context.Background(), raw); !errors.Is(err, ErrUserNotActive) {
+		t.Errorf("err = %v, want ErrUserNotActive", err)
+	}
+}
@@ -0,0 +1,59 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"basic-service/domain"
+	"basic-service/interface/sql"
+
+	"braces.dev/errtrace"
+	"github.com/google/uuid"
+)
+
+const (
+	AuditLoginLockout = "auth.lockout"
+	AuditLoginUnlock  = "auth.unlock"
+
+	AuditSessionRevoke = "session.revoke"
+
+	AuditImpersonationStart   = "impersonation.start"
+	AuditImpersonationRequest = "impersonation.request"
+	AuditImpersonationRevoke  = "impersonation.revoke"
+)
+
+// Auditor writes the audit trail. The actor is taken from the claims in ctx
+// when the entry does not set one.
+type Auditor struct {
+	repo *sql.AuditRepository
+}
+
+func NewAuditor(repo *sql.AuditRepository) *Auditor {
+	return &Auditor{repo: repo}
+}
+
+func (a *Auditor) Record(ctx context.Context, entry domain.AuditLog) error {
+	if entry.ID == "" {
+		entry.ID = uuid.New().String()
+	}
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+	if claims, err := GetClaimFromContext(ctx); err == nil {
+		if entry.ActorID == "" {
+			entry.ActorID = claims.UserID
+		}
+		// the admin is the real actor of anything done while impersonating
+		if claims.IsImpersonated() {
+			if entry.ActorID == claims.UserID {
+				entry.ActorID = claims.ImpersonatorID
+			}
+			if entry.Metadata == nil {
+				entry.Metadata = map[string]any{}
+			}
+			entry.Metadata["impersonated_user_id"] = claims.UserID
+		}
+	}
+
+	return errtrace.Wrap(a.repo.Create(ctx, entry))
+}
@@ -2,42 +2,132 @@ package usecase
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
+	"log"
+	"strings"
+	"sync"
 	"time"
 
 	"basic-service/domain"
 	"basic-service/interface/sql"
+	"basic-service/pkg/jwtkey"
+	"basic-service/pkg/mailer"
 
 	"braces.dev/errtrace"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 )
 
+const (
+	defaultAccessTokenTTL  = 15 * time.Minute
+	defaultRefreshTokenTTL = 30 * 24 * time.Hour
+	defaultResetTokenTTL   = time.Hour
+	defaultVerifyTokenTTL  = 48 * time.Hour
+)
+
 type Auth struct {
-	UserManager *sql.UserRepository
-	JWTSecret   string
+	UserManager     *sql.UserRepository
+	Tokens          *sql.TokenRepository
+	UserTokens      *sql.UserTokenRepository
+	APITokens       *sql.APITokenRepository
+	TOTP            *sql.TOTPRepository
+	Keys            *jwtkey.KeySet
+	Mailer          mailer.Mailer
+	Guard           *LoginGuard
+	Audit           *Auditor
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+	ResetTokenTTL   time.Duration
+	VerifyTokenTTL  time.Duration
+	// ImpersonationTTL is the longest an impersonation token can live
+	ImpersonationTTL time.Duration
+	// AppURL is the web frontend base URL used to build links sent by email
+	AppURL string
+	// TOTPIssuer is the account issuer shown by authenticator apps
+	TOTPIssuer string
+	// RequireAdminTOTP makes admins without a second factor act as regular
+	// users until they enroll
+	RequireAdminTOTP bool
+
+	// mails tracks the emails sent in the background
+	mails sync.WaitGroup
 }
 
-func NewAuth(userManager *sql.UserRepository, jwtSecret string) *Auth {
+func NewAuth(
+	userManager *sql.UserRepository,
+	tokens *sql.TokenRepository,
+	userTokens *sql.UserTokenRepository,
+	apiTokens *sql.APITokenRepository,
+	totpRepo *sql.TOTPRepository,
+	keys *jwtkey.KeySet,
+	mail mailer.Mailer,
+	guard *LoginGuard,
+	audit *Auditor,
+) *Auth {
 	return &Auth{
-		UserManager: userManager,
-		JWTSecret:   jwtSecret,
+		UserManager:      userManager,
+		Tokens:           tokens,
+		UserTokens:       userTokens,
+		APITokens:        apiTokens,
+		TOTP:             totpRepo,
+		Keys:             keys,
+		Mailer:           mail,
+		Guard:            guard,
+		Audit:            audit,
+		AccessTokenTTL:   defaultAccessTokenTTL,
+		RefreshTokenTTL:  defaultRefreshTokenTTL,
+		ResetTokenTTL:    defaultResetTokenTTL,
+		VerifyTokenTTL:   defaultVerifyTokenTTL,
+		ImpersonationTTL: defaultImpersonationTTL,
+		TOTPIssuer:       defaultTOTPIssuer,
 	}
 }
 
 var (
-	ErrInvalidCredentials = errors.New("invalid credentials")
-	ErrUserNotActive      = errors.New("user is not active")
+	ErrInvalidCredentials  = errors.New("invalid credentials")
+	ErrUserNotActive       = errors.New("user is not active")
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token reused, session revoked")
+	ErrTokenRevoked        = errors.New("token has been revoked")
 )
 
 // Claims represents the JWT claims
 type Claims struct {
-	UserID string          `json:"user_id"`
-	Email  string          `json:"email"`
-	Role   domain.RoleType `json:"role"`
+	UserID    string          `json:"user_id"`
+	Email     string          `json:"email"`
+	Role      domain.RoleType `json:"role"`
+	SessionID string          `json:"sid,omitempty"`
+	// MFA is set when the user has a second factor, every session of such a
+	// user went through it
+	MFA bool `json:"mfa,omitempty"`
+	// ImpersonatorID is the admin acting as UserID, AllowDestructive lets
+	// that admin delete data
+	ImpersonatorID   string `json:"imp,omitempty"`
+	AllowDestructive bool   `json:"imp_destructive,omitempty"`
+	// APITokenID and Scopes are only set for personal API tokens, which are
+	// never encoded as a JWT
+	APITokenID string  `json:"-"`
+	Scopes     []Scope `json:"-"`
 	jwt.RegisteredClaims
 }
 
+// TokenPair is returned on login and refresh
+type TokenPair struct {
+	AccessToken  string
+	RefreshToken string
+	ExpiresAt    time.Time
+	// MFAChallenge is set instead of the tokens when the login needs a second
+	// factor, ExpiresAt is then the challenge expiry
+	MFAChallenge string
+	// MFASetupRequired tells an admin to enroll a second factor
+	MFASetupRequired bool
+}
+
 func GetClaimFromContext(ctx context.Context) (*Claims, error) {
 	claims, ok := ctx.Value("claims").(*Claims)
 	if !ok {
@@ -56,45 +146,237 @@ func (a *Auth) checkPasswordHash(password, hash string) bool {
 	return err == nil
 }
 
-func (a *Auth) generateJWT(user *domain.User) (string, error) {
-	expirationTime := time.Now().Add(24 * time.Hour) // 1 day expiration
-
+func (a *Auth) generateJWT(user *domain.User, sessionID string, mfa bool, expirationTime time.Time) (string, error) {
 	claims := &Claims{
-		UserID: user.ID,
-		Email:  user.Email,
-		Role:   user.Role,
+		UserID:    user.ID,
+		Email:     user.Email,
+		Role:      user.Role,
+		SessionID: sessionID,
+		MFA:       mfa,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.New().String(),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			ExpiresAt: jwt.NewNumericDate(expirationTime),
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return errtrace.Wrap2(token.SignedString([]byte(a.JWTSecret)))
+	return errtrace.Wrap2(a.Keys.Sign(claims))
+}
+
+// newRefreshToken returns an opaque random token and the record to store,
+// only the sha256 of the token is persisted.
+func (a *Auth) newRefreshToken(userID, familyID string) (string, domain.RefreshToken, error) {
+	raw, err := randomToken()
+	if err != nil {
+		return "", domain.RefreshToken{}, errtrace.Wrap(err)
+	}
+
+	now := time.Now()
+	return raw, domain.RefreshToken{
+		ID:        uuid.New().String(),
+		UserID:    userID,
+		FamilyID:  familyID,
+		TokenHash: hashToken(raw),
+		ExpiresAt: now.Add(a.RefreshTokenTTL),
+		CreatedAt: now,
+	}, nil
+}
+
+// randomToken returns 32 random bytes encoded for use in URLs.
+func randomToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", errtrace.Wrap(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+func hashToken(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
 }
 
-func (a *Auth) Login(ctx context.Context, email, password string) (string, error) {
+// Login checks the credentials, ip is the client address used to throttle
+// repeated failures.
+func (a *Auth) Login(ctx context.Context, email, password, ip string) (TokenPair, error) {
+	if err := a.Guard.Check(ctx, email, ip); err != nil {
+		return TokenPair{}, err
+	}
+
 	user, err := a.UserManager.GetEmail(ctx, email)
 	if err != nil {
 		if errors.Is(err, sql.UserNotFoundErr) {
-			return "", errtrace.Wrap(ErrInvalidCredentials)
+			if err := a.Guard.Fail(ctx, email, ip, ""); err != nil {
+				return TokenPair{}, errtrace.Wrap(err)
+			}
+			return TokenPair{}, errtrace.Wrap(ErrInvalidCredentials)
 		}
-		return "", errtrace.Wrap(err)
+		return TokenPair{}, errtrace.Wrap(err)
 	}
 
 	if !user.IsActive {
-		return "", errtrace.Wrap(ErrUserNotActive)
+		return TokenPair{}, errtrace.Wrap(ErrUserNotActive)
 	}
 
 	if !a.checkPasswordHash(password, user.Password) {
-		return "", errtrace.Wrap(ErrInvalidCredentials)
+		if err := a.Guard.Fail(ctx, email, ip, user.ID); err != nil {
+			return TokenPair{}, errtrace.Wrap(err)
+		}
+		return TokenPair{}, errtrace.Wrap(ErrInvalidCredentials)
 	}
 
-	token, err := a.generateJWT(user)
+	mfa, err := a.TOTP.IsEnabled(ctx, user.ID)
 	if err != nil {
-		return "", errtrace.Wrap(err)
+		return TokenPair{}, errtrace.Wrap(err)
+	}
+
+	// failures are only cleared once the second factor is verified
+	if mfa {
+		return a.mfaChallenge(ctx, user)
+	}
+
+	if err := a.Guard.Succeed(ctx, email); err != nil {
+		return TokenPair{}, errtrace.Wrap(err)
+	}
+
+	return a.startSession(ctx, user)
+}
+
+// startSession creates a new session with its refresh token family and first
+// token pair. The device is taken from the client in ctx.
+func (a *Auth) startSession(ctx context.Context, user *domain.User) (TokenPair, error) {
+	raw, refresh, err := a.newRefreshToken(user.ID, uuid.New().String())
+	if err != nil {
+		return TokenPair{}, errtrace.Wrap(err)
+	}
+
+	client := ClientFromContext(ctx)
+	session := domain.Session{
+		ID:         refresh.FamilyID,
+		UserID:     user.ID,
+		UserAgent:  client.UserAgent,
+		IP:         client.IP,
+		CreatedAt:  refresh.CreatedAt,
+		LastSeenAt: refresh.CreatedAt,
+	}
+
+	if err := a.Tokens.CreateSession(ctx, session, refresh); err != nil {
+		return TokenPair{}, errtrace.Wrap(err)
 	}
 
-	return token, nil
+	return a.tokenPair(ctx, user, refresh.FamilyID, raw)
+}
+
+func (a *Auth) tokenPair(ctx context.Context, user *domain.User, familyID, refreshToken string) (TokenPair, error) {
+	mfa, err := a.TOTP.IsEnabled(ctx, user.ID)
+	if err != nil {
+		return TokenPair{}, errtrace.Wrap(err)
+	}
+
+	expiresAt := time.Now().Add(a.AccessTokenTTL)
+	token, err := a.generateJWT(user, familyID, mfa, expiresAt)
+	if err != nil {
+		return TokenPair{}, errtrace.Wrap(err)
+	}
+
+	return TokenPair{
+		AccessToken:      token,
+		RefreshToken:     refreshToken,
+		ExpiresAt:        expiresAt,
+		MFASetupRequired: !mfa && a.requiresTOTP(user.Role),
+	}, nil
+}
+
+// Refresh exchanges a refresh token for a new token pair. The presented token
+// is rotated, presenting an already rotated token revokes the whole session.
+func (a *Auth) Refresh(ctx context.Context, refreshToken string) (TokenPair, error) {
+	current, err := a.Tokens.GetRefreshTokenByHash(ctx, hashToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, sql.ErrRefreshTokenNotFound) {
+			return TokenPair{}, errtrace.Wrap(ErrInvalidRefreshToken)
+		}
+		return TokenPair{}, errtrace.Wrap(err)
+	}
+
+	if current.RevokedAt != nil {
+		if err := a.Tokens.RevokeFamily(ctx, current.FamilyID); err != nil {
+			return TokenPair{}, errtrace.Wrap(err)
+		}
+		return TokenPair{}, errtrace.Wrap(ErrRefreshTokenReused)
+	}
+
+	if time.Now().After(current.ExpiresAt) {
+		return TokenPair{}, errtrace.Wrap(ErrInvalidRefreshToken)
+	}
+
+	user, err := a.UserManager.GetUserByID(ctx, current.UserID)
+	if err != nil {
+		return TokenPair{}, errtrace.Wrap(err)
+	}
+
+	if !user.IsActive {
+		if err := a.Tokens.RevokeFamily(ctx, current.FamilyID); err != nil {
+			return TokenPair{}, errtrace.Wrap(err)
+		}
+		return TokenPair{}, errtrace.Wrap(ErrUserNotActive)
+	}
+
+	raw, next, err := a.newRefreshToken(user.ID, current.FamilyID)
+	if err != nil {
+		return TokenPair{}, errtrace.Wrap(err)
+	}
+
+	if err := a.Tokens.RotateRefreshToken(ctx, current.ID, next); err != nil {
+		if errors.Is(err, sql.ErrRefreshTokenNotFound) {
+			return TokenPair{}, errtrace.Wrap(ErrInvalidRefreshToken)
+		}
+		return TokenPair{}, errtrace.Wrap(err)
+	}
+
+	a.touchSession(ctx, next.FamilyID)
+
+	return a.tokenPair(ctx, user, next.FamilyID, raw)
+}
+
+// Logout revokes the access token used for the request and the session it
+// belongs to.
+func (a *Auth) Logout(ctx context.Context) error {
+	claims, err := GetClaimFromContext(ctx)
+	if err != nil {
+		return errtrace.Wrap(errors.New("invalid token claims"))
+	}
+
+	if claims.ID != "" && claims.ExpiresAt != nil {
+		if err := a.Tokens.RevokeJTI(ctx, claims.ID, claims.UserID, claims.ExpiresAt.Time); err != nil {
+			return errtrace.Wrap(err)
+		}
+	}
+
+	if claims.SessionID != "" {
+		return errtrace.Wrap(a.Tokens.RevokeFamily(ctx, claims.SessionID))
+	}
+
+	return nil
+}
+
+// RevokeUserTokens signs the user out everywhere.
+func (a *Auth) RevokeUserTokens(ctx context.Context, userID string) error {
+	return errtrace.Wrap(a.Tokens.RevokeUser(ctx, userID))
+}
+
+// SetPassword stores a new password for the user and revokes every session,
+// it is the only place that should call UserRepository.UpdatePassword.
+func (a *Auth) SetPassword(ctx context.Context, userID, password string) error {
+	hashedPassword, err := a.hashPassword(password)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	if err := a.UserManager.UpdatePassword(ctx, userID, hashedPassword); err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	return errtrace.Wrap(a.RevokeUserTokens(ctx, userID))
 }
 
 func (a *Auth) Register(ctx context.Context, user domain.User) error {
@@ -115,15 +397,30 @@ func (a *Auth) Register(ctx context.Context, user domain.User) error {
 		user.IsActive = true
 	}
 
-	return errtrace.Wrap(a.UserManager.Create(ctx, &user))
+	// the address is only trusted once the emailed link is opened
+	user.VerifiedAt = nil
+
+	if err := a.UserManager.Create(ctx, &user); err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	// the account exists now, the link can be sent again with
+	// ResendVerification
+	if err := a.SendVerification(ctx, &user); err != nil {
+		log.Printf("verification email for user %s: %v", user.ID, err)
+	}
+
+	return nil
 }
 
-func (a *Auth) ValidateToken(tokenString string) (*Claims, error) {
+func (a *Auth) ValidateToken(ctx context.Context, tokenString string) (*Claims, error) {
+	if strings.HasPrefix(tokenString, apiTokenPrefix) {
+		return a.validateAPIToken(ctx, tokenString)
+	}
+
 	claims := &Claims{}
 
-	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-		return []byte(a.JWTSecret), nil
-	})
+	token, err := a.Keys.Parse(tokenString, claims)
 	if err != nil {
 		return nil, errtrace.Wrap(err)
 	}
@@ -132,9 +429,48 @@ func (a *Auth) ValidateToken(tokenString string) (*Claims, error) {
 		return nil, errtrace.Wrap(errors.New("invalid token"))
 	}
 
+	if claims.ID != "" {
+		revoked, err := a.Tokens.IsJTIRevoked(ctx, claims.ID)
+		if err != nil {
+			return nil, errtrace.Wrap(err)
+		}
+		if revoked {
+			return nil, errtrace.Wrap(ErrTokenRevoked)
+		}
+	}
+
+	if claims.SessionID != "" {
+		active, err := a.Tokens.IsFamilyActive(ctx, claims.SessionID)
+		if err != nil {
+			return nil, errtrace.Wrap(err)
+		}
+		if !active {
+			return nil, errtrace.Wrap(ErrTokenRevoked)
+		}
+
+		a.touchSession(ctx, claims.SessionID)
+	}
+
+	if claims.IsImpersonated() {
+		if err := a.validateImpersonation(ctx, claims); err != nil {
+			return nil, err
+		}
+	}
+
+	// an admin session without a second factor only gets user rights, which
+	// still lets the admin enroll
+	if !claims.MFA && a.requiresTOTP(claims.Role) {
+		claims.Role = domain.RoleUser
+	}
+
 	return claims, nil
 }
 
+// JWKS returns the public verification keys.
+func (a *Auth) JWKS() jwtkey.JWKS {
+	return a.Keys.JWKS()
+}
+
 func (a *Auth) Me(ctx context.Context) (domain.SafeUser, error) {
 	claims, err := GetClaimFromContext(ctx)
 	if err != nil {
@@ -146,14 +482,57 @@ func (a *Auth) Me(ctx context.Context) (domain.SafeUser, error) {
 		return domain.SafeUser{}, errtrace.Wrap(err)
 	}
 
-	return domain.SafeUser{
-		ID:        user.ID,
-		Email:     user.Email,
-		Name:      user.Name,
-		Profile:   user.Profile,
-		Role:      user.Role,
-		IsActive:  user.IsActive,
-		CreatedAt: user.CreatedAt,
-		UpdatedAt: user.UpdatedAt,
-	}, nil
+	return toSafeUser(*user), nil
+}
+
+// UpdateProfile changes the name of the current user and, when profile is not
+// empty, the profile picture. The previous picture is returned so the caller
+// can remove the file.
+func (a *Auth) UpdateProfile(ctx context.Context, name, profile string) (domain.SafeUser, string, error) {
+	claims, err := GetClaimFromContext(ctx)
+	if err != nil {
+		return domain.SafeUser{}, "", errtrace.Wrap(errors.New("invalid token claims"))
+	}
+
+	user, err := a.UserManager.GetUserByID(ctx, claims.UserID)
+	if err != nil {
+		return domain.SafeUser{}, "", errtrace.Wrap(err)
+	}
+
+	previous := user.Profile
+	user.Name = name
+	if profile != "" {
+		user.Profile = profile
+	}
+
+	if err := a.UserManager.Update(ctx, user); err != nil {
+		return domain.SafeUser{}, "", errtrace.Wrap(err)
+	}
+
+	user.UpdatedAt = time.Now()
+	return toSafeUser(*user), previous, nil
+}
+
+// ChangePassword checks the current password before storing the new one.
+// Every session is revoked, the caller gets a fresh token pair.
+func (a *Auth) ChangePassword(ctx context.Context, currentPassword, newPassword string) (TokenPair, error) {
+	claims, err := GetClaimFromContext(ctx)
+	if err != nil {
+		return TokenPair{}, errtrace.Wrap(errors.New("invalid token claims"))
+	}
+
+	user, err := a.UserManager.GetUserByID(ctx, claims.UserID)
+	if err != nil {
+		return TokenPair{}, errtrace.Wrap(err)
+	}
+
+	if !a.checkPasswordHash(currentPassword, user.Password) {
+		return TokenPair{}, errtrace.Wrap(ErrInvalidCredentials)
+	}
+
+	if err := a.SetPassword(ctx, user.ID, newPassword); err != nil {
+		return TokenPair{}, errtrace.Wrap(err)
+	}
+
+	return a.startSession(ctx, user)
 }
@@ -0,0 +1,141 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"basic-service/domain"
+	"basic-service/interface/sql"
+)
+
+func TestRefreshRotatesToken(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "u1", "u1@example.com", domain.RoleUser)
+	ctx := context.Background()
+
+	first, _ := env.login(t, "u1@example.com")
+
+	second, err := env.auth.Refresh(ctx, first.RefreshToken)
+	if err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+	if second.RefreshToken == first.RefreshToken {
+		t.Fatal("refresh returned the same refresh token")
+	}
+	if _, err := env.auth.ValidateToken(ctx, second.AccessToken); err != nil {
+		t.Fatalf("validate refreshed access token: %v", err)
+	}
+
+	// replaying the rotated token signs the whole session out
+	if _, err := env.auth.Refresh(ctx, first.RefreshToken); !errors.Is(err, ErrRefreshTokenReused) {
+		t.Fatalf("reuse: err = %v, want ErrRefreshTokenReused", err)
+	}
+	if _, err := env.auth.Refresh(ctx, second.RefreshToken); !errors.Is(err, ErrRefreshTokenReused) {
+		t.Errorf("refresh after reuse: err = %v, want ErrRefreshTokenReused", err)
+	}
+	if _, err := env.auth.ValidateToken(ctx, second.AccessToken); !errors.Is(err, ErrTokenRevoked) {
+		t.Errorf("access token after reuse: err = %v, want ErrTokenRevoked", err)
+	}
+}
+
+func TestRefreshUnknownToken(t *testing.T) {
+	env := newTestEnv(t)
+
+	if _, err := env.auth.Refresh(context.Background(), "unknown"); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("err = %v, want ErrInvalidRefreshToken", err)
+	}
+}
+
+func TestLogoutRevokesSession(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "u1", "u1@example.com", domain.RoleUser)
+	ctx := context.Background()
+
+	pair, reqCtx := env.login(t, "u1@example.com")
+	other, _ := env.login(t, "u1@example.com")
+
+	if err := env.auth.Logout(reqCtx); err != nil {
+		t.Fatalf("logout: %v", err)
+	}
+
+	if _, err := env.auth.ValidateToken(ctx, pair.AccessToken); !errors.Is(err, ErrTokenRevoked) {
+		t.Errorf("access token: err = %v, want ErrTokenRevoked", err)
+	}
+	if _, err := env.auth.Refresh(ctx, pair.RefreshToken); err == nil {
+		t.Error("refresh token still works after logout")
+	}
+	if _, err := env.auth.ValidateToken(ctx, other.AccessToken); err != nil {
+		t.Errorf("other session: %v", err)
+	}
+}
+
+func TestSetPasswordRevokesSessions(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "u1", "u1@example.com", domain.RoleUser)
+	ctx := context.Background()
+
+	pair, _ := env.login(t, "u1@example.com")
+
+	if err := env.auth.SetPassword(ctx, "u1", "new-password"); err != nil {
+		t.Fatalf("set password: %v", err)
+	}
+
+	if _, err := env.auth.ValidateToken(ctx, pair.AccessToken); !errors.Is(err, ErrTokenRevoked) {
+		t.Errorf("access token: err = %v, want ErrTokenRevoked", err)
+	}
+	if _, err := env.auth.Login(ctx, "u1@example.com", "password", ""); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("old password: err = %v, want ErrInvalidCredentials", err)
+	}
+	if _, err := env.auth.Login(ctx, "u1@example.com", "new-password", ""); err != nil {
+		t.Errorf("new password: %v", err)
+	}
+}
+
+func TestDeactivateRevokesSessions(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "admin", "admin@example.com", domain.RoleAdmin)
+	env.register(t, "u1", "u1@example.com", domain.RoleUser)
+	ctx := context.Background()
+
+	pair, _ := env.login(t, "u1@example.com")
+	_, adminCtx := env.login(t, "admin@example.com")
+
+	users := env.users()
+	if err := users.UpdateState(adminCtx, "u1", false); err != nil {
+		t.Fatalf("deactivate: %v", err)
+	}
+
+	if _, err := env.auth.ValidateToken(ctx, pair.AccessToken); !errors.Is(err, ErrTokenRevoked) {
+		t.Errorf("access token: err = %v, want ErrTokenRevoked", err)
+	}
+	if _, err := env.auth.Refresh(ctx, pair.RefreshToken); err == nil {
+		t.Error("refresh token still works after deactivation")
+	}
+}
+
+func TestDeleteExpiredTokens(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "u1", "u1@example.com", domain.RoleUser)
+	ctx := context.Background()
+
+	env.auth.RefreshTokenTTL = -time.Hour
+	expired, err := env.auth.Login(ctx, "u1@example.com", "password", "")
+	if err != nil {
+		t.Fatalf("login: %v", err)
+	}
+	env.auth.RefreshTokenTTL = defaultRefreshTokenTTL
+	live, _ := env.login(t, "u1@example.com")
+
+	if err := env.auth.Tokens.DeleteExpired(ctx); err != nil {
+		t.Fatalf("delete expired: %v", err)
+	}
+
+	if _, err := env.auth.Tokens.GetRefreshTokenByHash(ctx, hashToken(expired.RefreshToken)); !errors.Is(err, sql.ErrRefreshTokenNotFound) {
+		t.Errorf("expired token: err = %v, want ErrRefreshTokenNotFound", err)
+	}
+	if _, err := env.auth.Tokens.GetRefreshTokenByHash(ctx, hashToken(live.RefreshToken)); err != nil {
+		t.Errorf("live token: %v", err)
+	}
+}
@@ -2,17 +2,27 @@ package usecase
 
 import (
 	"context"
-	"errors"
+	"fmt"
 	"time"
 
 	"basic-service/domain"
 	"basic-service/interface/sql"
+	"basic-service/pkg/phone"
 
 	"braces.dev/errtrace"
 )
 
 type GuestUsecase struct {
 	guestRepo *sql.GuestManager
+	members   *sql.TemplateMemberRepository
+	templates *sql.UserTemplateRepository
+	questions *sql.RSVPQuestionRepository
+
+	// InvitationKey signs the public invitation links of the guests
+	InvitationKey []byte
+	// PhoneCountry is the country of phone numbers written without a
+	// country code, phone.DefaultCountry when empty
+	PhoneCountry string
 }
 
 type GuestListResult struct {
@@ -20,32 +30,101 @@ type GuestListResult struct {
 	Data  []domain.Guest
 }
 
-func NewGuestUsecase(guestRepo *sql.GuestManager) *GuestUsecase {
-	return &GuestUsecase{guestRepo: guestRepo}
+// GuestExport is a template with its RSVP questions and guests
+type GuestExport struct {
+	Template  domain.UserTemplate
+	Questions []domain.RSVPQuestion
+	Guests    []domain.Guest
 }
 
-func (g *GuestUsecase) GetGuest(ctx context.Context, id string) (*domain.Guest, error) {
-	guest, err := g.guestRepo.Get(ctx, id)
+func NewGuestUsecase(guestRepo *sql.GuestManager, members *sql.TemplateMemberRepository, templates *sql.UserTemplateRepository, questions *sql.RSVPQuestionRepository) *GuestUsecase {
+	return &GuestUsecase{guestRepo: guestRepo, members: members, templates: templates, questions: questions}
+}
+
+// PublicGuest is what the invitation page of a guest shows
+type PublicGuest struct {
+	Guest     domain.Guest
+	Template  domain.UserTemplate
+	Questions []domain.RSVPQuestion
+}
+
+// GetGuest returns the guest of an invitation token with its template and
+// the RSVP questions of the template
+func (g *GuestUsecase) GetGuest(ctx context.Context, token string) (PublicGuest, error) {
+	guest, template, err := g.guestByToken(ctx, token)
 	if err != nil {
-		return guest, err
+		return PublicGuest{}, err
 	}
 
-	return guest, nil
+	questions, err := g.questions.List(ctx, guest.UserTemplateID)
+	if err != nil {
+		return PublicGuest{}, errtrace.Wrap(err)
+	}
+
+	return PublicGuest{Guest: *guest, Template: template, Questions: questions}, nil
 }
 
+// Create adds a guest with its household members, editors and owners of the
+// template can do it.
 func (g *GuestUsecase) Create(ctx context.Context, data domain.Guest) error {
+	if _, err := authorizeTemplate(ctx, g.members, data.UserTemplateID, domain.TemplateRoleEditor); err != nil {
+		return err
+	}
+
+	telp, err := g.normalizeTelp(data.Telp)
+	if err != nil {
+		return err
+	}
+
+	data.Telp = telp
+	if len(data.Members) > 0 {
+		data.Members, err = householdMembers(data.ID, nil, data.Members)
+		if err != nil {
+			return err
+		}
+		householdHeadcount(&data)
+	}
+
 	data.CreatedAt = time.Now()
 	data.UpdatedAt = time.Now()
+	data.InviteNonce = newInviteNonce()
 	return g.guestRepo.Create(ctx, data)
 }
 
-func (g *GuestUsecase) List(ctx context.Context, userTemplateID string, page int, limit int) (GuestListResult, error) {
-	claims, err := GetClaimFromContext(ctx)
+// normalizeTelp returns a phone number of a guest in E.164
+func (g *GuestUsecase) normalizeTelp(telp string) (string, error) {
+	normalized, err := phone.Normalize(telp, g.phoneCountry())
 	if err != nil {
-		return GuestListResult{}, errtrace.Wrap(errors.New("invalid token claims"))
+		return "", errtrace.Wrap(fmt.Errorf("telp %q: %w", telp, err))
+	}
+
+	return normalized, nil
+}
+
+func (g *GuestUsecase) phoneCountry() string {
+	if g.PhoneCountry == "" {
+		return phone.DefaultCountry
+	}
+	return g.PhoneCountry
+}
+
+// searchTelp lets a query written like a local number, "0812-34", find the
+// numbers stored in E.164
+func (g *GuestUsecase) searchTelp(filter sql.GuestFilter) sql.GuestFilter {
+	if telp, err := phone.Prefix(filter.Query, g.phoneCountry()); err == nil {
+		filter.Telp = telp
+	}
+	return filter
+}
+
+// List returns the guests of a template matching filter, every member can
+// read them.
+func (g *GuestUsecase) List(ctx context.Context, filter sql.GuestFilter, page int, limit int) (GuestListResult, error) {
+	if _, err := authorizeTemplate(ctx, g.members, filter.UserTemplateID, domain.TemplateRoleViewer); err != nil {
+		return GuestListResult{}, err
 	}
 
-	guests, total, err := g.guestRepo.List(ctx, claims.UserID, userTemplateID, page, limit)
+	guests, total, err := g.guestRepo.List(ctx, g.searchTelp(filter), page, limit)
 	if err != nil {
 		return GuestListResult{}, err
 	}
@@ -56,21 +135,139 @@ func (g *GuestUsecase) List(ctx context.Context, userTemplateID string, page int
 	}, nil
 }
 
-func (g *GuestUsecase) UpdateLastView(ctx context.Context, id string) error {
-	// Get the existing guest first
+// Export returns a template with its RSVP questions and all of its guests
+// matching filter, every member can export them.
+func (g *GuestUsecase) Export(ctx context.Context, filter sql.GuestFilter) (GuestExport, error) {
+	if _, err := authorizeTemplate(ctx, g.members, filter.UserTemplateID, domain.TemplateRoleViewer); err != nil {
+		return GuestExport{}, err
+	}
+
+	template, err := g.templates.Get(ctx, filter.UserTemplateID)
+	if err != nil {
+		return GuestExport{}, errtrace.Wrap(err)
+	}
+
+	questions, err := g.questions.List(ctx, filter.UserTemplateID)
+	if err != nil {
+		return GuestExport{}, errtrace.Wrap(err)
+	}
+
+	guests, err := g.guestRepo.ListAll(ctx, g.searchTelp(filter))
+	if err != nil {
+		return GuestExport{}, errtrace.Wrap(err)
+	}
+
+	return GuestExport{Template: template, Questions: questions, Guests: guests}, nil
+}
+
+// Update changes the given fields of a guest, editors and owners of the
+// guest's template can do it.
+func (g *GuestUsecase) Update(ctx context.Context, id string, data sql.GuestUpdate) (*domain.Guest, error) {
+	guest, err := g.guestRepo.Get(ctx, id)
+	if err != nil {
+		return nil, errtrace.Wrap(err)
+	}
+
+	if _, err := authorizeTemplate(ctx, g.members, guest.UserTemplateID, domain.TemplateRoleEditor); err != nil {
+		return nil, err
+	}
+
+	if len(guest.Members) > 0 && (data.Person != nil || data.Attend != nil || data.AttendPerson != nil) {
+		return nil, errtrace.Wrap(ErrGuestHousehold)
+	}
+
+	if data.Telp != nil {
+		telp, err := g.normalizeTelp(*data.Telp)
+		if err != nil {
+			return nil, err
+		}
+		data.Telp = &telp
+	}
+
+	if err := updateHeadcount(*guest, &data); err != nil {
+		return nil, err
+	}
+
+	if err := g.guestRepo.Update(ctx, id, data); err != nil {
+		return nil, errtrace.Wrap(err)
+	}
+
+	return errtrace.Wrap2(g.guestRepo.Get(ctx, id))
+}
+
+// updateHeadcount keeps the patched guest consistent, lowering person counts
+// too. Like rsvpHeadcount a guest who declines brings nobody and a guest who
+// attends comes at least alone.
+func updateHeadcount(guest domain.Guest, data *sql.GuestUpdate) error {
+	if data.Attend != nil {
+		switch {
+		case !*data.Attend && data.AttendPerson != nil && *data.AttendPerson != 0:
+			return errtrace.Wrap(fmt.Errorf("%w: a guest who does not attend brings nobody", ErrRSVPInvalid))
+		case !*data.Attend:
+			data.AttendPerson = new(int)
+		default:
+			attendPerson := guest.AttendPerson
+			if data.AttendPerson != nil {
+				attendPerson = data.AttendPerson
+			}
+			if attendPerson == nil || *attendPerson == 0 {
+				one := 1
+				data.AttendPerson = &one
+			}
+		}
+	}
+
+	person, attendPerson := guest.Person, guest.AttendPerson
+	if data.Person != nil {
+		person = *data.Person
+	}
+	if data.AttendPerson != nil {
+		attendPerson = data.AttendPerson
+	}
+
+	return checkHeadcount(person, attendPerson)
+}
+
+// checkHeadcount returns ErrRSVPInvalid when the confirmed headcount is
+// negative or above the invited persons, a guest invited without a headcount
+// is invited alone.
+func checkHeadcount(person int, attendPerson *int) error {
+	if attendPerson == nil {
+		return nil
+	}
+
+	if invited := max(person, 1); *attendPerson < 0 || *attendPerson > invited {
+		return errtrace.Wrap(fmt.Errorf("%w: the invitation is for at most %d persons", ErrRSVPInvalid, invited))
+	}
+
+	return nil
+}
+
+// Delete removes a guest, editors and owners of the guest's template can do
+// it.
+func (g *GuestUsecase) Delete(ctx context.Context, id string) error {
 	guest, err := g.guestRepo.Get(ctx, id)
 	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	if _, err := authorizeTemplate(ctx, g.members, guest.UserTemplateID, domain.TemplateRoleEditor); err != nil {
+		return err
+	}
+
+	if err := AuthorizeDestructive(ctx); err != nil {
 		return err
 	}
 
-	return g.guestRepo.UpdateMessageAndLastView(ctx, id, guest.Message, guest.Attend)
+	return errtrace.Wrap(g.guestRepo.Delete(ctx, id))
 }
 
-func (g *GuestUsecase) UpdateMessageAndLastView(ctx context.Context, id, message string, attend bool) error {
-	_, err := g.guestRepo.Get(ctx, id)
+// UpdateLastView records that the guest of an invitation token opened it
+func (g *GuestUsecase) UpdateLastView(ctx context.Context, token string) error {
+	guest, _, err := g.guestByToken(ctx, token)
 	if err != nil {
 		return err
 	}
 
-	return g.guestRepo.UpdateMessageAndLastView(ctx, id, message, &attend)
+	return g.guestRepo.UpdateMessageAndLastView(ctx, guest.ID, guest.Message, guest.Attend)
 }
@@ -0,0 +1,342 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"basic-service/domain"
+	"basic-service/interface/sql"
+
+	"braces.dev/errtrace"
+	"github.com/google/uuid"
+)
+
+// MaxGuestMerge is the largest number of guests merged into one at once
+const MaxGuestMerge = 50
+
+var ErrGuestMergeInvalid = errors.New("invalid guest merge")
+
+// Reasons guests are reported as duplicates
+const (
+	DuplicateTelp = "telp"
+	DuplicateName = "name"
+)
+
+// GuestDuplicate is a group of guests of a template that look like the same
+// person, Reasons says why: they share a phone number or have near identical
+// names.
+type GuestDuplicate struct {
+	Reasons []string
+	Guests  []domain.Guest
+}
+
+// Duplicates returns the groups of guests of a template that look like the
+// same person, every member can read them.
+func (g *GuestUsecase) Duplicates(ctx context.Context, templateID string) ([]GuestDuplicate, error) {
+	if _, err := authorizeTemplate(ctx, g.members, templateID, domain.TemplateRoleViewer); err != nil {
+		return nil, err
+	}
+
+	guests, err := g.guestRepo.ListAll(ctx, sql.GuestFilter{UserTemplateID: templateID})
+	if err != nil {
+		return nil, errtrace.Wrap(err)
+	}
+
+	parent := make([]int, len(guests))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	type link struct {
+		a, b   int
+		reason string
+	}
+	var links []link
+
+	phones := map[string]int{}
+	names := make([]string, len(guests))
+	for i, v := range guests {
+		names[i] = strings.Join(messageWords(v.Name), " ")
+
+		// numbers stored before they were normalized still match
+		telp, err := g.normalizeTelp(v.Telp)
+		if err != nil || telp == "" {
+			continue
+		}
+		if first, ok := phones[telp]; ok {
+			links = append(links, link{first, i, DuplicateTelp})
+			continue
+		}
+		phones[telp] = i
+	}
+
+	for i := range guests {
+		for j := i + 1; j < len(guests); j++ {
+			if similarNames(names[i], names[j]) {
+				links = append(links, link{i, j, DuplicateName})
+			}
+		}
+	}
+
+	for _, v := range links {
+		parent[find(v.a)] = find(v.b)
+	}
+
+	reasons := map[int][]string{}
+	for _, v := range links {
+		root := find(v.a)
+		if !slices.Contains(reasons[root], v.reason) {
+			reasons[root] = append(reasons[root], v.reason)
+		}
+	}
+
+	groups := map[int]*GuestDuplicate{}
+	result := []GuestDuplicate{}
+	order := []int{}
+	for i, v := range guests {
+		root := find(i)
+		if _, ok := reasons[root]; !ok {
+			continue
+		}
+		if _, ok := groups[root]; !ok {
+			slices.Sort(reasons[root])
+			groups[root] = &GuestDuplicate{Reasons: reasons[root]}
+			order = append(order, root)
+		}
+		groups[root].Guests = append(groups[root].Guests, v)
+	}
+
+	for _, root := range order {
+		result = append(result, *groups[root])
+	}
+
+	return result, nil
+}
+
+// similarNames reports whether two names, as lower case words, are the same
+// but for a typo: one edit apart, two for long names. Names shorter than four
+// letters only match exactly.
+func similarNames(a, b string) bool {
+	if a == "" || b == "" {
+		return false
+	}
+	if a == b {
+		return true
+	}
+
+	ra, rb := []rune(a), []rune(b)
+	shortest := min(len(ra), len(rb))
+	if shortest < 4 {
+		return false
+	}
+
+	limit := 1
+	if shortest >= 12 {
+		limit = 2
+	}
+	if max(len(ra), len(rb))-shortest > limit {
+		return false
+	}
+
+	return editDistance(ra, rb) <= limit
+}
+
+// editDistance is the Levenshtein distance of a and b
+func editDistance(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+
+	return prev[len(b)]
+}
+
+// Merge folds the guests of ids into the guest keepID and deletes them, the
+// invitation links of the deleted guests stop working. Editors and owners can
+// do it.
+func (g *GuestUsecase) Merge(ctx context.Context, templateID, keepID string, ids []string) (*domain.Guest, error) {
+	if _, err := authorizeTemplate(ctx, g.members, templateID, domain.TemplateRoleEditor); err != nil {
+		return nil, err
+	}
+
+	if len(ids) == 0 || len(ids) > MaxGuestMerge {
+		return nil, errtrace.Wrap(fmt.Errorf("%w: merge between 1 and %d guests", ErrGuestMergeInvalid, MaxGuestMerge))
+	}
+
+	keep, err := g.templateGuest(ctx, templateID, keepID)
+	if err != nil {
+		return nil, err
+	}
+
+	others := make([]domain.Guest, 0, len(ids))
+	for _, id := range ids {
+		if id == keepID || slices.ContainsFunc(others, func(v domain.Guest) bool { return v.ID == id }) {
+			return nil, errtrace.Wrap(fmt.Errorf("%w: guest %q is listed twice", ErrGuestMergeInvalid, id))
+		}
+
+		guest, err := g.templateGuest(ctx, templateID, id)
+		if err != nil {
+			return nil, err
+		}
+		others = append(others, *guest)
+	}
+
+	merged := mergeGuests(*keep, others)
+	if len(merged.Members) > MaxGuestMembers {
+		return nil, errtrace.Wrap(fmt.Errorf("%w: a household has at most %d members", ErrGuestMergeInvalid, MaxGuestMembers))
+	}
+
+	// the merged guests are deleted
+	if err := AuthorizeDestructive(ctx); err != nil {
+		return nil, err
+	}
+
+	if err := g.guestRepo.Merge(ctx, merged, ids); err != nil {
+		return nil, errtrace.Wrap(err)
+	}
+
+	return errtrace.Wrap2(g.guestRepo.Get(ctx, keepID))
+}
+
+// templateGuest returns a guest of the template, ErrGuestNotFound for guests
+// of other templates
+func (g *GuestUsecase) templateGuest(ctx context.Context, templateID, id string) (*domain.Guest, error) {
+	guest, err := g.guestRepo.Get(ctx, id)
+	if err != nil {
+		return nil, errtrace.Wrap(err)
+	}
+
+	if guest.UserTemplateID != templateID {
+		return nil, errtrace.Wrap(sql.ErrGuestNotFound)
+	}
+
+	return guest, nil
+}
+
+// mergeGuests fills what keep is missing from others in order. Person is the
+// largest, tags are joined, the RSVP and the message come from keep or the
+// first of others that has one, answers keep already has are not replaced.
+// Households are joined, with flatMembers for the guests without one, and
+// when there are members they decide the headcount.
+func mergeGuests(keep domain.Guest, others []domain.Guest) domain.Guest {
+	merged := keep
+	merged.Tags = slices.Clone(keep.Tags)
+	merged.Answers = make(map[string]string, len(keep.Answers))
+	for k, v := range keep.Answers {
+		merged.Answers[k] = v
+	}
+
+	fill := func(field *string, v string) {
+		if *field == "" {
+			*field = v
+		}
+	}
+
+	rsvpFrom := -1
+	for i, v := range others {
+		fill(&merged.Group, v.Group)
+		fill(&merged.Telp, v.Telp)
+		fill(&merged.Address, v.Address)
+		merged.Person = max(merged.Person, v.Person)
+
+		for _, tag := range v.Tags {
+			if !slices.Contains(merged.Tags, tag) {
+				merged.Tags = append(merged.Tags, tag)
+			}
+		}
+
+		if merged.Attend == nil && v.Attend != nil {
+			merged.Attend = v.Attend
+			merged.AttendPerson = v.AttendPerson
+			rsvpFrom = i
+		}
+
+		if merged.Message == "" && v.Message != "" {
+			merged.Message = v.Message
+			merged.MessageStatus = v.MessageStatus
+			merged.MessagePinned = v.MessagePinned
+			merged.MessageAt = v.MessageAt
+		}
+
+		if v.ViewAt != nil && (merged.ViewAt == nil || v.ViewAt.After(*merged.ViewAt)) {
+			merged.ViewAt = v.ViewAt
+		}
+	}
+
+	// answers follow the RSVP they were given with
+	if rsvpFrom >= 0 {
+		for k, v := range others[rsvpFrom].Answers {
+			if _, ok := merged.Answers[k]; !ok {
+				merged.Answers[k] = v
+			}
+		}
+	}
+
+	// the households are joined, their members then count the persons
+	guests := append([]domain.Guest{keep}, others...)
+	if slices.ContainsFunc(guests, func(v domain.Guest) bool { return len(v.Members) > 0 }) {
+		merged.Members = nil
+		for _, v := range guests {
+			if len(v.Members) == 0 {
+				v.Members = flatMembers(v)
+			}
+			merged.Members = append(merged.Members, v.Members...)
+		}
+	}
+	for i := range merged.Members {
+		merged.Members[i].GuestID = keep.ID
+		merged.Members[i].Position = i
+	}
+	if len(merged.Members) > 0 {
+		householdHeadcount(&merged)
+	}
+
+	return merged
+}
+
+// flatMembers returns the members of a guest without a household: the guest
+// and plus ones for the rest of its headcount, the first AttendPerson of them
+// attend when the guest does.
+func flatMembers(guest domain.Guest) []domain.GuestMember {
+	now := time.Now()
+	members := make([]domain.GuestMember, max(guest.Person, 1))
+	for i := range members {
+		members[i] = domain.GuestMember{ID: uuid.New().String(), CreatedAt: now}
+		if i == 0 {
+			members[i].Name = guest.Name
+		} else {
+			members[i].PlusOne = true
+		}
+
+		if guest.Attend != nil {
+			attend := *guest.Attend && (guest.AttendPerson == nil || i < *guest.AttendPerson)
+			members[i].Attend = &attend
+		}
+	}
+
+	return members
+}
@@ -0,0 +1,239 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"basic-service/domain"
+	"basic-service/interface/sql"
+)
+
+func TestSimilarNames(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"budi santoso", "budi santoso", true},
+		{"budi santoso", "budi santosa", true},
+		{"budi santoso", "budi santos", true},
+		{"budi santoso", "budii santoso", true},
+		{"budi santoso", "bdi santosa", false},
+		{"budi santoso wijaya", "bdi santoso wijayo", true},
+		{"budi santoso wijaya", "bdi santoso wijayoo", false},
+		{"siti", "sita", true},
+		{"siti", "sitti", true},
+		{"siti", "sata", false},
+		{"ani", "ana", false},
+		{"ani", "ani", true},
+		{"andi", "andika", false},
+		{"rina", "dina", true},
+		{"", "", false},
+		{"budi", "", false},
+		{"dewi", "déwi", true},
+	}
+
+	for _, tt := range tests {
+		if got := similarNames(tt.a, tt.b); got != tt.want {
+			t.Errorf("similarNames(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+		if got := similarNames(tt.b, tt.a); got != tt.want {
+			t.Errorf("similarNames(%q, %q) = %v, want %v", tt.b, tt.a, got, tt.want)
+		}
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"abc", "", 3},
+		{"", "abc", 3},
+		{"kitten", "sitting", 3},
+		{"flaw", "lawn", 2},
+		{"déwi", "dewi", 1},
+	}
+
+	for _, tt := range tests {
+		if got := editDistance([]rune(tt.a), []rune(tt.b)); got != tt.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestGuestDuplicates(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "u1", "u1@example.com", domain.RoleUser)
+	env.register(t, "u2", "u2@example.com", domain.RoleUser)
+	_, ctx := env.login(t, "u1@example.com")
+	_, stranger := env.login(t, "u2@example.com")
+	env.createTemplate(t, ctx, "t1")
+	guests := env.guests()
+
+	env.createGuest(t, ctx, domain.Guest{ID: "g1", Name: "Budi Santoso", Telp: "081234567890", UserTemplateID: "t1"})
+	env.createGuest(t, ctx, domain.Guest{ID: "g2", Name: "Pak Budi", Telp: "+62 812 3456 7890", UserTemplateID: "t1"})
+	env.createGuest(t, ctx, domain.Guest{ID: "g3", Name: "Siti Rahma", UserTemplateID: "t1"})
+	env.createGuest(t, ctx, domain.Guest{ID: "g4", Name: "siti rahmah", UserTemplateID: "t1"})
+	env.createGuest(t, ctx, domain.Guest{ID: "g5", Name: "Andi", Telp: "081398765432", UserTemplateID: "t1"})
+	// a number stored before numbers were normalized
+	if err := guests.guestRepo.Create(ctx, domain.Guest{ID: "g6", Name: "Bu Budi", Telp: "0812-3456-7890", UserTemplateID: "t1", InviteNonce: newInviteNonce()}); err != nil {
+		t.Fatal(err)
+	}
+
+	groups, err := guests.Duplicates(ctx, "t1")
+	if err != nil {
+		t.Fatalf("duplicates: %v", err)
+	}
+
+	got := map[string][]string{}
+	for _, v := range groups {
+		got[strings.Join(v.Reasons, ",")] = guestIDs(v.Guests)
+	}
+	want := map[string][]string{
+		DuplicateTelp: {"g1", "g2", "g6"},
+		DuplicateName: {"g3", "g4"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("duplicates = %v, want %v", got, want)
+	}
+
+	if _, err := guests.Duplicates(stranger, "t1"); !errors.Is(err, ErrForbidden) {
+		t.Errorf("stranger: err = %v, want ErrForbidden", err)
+	}
+}
+
+func TestMergeGuests(t *testing.T) {
+	yes, no, two := true, false, 2
+	keep := domain.Guest{ID: "g1", Name: "Budi", Person: 1, Tags: []string{"vip"}, Answers: map[string]string{"q1": "a"}}
+	others := []domain.Guest{
+		{ID: "g2", Group: "family", Person: 3, Tags: []string{"vip", "table 1"}, Telp: "+6281234567890", Attend: &yes, AttendPerson: &two, Answers: map[string]string{"q1": "b", "q2": "c"}},
+		{ID: "g3", Group: "friends", Address: "Jakarta", Attend: &no, Message: "Selamat", Answers: map[string]string{"q3": "d"}},
+	}
+
+	got := mergeGuests(keep, others)
+	if got.ID != "g1" || got.Name != "Budi" || got.Group != "family" || got.Telp != "+6281234567890" || got.Address != "Jakarta" || got.Person != 3 {
+		t.Errorf("merged = %+v", got)
+	}
+	if !slices.Equal(got.Tags, []string{"vip", "table 1"}) {
+		t.Errorf("tags = %v", got.Tags)
+	}
+	if got.Attend == nil || !*got.Attend || got.AttendPerson == nil || *got.AttendPerson != 2 || got.Message != "Selamat" {
+		t.Errorf("rsvp = %v %v %q, want the RSVP of g2 and the message of g3", got.Attend, got.AttendPerson, got.Message)
+	}
+	if want := map[string]string{"q1": "a", "q2": "c"}; !reflect.DeepEqual(got.Answers, want) {
+		t.Errorf("answers = %v, want %v", got.Answers, want)
+	}
+	if keep.Answers["q2"] != "" || len(keep.Tags) != 1 {
+		t.Errorf("keep was modified: %+v", keep)
+	}
+}
+
+func TestMergeGuestsHousehold(t *testing.T) {
+	yes, two := true, 2
+	household := domain.Guest{ID: "g1", Name: "Keluarga Ani", Members: []domain.GuestMember{{ID: "m1", Name: "Ani", Attend: &yes}}}
+	flat := domain.Guest{ID: "g2", Name: "Budi", Person: 3, Attend: &yes, AttendPerson: &two}
+
+	tests := []struct {
+		name   string
+		keep   domain.Guest
+		others []domain.Guest
+		want   []string
+		attend int
+	}{
+		{"into a household", household, []domain.Guest{flat}, []string{"Ani", "Budi", "+1", "+1"}, 3},
+		{"household into a guest", flat, []domain.Guest{household}, []string{"Budi", "+1", "+1", "Ani"}, 3},
+		{"guest without rsvp", household, []domain.Guest{{ID: "g3", Name: "Citra"}}, []string{"Ani", "Citra"}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := mergeGuests(tt.keep, tt.others)
+
+			var names []string
+			for i, v := range got.Members {
+				if v.GuestID != tt.keep.ID || v.Position != i || v.ID == "" {
+					t.Errorf("member %d = %+v", i, v)
+				}
+				if v.PlusOne {
+					names = append(names, "+1")
+				} else {
+					names = append(names, v.Name)
+				}
+			}
+			if !slices.Equal(names, tt.want) {
+				t.Errorf("members = %v, want %v", names, tt.want)
+			}
+			if got.Person != len(tt.want) || got.AttendPerson == nil || *got.AttendPerson != tt.attend {
+				t.Errorf("headcount = %d, attending %v, want %d and %d", got.Person, got.AttendPerson, len(tt.want), tt.attend)
+			}
+		})
+	}
+}
+
+func TestGuestMerge(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "admin", "admin@example.com", domain.RoleAdmin)
+	env.register(t, "u1", "u1@example.com", domain.RoleUser)
+	env.register(t, "u2", "u2@example.com", domain.RoleUser)
+	_, admin := env.login(t, "admin@example.com")
+	_, ctx := env.login(t, "u1@example.com")
+	env.createTemplate(t, ctx, "t1")
+	env.createTemplate(t, ctx, "t2")
+	viewer := env.addMember(t, ctx, "t1", "u2@example.com", domain.TemplateRoleViewer)
+	guests := env.guests()
+
+	env.createGuest(t, ctx, domain.Guest{ID: "g1", Name: "Budi", Person: 1, UserTemplateID: "t1"})
+	env.createGuest(t, ctx, domain.Guest{ID: "g2", Name: "Pak Budi", Telp: "081234567890", Person: 2, UserTemplateID: "t1"})
+	env.createGuest(t, ctx, domain.Guest{ID: "g3", Name: "Budi", UserTemplateID: "t2"})
+	_, readOnly := env.impersonate(t, admin, "u1", false)
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		keep string
+		ids  []string
+		want error
+	}{
+		{"viewer", viewer, "g1", []string{"g2"}, ErrForbidden},
+		{"read-only impersonation", readOnly, "g1", []string{"g2"}, ErrImpersonationReadOnly},
+		{"no guests", ctx, "g1", nil, ErrGuestMergeInvalid},
+		{"into itself", ctx, "g1", []string{"g1"}, ErrGuestMergeInvalid},
+		{"listed twice", ctx, "g1", []string{"g2", "g2"}, ErrGuestMergeInvalid},
+		{"guest of another template", ctx, "g1", []string{"g3"}, sql.ErrGuestNotFound},
+	}
+
+	for _, tt := range tests {
+		if _, err := guests.Merge(tt.ctx, "t1", tt.keep, tt.ids); !errors.Is(err, tt.want) {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+
+	merged, err := guests.Merge(ctx, "t1", "g1", []string{"g2"})
+	if err != nil {
+		t.Fatalf("merge: %v", err)
+	}
+	if merged.ID != "g1" || merged.Name != "Budi" || merged.Telp != "+6281234567890" || merged.Person != 2 {
+		t.Errorf("merged guest = %+v", merged)
+	}
+	if _, err := guests.guestRepo.Get(ctx, "g2"); !errors.Is(err, sql.ErrGuestNotFound) {
+		t.Errorf("merged guest g2 still exists: err = %v", err)
+	}
+
+	// g1 keeps its persons in a household
+	env.createGuest(t, ctx, domain.Guest{ID: "g4", Name: "Keluarga Ani", UserTemplateID: "t1"})
+	if _, err := guests.SetMembers(ctx, "g4", []domain.GuestMember{{Name: "Ani"}}); err != nil {
+		t.Fatal(err)
+	}
+	merged, err = guests.Merge(ctx, "t1", "g4", []string{"g1"})
+	if err != nil {
+		t.Fatalf("merge into household: %v", err)
+	}
+	if merged.Person != 3 || len(merged.Members) != 3 || merged.Members[1].Name != "Budi" || !merged.Members[2].PlusOne {
+		t.Errorf("household = %+v", merged)
+	}
+}
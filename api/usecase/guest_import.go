@@ -0,0 +1,312 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"basic-service/domain"
+	"basic-service/interface/sql"
+
+	"braces.dev/errtrace"
+	"github.com/google/uuid"
+)
+
+// MaxGuestImportRows is the largest guest list accepted in one import
+const MaxGuestImportRows = 2000
+
+// guestImportFields are the guest fields a column can be mapped to
+var guestImportFields = []string{"name", "group", "person", "tags", "telp", "address"}
+
+var (
+	ErrGuestImportEmpty   = errors.New("the file has no rows")
+	ErrGuestImportTooBig  = fmt.Errorf("an import can have at most %d guests", MaxGuestImportRows)
+	ErrGuestImportMapping = errors.New("invalid column mapping")
+)
+
+// GuestImportOptions tells Import how to read the rows
+type GuestImportOptions struct {
+	// Mapping maps a guest field to the header of its column, fields that
+	// are not mapped are read from the column named after the field
+	Mapping map[string]string
+	// DryRun validates the rows and reports what would happen without
+	// writing anything
+	DryRun bool
+	// UpdateExisting updates guests already on the list instead of skipping
+	// them
+	UpdateExisting bool
+}
+
+// GuestImportRowError is a problem with one row, Row is the line in the file
+// counting the header as 1
+type GuestImportRowError struct {
+	Row     int
+	Field   string
+	Message string
+}
+
+type GuestImportResult struct {
+	DryRun  bool
+	Total   int
+	Created int
+	Updated int
+	Skipped int
+	Errors  []GuestImportRowError
+}
+
+// guestImportKey identifies a guest within a list, by phone number when
+// there is one and by name otherwise.
+func guestImportKey(name, telp string) string {
+	if telp != "" {
+		return "telp:" + telp
+	}
+	return "name:" + strings.ToLower(name)
+}
+
+// Import adds the guests of a spreadsheet to a template. The first row is the
+// header. Invalid rows are reported and skipped, guests already on the list
+// are skipped or updated, the rest is written in one transaction.
+func (g *GuestUsecase) Import(ctx context.Context, templateID string, rows [][]string, opt GuestImportOptions) (GuestImportResult, error) {
+	result := GuestImportResult{DryRun: opt.DryRun, Errors: []GuestImportRowError{}}
+
+	if _, err := authorizeTemplate(ctx, g.members, templateID, domain.TemplateRoleEditor); err != nil {
+		return result, err
+	}
+
+	// updating overwrites the fields of guests already on the list
+	if opt.UpdateExisting && !opt.DryRun {
+		if err := AuthorizeDestructive(ctx); err != nil {
+			return result, err
+		}
+	}
+
+	if len(rows) < 2 {
+		return result, errtrace.Wrap(ErrGuestImportEmpty)
+	}
+	if len(rows)-1 > MaxGuestImportRows {
+		return result, errtrace.Wrap(ErrGuestImportTooBig)
+	}
+
+	columns, err := guestImportColumns(rows[0], opt.Mapping)
+	if err != nil {
+		return result, err
+	}
+
+	existing, err := g.guestRepo.ListAll(ctx, sql.GuestFilter{UserTemplateID: templateID})
+	if err != nil {
+		return result, errtrace.Wrap(err)
+	}
+
+	known := make(map[string]string, len(existing))
+	guests := make(map[string]domain.Guest, len(existing))
+	for _, v := range existing {
+		guests[v.ID] = v
+		// numbers stored before they were normalized still match
+		if telp, err := g.normalizeTelp(v.Telp); err == nil {
+			v.Telp = telp
+		}
+		known[guestImportKey(v.Name, v.Telp)] = v.ID
+	}
+
+	seen := map[string]int{}
+	var create []domain.Guest
+	update := map[string]sql.GuestUpdate{}
+
+	for i, row := range rows[1:] {
+		line := i + 2
+		cell := func(field string) string {
+			idx, ok := columns[field]
+			if !ok || idx >= len(row) {
+				return ""
+			}
+			return row[idx]
+		}
+
+		if strings.Join(row, "") == "" {
+			continue
+		}
+		result.Total++
+
+		guest, rowErrors := parseGuestImportRow(line, cell, g.normalizeTelp)
+		if len(rowErrors) > 0 {
+			result.Errors = append(result.Errors, rowErrors...)
+			result.Skipped++
+			continue
+		}
+
+		key := guestImportKey(guest.Name, guest.Telp)
+		if first, ok := seen[key]; ok {
+			result.Errors = append(result.Errors, GuestImportRowError{
+				Row:     line,
+				Message: fmt.Sprintf("duplicate of row %d, skipped", first),
+			})
+			result.Skipped++
+			continue
+		}
+		seen[key] = line
+
+		if id, ok := known[key]; ok {
+			if !opt.UpdateExisting {
+				result.Skipped++
+				continue
+			}
+
+			data := guestImportUpdate(guest, columns, cell)
+			if current := guests[id]; len(current.Members) > 0 {
+				// the members of a household count its persons
+				data.Person = nil
+			} else if data.Person != nil {
+				if err := checkHeadcount(*data.Person, current.AttendPerson); err != nil {
+					result.Errors = append(result.Errors, GuestImportRowError{
+						Row:     line,
+						Field:   "person",
+						Message: fmt.Sprintf("%d persons already confirmed", *current.AttendPerson),
+					})
+					result.Skipped++
+					continue
+				}
+			}
+			update[id] = data
+			result.Updated++
+			continue
+		}
+
+		guest.ID = uuid.New().String()
+		guest.UserTemplateID = templateID
+		guest.InviteNonce = newInviteNonce()
+		create = append(create, guest)
+		result.Created++
+	}
+
+	if opt.DryRun || (len(create) == 0 && len(update) == 0) {
+		return result, nil
+	}
+
+	if err := g.guestRepo.Import(ctx, create, update); err != nil {
+		return result, errtrace.Wrap(err)
+	}
+
+	return result, nil
+}
+
+// guestImportColumns returns the column index of every mapped field, headers
+// are matched case insensitively.
+func guestImportColumns(header []string, mapping map[string]string) (map[string]int, error) {
+	index := make(map[string]int, len(header))
+	for i, v := range header {
+		name := strings.ToLower(strings.TrimSpace(v))
+		if _, ok := index[name]; !ok && name != "" {
+			index[name] = i
+		}
+	}
+
+	for field := range mapping {
+		known := false
+		for _, v := range guestImportFields {
+			known = known || v == field
+		}
+		if !known {
+			return nil, errtrace.Wrap(fmt.Errorf("%w: unknown field %q", ErrGuestImportMapping, field))
+		}
+	}
+
+	columns := map[string]int{}
+	for _, field := range guestImportFields {
+		header, mapped := mapping[field]
+		if !mapped {
+			header = field
+		}
+
+		idx, ok := index[strings.ToLower(strings.TrimSpace(header))]
+		if !ok {
+			if mapped {
+				return nil, errtrace.Wrap(fmt.Errorf("%w: no column %q for %s", ErrGuestImportMapping, header, field))
+			}
+			continue
+		}
+		columns[field] = idx
+	}
+
+	if _, ok := columns["name"]; !ok {
+		return nil, errtrace.Wrap(fmt.Errorf("%w: no column for the guest name", ErrGuestImportMapping))
+	}
+
+	return columns, nil
+}
+
+func parseGuestImportRow(line int, cell func(string) string, normalizeTelp func(string) (string, error)) (domain.Guest, []GuestImportRowError) {
+	var rowErrors []GuestImportRowError
+
+	guest := domain.Guest{
+		Name:    cell("name"),
+		Group:   cell("group"),
+		Telp:    cell("telp"),
+		Address: cell("address"),
+		Tags:    splitGuestTags(cell("tags")),
+	}
+
+	if guest.Name == "" {
+		rowErrors = append(rowErrors, GuestImportRowError{Row: line, Field: "name", Message: "name is required"})
+	} else if len(guest.Name) > 255 {
+		rowErrors = append(rowErrors, GuestImportRowError{Row: line, Field: "name", Message: "name is longer than 255 characters"})
+	}
+
+	if telp, err := normalizeTelp(guest.Telp); err != nil {
+		rowErrors = append(rowErrors, GuestImportRowError{Row: line, Field: "telp", Message: fmt.Sprintf("%q is not a phone number", guest.Telp)})
+	} else {
+		guest.Telp = telp
+	}
+
+	if v := cell("person"); v != "" {
+		person, err := strconv.Atoi(v)
+		if err != nil || person < 0 {
+			rowErrors = append(rowErrors, GuestImportRowError{Row: line, Field: "person", Message: fmt.Sprintf("%q is not a number of persons", v)})
+		}
+		guest.Person = person
+	}
+
+	return guest, rowErrors
+}
+
+// guestImportUpdate only changes the mapped fields that have a value in the
+// row, an empty cell keeps what is stored.
+func guestImportUpdate(guest domain.Guest, columns map[string]int, cell func(string) string) sql.GuestUpdate {
+	var update sql.GuestUpdate
+
+	has := func(field string) bool {
+		_, ok := columns[field]
+		return ok && cell(field) != ""
+	}
+
+	update.Name = &guest.Name
+	if has("group") {
+		update.Group = &guest.Group
+	}
+	if has("person") {
+		update.Person = &guest.Person
+	}
+	if has("tags") {
+		update.Tags = &guest.Tags
+	}
+	if has("telp") {
+		update.Telp = &guest.Telp
+	}
+	if has("address") {
+		update.Address = &guest.Address
+	}
+
+	return update
+}
+
+// splitGuestTags reads tags separated by commas or semicolons
+func splitGuestTags(v string) []string {
+	tags := []string{}
+	for _, tag := range strings.FieldsFunc(v, func(r rune) bool { return r == ',' || r == ';' }) {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
@@ -0,0 +1,227 @@
+package usecase
+
+import (
+	"errors"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"basic-service/domain"
+	"basic-service/interface/sql"
+)
+
+func TestGuestImportColumns(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  []string
+		mapping map[string]string
+		want    map[string]int
+		err     error
+	}{
+		{
+			"field names",
+			[]string{"name", "telp", "other"},
+			nil,
+			map[string]int{"name": 0, "telp": 1},
+			nil,
+		},
+		{
+			"case and spaces are ignored",
+			[]string{" Name ", "GROUP", "Person"},
+			nil,
+			map[string]int{"name": 0, "group": 1, "person": 2},
+			nil,
+		},
+		{
+			"mapped headers",
+			[]string{"Nama", "No HP", "telp"},
+			map[string]string{"name": "nama", "telp": "No HP"},
+			map[string]int{"name": 0, "telp": 1},
+			nil,
+		},
+		{
+			"first of duplicate headers",
+			[]string{"name", "name"},
+			nil,
+			map[string]int{"name": 0},
+			nil,
+		},
+		{"no name column", []string{"telp"}, nil, nil, ErrGuestImportMapping},
+		{"unknown field", []string{"name"}, map[string]string{"email": "name"}, nil, ErrGuestImportMapping},
+		{"mapped column missing", []string{"name"}, map[string]string{"telp": "phone"}, nil, ErrGuestImportMapping},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := guestImportColumns(tt.header, tt.mapping)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("err = %v, want %v", err, tt.err)
+			}
+			if tt.err == nil && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseGuestImportRow(t *testing.T) {
+	tests := []struct {
+		name   string
+		row    map[string]string
+		want   domain.Guest
+		fields []string
+	}{
+		{
+			"full row",
+			map[string]string{"name": "Budi", "group": "family", "person": "2", "tags": "vip; table 1,,", "telp": "0812-3456-7890", "address": "Jakarta"},
+			domain.Guest{Name: "Budi", Group: "family", Person: 2, Tags: []string{"vip", "table 1"}, Telp: "+6281234567890", Address: "Jakarta"},
+			nil,
+		},
+		{
+			"only name",
+			map[string]string{"name": "Budi"},
+			domain.Guest{Name: "Budi", Tags: []string{}},
+			nil,
+		},
+		{"missing name", map[string]string{"telp": "081234567890"}, domain.Guest{}, []string{"name"}},
+		{"invalid telp", map[string]string{"name": "Budi", "telp": "0812abc"}, domain.Guest{}, []string{"telp"}},
+		{"long name", map[string]string{"name": strings.Repeat("a", 256)}, domain.Guest{}, []string{"name"}},
+		{"invalid person", map[string]string{"name": "Budi", "person": "two"}, domain.Guest{}, []string{"person"}},
+		{"negative person", map[string]string{"name": "", "person": "-1"}, domain.Guest{}, []string{"name", "person"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, rowErrors := parseGuestImportRow(3, func(field string) string { return tt.row[field] }, (&GuestUsecase{}).normalizeTelp)
+
+			var fields []string
+			for _, v := range rowErrors {
+				if v.Row != 3 {
+					t.Errorf("error reported for row %d, want 3", v.Row)
+				}
+				fields = append(fields, v.Field)
+			}
+			if !slices.Equal(fields, tt.fields) {
+				t.Errorf("errors on %v, want %v", fields, tt.fields)
+			}
+			if tt.fields == nil && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGuestImport(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "u1", "u1@example.com", domain.RoleUser)
+	_, ctx := env.login(t, "u1@example.com")
+	env.createTemplate(t, ctx, "t1")
+	env.createGuest(t, ctx, domain.Guest{ID: "g1", Name: "Budi", Group: "family", Telp: "081234567890", UserTemplateID: "t1"})
+	guests := env.guests()
+
+	rows := [][]string{
+		{"Nama", "Telp", "Group"},
+		{"Budi S", "081234567890", ""},
+		{"Siti", "", "friends"},
+		{"", "081398765432", ""},
+		{"siti", "", ""},
+		{"", "", ""},
+	}
+	opt := GuestImportOptions{Mapping: map[string]string{"name": "Nama"}, DryRun: true}
+
+	result, err := guests.Import(ctx, "t1", rows, opt)
+	if err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+	want := GuestImportResult{DryRun: true, Total: 4, Created: 1, Skipped: 3}
+	if result.Total != want.Total || result.Created != want.Created || result.Updated != want.Updated || result.Skipped != want.Skipped {
+		t.Errorf("dry run = %+v, want %+v", result, want)
+	}
+	if len(result.Errors) != 2 || result.Errors[0].Row != 4 || result.Errors[1].Row != 5 {
+		t.Errorf("errors = %+v, want rows 4 and 5", result.Errors)
+	}
+
+	list, _ := guests.guestRepo.ListAll(ctx, sql.GuestFilter{UserTemplateID: "t1"})
+	if len(list) != 1 {
+		t.Fatalf("dry run wrote %d guests", len(list)-1)
+	}
+
+	opt.DryRun = false
+	opt.UpdateExisting = true
+	result, err = guests.Import(ctx, "t1", rows, opt)
+	if err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	if result.Created != 1 || result.Updated != 1 {
+		t.Errorf("import = %+v, want 1 created and 1 updated", result)
+	}
+
+	// the empty group cell keeps the stored group
+	budi, err := guests.guestRepo.Get(ctx, "g1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if budi.Name != "Budi S" || budi.Group != "family" {
+		t.Errorf("updated guest = %+v", budi)
+	}
+
+	if _, err := guests.Import(ctx, "t1", rows[:1], opt); !errors.Is(err, ErrGuestImportEmpty) {
+		t.Errorf("header only: err = %v, want ErrGuestImportEmpty", err)
+	}
+	tooBig := make([][]string, MaxGuestImportRows+2)
+	if _, err := guests.Import(ctx, "t1", tooBig, opt); !errors.Is(err, ErrGuestImportTooBig) {
+		t.Errorf("too many rows: err = %v, want ErrGuestImportTooBig", err)
+	}
+}
+
+func TestGuestImportImpersonated(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "admin", "admin@example.com", domain.RoleAdmin)
+	env.register(t, "u1", "u1@example.com", domain.RoleUser)
+	_, admin := env.login(t, "admin@example.com")
+	_, ctx := env.login(t, "u1@example.com")
+	env.createTemplate(t, ctx, "t1")
+	_, readOnly := env.impersonate(t, admin, "u1", false)
+	rows := [][]string{{"name"}, {"Budi"}}
+
+	if _, err := env.guests().Import(readOnly, "t1", rows, GuestImportOptions{UpdateExisting: true}); !errors.Is(err, ErrImpersonationReadOnly) {
+		t.Errorf("updating: err = %v, want ErrImpersonationReadOnly", err)
+	}
+
+	// adding guests and trying an update are fine
+	if _, err := env.guests().Import(readOnly, "t1", rows, GuestImportOptions{UpdateExisting: true, DryRun: true}); err != nil {
+		t.Errorf("dry run: %v", err)
+	}
+	if _, err := env.guests().Import(readOnly, "t1", rows, GuestImportOptions{}); err != nil {
+		t.Errorf("adding: %v", err)
+	}
+}
+
+func TestGuestImportHeadcount(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "u1", "u1@example.com", domain.RoleUser)
+	_, ctx := env.login(t, "u1@example.com")
+	env.createTemplate(t, ctx, "t1")
+	env.createGuest(t, ctx, domain.Guest{ID: "g1", Name: "Budi", Person: 3, UserTemplateID: "t1"})
+	guests := env.guests()
+
+	yes, two := true, 2
+	if _, err := guests.Update(ctx, "g1", sql.GuestUpdate{Attend: &yes, AttendPerson: &two}); err != nil {
+		t.Fatal(err)
+	}
+
+	rows := [][]string{{"name", "person"}, {"Budi", "1"}}
+	result, err := guests.Import(ctx, "t1", rows, GuestImportOptions{UpdateExisting: true})
+	if err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	if result.Updated != 0 || result.Skipped != 1 || len(result.Errors) != 1 || result.Errors[0].Field != "person" {
+		t.Errorf("import = %+v, want the row skipped for its person", result)
+	}
+
+	guest, _ := guests.guestRepo.Get(ctx, "g1")
+	if guest.Person != 3 {
+		t.Errorf("person = %d, want 3", guest.Person)
+	}
+}
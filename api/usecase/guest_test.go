@@ -0,0 +1,232 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"basic-service/domain"
+	"basic-service/interface/sql"
+)
+
+func TestGuestUpdate(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "u1", "u1@example.com", domain.RoleUser)
+	env.register(t, "u2", "u2@example.com", domain.RoleUser)
+	env.register(t, "u3", "u3@example.com", domain.RoleUser)
+	_, owner := env.login(t, "u1@example.com")
+	_, stranger := env.login(t, "u3@example.com")
+	env.createTemplate(t, owner, "t1")
+	viewer := env.addMember(t, owner, "t1", "u2@example.com", domain.TemplateRoleViewer)
+	env.createGuest(t, owner, domain.Guest{
+		ID:             "g1",
+		Name:           "Budi",
+		Group:          "family",
+		Person:         2,
+		Tags:           []string{"vip"},
+		Telp:           "081234567890",
+		UserTemplateID: "t1",
+	})
+	guests := env.guests()
+
+	name := "Budi Santoso"
+	tags := []string{"vip", "table 1"}
+	guest, err := guests.Update(owner, "g1", sql.GuestUpdate{Name: &name, Tags: &tags})
+	if err != nil {
+		t.Fatalf("update: %v", err)
+	}
+
+	// fields that are not given keep their value
+	if guest.Name != name || !slices.Equal(guest.Tags, tags) || guest.Group != "family" || guest.Person != 2 || guest.Telp != "+6281234567890" {
+		t.Errorf("updated guest = %+v", guest)
+	}
+
+	if _, err := guests.Update(viewer, "g1", sql.GuestUpdate{Name: &name}); !errors.Is(err, ErrForbidden) {
+		t.Errorf("viewer: err = %v, want ErrForbidden", err)
+	}
+	if _, err := guests.Update(stranger, "g1", sql.GuestUpdate{Name: &name}); !errors.Is(err, ErrForbidden) {
+		t.Errorf("stranger: err = %v, want ErrForbidden", err)
+	}
+	if _, err := guests.Update(owner, "unknown", sql.GuestUpdate{Name: &name}); !errors.Is(err, sql.ErrGuestNotFound) {
+		t.Errorf("unknown guest: err = %v, want ErrGuestNotFound", err)
+	}
+}
+
+func TestGuestDelete(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "admin", "admin@example.com", domain.RoleAdmin)
+	env.register(t, "u1", "u1@example.com", domain.RoleUser)
+	env.register(t, "u2", "u2@example.com", domain.RoleUser)
+	_, admin := env.login(t, "admin@example.com")
+	_, owner := env.login(t, "u1@example.com")
+	env.createTemplate(t, owner, "t1")
+	editor := env.addMember(t, owner, "t1", "u2@example.com", domain.TemplateRoleEditor)
+	env.createGuest(t, owner, domain.Guest{ID: "g1", Name: "g1", UserTemplateID: "t1"})
+	guests := env.guests()
+
+	// admins can read every template but not change it
+	if err := guests.Delete(admin, "g1"); !errors.Is(err, ErrForbidden) {
+		t.Errorf("admin: err = %v, want ErrForbidden", err)
+	}
+
+	_, readOnly := env.impersonate(t, admin, "u1", false)
+	if err := guests.Delete(readOnly, "g1"); !errors.Is(err, ErrImpersonationReadOnly) {
+		t.Errorf("impersonated: err = %v, want ErrImpersonationReadOnly", err)
+	}
+
+	if err := guests.Delete(editor, "g1"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, err := guests.guestRepo.Get(context.Background(), "g1"); !errors.Is(err, sql.ErrGuestNotFound) {
+		t.Errorf("deleted guest: err = %v, want ErrGuestNotFound", err)
+	}
+}
+
+func TestGuestExport(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "u1", "u1@example.com", domain.RoleUser)
+	env.register(t, "u2", "u2@example.com", domain.RoleUser)
+	env.register(t, "u3", "u3@example.com", domain.RoleUser)
+	_, owner := env.login(t, "u1@example.com")
+	_, stranger := env.login(t, "u3@example.com")
+	env.createTemplate(t, owner, "t1")
+	viewer := env.addMember(t, owner, "t1", "u2@example.com", domain.TemplateRoleViewer)
+	env.createGuest(t, owner, domain.Guest{ID: "g1", Name: "g1", UserTemplateID: "t1"})
+	env.createGuest(t, owner, domain.Guest{ID: "g2", Name: "g2", UserTemplateID: "t1"})
+
+	export, err := env.guests().Export(viewer, sql.GuestFilter{UserTemplateID: "t1"})
+	if err != nil {
+		t.Fatalf("viewer: %v", err)
+	}
+	if export.Template.ID != "t1" || len(export.Guests) != 2 {
+		t.Errorf("exported %s with %d guests, want t1 with 2", export.Template.ID, len(export.Guests))
+	}
+
+	if _, err := env.guests().Export(stranger, sql.GuestFilter{UserTemplateID: "t1"}); !errors.Is(err, ErrForbidden) {
+		t.Errorf("stranger: err = %v, want ErrForbidden", err)
+	}
+}
+
+func TestGuestListFilter(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "u1", "u1@example.com", domain.RoleUser)
+	_, ctx := env.login(t, "u1@example.com")
+	env.createTemplate(t, ctx, "t1")
+	env.createTemplate(t, ctx, "t2")
+
+	yes, no := true, false
+	now := time.Now()
+	for _, v := range []domain.Guest{
+		{ID: "g1", Name: "Budi", Group: "family", Person: 2, Tags: []string{"vip"}, Telp: "081234567890", Attend: &yes, ViewAt: &now},
+		{ID: "g2", Name: "Andi", Group: "friends", Person: 1, Tags: []string{"table 1"}, Address: "Jl. Budi Utomo", Attend: &no, ViewAt: &now},
+		{ID: "g3", Name: "Citra", Group: "family", Person: 3, Tags: []string{"vip", "table 1"}, Telp: "081398765432"},
+	} {
+		v.UserTemplateID = "t1"
+		env.createGuest(t, ctx, v)
+	}
+	env.createGuest(t, ctx, domain.Guest{ID: "g4", Name: "Budi", UserTemplateID: "t2"})
+	guests := env.guests()
+
+	viewed, notViewed := true, false
+	tests := []struct {
+		name   string
+		filter sql.GuestFilter
+		want   []string
+	}{
+		{"all oldest first", sql.GuestFilter{}, []string{"g1", "g2", "g3"}},
+		{"query matches name and address", sql.GuestFilter{Query: "budi"}, []string{"g1", "g2"}},
+		{"query matches telp", sql.GuestFilter{Query: "3987"}, []string{"g3"}},
+		{"query matches local telp", sql.GuestFilter{Query: "0812-3456"}, []string{"g1"}},
+		{"query matches telp with country code", sql.GuestFilter{Query: "62 813"}, []string{"g3"}},
+		{"query matches international telp", sql.GuestFilter{Query: "+62 812"}, []string{"g1"}},
+		{"query is not a pattern", sql.GuestFilter{Query: "%"}, nil},
+		{"query underscore is not a wildcard", sql.GuestFilter{Query: "B_di"}, nil},
+		{"group", sql.GuestFilter{Group: "family"}, []string{"g1", "g3"}},
+		{"tag", sql.GuestFilter{Tag: "table 1"}, []string{"g2", "g3"}},
+		{"attending", sql.GuestFilter{Attend: "yes"}, []string{"g1"}},
+		{"not attending", sql.GuestFilter{Attend: "no"}, []string{"g2"}},
+		{"pending", sql.GuestFilter{Attend: "pending"}, []string{"g3"}},
+		{"viewed", sql.GuestFilter{Viewed: &viewed}, []string{"g1", "g2"}},
+		{"not viewed", sql.GuestFilter{Viewed: &notViewed}, []string{"g3"}},
+		{"sort by name", sql.GuestFilter{SortBy: "name"}, []string{"g2", "g1", "g3"}},
+		{"sort by person descending", sql.GuestFilter{SortBy: "person", Desc: true}, []string{"g3", "g1", "g2"}},
+		{"unknown sort column", sql.GuestFilter{SortBy: "telp; DROP TABLE guests"}, []string{"g1", "g2", "g3"}},
+		{"combined", sql.GuestFilter{Group: "family", Tag: "vip", Attend: "pending"}, []string{"g3"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.filter.UserTemplateID = "t1"
+			result, err := guests.List(ctx, tt.filter, 1, 10)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			for _, v := range result.Data {
+				got = append(got, v.ID)
+			}
+			if !slices.Equal(got, tt.want) || result.Total != int64(len(tt.want)) {
+				t.Errorf("got %v (total %d), want %v", got, result.Total, tt.want)
+			}
+		})
+	}
+
+	// the total counts every match, not only the page
+	result, err := guests.List(ctx, sql.GuestFilter{UserTemplateID: "t1"}, 2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Data) != 1 || result.Data[0].ID != "g3" || result.Total != 3 {
+		t.Errorf("second page = %+v", result)
+	}
+}
+
+func TestUpdateHeadcount(t *testing.T) {
+	yes, no := true, false
+	zero, one, two, three := 0, 1, 2, 3
+	attending := domain.Guest{Person: 2, Attend: &yes, AttendPerson: &two}
+	invited := domain.Guest{Person: 2}
+
+	tests := []struct {
+		name    string
+		guest   domain.Guest
+		data    sql.GuestUpdate
+		want    *int
+		wantErr bool
+	}{
+		{"decline clears headcount", attending, sql.GuestUpdate{Attend: &no}, &zero, false},
+		{"decline with zero", attending, sql.GuestUpdate{Attend: &no, AttendPerson: &zero}, &zero, false},
+		{"decline with headcount", attending, sql.GuestUpdate{Attend: &no, AttendPerson: &one}, nil, true},
+		{"attend keeps headcount", attending, sql.GuestUpdate{Attend: &yes}, nil, false},
+		{"attend without headcount", invited, sql.GuestUpdate{Attend: &yes}, &one, false},
+		{"attend with zero", invited, sql.GuestUpdate{Attend: &yes, AttendPerson: &zero}, &one, false},
+		{"attend above invited", invited, sql.GuestUpdate{Attend: &yes, AttendPerson: &three}, nil, true},
+		{"lower person below headcount", attending, sql.GuestUpdate{Person: &one}, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := tt.data
+			err := updateHeadcount(tt.guest, &data)
+			if tt.wantErr {
+				if !errors.Is(err, ErrRSVPInvalid) {
+					t.Fatalf("err = %v, want ErrRSVPInvalid", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("err = %v", err)
+			}
+
+			switch {
+			case tt.want == nil && data.AttendPerson != nil:
+				t.Errorf("AttendPerson = %d, want unchanged", *data.AttendPerson)
+			case tt.want != nil && (data.AttendPerson == nil || *data.AttendPerson != *tt.want):
+				t.Errorf("AttendPerson = %v, want %d", data.AttendPerson, *tt.want)
+			}
+		})
+	}
+}
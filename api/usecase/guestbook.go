@@ -0,0 +1,227 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	"basic-service/domain"
+	"basic-service/interface/sql"
+
+	"braces.dev/errtrace"
+)
+
+// MaxGuestbookBlockedWords is the longest blocked word list of a template
+const MaxGuestbookBlockedWords = 500
+
+var ErrGuestbookBlockedWords = fmt.Errorf("a template can block at most %d words", MaxGuestbookBlockedWords)
+
+// Guestbook is the wall of guest messages of a template, the public only
+// sees approved messages and the hosts moderate them.
+type Guestbook struct {
+	guests    *sql.GuestManager
+	templates *sql.UserTemplateRepository
+	members   *sql.TemplateMemberRepository
+}
+
+func NewGuestbook(guests *sql.GuestManager, templates *sql.UserTemplateRepository, members *sql.TemplateMemberRepository) *Guestbook {
+	return &Guestbook{guests: guests, templates: templates, members: members}
+}
+
+// GuestbookEntry is a message on the moderation list, Flagged when it
+// contains a blocked word
+type GuestbookEntry struct {
+	domain.Guest
+	Flagged bool
+}
+
+type GuestbookList struct {
+	Total int64
+	Data  []GuestbookEntry
+}
+
+type GuestbookSettings struct {
+	ApproveFirst bool
+	BlockedWords []string
+}
+
+// guestbookFilter lists the messages of a template, pinned first and newest
+// first
+func guestbookFilter(templateID string, status domain.MessageStatus) sql.GuestFilter {
+	return sql.GuestFilter{
+		UserTemplateID: templateID,
+		Guestbook:      true,
+		MessageStatus:  status,
+		PinnedFirst:    true,
+		SortBy:         "message_at",
+		Desc:           true,
+	}
+}
+
+// Public returns a page of the approved messages of the template published
+// under slug.
+func (b *Guestbook) Public(ctx context.Context, slug string, page, limit int) (GuestListResult, error) {
+	template, err := b.templates.GetBySlug(ctx, slug)
+	if err != nil {
+		return GuestListResult{}, errtrace.Wrap(err)
+	}
+
+	if err := templateAvailable(template, time.Now()); err != nil {
+		return GuestListResult{}, err
+	}
+
+	guests, total, err := b.guests.List(ctx, guestbookFilter(template.ID, domain.MessageApproved), page, limit)
+	if err != nil {
+		return GuestListResult{}, errtrace.Wrap(err)
+	}
+
+	return GuestListResult{Total: total, Data: guests}, nil
+}
+
+// List returns a page of the messages of a template in any state, status
+// narrows them to one. Every member can read it.
+func (b *Guestbook) List(ctx context.Context, templateID string, status domain.MessageStatus, page, limit int) (GuestbookList, error) {
+	if _, err := authorizeTemplate(ctx, b.members, templateID, domain.TemplateRoleViewer); err != nil {
+		return GuestbookList{}, err
+	}
+
+	template, err := b.templates.Get(ctx, templateID)
+	if err != nil {
+		return GuestbookList{}, errtrace.Wrap(err)
+	}
+
+	guests, total, err := b.guests.List(ctx, guestbookFilter(templateID, status), page, limit)
+	if err != nil {
+		return GuestbookList{}, errtrace.Wrap(err)
+	}
+
+	result := GuestbookList{Total: total, Data: make([]GuestbookEntry, 0, len(guests))}
+	for _, v := range guests {
+		result.Data = append(result.Data, GuestbookEntry{
+			Guest:   v,
+			Flagged: hasBlockedWord(template.GuestbookBlockedWords, v.Message),
+		})
+	}
+
+	return result, nil
+}
+
+// Moderate approves, holds or hides the message of a guest and pins or
+// unpins it, nil leaves it as it is. Editors and owners can do it.
+func (b *Guestbook) Moderate(ctx context.Context, templateID, guestID string, status *domain.MessageStatus, pinned *bool) (GuestbookEntry, error) {
+	if _, err := authorizeTemplate(ctx, b.members, templateID, domain.TemplateRoleEditor); err != nil {
+		return GuestbookEntry{}, err
+	}
+
+	guest, err := b.guests.Get(ctx, guestID)
+	if err != nil {
+		return GuestbookEntry{}, errtrace.Wrap(err)
+	}
+
+	if guest.UserTemplateID != templateID {
+		return GuestbookEntry{}, errtrace.Wrap(sql.ErrGuestNotFound)
+	}
+
+	template, err := b.templates.Get(ctx, templateID)
+	if err != nil {
+		return GuestbookEntry{}, errtrace.Wrap(err)
+	}
+
+	if err := b.guests.Update(ctx, guestID, sql.GuestUpdate{MessageStatus: status, MessagePinned: pinned}); err != nil {
+		return GuestbookEntry{}, errtrace.Wrap(err)
+	}
+
+	if status != nil {
+		guest.MessageStatus = *status
+	}
+	if pinned != nil {
+		guest.MessagePinned = *pinned
+	}
+
+	return GuestbookEntry{
+		Guest:   *guest,
+		Flagged: hasBlockedWord(template.GuestbookBlockedWords, guest.Message),
+	}, nil
+}
+
+// Settings returns the moderation settings of a template, every member can
+// read them.
+func (b *Guestbook) Settings(ctx context.Context, templateID string) (GuestbookSettings, error) {
+	if _, err := authorizeTemplate(ctx, b.members, templateID, domain.TemplateRoleViewer); err != nil {
+		return GuestbookSettings{}, err
+	}
+
+	template, err := b.templates.Get(ctx, templateID)
+	if err != nil {
+		return GuestbookSettings{}, errtrace.Wrap(err)
+	}
+
+	return GuestbookSettings{
+		ApproveFirst: template.GuestbookApproveFirst,
+		BlockedWords: template.GuestbookBlockedWords,
+	}, nil
+}
+
+// UpdateSettings changes the moderation settings of a template, messages
+// already written keep their state. Editors and owners can do it.
+func (b *Guestbook) UpdateSettings(ctx context.Context, templateID string, settings GuestbookSettings) (GuestbookSettings, error) {
+	if _, err := authorizeTemplate(ctx, b.members, templateID, domain.TemplateRoleEditor); err != nil {
+		return GuestbookSettings{}, err
+	}
+
+	words := make([]string, 0, len(settings.BlockedWords))
+	for _, v := range settings.BlockedWords {
+		v = strings.Join(messageWords(v), " ")
+		if v != "" && !slices.Contains(words, v) {
+			words = append(words, v)
+		}
+	}
+
+	if len(words) > MaxGuestbookBlockedWords {
+		return GuestbookSettings{}, errtrace.Wrap(ErrGuestbookBlockedWords)
+	}
+
+	if err := b.templates.UpdateGuestbook(ctx, templateID, settings.ApproveFirst, words); err != nil {
+		return GuestbookSettings{}, errtrace.Wrap(err)
+	}
+
+	return GuestbookSettings{ApproveFirst: settings.ApproveFirst, BlockedWords: words}, nil
+}
+
+// messageWords splits a message into lower case words, punctuation is a
+// separator
+func messageWords(message string) []string {
+	return strings.FieldsFunc(strings.ToLower(message), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// hasBlockedWord reports whether message contains one of words as a whole
+// word, a blocked entry of several words matches them in sequence.
+func hasBlockedWord(words []string, message string) bool {
+	if len(words) == 0 {
+		return false
+	}
+
+	text := " " + strings.Join(messageWords(message), " ") + " "
+	for _, v := range words {
+		if strings.Contains(text, " "+v+" ") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// guestbookStatus is the state of a new message on the guestbook of
+// template, messages with a blocked word always wait for a host.
+func guestbookStatus(template domain.UserTemplate, message string) domain.MessageStatus {
+	if template.GuestbookApproveFirst || hasBlockedWord(template.GuestbookBlockedWords, message) {
+		return domain.MessagePending
+	}
+
+	return domain.MessageApproved
+}
@@ -0,0 +1,132 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"basic-service/domain"
+)
+
+func TestHasBlockedWord(t *testing.T) {
+	words := []string{"bad", "very rude"}
+
+	tests := []struct {
+		message string
+		want    bool
+	}{
+		{"Congratulations!", false},
+		{"That is BAD.", true},
+		{"badminton after the party", false},
+		{"very, rude", true},
+		{"very kind and rude", false},
+	}
+
+	for _, tt := range tests {
+		if got := hasBlockedWord(words, tt.message); got != tt.want {
+			t.Errorf("hasBlockedWord(%q) = %v, want %v", tt.message, got, tt.want)
+		}
+	}
+	if hasBlockedWord(nil, "bad") {
+		t.Error("no blocked words matched a message")
+	}
+}
+
+// submitMessage writes a guestbook message as the guest id of template t1
+func submitMessage(t *testing.T, env *testEnv, ctx context.Context, id, message string) {
+	t.Helper()
+
+	invitation, err := env.guests().GetInvitation(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := env.guests().SubmitRSVP(context.Background(), invitation.Token, RSVP{Attend: true, Message: message}); err != nil {
+		t.Fatalf("rsvp of %s: %v", id, err)
+	}
+}
+
+func TestGuestbook(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "u1", "u1@example.com", domain.RoleUser)
+	env.register(t, "u2", "u2@example.com", domain.RoleUser)
+	_, ctx := env.login(t, "u1@example.com")
+	env.createTemplate(t, ctx, "t1")
+	viewer := env.addMember(t, ctx, "t1", "u2@example.com", domain.TemplateRoleViewer)
+	for _, id := range []string{"g1", "g2", "g3", "g4"} {
+		env.createGuest(t, ctx, domain.Guest{ID: id, Name: id, UserTemplateID: "t1"})
+	}
+	book := env.guestbook()
+
+	settings, err := book.UpdateSettings(ctx, "t1", GuestbookSettings{BlockedWords: []string{" Rude ", "rude", "", "very  BAD"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(settings.BlockedWords, []string{"rude", "very bad"}) {
+		t.Errorf("blocked words = %q", settings.BlockedWords)
+	}
+	if _, err := book.UpdateSettings(viewer, "t1", settings); !errors.Is(err, ErrForbidden) {
+		t.Errorf("viewer: err = %v, want ErrForbidden", err)
+	}
+
+	submitMessage(t, env, ctx, "g1", "Congratulations")
+	submitMessage(t, env, ctx, "g2", "how rude")
+	submitMessage(t, env, ctx, "g3", "Best wishes")
+
+	public, err := book.Public(context.Background(), "t1", 1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := guestIDs(public.Data); len(ids) != 2 || !slices.Contains(ids, "g1") || !slices.Contains(ids, "g3") {
+		t.Errorf("public messages = %v, want the approved g1 and g3", ids)
+	}
+
+	pending, err := book.List(viewer, "t1", domain.MessagePending, 1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending.Data) != 1 || pending.Data[0].ID != "g2" || !pending.Data[0].Flagged {
+		t.Errorf("pending messages = %+v, want g2 flagged", pending.Data)
+	}
+
+	// once hosts approve first, new messages wait
+	settings.ApproveFirst = true
+	if _, err := book.UpdateSettings(ctx, "t1", settings); err != nil {
+		t.Fatal(err)
+	}
+	submitMessage(t, env, ctx, "g4", "See you")
+
+	approved, pinned, hidden := domain.MessageApproved, true, domain.MessageHidden
+	if _, err := book.Moderate(viewer, "t1", "g4", &approved, nil); !errors.Is(err, ErrForbidden) {
+		t.Errorf("viewer moderating: err = %v, want ErrForbidden", err)
+	}
+	if _, err := book.Moderate(ctx, "t1", "g4", &approved, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := book.Moderate(ctx, "t1", "g1", nil, &pinned); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := book.Moderate(ctx, "t1", "g3", &hidden, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	public, _ = book.Public(context.Background(), "t1", 1, 10)
+	if ids := guestIDs(public.Data); !slices.Equal(ids, []string{"g1", "g4"}) {
+		t.Errorf("public messages = %v, want pinned g1 then g4", ids)
+	}
+
+	// editing the message sends it through moderation again
+	submitMessage(t, env, ctx, "g4", "See you soon")
+	public, _ = book.Public(context.Background(), "t1", 1, 10)
+	if ids := guestIDs(public.Data); !slices.Equal(ids, []string{"g1"}) {
+		t.Errorf("public messages = %v, want only g1", ids)
+	}
+}
+
+func guestIDs(guests []domain.Guest) []string {
+	ids := []string{}
+	for _, v := range guests {
+		ids = append(ids, v.ID)
+	}
+	return ids
+}
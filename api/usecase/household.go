@@ -0,0 +1,170 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"basic-service/domain"
+
+	"braces.dev/errtrace"
+	"github.com/google/uuid"
+)
+
+// MaxGuestMembers is the largest household invited as one guest
+const MaxGuestMembers = 20
+
+var (
+	ErrGuestMembersInvalid = errors.New("invalid household members")
+	ErrGuestHousehold      = errors.New("the headcount of a household comes from its members")
+)
+
+// MemberRSVP is the answer of one member of a household, Name names a plus
+// one and is ignored for the other members
+type MemberRSVP struct {
+	ID     string
+	Attend bool
+	Name   string
+}
+
+// SetMembers replaces the household of a guest, members with an id keep
+// their RSVP and the others are added. Person and the RSVP of the guest are
+// derived from the members, an empty list keeps them as they are. Editors and
+// owners can do it, removing members is destructive.
+func (g *GuestUsecase) SetMembers(ctx context.Context, guestID string, members []domain.GuestMember) (*domain.Guest, error) {
+	guest, err := g.guestRepo.Get(ctx, guestID)
+	if err != nil {
+		return nil, errtrace.Wrap(err)
+	}
+
+	if _, err := authorizeTemplate(ctx, g.members, guest.UserTemplateID, domain.TemplateRoleEditor); err != nil {
+		return nil, err
+	}
+
+	members, err = householdMembers(guest.ID, guest.Members, members)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, v := range guest.Members {
+		if !slices.ContainsFunc(members, func(m domain.GuestMember) bool { return m.ID == v.ID }) {
+			if err := AuthorizeDestructive(ctx); err != nil {
+				return nil, err
+			}
+			break
+		}
+	}
+
+	guest.Members = members
+	if len(members) > 0 {
+		householdHeadcount(guest)
+	}
+
+	if err := g.guestRepo.SetMembers(ctx, *guest); err != nil {
+		return nil, errtrace.Wrap(err)
+	}
+
+	return errtrace.Wrap2(g.guestRepo.Get(ctx, guestID))
+}
+
+// householdMembers checks the new members of a guest against the current
+// ones, members with an id must already be in the household.
+func householdMembers(guestID string, current, members []domain.GuestMember) ([]domain.GuestMember, error) {
+	if len(members) > MaxGuestMembers {
+		return nil, errtrace.Wrap(fmt.Errorf("%w: a household has at most %d members", ErrGuestMembersInvalid, MaxGuestMembers))
+	}
+
+	now := time.Now()
+	result := make([]domain.GuestMember, 0, len(members))
+	for i, v := range members {
+		v.Name = strings.TrimSpace(v.Name)
+		if v.Name == "" && !v.PlusOne {
+			return nil, errtrace.Wrap(fmt.Errorf("%w: member %d needs a name", ErrGuestMembersInvalid, i+1))
+		}
+		if len(v.Name) > 255 {
+			return nil, errtrace.Wrap(fmt.Errorf("%w: the name of member %d is longer than 255 characters", ErrGuestMembersInvalid, i+1))
+		}
+
+		if v.ID == "" {
+			v.ID = uuid.New().String()
+			v.CreatedAt = now
+		} else {
+			j := slices.IndexFunc(current, func(m domain.GuestMember) bool { return m.ID == v.ID })
+			if j < 0 || slices.ContainsFunc(result, func(m domain.GuestMember) bool { return m.ID == v.ID }) {
+				return nil, errtrace.Wrap(fmt.Errorf("%w: unknown member %q", ErrGuestMembersInvalid, v.ID))
+			}
+			v.Attend, v.CreatedAt = current[j].Attend, current[j].CreatedAt
+		}
+
+		v.GuestID = guestID
+		v.Position = i
+		result = append(result, v)
+	}
+
+	return result, nil
+}
+
+// householdHeadcount derives the headcount of a guest from its members:
+// Person is the number of members, AttendPerson the number who attend and
+// Attend is true when one attends, nil until a member answers.
+func householdHeadcount(guest *domain.Guest) {
+	guest.Person = len(guest.Members)
+
+	answered, coming := 0, 0
+	for _, v := range guest.Members {
+		if v.Attend == nil {
+			continue
+		}
+		answered++
+		if *v.Attend {
+			coming++
+		}
+	}
+
+	if answered == 0 {
+		guest.Attend, guest.AttendPerson = nil, nil
+		return
+	}
+
+	attend := coming > 0
+	guest.Attend, guest.AttendPerson = &attend, &coming
+}
+
+// memberRSVP records the answers of the members of a household, members who
+// are not listed keep their answer. A household that declines without
+// listing members declines for all of them.
+func memberRSVP(guest *domain.Guest, rsvp RSVP) error {
+	if len(rsvp.Members) == 0 {
+		if rsvp.Attend {
+			return errtrace.Wrap(fmt.Errorf("%w: say which members of the household attend", ErrRSVPInvalid))
+		}
+
+		for i := range guest.Members {
+			attend := false
+			guest.Members[i].Attend = &attend
+		}
+	}
+
+	for _, v := range rsvp.Members {
+		i := slices.IndexFunc(guest.Members, func(m domain.GuestMember) bool { return m.ID == v.ID })
+		if i < 0 {
+			return errtrace.Wrap(fmt.Errorf("%w: unknown member %q", ErrRSVPInvalid, v.ID))
+		}
+
+		attend := v.Attend
+		guest.Members[i].Attend = &attend
+
+		if name := strings.TrimSpace(v.Name); guest.Members[i].PlusOne && name != "" {
+			if len(name) > 255 {
+				return errtrace.Wrap(fmt.Errorf("%w: the name of a plus one is longer than 255 characters", ErrRSVPInvalid))
+			}
+			guest.Members[i].Name = name
+		}
+	}
+
+	householdHeadcount(guest)
+	return nil
+}
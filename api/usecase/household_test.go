@@ -0,0 +1,186 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"basic-service/domain"
+	"basic-service/interface/sql"
+)
+
+func TestHouseholdMembers(t *testing.T) {
+	yes := true
+	current := []domain.GuestMember{{ID: "m1", Name: "Budi", Attend: &yes}}
+
+	members, err := householdMembers("g1", current, []domain.GuestMember{
+		{Name: " Siti "},
+		{ID: "m1", Name: "Budi S"},
+		{PlusOne: true},
+	})
+	if err != nil {
+		t.Fatalf("members: %v", err)
+	}
+	if len(members) != 3 || members[0].Name != "Siti" || members[0].ID == "" || members[1].ID != "m1" || members[2].Position != 2 {
+		t.Fatalf("members = %+v", members)
+	}
+	// existing members keep their RSVP
+	if members[1].Attend == nil || !*members[1].Attend || members[0].Attend != nil || members[0].GuestID != "g1" {
+		t.Errorf("members = %+v", members)
+	}
+
+	invalid := []struct {
+		name    string
+		members []domain.GuestMember
+	}{
+		{"no name", []domain.GuestMember{{Name: " "}}},
+		{"long name", []domain.GuestMember{{Name: strings.Repeat("a", 256)}}},
+		{"unknown member", []domain.GuestMember{{ID: "m2", Name: "Andi"}}},
+		{"listed twice", []domain.GuestMember{{ID: "m1", Name: "Budi"}, {ID: "m1", Name: "Budi"}}},
+		{"too many", make([]domain.GuestMember, MaxGuestMembers+1)},
+	}
+	for _, tt := range invalid {
+		if _, err := householdMembers("g1", current, tt.members); !errors.Is(err, ErrGuestMembersInvalid) {
+			t.Errorf("%s: err = %v, want ErrGuestMembersInvalid", tt.name, err)
+		}
+	}
+}
+
+func TestHouseholdHeadcount(t *testing.T) {
+	yes, no := true, false
+	tests := []struct {
+		name    string
+		attend  []*bool
+		want    *bool
+		persons int
+	}{
+		{"no answers", []*bool{nil, nil}, nil, 0},
+		{"one attends", []*bool{&yes, nil, &no}, &yes, 1},
+		{"all decline", []*bool{&no, &no}, &no, 0},
+	}
+
+	for _, tt := range tests {
+		guest := domain.Guest{Person: 9}
+		for _, v := range tt.attend {
+			guest.Members = append(guest.Members, domain.GuestMember{Attend: v})
+		}
+
+		householdHeadcount(&guest)
+		if guest.Person != len(tt.attend) {
+			t.Errorf("%s: person = %d, want %d", tt.name, guest.Person, len(tt.attend))
+		}
+		if (guest.Attend == nil) != (tt.want == nil) || (tt.want != nil && (*guest.Attend != *tt.want || *guest.AttendPerson != tt.persons)) {
+			t.Errorf("%s: attend = %v %v, want %v %d", tt.name, guest.Attend, guest.AttendPerson, tt.want, tt.persons)
+		}
+	}
+}
+
+func TestSetMembers(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "admin", "admin@example.com", domain.RoleAdmin)
+	env.register(t, "u1", "u1@example.com", domain.RoleUser)
+	env.register(t, "u2", "u2@example.com", domain.RoleUser)
+	_, admin := env.login(t, "admin@example.com")
+	_, ctx := env.login(t, "u1@example.com")
+	env.createTemplate(t, ctx, "t1")
+	viewer := env.addMember(t, ctx, "t1", "u2@example.com", domain.TemplateRoleViewer)
+	env.createGuest(t, ctx, domain.Guest{ID: "g1", Name: "Keluarga Budi", UserTemplateID: "t1", Members: []domain.GuestMember{{Name: "Budi"}, {Name: "Siti"}}})
+	guests := env.guests()
+	_, readOnly := env.impersonate(t, admin, "u1", false)
+
+	guest, _ := guests.guestRepo.Get(ctx, "g1")
+	if guest.Person != 2 || len(guest.Members) != 2 {
+		t.Fatalf("created household = %+v", guest)
+	}
+	budi, siti := guest.Members[0], guest.Members[1]
+
+	if _, err := guests.SetMembers(viewer, "g1", []domain.GuestMember{budi}); !errors.Is(err, ErrForbidden) {
+		t.Errorf("viewer: err = %v, want ErrForbidden", err)
+	}
+
+	// adding members is not destructive, removing them is
+	guest, err := guests.SetMembers(readOnly, "g1", []domain.GuestMember{budi, siti, {PlusOne: true}})
+	if err != nil {
+		t.Fatalf("add plus one: %v", err)
+	}
+	if guest.Person != 3 {
+		t.Errorf("person = %d, want 3", guest.Person)
+	}
+	if _, err := guests.SetMembers(readOnly, "g1", []domain.GuestMember{budi}); !errors.Is(err, ErrImpersonationReadOnly) {
+		t.Errorf("read-only remove: err = %v, want ErrImpersonationReadOnly", err)
+	}
+
+	guest, err = guests.SetMembers(ctx, "g1", []domain.GuestMember{budi})
+	if err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+	if guest.Person != 1 || len(guest.Members) != 1 || guest.Members[0].ID != budi.ID {
+		t.Errorf("household = %+v", guest)
+	}
+
+	person := 4
+	if _, err := guests.Update(ctx, "g1", sql.GuestUpdate{Person: &person}); !errors.Is(err, ErrGuestHousehold) {
+		t.Errorf("update person: err = %v, want ErrGuestHousehold", err)
+	}
+}
+
+func TestHouseholdRSVP(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "u1", "u1@example.com", domain.RoleUser)
+	_, ctx := env.login(t, "u1@example.com")
+	env.createTemplate(t, ctx, "t1")
+	env.createGuest(t, ctx, domain.Guest{ID: "g1", Name: "Keluarga Budi", UserTemplateID: "t1", Members: []domain.GuestMember{{Name: "Budi"}, {Name: "Siti"}, {PlusOne: true}}})
+	env.createGuest(t, ctx, domain.Guest{ID: "g2", Name: "Andi", UserTemplateID: "t1"})
+	guests := env.guests()
+	bg := context.Background()
+
+	invitation, _ := guests.GetInvitation(ctx, "g1")
+	public, _ := guests.GetGuest(bg, invitation.Token)
+	members := public.Guest.Members
+
+	invalid := []struct {
+		name string
+		rsvp RSVP
+	}{
+		{"attend without members", RSVP{Attend: true}},
+		{"unknown member", RSVP{Members: []MemberRSVP{{ID: "other", Attend: true}}}},
+		{"long plus one name", RSVP{Members: []MemberRSVP{{ID: members[2].ID, Attend: true, Name: strings.Repeat("a", 256)}}}},
+	}
+	for _, tt := range invalid {
+		if err := guests.SubmitRSVP(bg, invitation.Token, tt.rsvp); !errors.Is(err, ErrRSVPInvalid) {
+			t.Errorf("%s: err = %v, want ErrRSVPInvalid", tt.name, err)
+		}
+	}
+
+	rsvp := RSVP{Members: []MemberRSVP{
+		{ID: members[0].ID, Attend: true},
+		{ID: members[1].ID, Attend: false, Name: "ignored"},
+		{ID: members[2].ID, Attend: true, Name: " Andi "},
+	}}
+	if err := guests.SubmitRSVP(bg, invitation.Token, rsvp); err != nil {
+		t.Fatalf("submit: %v", err)
+	}
+
+	guest, _ := guests.guestRepo.Get(ctx, "g1")
+	if guest.Attend == nil || !*guest.Attend || guest.AttendPerson == nil || *guest.AttendPerson != 2 {
+		t.Errorf("headcount = %v %v, want 2 attending", guest.Attend, guest.AttendPerson)
+	}
+	if guest.Members[1].Name != "Siti" || guest.Members[2].Name != "Andi" {
+		t.Errorf("members = %+v", guest.Members)
+	}
+
+	// declining without members declines for the whole household
+	if err := guests.SubmitRSVP(bg, invitation.Token, RSVP{}); err != nil {
+		t.Fatalf("decline: %v", err)
+	}
+	guest, _ = guests.guestRepo.Get(ctx, "g1")
+	if guest.Attend == nil || *guest.Attend || *guest.AttendPerson != 0 {
+		t.Errorf("declined headcount = %v %v", guest.Attend, guest.AttendPerson)
+	}
+
+	single, _ := guests.GetInvitation(ctx, "g2")
+	if err := guests.SubmitRSVP(bg, single.Token, rsvp); !errors.Is(err, ErrRSVPInvalid) {
+		t.Errorf("members for a single guest: err = %v, want ErrRSVPInvalid", err)
+	}
+}
@@ -0,0 +1,173 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"basic-service/domain"
+
+	"braces.dev/errtrace"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+const defaultImpersonationTTL = 30 * time.Minute
+
+var (
+	ErrCannotImpersonate     = errors.New("this user cannot be impersonated")
+	ErrImpersonationReadOnly = errors.New("destructive operations are not allowed while impersonating")
+)
+
+// ImpersonationToken is an access token acting as another user. It has no
+// refresh token, support has to mint a new one once it expires. ID is the
+// JTI of the token, RevokeImpersonation takes it.
+type ImpersonationToken struct {
+	ID               string
+	AccessToken      string
+	ExpiresAt        time.Time
+	UserID           string
+	AllowDestructive bool
+}
+
+// IsImpersonated reports whether the claims were minted by an admin acting as
+// the user.
+func (c *Claims) IsImpersonated() bool {
+	return c.ImpersonatorID != ""
+}
+
+// Impersonate mints a short lived access token for userID on behalf of the
+// admin in ctx. ttl is capped at Auth.ImpersonationTTL, allowDestructive
+// lets the token delete data.
+func (a *Auth) Impersonate(ctx context.Context, userID, reason string, ttl time.Duration, allowDestructive bool) (ImpersonationToken, error) {
+	if err := Authorize(ctx, PermUserImpersonate); err != nil {
+		return ImpersonationToken{}, err
+	}
+
+	claims, err := GetClaimFromContext(ctx)
+	if err != nil {
+		return ImpersonationToken{}, errtrace.Wrap(errors.New("invalid token claims"))
+	}
+
+	if claims.IsImpersonated() || claims.UserID == userID {
+		return ImpersonationToken{}, errtrace.Wrap(ErrCannotImpersonate)
+	}
+
+	user, err := a.UserManager.GetUserByID(ctx, userID)
+	if err != nil {
+		return ImpersonationToken{}, errtrace.Wrap(err)
+	}
+
+	// admins would hand their rights to whoever holds the token
+	if user.Role == domain.RoleAdmin || !user.IsActive {
+		return ImpersonationToken{}, errtrace.Wrap(ErrCannotImpersonate)
+	}
+
+	if ttl <= 0 || ttl > a.ImpersonationTTL {
+		ttl = a.ImpersonationTTL
+	}
+	expiresAt := time.Now().Add(ttl)
+	tokenID := uuid.New().String()
+
+	token, err := a.Keys.Sign(&Claims{
+		UserID:           user.ID,
+		Email:            user.Email,
+		Role:             user.Role,
+		ImpersonatorID:   claims.UserID,
+		AllowDestructive: allowDestructive,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        tokenID,
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	})
+	if err != nil {
+		return ImpersonationToken{}, errtrace.Wrap(err)
+	}
+
+	if err := a.Audit.Record(ctx, domain.AuditLog{
+		Action:     AuditImpersonationStart,
+		TargetType: "user",
+		TargetID:   user.ID,
+		IP:         ClientFromContext(ctx).IP,
+		Metadata: map[string]any{
+			"token_id":          tokenID,
+			"reason":            reason,
+			"expires_at":        expiresAt,
+			"allow_destructive": allowDestructive,
+		},
+	}); err != nil {
+		return ImpersonationToken{}, errtrace.Wrap(err)
+	}
+
+	return ImpersonationToken{
+		ID:               tokenID,
+		AccessToken:      token,
+		ExpiresAt:        expiresAt,
+		UserID:           user.ID,
+		AllowDestructive: allowDestructive,
+	}, nil
+}
+
+// RevokeImpersonation ends an impersonation token of userID before it
+// expires, tokenID is the ID returned by Impersonate.
+func (a *Auth) RevokeImpersonation(ctx context.Context, userID, tokenID string) error {
+	if err := Authorize(ctx, PermUserImpersonate); err != nil {
+		return err
+	}
+
+	if _, err := a.UserManager.GetUserByID(ctx, userID); err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	// the token can't outlive the maximum ttl, the revocation is purged
+	// after that
+	if err := a.Tokens.RevokeJTI(ctx, tokenID, userID, time.Now().Add(a.ImpersonationTTL)); err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	return errtrace.Wrap(a.Audit.Record(ctx, domain.AuditLog{
+		Action:     AuditImpersonationRevoke,
+		TargetType: "user",
+		TargetID:   userID,
+		IP:         ClientFromContext(ctx).IP,
+		Metadata:   map[string]any{"token_id": tokenID},
+	}))
+}
+
+// validateImpersonation keeps an impersonation token valid only while the
+// admin that minted it is still an active admin and the user is still active.
+func (a *Auth) validateImpersonation(ctx context.Context, claims *Claims) error {
+	admin, err := a.UserManager.GetUserByID(ctx, claims.ImpersonatorID)
+	if err != nil {
+		return errtrace.Wrap(ErrTokenRevoked)
+	}
+	if !admin.IsActive || admin.Role != domain.RoleAdmin {
+		return errtrace.Wrap(ErrTokenRevoked)
+	}
+
+	user, err := a.UserManager.GetUserByID(ctx, claims.UserID)
+	if err != nil {
+		return errtrace.Wrap(ErrTokenRevoked)
+	}
+	if !user.IsActive {
+		return errtrace.Wrap(ErrTokenRevoked)
+	}
+
+	return nil
+}
+
+// AuthorizeDestructive returns ErrImpersonationReadOnly when the request is
+// impersonated without the destructive flag.
+func AuthorizeDestructive(ctx context.Context) error {
+	claims, err := GetClaimFromContext(ctx)
+	if err != nil {
+		return errtrace.Wrap(ErrForbidden)
+	}
+
+	if claims.IsImpersonated() && !claims.AllowDestructive {
+		return errtrace.Wrap(ErrImpersonationReadOnly)
+	}
+
+	return nil
+}
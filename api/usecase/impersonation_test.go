@@ -0,0 +1,177 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"basic-service/domain"
+)
+
+// impersonate returns the context of a request made with an impersonation
+// token of userID minted by the admin of ctx
+func (e *testEnv) impersonate(t *testing.T, ctx context.Context, userID string, allowDestructive bool) (ImpersonationToken, context.Context) {
+	t.Helper()
+
+	token, err := e.auth.Impersonate(ctx, userID, "support ticket", 0, allowDestructive)
+	if err != nil {
+		t.Fatalf("impersonate %s: %v", userID, err)
+	}
+
+	claims, err := e.auth.ValidateToken(context.Background(), token.AccessToken)
+	if err != nil {
+		t.Fatalf("validate impersonation token: %v", err)
+	}
+
+	return token, context.WithValue(context.Background(), "claims", claims)
+}
+
+func TestImpersonate(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "admin", "admin@example.com", domain.RoleAdmin)
+	env.register(t, "admin2", "admin2@example.com", domain.RoleAdmin)
+	env.register(t, "u1", "u1@example.com", domain.RoleUser)
+	env.register(t, "u2", "u2@example.com", domain.RoleUser)
+	_, admin := env.login(t, "admin@example.com")
+	_, user := env.login(t, "u1@example.com")
+
+	token, ctx := env.impersonate(t, admin, "u1", false)
+	claims, _ := GetClaimFromContext(ctx)
+	if claims.UserID != "u1" || claims.ImpersonatorID != "admin" || claims.AllowDestructive {
+		t.Errorf("claims = %+v", claims)
+	}
+	if token.ExpiresAt.After(time.Now().Add(env.auth.ImpersonationTTL)) {
+		t.Errorf("token expires at %s, after the maximum ttl", token.ExpiresAt)
+	}
+
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		userID string
+		want   error
+	}{
+		{"as user", user, "u2", ErrForbidden},
+		{"another admin", admin, "admin2", ErrCannotImpersonate},
+		{"self", admin, "admin", ErrCannotImpersonate},
+		{"while impersonating", ctx, "u2", ErrForbidden},
+	}
+
+	for _, tt := range tests {
+		if _, err := env.auth.Impersonate(tt.ctx, tt.userID, "", time.Hour, false); !errors.Is(err, tt.want) {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestAuthorizeDestructive(t *testing.T) {
+	tests := []struct {
+		name   string
+		claims *Claims
+		want   error
+	}{
+		{"session", &Claims{UserID: "u1"}, nil},
+		{"impersonated", &Claims{UserID: "u1", ImpersonatorID: "admin"}, ErrImpersonationReadOnly},
+		{"impersonated destructive", &Claims{UserID: "u1", ImpersonatorID: "admin", AllowDestructive: true}, nil},
+	}
+
+	for _, tt := range tests {
+		ctx := context.WithValue(context.Background(), "claims", tt.claims)
+		if err := AuthorizeDestructive(ctx); !errors.Is(err, tt.want) {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+
+	if err := AuthorizeDestructive(context.Background()); !errors.Is(err, ErrForbidden) {
+		t.Errorf("without claims: err = %v, want ErrForbidden", err)
+	}
+}
+
+func TestImpersonationDestructive(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "admin", "admin@example.com", domain.RoleAdmin)
+	env.register(t, "u1", "u1@example.com", domain.RoleUser)
+	_, admin := env.login(t, "admin@example.com")
+	_, user := env.login(t, "u1@example.com")
+	env.createTemplate(t, user, "t1")
+
+	_, readOnly := env.impersonate(t, admin, "u1", false)
+	if err := env.templates().Delete(readOnly, "t1"); !errors.Is(err, ErrImpersonationReadOnly) {
+		t.Errorf("delete template: err = %v, want ErrImpersonationReadOnly", err)
+	}
+
+	env.register(t, "u2", "u2@example.com", domain.RoleUser)
+	env.addMember(t, user, "t1", "u2@example.com", domain.TemplateRoleEditor)
+	if err := env.members().Remove(readOnly, "t1", "u2"); !errors.Is(err, ErrImpersonationReadOnly) {
+		t.Errorf("remove member: err = %v, want ErrImpersonationReadOnly", err)
+	}
+
+	invite, err := env.members().Invite(readOnly, "t1", "u3@example.com", domain.TemplateRoleEditor)
+	if err != nil {
+		t.Fatalf("invite: %v", err)
+	}
+	if err := env.members().RevokeInvite(readOnly, "t1", invite.ID); !errors.Is(err, ErrImpersonationReadOnly) {
+		t.Errorf("revoke invite: err = %v, want ErrImpersonationReadOnly", err)
+	}
+}
+
+func TestImpersonationRevoked(t *testing.T) {
+	tests := []struct {
+		name   string
+		revoke func(users *UserUsecase, admin context.Context) error
+	}{
+		{"admin demoted", func(users *UserUsecase, admin context.Context) error {
+			return users.ChangeRole(withClaims(context.Background(), "root", domain.RoleAdmin), "admin", domain.RoleUser)
+		}},
+		{"admin deactivated", func(users *UserUsecase, admin context.Context) error {
+			return users.UpdateState(withClaims(context.Background(), "root", domain.RoleAdmin), "admin", false)
+		}},
+		{"user deactivated", func(users *UserUsecase, admin context.Context) error {
+			return users.UpdateState(admin, "u1", false)
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			env.register(t, "admin", "admin@example.com", domain.RoleAdmin)
+			env.register(t, "u1", "u1@example.com", domain.RoleUser)
+			_, admin := env.login(t, "admin@example.com")
+			token, _ := env.impersonate(t, admin, "u1", false)
+
+			if err := tt.revoke(env.users(), admin); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := env.auth.ValidateToken(context.Background(), token.AccessToken); !errors.Is(err, ErrTokenRevoked) {
+				t.Errorf("err = %v, want ErrTokenRevoked", err)
+			}
+		})
+	}
+}
+
+func TestRevokeImpersonation(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "admin", "admin@example.com", domain.RoleAdmin)
+	env.register(t, "u1", "u1@example.com", domain.RoleUser)
+	_, admin := env.login(t, "admin@example.com")
+	_, user := env.login(t, "u1@example.com")
+	token, ctx := env.impersonate(t, admin, "u1", true)
+	other, _ := env.impersonate(t, admin, "u1", false)
+
+	if err := env.auth.RevokeImpersonation(user, "u1", token.ID); !errors.Is(err, ErrForbidden) {
+		t.Errorf("as user: err = %v, want ErrForbidden", err)
+	}
+	if err := env.auth.RevokeImpersonation(ctx, "u1", token.ID); !errors.Is(err, ErrForbidden) {
+		t.Errorf("while impersonating: err = %v, want ErrForbidden", err)
+	}
+
+	if err := env.auth.RevokeImpersonation(admin, "u1", token.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := env.auth.ValidateToken(context.Background(), token.AccessToken); !errors.Is(err, ErrTokenRevoked) {
+		t.Errorf("revoked token: err = %v, want ErrTokenRevoked", err)
+	}
+	if _, err := env.auth.ValidateToken(context.Background(), other.AccessToken); err != nil {
+		t.Errorf("other token: %v", err)
+	}
+}
@@ -0,0 +1,161 @@
+package usecase
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"basic-service/domain"
+	"basic-service/interface/sql"
+
+	"braces.dev/errtrace"
+)
+
+var (
+	ErrInvitationInvalid = errors.New("invalid invitation link")
+	ErrInvitationExpired = errors.New("the invitation has expired")
+)
+
+// Invitation is the public link of a guest, Token is empty when the link was
+// revoked
+type Invitation struct {
+	GuestID  string
+	Token    string
+	ExpireAt time.Time
+	Revoked  bool
+}
+
+// newInviteNonce returns a random nonce for an invitation link
+func newInviteNonce() *string {
+	b := make([]byte, 12)
+	_, _ = rand.Read(b)
+	nonce := hex.EncodeToString(b)
+	return &nonce
+}
+
+// invitationSignature signs the nonce of a guest, the guest id is part of the
+// signature so a nonce cannot be moved to another guest.
+func (g *GuestUsecase) invitationSignature(guestID, nonce string) []byte {
+	mac := hmac.New(sha256.New, g.InvitationKey)
+	mac.Write([]byte(guestID + ":" + nonce))
+	return mac.Sum(nil)[:16]
+}
+
+// InvitationToken returns the token of the public link of a guest, empty when
+// the link was revoked.
+func (g *GuestUsecase) InvitationToken(guest domain.Guest) string {
+	if guest.InviteNonce == nil {
+		return ""
+	}
+
+	sig := g.invitationSignature(guest.ID, *guest.InviteNonce)
+	return *guest.InviteNonce + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+// guestByToken returns the guest of an invitation token with its template.
+// The link only works while the template is published or has its RSVP
+// closed.
+func (g *GuestUsecase) guestByToken(ctx context.Context, token string) (*domain.Guest, domain.UserTemplate, error) {
+	nonce, encoded, ok := strings.Cut(token, ".")
+	if !ok || nonce == "" {
+		return nil, domain.UserTemplate{}, errtrace.Wrap(ErrInvitationInvalid)
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, domain.UserTemplate{}, errtrace.Wrap(ErrInvitationInvalid)
+	}
+
+	guest, err := g.guestRepo.GetByInviteNonce(ctx, nonce)
+	if err != nil {
+		if errors.Is(err, sql.ErrGuestNotFound) {
+			return nil, domain.UserTemplate{}, errtrace.Wrap(ErrInvitationInvalid)
+		}
+		return nil, domain.UserTemplate{}, errtrace.Wrap(err)
+	}
+
+	if !hmac.Equal(sig, g.invitationSignature(guest.ID, nonce)) {
+		return nil, domain.UserTemplate{}, errtrace.Wrap(ErrInvitationInvalid)
+	}
+
+	template, err := g.templates.Get(ctx, guest.UserTemplateID)
+	if err != nil {
+		return nil, domain.UserTemplate{}, errtrace.Wrap(err)
+	}
+
+	if err := templateAvailable(template, time.Now()); err != nil {
+		return nil, domain.UserTemplate{}, err
+	}
+
+	return guest, template, nil
+}
+
+func (g *GuestUsecase) toInvitation(ctx context.Context, guest domain.Guest) (Invitation, error) {
+	template, err := g.templates.Get(ctx, guest.UserTemplateID)
+	if err != nil {
+		return Invitation{}, errtrace.Wrap(err)
+	}
+
+	return Invitation{
+		GuestID:  guest.ID,
+		Token:    g.InvitationToken(guest),
+		ExpireAt: template.ExpireAt,
+		Revoked:  guest.InviteNonce == nil,
+	}, nil
+}
+
+// GetInvitation returns the public link of a guest, every member of the
+// template can read it.
+func (g *GuestUsecase) GetInvitation(ctx context.Context, guestID string) (Invitation, error) {
+	guest, err := g.guestRepo.Get(ctx, guestID)
+	if err != nil {
+		return Invitation{}, errtrace.Wrap(err)
+	}
+
+	if _, err := authorizeTemplate(ctx, g.members, guest.UserTemplateID, domain.TemplateRoleViewer); err != nil {
+		return Invitation{}, err
+	}
+
+	return g.toInvitation(ctx, *guest)
+}
+
+// RegenerateInvitation gives a guest a new public link, the previous one stops
+// working. Editors and owners of the template can do it.
+func (g *GuestUsecase) RegenerateInvitation(ctx context.Context, guestID string) (Invitation, error) {
+	return g.setInvitation(ctx, guestID, newInviteNonce())
+}
+
+// RevokeInvitation disables the public link of a guest until it is
+// regenerated. Editors and owners of the template can do it.
+func (g *GuestUsecase) RevokeInvitation(ctx context.Context, guestID string) (Invitation, error) {
+	return g.setInvitation(ctx, guestID, nil)
+}
+
+func (g *GuestUsecase) setInvitation(ctx context.Context, guestID string, nonce *string) (Invitation, error) {
+	guest, err := g.guestRepo.Get(ctx, guestID)
+	if err != nil {
+		return Invitation{}, errtrace.Wrap(err)
+	}
+
+	if _, err := authorizeTemplate(ctx, g.members, guest.UserTemplateID, domain.TemplateRoleEditor); err != nil {
+		return Invitation{}, err
+	}
+
+	// the link already sent to the guest stops working
+	if err := AuthorizeDestructive(ctx); err != nil {
+		return Invitation{}, err
+	}
+
+	if err := g.guestRepo.SetInviteNonce(ctx, guestID, nonce); err != nil {
+		return Invitation{}, errtrace.Wrap(err)
+	}
+
+	guest.InviteNonce = nonce
+	return g.toInvitation(ctx, *guest)
+}
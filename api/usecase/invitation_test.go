@@ -0,0 +1,124 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"basic-service/domain"
+)
+
+func TestInvitationToken(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "u1", "u1@example.com", domain.RoleUser)
+	_, ctx := env.login(t, "u1@example.com")
+	env.createTemplate(t, ctx, "t1")
+	env.createGuest(t, ctx, domain.Guest{ID: "g1", Name: "g1", UserTemplateID: "t1"})
+	env.createGuest(t, ctx, domain.Guest{ID: "g2", Name: "g2", UserTemplateID: "t1"})
+	guests := env.guests()
+	bg := context.Background()
+
+	invitation, err := guests.GetInvitation(ctx, "g1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, _ := guests.GetInvitation(ctx, "g2")
+
+	public, err := guests.GetGuest(bg, invitation.Token)
+	if err != nil || public.Guest.ID != "g1" || public.Template.ID != "t1" {
+		t.Fatalf("guest of the token = %+v, %v", public, err)
+	}
+
+	nonce, sig, _ := strings.Cut(invitation.Token, ".")
+	_, otherSig, _ := strings.Cut(other.Token, ".")
+	for name, token := range map[string]string{
+		"guest id":             "g1",
+		"no signature":         nonce,
+		"signature of another": nonce + "." + otherSig,
+		"unknown nonce":        "0000." + sig,
+	} {
+		if _, err := guests.GetGuest(bg, token); !errors.Is(err, ErrInvitationInvalid) {
+			t.Errorf("%s: err = %v, want ErrInvitationInvalid", name, err)
+		}
+	}
+
+	if err := guests.SubmitRSVP(bg, invitation.Token, RSVP{Attend: true, Message: "congrats"}); err != nil {
+		t.Fatalf("rsvp: %v", err)
+	}
+	public, _ = guests.GetGuest(bg, invitation.Token)
+	if guest := public.Guest; guest.Message != "congrats" || guest.Attend == nil || !*guest.Attend || guest.ViewAt == nil {
+		t.Errorf("guest after rsvp = %+v", public.Guest)
+	}
+}
+
+func TestRegenerateInvitation(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "admin", "admin@example.com", domain.RoleAdmin)
+	env.register(t, "u1", "u1@example.com", domain.RoleUser)
+	env.register(t, "u2", "u2@example.com", domain.RoleUser)
+	_, admin := env.login(t, "admin@example.com")
+	_, ctx := env.login(t, "u1@example.com")
+	env.createTemplate(t, ctx, "t1")
+	viewer := env.addMember(t, ctx, "t1", "u2@example.com", domain.TemplateRoleViewer)
+	env.createGuest(t, ctx, domain.Guest{ID: "g1", Name: "g1", UserTemplateID: "t1"})
+	guests := env.guests()
+	bg := context.Background()
+
+	old, _ := guests.GetInvitation(viewer, "g1")
+
+	if _, err := guests.RegenerateInvitation(viewer, "g1"); !errors.Is(err, ErrForbidden) {
+		t.Errorf("viewer: err = %v, want ErrForbidden", err)
+	}
+	_, readOnly := env.impersonate(t, admin, "u1", false)
+	if _, err := guests.RegenerateInvitation(readOnly, "g1"); !errors.Is(err, ErrImpersonationReadOnly) {
+		t.Errorf("impersonated: err = %v, want ErrImpersonationReadOnly", err)
+	}
+
+	regenerated, err := guests.RegenerateInvitation(ctx, "g1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := guests.GetGuest(bg, old.Token); !errors.Is(err, ErrInvitationInvalid) {
+		t.Errorf("previous link: err = %v, want ErrInvitationInvalid", err)
+	}
+	if _, err := guests.GetGuest(bg, regenerated.Token); err != nil {
+		t.Errorf("new link: %v", err)
+	}
+
+	revoked, err := guests.RevokeInvitation(ctx, "g1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !revoked.Revoked || revoked.Token != "" {
+		t.Errorf("revoked invitation = %+v", revoked)
+	}
+	if _, err := guests.GetGuest(bg, regenerated.Token); !errors.Is(err, ErrInvitationInvalid) {
+		t.Errorf("revoked link: err = %v, want ErrInvitationInvalid", err)
+	}
+}
+
+func TestInvitationExpired(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "u1", "u1@example.com", domain.RoleUser)
+	_, ctx := env.login(t, "u1@example.com")
+	err := env.templates().Create(ctx, domain.UserTemplate{
+		ID:       "t1",
+		Name:     "t1",
+		Slug:     "t1",
+		ExpireAt: time.Now().Add(-time.Hour),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	env.createGuest(t, ctx, domain.Guest{ID: "g1", Name: "g1", UserTemplateID: "t1"})
+
+	invitation, err := env.guests().GetInvitation(ctx, "g1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := env.guests().GetGuest(context.Background(), invitation.Token); !errors.Is(err, ErrInvitationExpired) {
+		t.Errorf("err = %v, want ErrInvitationExpired", err)
+	}
+}
@@ -0,0 +1,110 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"basic-service/domain"
+
+	"braces.dev/errtrace"
+)
+
+var (
+	ErrTemplateNotPublished = errors.New("the invitation is not published")
+	ErrTemplateArchived     = errors.New("the invitation was archived")
+	ErrTemplateTransition   = errors.New("invalid template state transition")
+	ErrRSVPClosed           = errors.New("the rsvp is closed")
+	ErrRSVPDeadlineAfterEnd = errors.New("the rsvp deadline must be before the invitation expires")
+	ErrTemplateStateUnknown = errors.New("unknown template state")
+)
+
+// templateAvailable returns why guests cannot open template at now, nil when
+// they can.
+func templateAvailable(template domain.UserTemplate, now time.Time) error {
+	switch template.Lifecycle(now) {
+	case domain.TemplatePublished, domain.TemplateRSVPClosed:
+		return nil
+	case domain.TemplateExpired:
+		return errtrace.Wrap(ErrInvitationExpired)
+	case domain.TemplateArchived:
+		return errtrace.Wrap(ErrTemplateArchived)
+	}
+	return errtrace.Wrap(ErrTemplateNotPublished)
+}
+
+// Published returns the template published under slug, or why guests cannot
+// open it.
+func (p *UserTemplate) Published(ctx context.Context, slug string) (domain.UserTemplate, error) {
+	template, err := p.repo.GetBySlug(ctx, slug)
+	if err != nil {
+		return domain.UserTemplate{}, errtrace.Wrap(err)
+	}
+
+	if err := templateAvailable(template, time.Now()); err != nil {
+		return domain.UserTemplate{}, err
+	}
+
+	return template, nil
+}
+
+// SetState moves a template to another lifecycle state, editors and owners
+// can do it and archiving is destructive. Expired is reached when ExpireAt
+// passes and cannot be set.
+func (p *UserTemplate) SetState(ctx context.Context, id, name string) (domain.UserTemplate, error) {
+	state, ok := domain.ParseTemplateState(name)
+	if !ok {
+		return domain.UserTemplate{}, errtrace.Wrap(fmt.Errorf("%w: %q", ErrTemplateStateUnknown, name))
+	}
+
+	template, err := p.lifecycleTemplate(ctx, id)
+	if err != nil {
+		return domain.UserTemplate{}, err
+	}
+
+	current := template.Lifecycle(time.Now())
+	if !current.CanBecome(state) {
+		return domain.UserTemplate{}, errtrace.Wrap(fmt.Errorf("%w: %s to %s", ErrTemplateTransition, current, state))
+	}
+
+	// guests lose the invitation site of an archived template
+	if state == domain.TemplateArchived {
+		if err := AuthorizeDestructive(ctx); err != nil {
+			return domain.UserTemplate{}, err
+		}
+	}
+
+	if err := p.repo.UpdateState(ctx, id, state); err != nil {
+		return domain.UserTemplate{}, errtrace.Wrap(err)
+	}
+
+	return errtrace.Wrap2(p.repo.Get(ctx, id))
+}
+
+// SetRSVPDeadline changes when the RSVP form of a template closes, nil keeps
+// it open until the template expires. Editors and owners can do it.
+func (p *UserTemplate) SetRSVPDeadline(ctx context.Context, id string, deadline *time.Time) (domain.UserTemplate, error) {
+	template, err := p.lifecycleTemplate(ctx, id)
+	if err != nil {
+		return domain.UserTemplate{}, err
+	}
+
+	if deadline != nil && !template.ExpireAt.IsZero() && deadline.After(template.ExpireAt) {
+		return domain.UserTemplate{}, errtrace.Wrap(ErrRSVPDeadlineAfterEnd)
+	}
+
+	if err := p.repo.UpdateRSVPDeadline(ctx, id, deadline); err != nil {
+		return domain.UserTemplate{}, errtrace.Wrap(err)
+	}
+
+	return errtrace.Wrap2(p.repo.Get(ctx, id))
+}
+
+func (p *UserTemplate) lifecycleTemplate(ctx context.Context, id string) (domain.UserTemplate, error) {
+	if _, err := authorizeTemplate(ctx, p.members, id, domain.TemplateRoleEditor); err != nil {
+		return domain.UserTemplate{}, err
+	}
+
+	return errtrace.Wrap2(p.repo.Get(ctx, id))
+}
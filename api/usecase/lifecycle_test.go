@@ -0,0 +1,135 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"basic-service/domain"
+)
+
+func TestTemplateAvailable(t *testing.T) {
+	now := time.Now()
+	past, future := now.Add(-time.Hour), now.Add(time.Hour)
+
+	tests := []struct {
+		name     string
+		template domain.UserTemplate
+		want     error
+	}{
+		{"draft", domain.UserTemplate{State: domain.TemplateDraft, ExpireAt: future}, ErrTemplateNotPublished},
+		{"published", domain.UserTemplate{State: domain.TemplatePublished, ExpireAt: future}, nil},
+		{"rsvp closed", domain.UserTemplate{State: domain.TemplateRSVPClosed, ExpireAt: future}, nil},
+		{"archived", domain.UserTemplate{State: domain.TemplateArchived, ExpireAt: future}, ErrTemplateArchived},
+		{"expired", domain.UserTemplate{State: domain.TemplatePublished, ExpireAt: past}, ErrInvitationExpired},
+		{"archived after expiring", domain.UserTemplate{State: domain.TemplateArchived, ExpireAt: past}, ErrTemplateArchived},
+	}
+
+	for _, tt := range tests {
+		if err := templateAvailable(tt.template, now); !errors.Is(err, tt.want) {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestTemplateLifecycle(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "admin", "admin@example.com", domain.RoleAdmin)
+	env.register(t, "u1", "u1@example.com", domain.RoleUser)
+	env.register(t, "u2", "u2@example.com", domain.RoleUser)
+	_, admin := env.login(t, "admin@example.com")
+	_, ctx := env.login(t, "u1@example.com")
+	templates := env.templates()
+	bg := context.Background()
+
+	if err := templates.Create(ctx, domain.UserTemplate{ID: "t1", Name: "t1", Slug: "t1", ExpireAt: time.Now().Add(24 * time.Hour)}); err != nil {
+		t.Fatal(err)
+	}
+	viewer := env.addMember(t, ctx, "t1", "u2@example.com", domain.TemplateRoleViewer)
+
+	if _, err := templates.Published(bg, "t1"); !errors.Is(err, ErrTemplateNotPublished) {
+		t.Errorf("draft: err = %v, want ErrTemplateNotPublished", err)
+	}
+	if _, err := templates.SetState(viewer, "t1", "published"); !errors.Is(err, ErrForbidden) {
+		t.Errorf("viewer: err = %v, want ErrForbidden", err)
+	}
+	if _, err := templates.SetState(ctx, "t1", "rsvp_closed"); !errors.Is(err, ErrTemplateTransition) {
+		t.Errorf("draft to rsvp_closed: err = %v, want ErrTemplateTransition", err)
+	}
+	if _, err := templates.SetState(ctx, "t1", "expired"); !errors.Is(err, ErrTemplateTransition) {
+		t.Errorf("setting expired: err = %v, want ErrTemplateTransition", err)
+	}
+	if _, err := templates.SetState(ctx, "t1", "deleted"); !errors.Is(err, ErrTemplateStateUnknown) {
+		t.Errorf("unknown state: err = %v, want ErrTemplateStateUnknown", err)
+	}
+
+	template, err := templates.SetState(ctx, "t1", "published")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if template.State != domain.TemplatePublished {
+		t.Errorf("state = %s, want published", template.State)
+	}
+	if _, err := templates.Published(bg, "t1"); err != nil {
+		t.Errorf("published: %v", err)
+	}
+
+	_, readOnly := env.impersonate(t, admin, "u1", false)
+	if _, err := templates.SetState(readOnly, "t1", "rsvp_closed"); err != nil {
+		t.Errorf("impersonated closing the rsvp: %v", err)
+	}
+	if _, err := templates.SetState(readOnly, "t1", "archived"); !errors.Is(err, ErrImpersonationReadOnly) {
+		t.Errorf("impersonated archiving: err = %v, want ErrImpersonationReadOnly", err)
+	}
+	if _, err := templates.SetState(ctx, "t1", "archived"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := templates.Published(bg, "t1"); !errors.Is(err, ErrTemplateArchived) {
+		t.Errorf("archived: err = %v, want ErrTemplateArchived", err)
+	}
+}
+
+func TestRSVPDeadline(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "u1", "u1@example.com", domain.RoleUser)
+	_, ctx := env.login(t, "u1@example.com")
+	env.createTemplate(t, ctx, "t1")
+	env.createGuest(t, ctx, domain.Guest{ID: "g1", Name: "g1", UserTemplateID: "t1"})
+	templates := env.templates()
+	guests := env.guests()
+	bg := context.Background()
+
+	invitation, _ := guests.GetInvitation(ctx, "g1")
+
+	tooLate := time.Now().Add(365 * 24 * time.Hour)
+	if _, err := templates.SetRSVPDeadline(ctx, "t1", &tooLate); !errors.Is(err, ErrRSVPDeadlineAfterEnd) {
+		t.Errorf("after the end: err = %v, want ErrRSVPDeadlineAfterEnd", err)
+	}
+
+	passed := time.Now().Add(-time.Minute)
+	if _, err := templates.SetRSVPDeadline(ctx, "t1", &passed); err != nil {
+		t.Fatal(err)
+	}
+	if err := guests.SubmitRSVP(bg, invitation.Token, RSVP{Attend: true}); !errors.Is(err, ErrRSVPClosed) {
+		t.Errorf("after the deadline: err = %v, want ErrRSVPClosed", err)
+	}
+
+	if _, err := templates.SetRSVPDeadline(ctx, "t1", nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := guests.SubmitRSVP(bg, invitation.Token, RSVP{Attend: true}); err != nil {
+		t.Errorf("without a deadline: %v", err)
+	}
+
+	// guests can still open an invitation with the rsvp closed
+	if _, err := templates.SetState(ctx, "t1", "rsvp_closed"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := guests.GetGuest(bg, invitation.Token); err != nil {
+		t.Errorf("open with rsvp closed: %v", err)
+	}
+	if err := guests.SubmitRSVP(bg, invitation.Token, RSVP{Attend: false}); !errors.Is(err, ErrRSVPClosed) {
+		t.Errorf("rsvp closed: err = %v, want ErrRSVPClosed", err)
+	}
+}
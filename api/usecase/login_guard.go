@@ -0,0 +1,270 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"basic-service/domain"
+	"basic-service/interface/sql"
+	"basic-service/pkg/otel"
+
+	"braces.dev/errtrace"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const (
+	defaultMaxEmailFailures = 5
+	defaultMaxIPFailures    = 20
+	defaultLockoutDuration  = 15 * time.Minute
+	defaultFailureWindow    = 15 * time.Minute
+
+	// password reset requests allowed per email and per client IP within
+	// defaultResetWindow
+	defaultMaxResetEmails = 3
+	defaultMaxResetIPs    = 10
+	defaultResetWindow    = time.Hour
+
+	// failures below freeAttempts are not delayed, after that every failure
+	// doubles the wait up to maxAttemptDelay
+	freeAttempts    = 2
+	baseAttemptWait = time.Second
+	maxAttemptDelay = 30 * time.Second
+)
+
+var ErrTooManyAttempts = errors.New("too many failed login attempts")
+
+// LockedError is returned while a key is delayed or locked out.
+type LockedError struct {
+	Until time.Time
+}
+
+func (e *LockedError) Error() string {
+	return fmt.Sprintf("%s, retry after %s", ErrTooManyAttempts, e.Until.UTC().Format(time.RFC3339))
+}
+
+func (e *LockedError) Unwrap() error {
+	return ErrTooManyAttempts
+}
+
+// RetryAfter is the time left before the next attempt is accepted.
+func (e *LockedError) RetryAfter() time.Duration {
+	return time.Until(e.Until)
+}
+
+// LoginGuard tracks failed logins per email and per client IP. Repeated
+// failures for an email delay the next attempt and, past the limit, lock the
+// email or IP out for LockoutDuration. The IP limit is higher because many
+// users can share one address.
+type LoginGuard struct {
+	attempts *sql.LoginAttemptRepository
+	audit    *Auditor
+	otel     otel.Otel
+	failures metric.Int64Counter
+	lockouts metric.Int64Counter
+
+	MaxEmailFailures int
+	MaxIPFailures    int
+	LockoutDuration  time.Duration
+	// FailureWindow forgets failures older than this
+	FailureWindow time.Duration
+
+	// MaxResetEmails and MaxResetIPs limit the password reset requests
+	// within ResetWindow
+	MaxResetEmails int
+	MaxResetIPs    int
+	ResetWindow    time.Duration
+}
+
+func NewLoginGuard(attempts *sql.LoginAttemptRepository, audit *Auditor, otl otel.Otel) (*LoginGuard, error) {
+	failures, err := otl.Metric.Int64Counter("auth.login.failures",
+		metric.WithDescription("Failed login attempts"))
+	if err != nil {
+		return nil, errtrace.Wrap(err)
+	}
+
+	lockouts, err := otl.Metric.Int64Counter("auth.login.lockouts",
+		metric.WithDescription("Login lockouts per email or client IP"))
+	if err != nil {
+		return nil, errtrace.Wrap(err)
+	}
+
+	return &LoginGuard{
+		attempts:         attempts,
+		audit:            audit,
+		otel:             otl,
+		failures:         failures,
+		lockouts:         lockouts,
+		MaxEmailFailures: defaultMaxEmailFailures,
+		MaxIPFailures:    defaultMaxIPFailures,
+		LockoutDuration:  defaultLockoutDuration,
+		FailureWindow:    defaultFailureWindow,
+		MaxResetEmails:   defaultMaxResetEmails,
+		MaxResetIPs:      defaultMaxResetIPs,
+		ResetWindow:      defaultResetWindow,
+	}, nil
+}
+
+func emailKey(email string) string {
+	return "email:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
+
+// Check returns a *LockedError when the email or the IP may not try yet.
+func (g *LoginGuard) Check(ctx context.Context, email, ip string) error {
+	now := time.Now()
+
+	for _, key := range g.keys(email, ip) {
+		attempt, err := g.attempts.Get(ctx, key)
+		if err != nil {
+			return errtrace.Wrap(err)
+		}
+
+		if attempt.LockedUntil != nil && attempt.LockedUntil.After(now) {
+			return errtrace.Wrap(&LockedError{Until: *attempt.LockedUntil})
+		}
+	}
+
+	return nil
+}
+
+// Fail records a failed login for the email and the IP. userID is empty when
+// the email does not belong to an account.
+func (g *LoginGuard) Fail(ctx context.Context, email, ip, userID string) error {
+	g.failures.Add(ctx, 1)
+
+	if err := g.fail(ctx, emailKey(email), g.MaxEmailFailures, true, ip, userID); err != nil {
+		return err
+	}
+
+	if ip == "" {
+		return nil
+	}
+
+	return g.fail(ctx, ipKey(ip), g.MaxIPFailures, false, ip, userID)
+}
+
+// fail increments the counter of key and decides the lock from the counter
+// the database returns, so parallel failures cannot share a count. Only
+// email keys are delayed, an IP is just locked out once it reaches its limit.
+func (g *LoginGuard) fail(ctx context.Context, key string, limit int, delayed bool, ip, userID string) error {
+	now := time.Now()
+
+	attempt, err := g.attempts.Fail(ctx, key, now, now.Add(-g.FailureWindow))
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	var until time.Time
+	lockedOut := attempt.Failures >= limit
+	if lockedOut {
+		until = now.Add(g.LockoutDuration)
+	} else if delay := attemptDelay(attempt.Failures); delayed && delay > 0 {
+		until = now.Add(delay)
+	} else {
+		return nil
+	}
+
+	// a lockout starts over once it expires
+	if err := g.attempts.Lock(ctx, key, until, lockedOut); err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	// failures racing past the limit share the lockout of the one that
+	// reached it
+	if attempt.Failures != limit {
+		return nil
+	}
+
+	kind, value, _ := strings.Cut(key, ":")
+	g.lockouts.Add(ctx, 1, metric.WithAttributes(attribute.String("key", kind)))
+	g.otel.Log.Warn(ctx, "login locked out", "key", key, "until", until)
+
+	targetType, targetID := kind, value
+	if userID != "" && kind == "email" {
+		targetType, targetID = "user", userID
+	}
+
+	return errtrace.Wrap(g.audit.Record(ctx, domain.AuditLog{
+		Action:     AuditLoginLockout,
+		TargetType: targetType,
+		TargetID:   targetID,
+		IP:         ip,
+		Metadata: map[string]any{
+			"key":          key,
+			"locked_until": until,
+		},
+	}))
+}
+
+// ThrottleReset counts a password reset request for the email and the IP
+// and returns a *LockedError past their limit. Emails are counted whether or
+// not they belong to an account, so the limit does not reveal which do.
+func (g *LoginGuard) ThrottleReset(ctx context.Context, email, ip string) error {
+	now := time.Now()
+
+	keys, limits := []string{"reset-" + emailKey(email)}, []int{g.MaxResetEmails}
+	if ip != "" {
+		keys, limits = append(keys, "reset-"+ipKey(ip)), append(limits, g.MaxResetIPs)
+	}
+
+	for i, key := range keys {
+		attempt, err := g.attempts.Fail(ctx, key, now, now.Add(-g.ResetWindow))
+		if err != nil {
+			return errtrace.Wrap(err)
+		}
+
+		if attempt.Failures > limits[i] {
+			return errtrace.Wrap(&LockedError{Until: now.Add(g.ResetWindow)})
+		}
+	}
+
+	return nil
+}
+
+// Succeed clears the email failures. The IP counter is kept so one valid
+// account cannot be used to reset it.
+func (g *LoginGuard) Succeed(ctx context.Context, email string) error {
+	return errtrace.Wrap(g.attempts.Reset(ctx, emailKey(email)))
+}
+
+// Unlock clears the failures and lockout of the user's email, the actor is
+// taken from ctx for the audit trail.
+func (g *LoginGuard) Unlock(ctx context.Context, user domain.SafeUser) error {
+	if err := g.attempts.Reset(ctx, emailKey(user.Email)); err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	return errtrace.Wrap(g.audit.Record(ctx, domain.AuditLog{
+		Action:     AuditLoginUnlock,
+		TargetType: "user",
+		TargetID:   user.ID,
+	}))
+}
+
+func (g *LoginGuard) keys(email, ip string) []string {
+	keys := []string{emailKey(email)}
+	if ip != "" {
+		keys = append(keys, ipKey(ip))
+	}
+	return keys
+}
+
+// attemptDelay is the wait imposed after the given number of failures.
+func attemptDelay(failures int) time.Duration {
+	if failures <= freeAttempts {
+		return 0
+	}
+
+	delay := baseAttemptWait << (failures - freeAttempts - 1)
+	if delay > maxAttemptDelay || delay <= 0 {
+		return maxAttemptDelay
+	}
+	return delay
+}
@@ -0,0 +1,146 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"basic-service/domain"
+)
+
+func TestAttemptDelay(t *testing.T) {
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{0, 0},
+		{1, 0},
+		{2, 0},
+		{3, time.Second},
+		{4, 2 * time.Second},
+		{5, 4 * time.Second},
+		{7, 16 * time.Second},
+		{8, maxAttemptDelay},
+		{100, maxAttemptDelay},
+	}
+
+	for _, tt := range tests {
+		if got := attemptDelay(tt.failures); got != tt.want {
+			t.Errorf("attemptDelay(%d) = %s, want %s", tt.failures, got, tt.want)
+		}
+	}
+}
+
+func TestLoginLockout(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "admin", "admin@example.com", domain.RoleAdmin)
+	env.register(t, "u1", "u1@example.com", domain.RoleUser)
+	env.auth.Guard.MaxEmailFailures = 2
+	_, adminCtx := env.login(t, "admin@example.com")
+	ctx := context.Background()
+
+	for range 2 {
+		if _, err := env.auth.Login(ctx, "u1@example.com", "wrong", "10.0.0.1"); !errors.Is(err, ErrInvalidCredentials) {
+			t.Fatalf("wrong password: err = %v, want ErrInvalidCredentials", err)
+		}
+	}
+
+	// the right password is refused too while locked, from any address
+	_, err := env.auth.Login(ctx, "U1@example.com ", "password", "10.0.0.2")
+	var locked *LockedError
+	if !errors.As(err, &locked) || !errors.Is(err, ErrTooManyAttempts) {
+		t.Fatalf("locked: err = %v, want *LockedError", err)
+	}
+	if locked.RetryAfter() <= 0 || locked.RetryAfter() > defaultLockoutDuration {
+		t.Errorf("retry after %s", locked.RetryAfter())
+	}
+
+	if err := env.users().Unlock(adminCtx, "u1"); err != nil {
+		t.Fatalf("unlock: %v", err)
+	}
+	if _, err := env.auth.Login(ctx, "u1@example.com", "password", "10.0.0.2"); err != nil {
+		t.Errorf("after unlock: %v", err)
+	}
+}
+
+func TestLoginConcurrentFailures(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "u1", "u1@example.com", domain.RoleUser)
+	env.auth.Guard.MaxEmailFailures = 100
+	ctx := context.Background()
+
+	// parallel failures must not read the same count and overwrite each other
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- env.auth.Guard.Fail(ctx, "u1@example.com", "", "u1")
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("fail: %v", err)
+		}
+	}
+
+	attempt, err := env.auth.Guard.attempts.Get(ctx, emailKey("u1@example.com"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if attempt.Failures != 10 {
+		t.Errorf("counted %d failures, want 10", attempt.Failures)
+	}
+}
+
+func TestLoginSuccessResetsFailures(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "u1", "u1@example.com", domain.RoleUser)
+	env.auth.Guard.MaxEmailFailures = 2
+	ctx := context.Background()
+
+	for range 3 {
+		if _, err := env.auth.Login(ctx, "u1@example.com", "wrong", ""); !errors.Is(err, ErrInvalidCredentials) {
+			t.Fatalf("wrong password: err = %v, want ErrInvalidCredentials", err)
+		}
+		if _, err := env.auth.Login(ctx, "u1@example.com", "password", ""); err != nil {
+			t.Fatalf("right password: %v", err)
+		}
+	}
+}
+
+func TestLoginIPLockout(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "u1", "u1@example.com", domain.RoleUser)
+	env.auth.Guard.MaxIPFailures = 2
+	ctx := context.Background()
+
+	// unknown emails count against the address as well
+	for _, email := range []string{"a@example.com", "b@example.com"} {
+		if _, err := env.auth.Login(ctx, email, "wrong", "10.0.0.1"); !errors.Is(err, ErrInvalidCredentials) {
+			t.Fatalf("%s: err = %v, want ErrInvalidCredentials", email, err)
+		}
+	}
+
+	if _, err := env.auth.Login(ctx, "u1@example.com", "password", "10.0.0.1"); !errors.Is(err, ErrTooManyAttempts) {
+		t.Errorf("locked address: err = %v, want ErrTooManyAttempts", err)
+	}
+	if _, err := env.auth.Login(ctx, "u1@example.com", "password", "10.0.0.2"); err != nil {
+		t.Errorf("other address: %v", err)
+	}
+}
+
+func TestUnlockRequiresManagePermission(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "u1", "u1@example.com", domain.RoleUser)
+	_, userCtx := env.login(t, "u1@example.com")
+
+	if err := env.users().Unlock(userCtx, "u1"); !errors.Is(err, ErrForbidden) {
+		t.Errorf("err = %v, want ErrForbidden", err)
+	}
+}
@@ -0,0 +1,343 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"basic-service/domain"
+	"basic-service/interface/sql"
+
+	"braces.dev/errtrace"
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/google/uuid"
+	"golang.org/x/oauth2"
+)
+
+const oidcStateTTL = 10 * time.Minute
+
+var (
+	ErrOIDCProviderNotFound = errors.New("unknown identity provider")
+	ErrOIDCEmailNotVerified = errors.New("identity provider did not return a verified email")
+	ErrOIDCInvalidToken     = errors.New("invalid id token")
+	ErrOIDCUnavailable      = errors.New("identity provider is unavailable")
+)
+
+// OIDCProviderOption configures one OpenID Connect identity provider,
+// endpoints are discovered from Issuer.
+type OIDCProviderOption struct {
+	Name         string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	// RedirectURL is the frontend page that receives the code and state and
+	// posts them to the callback endpoint
+	RedirectURL string
+	Scopes      []string
+}
+
+// oidcProvider runs discovery on first use so an unreachable provider does
+// not prevent the service from starting.
+type oidcProvider struct {
+	opt OIDCProviderOption
+
+	mu       sync.Mutex
+	oauth    *oauth2.Config
+	verifier *oidc.IDTokenVerifier
+}
+
+func (p *oidcProvider) init(ctx context.Context) (*oauth2.Config, *oidc.IDTokenVerifier, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.oauth != nil {
+		return p.oauth, p.verifier, nil
+	}
+
+	provider, err := oidc.NewProvider(ctx, p.opt.Issuer)
+	if err != nil {
+		return nil, nil, errtrace.Wrap(fmt.Errorf("%w: %s: %w", ErrOIDCUnavailable, p.opt.Name, err))
+	}
+
+	scopes := p.opt.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{"profile", "email"}
+	}
+
+	p.oauth = &oauth2.Config{
+		ClientID:     p.opt.ClientID,
+		ClientSecret: p.opt.ClientSecret,
+		RedirectURL:  p.opt.RedirectURL,
+		Endpoint:     provider.Endpoint(),
+		Scopes:       append([]string{oidc.ScopeOpenID}, scopes...),
+	}
+	p.verifier = provider.Verifier(&oidc.Config{ClientID: p.opt.ClientID})
+
+	return p.oauth, p.verifier, nil
+}
+
+// OIDCLogin signs users in with an external identity provider using the
+// authorization code flow with PKCE.
+type OIDCLogin struct {
+	auth      *Auth
+	repo      *sql.OIDCRepository
+	providers map[string]*oidcProvider
+	client    *http.Client
+}
+
+func NewOIDCLogin(auth *Auth, repo *sql.OIDCRepository, opts []OIDCProviderOption) (*OIDCLogin, error) {
+	providers := make(map[string]*oidcProvider, len(opts))
+
+	for _, opt := range opts {
+		if opt.Name == "" || opt.Issuer == "" || opt.ClientID == "" || opt.RedirectURL == "" {
+			return nil, errtrace.Wrap(fmt.Errorf("oidc provider %q: name, issuer, client_id and redirect_url are required", opt.Name))
+		}
+		if _, ok := providers[opt.Name]; ok {
+			return nil, errtrace.Wrap(fmt.Errorf("duplicate oidc provider: %s", opt.Name))
+		}
+		providers[opt.Name] = &oidcProvider{opt: opt}
+	}
+
+	return &OIDCLogin{
+		auth:      auth,
+		repo:      repo,
+		providers: providers,
+		client:    &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+// Providers returns the configured provider names.
+func (o *OIDCLogin) Providers() []string {
+	names := make([]string, 0, len(o.providers))
+	for name := range o.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// AuthorizationURL starts a login and returns the provider URL the user has
+// to be sent to.
+func (o *OIDCLogin) AuthorizationURL(ctx context.Context, name string) (string, error) {
+	provider, ok := o.providers[name]
+	if !ok {
+		return "", errtrace.Wrap(ErrOIDCProviderNotFound)
+	}
+
+	config, _, err := provider.init(oidc.ClientContext(ctx, o.client))
+	if err != nil {
+		return "", err
+	}
+
+	state, err := randomToken()
+	if err != nil {
+		return "", errtrace.Wrap(err)
+	}
+
+	nonce, err := randomToken()
+	if err != nil {
+		return "", errtrace.Wrap(err)
+	}
+
+	now := time.Now()
+	pending := domain.OIDCState{
+		State:        state,
+		Provider:     name,
+		CodeVerifier: oauth2.GenerateVerifier(),
+		Nonce:        nonce,
+		ExpiresAt:    now.Add(oidcStateTTL),
+		CreatedAt:    now,
+	}
+
+	if err := o.repo.CreateState(ctx, pending); err != nil {
+		return "", errtrace.Wrap(err)
+	}
+
+	return config.AuthCodeURL(state,
+		oidc.Nonce(nonce),
+		oauth2.S256ChallengeOption(pending.CodeVerifier),
+	), nil
+}
+
+type oidcClaims struct {
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+	Name          string `json:"name"`
+}
+
+// Callback finishes the login with the code and state the provider sent back.
+// Users are found by their linked identity first, then by verified email, and
+// are created on their first login.
+func (o *OIDCLogin) Callback(ctx context.Context, name, code, state string) (TokenPair, error) {
+	provider, ok := o.providers[name]
+	if !ok {
+		return TokenPair{}, errtrace.Wrap(ErrOIDCProviderNotFound)
+	}
+
+	pending, err := o.repo.ConsumeState(ctx, name, state)
+	if err != nil {
+		return TokenPair{}, errtrace.Wrap(err)
+	}
+
+	httpCtx := oidc.ClientContext(ctx, o.client)
+	config, verifier, err := provider.init(httpCtx)
+	if err != nil {
+		return TokenPair{}, err
+	}
+
+	token, err := config.Exchange(httpCtx, code, oauth2.VerifierOption(pending.CodeVerifier))
+	if err != nil {
+		return TokenPair{}, errtrace.Wrap(err)
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return TokenPair{}, errtrace.Wrap(ErrOIDCInvalidToken)
+	}
+
+	idToken, err := verifier.Verify(httpCtx, rawIDToken)
+	if err != nil {
+		return TokenPair{}, errtrace.Wrap(fmt.Errorf("%w: %w", ErrOIDCInvalidToken, err))
+	}
+
+	if idToken.Nonce != pending.Nonce {
+		return TokenPair{}, errtrace.Wrap(ErrOIDCInvalidToken)
+	}
+
+	var claims oidcClaims
+	if err := idToken.Claims(&claims); err != nil {
+		return TokenPair{}, errtrace.Wrap(err)
+	}
+
+	user, err := o.resolveUser(ctx, name, idToken.Subject, claims)
+	if err != nil {
+		return TokenPair{}, err
+	}
+
+	if !user.IsActive {
+		return TokenPair{}, errtrace.Wrap(ErrUserNotActive)
+	}
+
+	mfa, err := o.auth.TOTP.IsEnabled(ctx, user.ID)
+	if err != nil {
+		return TokenPair{}, errtrace.Wrap(err)
+	}
+
+	if mfa {
+		return o.auth.mfaChallenge(ctx, user)
+	}
+
+	return o.auth.startSession(ctx, user)
+}
+
+func (o *OIDCLogin) resolveUser(ctx context.Context, provider, subject string, claims oidcClaims) (*domain.User, error) {
+	identity, err := o.repo.GetIdentity(ctx, provider, subject)
+	if err == nil {
+		return errtrace.Wrap2(o.auth.UserManager.GetUserByID(ctx, identity.UserID))
+	}
+	if !errors.Is(err, sql.ErrIdentityNotFound) {
+		return nil, errtrace.Wrap(err)
+	}
+
+	email := strings.TrimSpace(claims.Email)
+	if email == "" || !claims.EmailVerified {
+		return nil, errtrace.Wrap(ErrOIDCEmailNotVerified)
+	}
+
+	user, err := o.auth.UserManager.GetEmail(ctx, email)
+	switch {
+	case errors.Is(err, sql.UserNotFoundErr):
+		user, err = o.createUser(ctx, email, claims.Name)
+		if err != nil {
+			return nil, err
+		}
+	case err != nil:
+		return nil, errtrace.Wrap(err)
+	case user.VerifiedAt == nil:
+		if err := o.claimUnverified(ctx, user); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := o.repo.CreateIdentity(ctx, domain.UserIdentity{
+		ID:        uuid.New().String(),
+		UserID:    user.ID,
+		Provider:  provider,
+		Subject:   subject,
+		Email:     email,
+		CreatedAt: time.Now(),
+	}); err != nil {
+		return nil, errtrace.Wrap(err)
+	}
+
+	return user, nil
+}
+
+// createUser registers a user from the provider claims. The password is
+// random, the user can set one with the forgot password flow.
+func (o *OIDCLogin) createUser(ctx context.Context, email, name string) (*domain.User, error) {
+	password, err := randomToken()
+	if err != nil {
+		return nil, errtrace.Wrap(err)
+	}
+
+	hashedPassword, err := o.auth.hashPassword(password)
+	if err != nil {
+		return nil, errtrace.Wrap(err)
+	}
+
+	if name == "" {
+		name, _, _ = strings.Cut(email, "@")
+	}
+
+	now := time.Now()
+	user := &domain.User{
+		ID:         uuid.New().String(),
+		Email:      email,
+		Password:   hashedPassword,
+		Name:       name,
+		Role:       domain.RoleUser,
+		IsActive:   true,
+		VerifiedAt: &now,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+
+	if err := o.auth.UserManager.Create(ctx, user); err != nil {
+		return nil, errtrace.Wrap(err)
+	}
+
+	if err := o.auth.UserManager.MarkVerified(ctx, user.ID); err != nil {
+		return nil, errtrace.Wrap(err)
+	}
+
+	return user, nil
+}
+
+// claimUnverified links a provider account to a user whose email was never
+// verified. Whoever registered that address may not own it, so the password
+// is replaced and their sessions are revoked before the owner takes over.
+func (o *OIDCLogin) claimUnverified(ctx context.Context, user *domain.User) error {
+	password, err := randomToken()
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	if err := o.auth.SetPassword(ctx, user.ID, password); err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	if err := o.auth.UserManager.MarkVerified(ctx, user.ID); err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	now := time.Now()
+	user.VerifiedAt = &now
+
+	return nil
+}
@@ -0,0 +1,163 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"basic-service/domain"
+	"basic-service/interface/sql"
+	"basic-service/pkg/mockidp"
+)
+
+func newOIDCLogin(t *testing.T, env *testEnv, opt mockidp.Option) *OIDCLogin {
+	t.Helper()
+
+	server := httptest.NewUnstartedServer(nil)
+	opt.Issuer = "http://" + server.Listener.Addr().String()
+	opt.ClientID = "client"
+	opt.ClientSecret = "secret"
+
+	idp, err := mockidp.New(opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server.Config.Handler = idp.Handler()
+	server.Start()
+	t.Cleanup(server.Close)
+
+	login, err := NewOIDCLogin(env.auth, sql.NewOIDCRepository(env.db), []OIDCProviderOption{{
+		Name:         "mock",
+		Issuer:       opt.Issuer,
+		ClientID:     opt.ClientID,
+		ClientSecret: opt.ClientSecret,
+		RedirectURL:  "http://app.example.com/oidc/callback",
+		Scopes:       []string{"openid", "email", "profile"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return login
+}
+
+// authorize runs the browser part of the login and returns the code and
+// state the provider redirected back with
+func authorize(t *testing.T, login *OIDCLogin) (string, string) {
+	t.Helper()
+
+	authURL, err := login.AuthorizationURL(context.Background(), "mock")
+	if err != nil {
+		t.Fatalf("authorization url: %v", err)
+	}
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	resp, err := client.Get(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	location, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil || resp.StatusCode != http.StatusFound {
+		t.Fatalf("authorize: status %d, location %q", resp.StatusCode, resp.Header.Get("Location"))
+	}
+
+	return location.Query().Get("code"), location.Query().Get("state")
+}
+
+func TestOIDCCreatesUser(t *testing.T) {
+	env := newTestEnv(t)
+	login := newOIDCLogin(t, env, mockidp.Option{Email: "new@example.com", Name: "New"})
+	ctx := context.Background()
+
+	code, state := authorize(t, login)
+	pair, err := login.Callback(ctx, "mock", code, state)
+	if err != nil {
+		t.Fatalf("callback: %v", err)
+	}
+
+	claims, err := env.auth.ValidateToken(ctx, pair.AccessToken)
+	if err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	user, err := env.auth.UserManager.GetUserByID(ctx, claims.UserID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Email != "new@example.com" || user.Name != "New" || user.VerifiedAt == nil {
+		t.Errorf("created user = %+v", user)
+	}
+
+	// the state is single use
+	if _, err := login.Callback(ctx, "mock", code, state); !errors.Is(err, sql.ErrOIDCStateInvalid) {
+		t.Errorf("reused state: err = %v, want ErrOIDCStateInvalid", err)
+	}
+
+	// the next login finds the linked identity
+	code, state = authorize(t, login)
+	pair, err = login.Callback(ctx, "mock", code, state)
+	if err != nil {
+		t.Fatalf("second callback: %v", err)
+	}
+	if claims, _ := env.auth.ValidateToken(ctx, pair.AccessToken); claims == nil || claims.UserID != user.ID {
+		t.Errorf("second login signed in %+v, want %s", claims, user.ID)
+	}
+}
+
+func TestOIDCLinksExistingUser(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "u1", "u1@example.com", domain.RoleUser)
+	login := newOIDCLogin(t, env, mockidp.Option{Email: "u1@example.com"})
+
+	code, state := authorize(t, login)
+	pair, err := login.Callback(context.Background(), "mock", code, state)
+	if err != nil {
+		t.Fatalf("callback: %v", err)
+	}
+	if claims, _ := env.auth.ValidateToken(context.Background(), pair.AccessToken); claims == nil || claims.UserID != "u1" {
+		t.Errorf("signed in %+v, want u1", claims)
+	}
+
+	// the password keeps working
+	env.login(t, "u1@example.com")
+}
+
+func TestOIDCClaimsUnverifiedUser(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerUnverified(t, "u1", "u1@example.com", domain.RoleUser)
+	pair, _ := env.login(t, "u1@example.com")
+	login := newOIDCLogin(t, env, mockidp.Option{Email: "u1@example.com"})
+	ctx := context.Background()
+
+	code, state := authorize(t, login)
+	if _, err := login.Callback(ctx, "mock", code, state); err != nil {
+		t.Fatalf("callback: %v", err)
+	}
+
+	// whoever registered the address loses access
+	if _, err := env.auth.Login(ctx, "u1@example.com", "password", ""); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("old password: err = %v, want ErrInvalidCredentials", err)
+	}
+	if _, err := env.auth.ValidateToken(ctx, pair.AccessToken); err == nil {
+		t.Error("session of the unverified user is still valid")
+	}
+}
+
+func TestOIDCUnverifiedEmail(t *testing.T) {
+	env := newTestEnv(t)
+	login := newOIDCLogin(t, env, mockidp.Option{Email: "u1@example.com", Unverified: true})
+
+	code, state := authorize(t, login)
+	if _, err := login.Callback(context.Background(), "mock", code, state); !errors.Is(err, ErrOIDCEmailNotVerified) {
+		t.Errorf("err = %v, want ErrOIDCEmailNotVerified", err)
+	}
+	if _, err := login.AuthorizationURL(context.Background(), "other"); !errors.Is(err, ErrOIDCProviderNotFound) {
+		t.Errorf("unknown provider: err = %v, want ErrOIDCProviderNotFound", err)
+	}
+}
@@ -0,0 +1,127 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+
+	"basic-service/domain"
+	"basic-service/interface/sql"
+	"basic-service/pkg/mailer"
+
+	"braces.dev/errtrace"
+	"github.com/google/uuid"
+)
+
+// resetMailTimeout bounds a password reset email sent in the background
+const resetMailTimeout = time.Minute
+
+var ErrInvalidResetToken = errors.New("reset token is invalid or expired")
+
+// ForgotPassword emails a password reset link. Unknown or inactive accounts
+// are silently ignored and the link is sent in the background, failures are
+// only logged, so the endpoint can't be used to discover emails by its
+// answer or its timing. Requests are throttled per email and per client IP.
+func (a *Auth) ForgotPassword(ctx context.Context, email, ip string) error {
+	if err := a.Guard.ThrottleReset(ctx, email, ip); err != nil {
+		return err
+	}
+
+	user, err := a.UserManager.GetEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, sql.UserNotFoundErr) {
+			return nil
+		}
+		return errtrace.Wrap(err)
+	}
+
+	if !user.IsActive {
+		return nil
+	}
+
+	// the request may be over before the email is sent
+	mailCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), resetMailTimeout)
+	a.mails.Add(1)
+	go func() {
+		defer a.mails.Done()
+		defer cancel()
+
+		if err := a.sendPasswordReset(mailCtx, user); err != nil {
+			log.Printf("password reset for user %s: %v", user.ID, err)
+		}
+	}()
+
+	return nil
+}
+
+func (a *Auth) sendPasswordReset(ctx context.Context, user *domain.User) error {
+	raw, err := a.issueUserToken(ctx, user.ID, domain.UserTokenPasswordReset, a.ResetTokenTTL)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	link := fmt.Sprintf("%s/reset-password?token=%s", a.AppURL, url.QueryEscape(raw))
+
+	return errtrace.Wrap(a.Mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hi %s,\n\nWe received a request to reset your password. "+
+			"Open the link below to choose a new one, it is valid for %s:\n\n%s\n\n"+
+			"If you didn't ask for this you can ignore this email.\n",
+			user.Name, a.ResetTokenTTL, link),
+	}))
+}
+
+// ResetPassword sets a new password using a token from ForgotPassword, every
+// session of the user is revoked afterwards. The links of deactivated
+// accounts are invalid.
+func (a *Auth) ResetPassword(ctx context.Context, token, password string) error {
+	userToken, err := a.UserTokens.Consume(ctx, domain.UserTokenPasswordReset, hashToken(token))
+	if err != nil {
+		if errors.Is(err, sql.ErrUserTokenInvalid) {
+			return errtrace.Wrap(ErrInvalidResetToken)
+		}
+		return errtrace.Wrap(err)
+	}
+
+	user, err := a.UserManager.GetUserByID(ctx, userToken.UserID)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	if !user.IsActive {
+		return errtrace.Wrap(ErrInvalidResetToken)
+	}
+
+	return errtrace.Wrap(a.SetPassword(ctx, userToken.UserID, password))
+}
+
+// issueUserToken stores a new single use token and invalidates the previous
+// ones with the same purpose, the raw token is returned to be emailed.
+func (a *Auth) issueUserToken(ctx context.Context, userID string, purpose domain.UserTokenPurpose, ttl time.Duration) (string, error) {
+	if err := a.UserTokens.Invalidate(ctx, userID, purpose); err != nil {
+		return "", errtrace.Wrap(err)
+	}
+
+	raw, err := randomToken()
+	if err != nil {
+		return "", errtrace.Wrap(err)
+	}
+
+	now := time.Now()
+	if err := a.UserTokens.Create(ctx, domain.UserToken{
+		ID:        uuid.New().String(),
+		UserID:    userID,
+		Purpose:   purpose,
+		TokenHash: hashToken(raw),
+		ExpiresAt: now.Add(ttl),
+		CreatedAt: now,
+	}); err != nil {
+		return "", errtrace.Wrap(err)
+	}
+
+	return raw, nil
+}
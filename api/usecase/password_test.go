@@ -0,0 +1,172 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"basic-service/domain"
+)
+
+// forgotPassword asks for a reset link for email and waits until it is sent
+func (e *testEnv) forgotPassword(t *testing.T, email string) {
+	t.Helper()
+
+	if err := e.auth.ForgotPassword(context.Background(), email, "10.0.0.1"); err != nil {
+		t.Fatalf("forgot password: %v", err)
+	}
+	e.auth.mails.Wait()
+}
+
+func TestForgotPasswordUnknownEmail(t *testing.T) {
+	env := newTestEnv(t)
+
+	if err := env.auth.ForgotPassword(context.Background(), "nobody@example.com", "10.0.0.1"); err != nil {
+		t.Fatalf("err = %v, want nil", err)
+	}
+	if got := env.mail.messages("nobody@example.com"); len(got) != 0 {
+		t.Errorf("sent %d mails to an unknown email", len(got))
+	}
+}
+
+func TestForgotPasswordMailFailure(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "u1", "u1@example.com", domain.RoleUser)
+	env.mail.err = errors.New("smtp down")
+
+	// a failure would tell that the account exists
+	if err := env.auth.ForgotPassword(context.Background(), "u1@example.com", "10.0.0.1"); err != nil {
+		t.Errorf("err = %v, want nil", err)
+	}
+}
+
+func TestForgotPasswordInBackground(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "u1", "u1@example.com", domain.RoleUser)
+	env.mail.wait = make(chan struct{})
+
+	// known emails answer before the link is sent, like unknown ones
+	if err := env.auth.ForgotPassword(context.Background(), "u1@example.com", "10.0.0.1"); err != nil {
+		t.Fatalf("forgot password: %v", err)
+	}
+	if got := env.mail.messages("u1@example.com"); len(got) != 1 {
+		t.Fatalf("sent %d mails before the link, want the verification only", len(got))
+	}
+
+	close(env.mail.wait)
+	env.auth.mails.Wait()
+	env.mail.lastToken(t, "u1@example.com")
+	if got := env.mail.messages("u1@example.com"); len(got) != 2 {
+		t.Errorf("sent %d mails, want the verification and the link", len(got))
+	}
+}
+
+func TestForgotPasswordThrottle(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "u1", "u1@example.com", domain.RoleUser)
+	env.auth.Guard.MaxResetEmails = 2
+	env.auth.Guard.MaxResetIPs = 4
+	ctx := context.Background()
+	sent := len(env.mail.messages("u1@example.com"))
+
+	// unknown emails are throttled the same way
+	for _, email := range []string{"u1@example.com", "nobody@example.com"} {
+		for range 2 {
+			if err := env.auth.ForgotPassword(ctx, email, "10.0.0.1"); err != nil {
+				t.Fatalf("%s: %v", email, err)
+			}
+		}
+		var locked *LockedError
+		if err := env.auth.ForgotPassword(ctx, email, "10.0.0.2"); !errors.As(err, &locked) {
+			t.Errorf("%s over the limit: err = %v, want *LockedError", email, err)
+		}
+	}
+	env.auth.mails.Wait()
+	if got := len(env.mail.messages("u1@example.com")) - sent; got != 2 {
+		t.Errorf("sent %d reset mails, want 2", got)
+	}
+
+	if err := env.auth.ForgotPassword(ctx, "other@example.com", "10.0.0.1"); !errors.Is(err, ErrTooManyAttempts) {
+		t.Errorf("address over the limit: err = %v, want ErrTooManyAttempts", err)
+	}
+}
+
+func TestResetPassword(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "u1", "u1@example.com", domain.RoleUser)
+	ctx := context.Background()
+
+	pair, _ := env.login(t, "u1@example.com")
+
+	env.forgotPassword(t, "u1@example.com")
+	token := env.mail.lastToken(t, "u1@example.com")
+
+	if err := env.auth.ResetPassword(ctx, token, "new-password"); err != nil {
+		t.Fatalf("reset password: %v", err)
+	}
+
+	if _, err := env.auth.Login(ctx, "u1@example.com", "new-password", ""); err != nil {
+		t.Errorf("login with the new password: %v", err)
+	}
+	if _, err := env.auth.ValidateToken(ctx, pair.AccessToken); !errors.Is(err, ErrTokenRevoked) {
+		t.Errorf("session before the reset: err = %v, want ErrTokenRevoked", err)
+	}
+	if err := env.auth.ResetPassword(ctx, token, "another-password"); !errors.Is(err, ErrInvalidResetToken) {
+		t.Errorf("second use: err = %v, want ErrInvalidResetToken", err)
+	}
+}
+
+func TestForgotPasswordInvalidatesPreviousLink(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "u1", "u1@example.com", domain.RoleUser)
+	ctx := context.Background()
+
+	env.forgotPassword(t, "u1@example.com")
+	first := env.mail.lastToken(t, "u1@example.com")
+
+	env.forgotPassword(t, "u1@example.com")
+	second := env.mail.lastToken(t, "u1@example.com")
+
+	if err := env.auth.ResetPassword(ctx, first, "new-password"); !errors.Is(err, ErrInvalidResetToken) {
+		t.Errorf("first link: err = %v, want ErrInvalidResetToken", err)
+	}
+	if err := env.auth.ResetPassword(ctx, second, "new-password"); err != nil {
+		t.Errorf("second link: %v", err)
+	}
+}
+
+func TestResetPasswordInactiveUser(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "admin", "admin@example.com", domain.RoleAdmin)
+	env.register(t, "u1", "u1@example.com", domain.RoleUser)
+	_, admin := env.login(t, "admin@example.com")
+	ctx := context.Background()
+
+	env.forgotPassword(t, "u1@example.com")
+	token := env.mail.lastToken(t, "u1@example.com")
+
+	if err := env.users().UpdateState(admin, "u1", false); err != nil {
+		t.Fatalf("deactivate: %v", err)
+	}
+
+	if err := env.auth.ResetPassword(ctx, token, "new-password"); !errors.Is(err, ErrInvalidResetToken) {
+		t.Errorf("err = %v, want ErrInvalidResetToken", err)
+	}
+	user, _ := env.auth.UserManager.GetUserByID(ctx, "u1")
+	if env.auth.checkPasswordHash("new-password", user.Password) {
+		t.Error("the password of a deactivated user was reset")
+	}
+}
+
+func TestResetPasswordExpiredToken(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "u1", "u1@example.com", domain.RoleUser)
+	ctx := context.Background()
+
+	env.auth.ResetTokenTTL = -defaultResetTokenTTL
+	env.forgotPassword(t, "u1@example.com")
+
+	if err := env.auth.ResetPassword(ctx, env.mail.lastToken(t, "u1@example.com"), "new-password"); !errors.Is(err, ErrInvalidResetToken) {
+		t.Errorf("err = %v, want ErrInvalidResetToken", err)
+	}
+}
@@ -0,0 +1,138 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+
+	"basic-service/domain"
+
+	"braces.dev/errtrace"
+)
+
+type Permission string
+
+const (
+	PermUserList             Permission = "users:list"
+	PermUserManage           Permission = "users:manage"
+	PermUserImpersonate      Permission = "users:impersonate"
+	PermPublicTemplateWrite  Permission = "public_templates:write"
+	PermUserTemplateReadAny  Permission = "user_templates:read_any"
+	PermUserTemplateRead     Permission = "user_templates:read"
+	PermUserTemplateWriteOwn Permission = "user_templates:write"
+	PermGuestRead            Permission = "guests:read"
+	PermGuestManageOwn       Permission = "guests:manage"
+)
+
+// Scope limits what a personal API token can do on behalf of its user
+type Scope string
+
+const (
+	ScopeGuestsRead     Scope = "guests:read"
+	ScopeGuestsWrite    Scope = "guests:write"
+	ScopeTemplatesRead  Scope = "templates:read"
+	ScopeTemplatesWrite Scope = "templates:write"
+)
+
+var ErrForbidden = errors.New("forbidden")
+
+// rolePermissions is the policy table, every permission granted to a role
+// must be listed here.
+var rolePermissions = map[domain.RoleType][]Permission{
+	domain.RoleAdmin: {
+		PermUserList,
+		PermUserManage,
+		PermUserImpersonate,
+		PermPublicTemplateWrite,
+		PermUserTemplateReadAny,
+		PermUserTemplateRead,
+		PermUserTemplateWriteOwn,
+		PermGuestRead,
+		PermGuestManageOwn,
+	},
+	domain.RoleUser: {
+		PermUserTemplateRead,
+		PermUserTemplateWriteOwn,
+		PermGuestRead,
+		PermGuestManageOwn,
+	},
+}
+
+// scopePermissions lists what each API token scope unlocks. A token never
+// gets more than its user role allows.
+var scopePermissions = map[Scope][]Permission{
+	ScopeGuestsRead:     {PermGuestRead},
+	ScopeGuestsWrite:    {PermGuestRead, PermGuestManageOwn},
+	ScopeTemplatesRead:  {PermUserTemplateRead},
+	ScopeTemplatesWrite: {PermUserTemplateRead, PermUserTemplateWriteOwn},
+}
+
+// ValidScope reports whether scope is known.
+func ValidScope(scope Scope) bool {
+	_, ok := scopePermissions[scope]
+	return ok
+}
+
+// HasPermission reports whether the role is granted the permission.
+func HasPermission(role domain.RoleType, perm Permission) bool {
+	for _, v := range rolePermissions[role] {
+		if v == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// HasRole reports whether the claims role is one of roles.
+func (c *Claims) HasRole(roles ...domain.RoleType) bool {
+	for _, v := range roles {
+		if c.Role == v {
+			return true
+		}
+	}
+	return false
+}
+
+// IsAPIToken reports whether the claims come from a personal API token.
+func (c *Claims) IsAPIToken() bool {
+	return c.APITokenID != ""
+}
+
+// Can reports whether the claims are granted every given permission. API
+// token claims also need a scope that covers each permission.
+func (c *Claims) Can(perms ...Permission) bool {
+	for _, p := range perms {
+		if !HasPermission(c.Role, p) {
+			return false
+		}
+		if c.IsAPIToken() && !c.scopeAllows(p) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *Claims) scopeAllows(perm Permission) bool {
+	for _, scope := range c.Scopes {
+		for _, v := range scopePermissions[scope] {
+			if v == perm {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Authorize checks the claims in ctx against perms and returns ErrForbidden
+// when one of them is missing.
+func Authorize(ctx context.Context, perms ...Permission) error {
+	claims, err := GetClaimFromContext(ctx)
+	if err != nil {
+		return errtrace.Wrap(ErrForbidden)
+	}
+
+	if !claims.Can(perms...) {
+		return errtrace.Wrap(ErrForbidden)
+	}
+
+	return nil
+}
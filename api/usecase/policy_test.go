@@ -0,0 +1,71 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"basic-service/domain"
+	"basic-service/interface/sql"
+)
+
+func TestHasPermission(t *testing.T) {
+	tests := []struct {
+		role domain.RoleType
+		perm Permission
+		want bool
+	}{
+		{domain.RoleAdmin, PermUserList, true},
+		{domain.RoleAdmin, PermUserManage, true},
+		{domain.RoleAdmin, PermPublicTemplateWrite, true},
+		{domain.RoleAdmin, PermGuestManageOwn, true},
+		{domain.RoleUser, PermUserTemplateWriteOwn, true},
+		{domain.RoleUser, PermGuestManageOwn, true},
+		{domain.RoleUser, PermUserList, false},
+		{domain.RoleUser, PermUserManage, false},
+		{domain.RoleUser, PermPublicTemplateWrite, false},
+		{domain.RoleUser, PermUserTemplateReadAny, false},
+		{0, PermGuestManageOwn, false},
+	}
+
+	for _, tt := range tests {
+		if got := HasPermission(tt.role, tt.perm); got != tt.want {
+			t.Errorf("HasPermission(%d, %s) = %v, want %v", tt.role, tt.perm, got, tt.want)
+		}
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	ctx := context.Background()
+
+	if err := Authorize(ctx, PermGuestManageOwn); !errors.Is(err, ErrForbidden) {
+		t.Errorf("without claims: err = %v, want ErrForbidden", err)
+	}
+
+	user := withClaims(ctx, "u1", domain.RoleUser)
+	if err := Authorize(user, PermGuestManageOwn, PermUserTemplateWriteOwn); err != nil {
+		t.Errorf("user with own permissions: err = %v", err)
+	}
+	if err := Authorize(user, PermGuestManageOwn, PermUserList); !errors.Is(err, ErrForbidden) {
+		t.Errorf("user listing users: err = %v, want ErrForbidden", err)
+	}
+
+	admin := withClaims(ctx, "a1", domain.RoleAdmin)
+	if err := Authorize(admin, PermUserList, PermUserManage); err != nil {
+		t.Errorf("admin: err = %v", err)
+	}
+}
+
+func TestAdminOnlyUsecases(t *testing.T) {
+	user := withClaims(context.Background(), "u1", domain.RoleUser)
+
+	if _, err := (&UserUsecase{}).List(user, sql.UserFilter{}, 1, 10); !errors.Is(err, ErrForbidden) {
+		t.Errorf("UserUsecase.List: err = %v, want ErrForbidden", err)
+	}
+	if err := (&PublicTemplateUseCase{}).Create(user, domain.PublicTemplate{}); !errors.Is(err, ErrForbidden) {
+		t.Errorf("PublicTemplateUseCase.Create: err = %v, want ErrForbidden", err)
+	}
+	if _, err := (&UserTemplate{}).List(user, 1, 10, "u2"); !errors.Is(err, ErrForbidden) {
+		t.Errorf("UserTemplate.List of another user: err = %v, want ErrForbidden", err)
+	}
+}
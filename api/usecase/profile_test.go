@@ -0,0 +1,67 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"basic-service/domain"
+)
+
+func TestUpdateProfile(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "u1", "u1@example.com", domain.RoleUser)
+	_, ctx := env.login(t, "u1@example.com")
+
+	user, previous, err := env.auth.UpdateProfile(ctx, "Budi", "a.png")
+	if err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if user.Name != "Budi" || user.Profile != "a.png" || previous != "" {
+		t.Errorf("got %q %q, previous %q", user.Name, user.Profile, previous)
+	}
+
+	// without a new picture the current one is kept
+	user, previous, err = env.auth.UpdateProfile(ctx, "Budi Santoso", "")
+	if err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if user.Name != "Budi Santoso" || user.Profile != "a.png" || previous != "a.png" {
+		t.Errorf("got %q %q, previous %q", user.Name, user.Profile, previous)
+	}
+
+	me, err := env.auth.Me(ctx)
+	if err != nil {
+		t.Fatalf("me: %v", err)
+	}
+	if me.Name != "Budi Santoso" || me.Email != "u1@example.com" {
+		t.Errorf("me = %q %q", me.Name, me.Email)
+	}
+}
+
+func TestChangePassword(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "u1", "u1@example.com", domain.RoleUser)
+	bg := context.Background()
+
+	pair, ctx := env.login(t, "u1@example.com")
+
+	if _, err := env.auth.ChangePassword(ctx, "wrong", "new-password"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("wrong current password: err = %v, want ErrInvalidCredentials", err)
+	}
+
+	fresh, err := env.auth.ChangePassword(ctx, "password", "new-password")
+	if err != nil {
+		t.Fatalf("change: %v", err)
+	}
+
+	if _, err := env.auth.ValidateToken(bg, pair.AccessToken); !errors.Is(err, ErrTokenRevoked) {
+		t.Errorf("previous session: err = %v, want ErrTokenRevoked", err)
+	}
+	if _, err := env.auth.ValidateToken(bg, fresh.AccessToken); err != nil {
+		t.Errorf("returned session: %v", err)
+	}
+	if _, err := env.auth.Login(bg, "u1@example.com", "new-password", ""); err != nil {
+		t.Errorf("login with the new password: %v", err)
+	}
+}
@@ -54,6 +54,10 @@ func (p *PublicTemplateUseCase) Get(ctx context.Context, id string) (domain.Publ
 }
 
 func (p *PublicTemplateUseCase) Create(ctx context.Context, data domain.PublicTemplate) error {
+	if err := Authorize(ctx, PermPublicTemplateWrite); err != nil {
+		return err
+	}
+
 	now := time.Now()
 	data.CreatedAt = now
 	data.UpdatedAt = now
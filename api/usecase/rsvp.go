@@ -0,0 +1,287 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"basic-service/domain"
+	"basic-service/interface/sql"
+
+	"braces.dev/errtrace"
+	"github.com/google/uuid"
+)
+
+// MaxRSVPQuestions is the largest number of questions a template can ask
+const MaxRSVPQuestions = 20
+
+// maxRSVPTextAnswerLength is the longest answer to a text question
+const maxRSVPTextAnswerLength = 1000
+
+// maxRSVPNumberAnswer bounds the answers to number questions, floats stay
+// exact up to it
+const maxRSVPNumberAnswer = 1e15
+
+var (
+	ErrRSVPInvalid         = errors.New("invalid rsvp")
+	ErrRSVPQuestionInvalid = errors.New("invalid rsvp question")
+	ErrRSVPQuestionTooMany = fmt.Errorf("a template can have at most %d rsvp questions", MaxRSVPQuestions)
+)
+
+// RSVP is what a guest sends back from the invitation, Person is the
+// confirmed headcount of a guest without members and Answers maps question
+// ids to answers.
+type RSVP struct {
+	Attend  bool
+	Person  int
+	Message string
+	Answers map[string]string
+	// Members are the answers of the members of a household, Person is
+	// derived from them
+	Members []MemberRSVP
+}
+
+// Questions returns the RSVP questions of a template, every member can read
+// them.
+func (g *GuestUsecase) Questions(ctx context.Context, templateID string) ([]domain.RSVPQuestion, error) {
+	if _, err := authorizeTemplate(ctx, g.members, templateID, domain.TemplateRoleViewer); err != nil {
+		return nil, err
+	}
+
+	return errtrace.Wrap2(g.questions.List(ctx, templateID))
+}
+
+// CreateQuestion adds a question to the RSVP form of a template, editors and
+// owners can do it.
+func (g *GuestUsecase) CreateQuestion(ctx context.Context, question domain.RSVPQuestion) (domain.RSVPQuestion, error) {
+	if _, err := authorizeTemplate(ctx, g.members, question.UserTemplateID, domain.TemplateRoleEditor); err != nil {
+		return domain.RSVPQuestion{}, err
+	}
+
+	if err := validateRSVPQuestion(&question); err != nil {
+		return domain.RSVPQuestion{}, err
+	}
+
+	questions, err := g.questions.List(ctx, question.UserTemplateID)
+	if err != nil {
+		return domain.RSVPQuestion{}, errtrace.Wrap(err)
+	}
+
+	if len(questions) >= MaxRSVPQuestions {
+		return domain.RSVPQuestion{}, errtrace.Wrap(ErrRSVPQuestionTooMany)
+	}
+
+	question.ID = uuid.New().String()
+	question.CreatedAt = time.Now()
+	if err := g.questions.Create(ctx, question); err != nil {
+		return domain.RSVPQuestion{}, errtrace.Wrap(err)
+	}
+
+	return question, nil
+}
+
+// UpdateQuestion replaces a question of a template, answers already given
+// are kept. Editors and owners can do it.
+func (g *GuestUsecase) UpdateQuestion(ctx context.Context, question domain.RSVPQuestion) (domain.RSVPQuestion, error) {
+	if _, err := authorizeTemplate(ctx, g.members, question.UserTemplateID, domain.TemplateRoleEditor); err != nil {
+		return domain.RSVPQuestion{}, err
+	}
+
+	if err := validateRSVPQuestion(&question); err != nil {
+		return domain.RSVPQuestion{}, err
+	}
+
+	if err := g.questions.Update(ctx, question); err != nil {
+		return domain.RSVPQuestion{}, errtrace.Wrap(err)
+	}
+
+	return errtrace.Wrap2(g.getQuestion(ctx, question.UserTemplateID, question.ID))
+}
+
+func (g *GuestUsecase) getQuestion(ctx context.Context, templateID, id string) (domain.RSVPQuestion, error) {
+	question, err := g.questions.Get(ctx, templateID, id)
+	if err != nil {
+		return domain.RSVPQuestion{}, errtrace.Wrap(err)
+	}
+
+	return *question, nil
+}
+
+// DeleteQuestion removes a question and its answers, editors and owners can
+// do it.
+func (g *GuestUsecase) DeleteQuestion(ctx context.Context, templateID, id string) error {
+	if _, err := authorizeTemplate(ctx, g.members, templateID, domain.TemplateRoleEditor); err != nil {
+		return err
+	}
+
+	if err := AuthorizeDestructive(ctx); err != nil {
+		return err
+	}
+
+	return errtrace.Wrap(g.questions.Delete(ctx, templateID, id))
+}
+
+// validateRSVPQuestion checks a question and cleans its options, only choice
+// questions have options.
+func validateRSVPQuestion(question *domain.RSVPQuestion) error {
+	question.Label = strings.TrimSpace(question.Label)
+	if question.Label == "" {
+		return errtrace.Wrap(fmt.Errorf("%w: label is required", ErrRSVPQuestionInvalid))
+	}
+
+	switch question.Type {
+	case domain.RSVPQuestionChoice:
+		options := make([]string, 0, len(question.Options))
+		for _, v := range question.Options {
+			v = strings.TrimSpace(v)
+			if v == "" || slices.Contains(options, v) {
+				continue
+			}
+			options = append(options, v)
+		}
+
+		if len(options) == 0 {
+			return errtrace.Wrap(fmt.Errorf("%w: a choice question needs options", ErrRSVPQuestionInvalid))
+		}
+		question.Options = options
+	case domain.RSVPQuestionText, domain.RSVPQuestionNumber:
+		question.Options = []string{}
+	default:
+		return errtrace.Wrap(fmt.Errorf("%w: unknown type %q", ErrRSVPQuestionInvalid, question.Type))
+	}
+
+	return nil
+}
+
+// SubmitRSVP stores the RSVP of the guest of an invitation token. The
+// headcount is capped at the invited persons, a guest who does not attend
+// brings nobody and required questions only apply to guests who attend. A
+// changed message goes through the guestbook moderation again. RSVPs are only
+// accepted while the template is published and before its RSVP deadline.
+func (g *GuestUsecase) SubmitRSVP(ctx context.Context, token string, rsvp RSVP) error {
+	guest, template, err := g.guestByToken(ctx, token)
+	if err != nil {
+		return err
+	}
+
+	if !template.RSVPOpen(time.Now()) {
+		return errtrace.Wrap(ErrRSVPClosed)
+	}
+
+	questions, err := g.questions.List(ctx, guest.UserTemplateID)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	var person int
+	if len(guest.Members) > 0 {
+		if err := memberRSVP(guest, rsvp); err != nil {
+			return err
+		}
+		rsvp.Attend, person = *guest.Attend, *guest.AttendPerson
+	} else {
+		if len(rsvp.Members) > 0 {
+			return errtrace.Wrap(fmt.Errorf("%w: the invitation has no members", ErrRSVPInvalid))
+		}
+
+		person, err = rsvpHeadcount(*guest, rsvp)
+		if err != nil {
+			return err
+		}
+	}
+
+	answers, err := rsvpAnswers(questions, rsvp)
+	if err != nil {
+		return err
+	}
+
+	data := sql.GuestRSVP{
+		Attend:       rsvp.Attend,
+		AttendPerson: person,
+		Message:      rsvp.Message,
+		Answers:      answers,
+	}
+	if rsvp.Message != guest.Message {
+		data.MessageStatus = guestbookStatus(template, rsvp.Message)
+	}
+	if len(guest.Members) > 0 {
+		data.Members = guest.Members
+	}
+
+	return errtrace.Wrap(g.guestRepo.SaveRSVP(ctx, *guest, data))
+}
+
+// rsvpHeadcount returns the confirmed headcount of an RSVP, one when an
+// attending guest does not say. A guest invited without a headcount is
+// invited alone.
+func rsvpHeadcount(guest domain.Guest, rsvp RSVP) (int, error) {
+	if !rsvp.Attend {
+		return 0, nil
+	}
+
+	person := rsvp.Person
+	if person == 0 {
+		person = 1
+	}
+
+	if person < 0 {
+		return 0, errtrace.Wrap(fmt.Errorf("%w: person cannot be negative", ErrRSVPInvalid))
+	}
+	if invited := max(guest.Person, 1); person > invited {
+		return 0, errtrace.Wrap(fmt.Errorf("%w: the invitation is for at most %d persons", ErrRSVPInvalid, invited))
+	}
+
+	return person, nil
+}
+
+// rsvpAnswers checks the answers of an RSVP against the questions of the
+// template, empty answers are dropped.
+func rsvpAnswers(questions []domain.RSVPQuestion, rsvp RSVP) (map[string]string, error) {
+	answers := make(map[string]string, len(rsvp.Answers))
+
+	for id, v := range rsvp.Answers {
+		i := slices.IndexFunc(questions, func(q domain.RSVPQuestion) bool { return q.ID == id })
+		if i < 0 {
+			return nil, errtrace.Wrap(fmt.Errorf("%w: unknown question %q", ErrRSVPInvalid, id))
+		}
+
+		question := questions[i]
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+
+		switch question.Type {
+		case domain.RSVPQuestionChoice:
+			if !slices.Contains(question.Options, v) {
+				return nil, errtrace.Wrap(fmt.Errorf("%w: %q is not an option of %q", ErrRSVPInvalid, v, question.Label))
+			}
+		case domain.RSVPQuestionNumber:
+			n, err := strconv.ParseFloat(v, 64)
+			if err != nil || math.IsNaN(n) || math.IsInf(n, 0) || math.Abs(n) > maxRSVPNumberAnswer {
+				return nil, errtrace.Wrap(fmt.Errorf("%w: %q needs a number", ErrRSVPInvalid, question.Label))
+			}
+		case domain.RSVPQuestionText:
+			if len(v) > maxRSVPTextAnswerLength {
+				return nil, errtrace.Wrap(fmt.Errorf("%w: the answer to %q is too long", ErrRSVPInvalid, question.Label))
+			}
+		}
+
+		answers[id] = v
+	}
+
+	if rsvp.Attend {
+		for _, question := range questions {
+			if _, ok := answers[question.ID]; question.Required && !ok {
+				return nil, errtrace.Wrap(fmt.Errorf("%w: %q is required", ErrRSVPInvalid, question.Label))
+			}
+		}
+	}
+
+	return answers, nil
+}
@@ -0,0 +1,231 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"basic-service/domain"
+	"basic-service/interface/sql"
+)
+
+func TestValidateRSVPQuestion(t *testing.T) {
+	tests := []struct {
+		name     string
+		question domain.RSVPQuestion
+		options  []string
+		err      error
+	}{
+		{"text", domain.RSVPQuestion{Label: " Wishes ", Type: domain.RSVPQuestionText, Options: []string{"a"}}, []string{}, nil},
+		{"number", domain.RSVPQuestion{Label: "Children", Type: domain.RSVPQuestionNumber}, []string{}, nil},
+		{"choice", domain.RSVPQuestion{Label: "Meal", Type: domain.RSVPQuestionChoice, Options: []string{" fish", "", "meat", "fish"}}, []string{"fish", "meat"}, nil},
+		{"choice without options", domain.RSVPQuestion{Label: "Meal", Type: domain.RSVPQuestionChoice, Options: []string{" "}}, nil, ErrRSVPQuestionInvalid},
+		{"no label", domain.RSVPQuestion{Label: " ", Type: domain.RSVPQuestionText}, nil, ErrRSVPQuestionInvalid},
+		{"unknown type", domain.RSVPQuestion{Label: "Meal", Type: "date"}, nil, ErrRSVPQuestionInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateRSVPQuestion(&tt.question)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("err = %v, want %v", err, tt.err)
+			}
+			if tt.err == nil && (!slices.Equal(tt.question.Options, tt.options) || strings.TrimSpace(tt.question.Label) != tt.question.Label) {
+				t.Errorf("cleaned question = %+v, want options %q", tt.question, tt.options)
+			}
+		})
+	}
+}
+
+func TestRSVPQuestions(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "admin", "admin@example.com", domain.RoleAdmin)
+	env.register(t, "u1", "u1@example.com", domain.RoleUser)
+	env.register(t, "u2", "u2@example.com", domain.RoleUser)
+	_, admin := env.login(t, "admin@example.com")
+	_, ctx := env.login(t, "u1@example.com")
+	env.createTemplate(t, ctx, "t1")
+	viewer := env.addMember(t, ctx, "t1", "u2@example.com", domain.TemplateRoleViewer)
+	guests := env.guests()
+
+	question := domain.RSVPQuestion{UserTemplateID: "t1", Label: "Meal", Type: domain.RSVPQuestionChoice, Options: []string{"fish", "meat"}}
+	if _, err := guests.CreateQuestion(viewer, question); !errors.Is(err, ErrForbidden) {
+		t.Errorf("viewer: err = %v, want ErrForbidden", err)
+	}
+
+	created, err := guests.CreateQuestion(ctx, question)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	created.Label = "Main course"
+	updated, err := guests.UpdateQuestion(ctx, created)
+	if err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if updated.Label != "Main course" {
+		t.Errorf("label = %q after the update", updated.Label)
+	}
+
+	list, err := guests.Questions(viewer, "t1")
+	if err != nil || len(list) != 1 {
+		t.Fatalf("questions = %v, %v", list, err)
+	}
+
+	_, readOnly := env.impersonate(t, admin, "u1", false)
+	if err := guests.DeleteQuestion(readOnly, "t1", created.ID); !errors.Is(err, ErrImpersonationReadOnly) {
+		t.Errorf("impersonated: err = %v, want ErrImpersonationReadOnly", err)
+	}
+	if err := guests.DeleteQuestion(ctx, "t1", created.ID); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+
+	for i := 0; i < MaxRSVPQuestions; i++ {
+		if _, err := guests.CreateQuestion(ctx, question); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := guests.CreateQuestion(ctx, question); !errors.Is(err, ErrRSVPQuestionTooMany) {
+		t.Errorf("too many: err = %v, want ErrRSVPQuestionTooMany", err)
+	}
+}
+
+func TestRSVPHeadcount(t *testing.T) {
+	tests := []struct {
+		name    string
+		invited int
+		rsvp    RSVP
+		want    int
+		err     error
+	}{
+		{"not attending", 3, RSVP{Person: 2}, 0, nil},
+		{"attending without a headcount", 3, RSVP{Attend: true}, 1, nil},
+		{"within the invitation", 3, RSVP{Attend: true, Person: 3}, 3, nil},
+		{"over the invitation", 3, RSVP{Attend: true, Person: 4}, 0, ErrRSVPInvalid},
+		{"negative", 3, RSVP{Attend: true, Person: -1}, 0, ErrRSVPInvalid},
+		{"invited alone", 0, RSVP{Attend: true, Person: 1}, 1, nil},
+		{"plus one of a guest invited alone", 0, RSVP{Attend: true, Person: 2}, 0, ErrRSVPInvalid},
+	}
+
+	for _, tt := range tests {
+		got, err := rsvpHeadcount(domain.Guest{Person: tt.invited}, tt.rsvp)
+		if !errors.Is(err, tt.err) || got != tt.want {
+			t.Errorf("%s: got %d, %v, want %d, %v", tt.name, got, err, tt.want, tt.err)
+		}
+	}
+}
+
+func TestRSVPNumberAnswers(t *testing.T) {
+	questions := []domain.RSVPQuestion{{ID: "q1", Label: "Children", Type: domain.RSVPQuestionNumber}}
+
+	tests := []struct {
+		answer string
+		err    error
+	}{
+		{"2", nil},
+		{"-1.5", nil},
+		{"1e15", nil},
+		{"1e16", ErrRSVPInvalid},
+		{"NaN", ErrRSVPInvalid},
+		{"Inf", ErrRSVPInvalid},
+		{"-infinity", ErrRSVPInvalid},
+		{"two", ErrRSVPInvalid},
+	}
+
+	for _, tt := range tests {
+		if _, err := rsvpAnswers(questions, RSVP{Answers: map[string]string{"q1": tt.answer}}); !errors.Is(err, tt.err) {
+			t.Errorf("answer %q: err = %v, want %v", tt.answer, err, tt.err)
+		}
+	}
+}
+
+func TestSubmitRSVP(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "u1", "u1@example.com", domain.RoleUser)
+	_, ctx := env.login(t, "u1@example.com")
+	env.createTemplate(t, ctx, "t1")
+	env.createGuest(t, ctx, domain.Guest{ID: "g1", Name: "g1", Person: 3, UserTemplateID: "t1"})
+	guests := env.guests()
+	bg := context.Background()
+
+	meal, err := guests.CreateQuestion(ctx, domain.RSVPQuestion{UserTemplateID: "t1", Label: "Meal", Type: domain.RSVPQuestionChoice, Options: []string{"fish", "meat"}, Required: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	children, err := guests.CreateQuestion(ctx, domain.RSVPQuestion{UserTemplateID: "t1", Label: "Children", Type: domain.RSVPQuestionNumber})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	invitation, _ := guests.GetInvitation(ctx, "g1")
+
+	invalid := []struct {
+		name string
+		rsvp RSVP
+	}{
+		{"over the invited persons", RSVP{Attend: true, Person: 4, Answers: map[string]string{meal.ID: "fish"}}},
+		{"negative persons", RSVP{Attend: true, Person: -1, Answers: map[string]string{meal.ID: "fish"}}},
+		{"required question missing", RSVP{Attend: true, Answers: map[string]string{children.ID: "1"}}},
+		{"not an option", RSVP{Attend: true, Answers: map[string]string{meal.ID: "soup"}}},
+		{"not a number", RSVP{Attend: true, Answers: map[string]string{meal.ID: "fish", children.ID: "two"}}},
+		{"unknown question", RSVP{Attend: true, Answers: map[string]string{meal.ID: "fish", "other": "x"}}},
+	}
+	for _, tt := range invalid {
+		if err := guests.SubmitRSVP(bg, invitation.Token, tt.rsvp); !errors.Is(err, ErrRSVPInvalid) {
+			t.Errorf("%s: err = %v, want ErrRSVPInvalid", tt.name, err)
+		}
+	}
+
+	if err := guests.SubmitRSVP(bg, invitation.Token, RSVP{Attend: true, Person: 2, Answers: map[string]string{meal.ID: " fish ", children.ID: ""}}); err != nil {
+		t.Fatalf("submit: %v", err)
+	}
+	public, _ := guests.GetGuest(bg, invitation.Token)
+	guest := public.Guest
+	if guest.AttendPerson == nil || *guest.AttendPerson != 2 || guest.Answers[meal.ID] != "fish" {
+		t.Errorf("guest after rsvp = %+v", guest)
+	}
+	if _, ok := guest.Answers[children.ID]; ok {
+		t.Error("empty answer was stored")
+	}
+
+	// required questions and the headcount only apply to guests who come
+	if err := guests.SubmitRSVP(bg, invitation.Token, RSVP{Attend: false, Person: 5}); err != nil {
+		t.Fatalf("decline: %v", err)
+	}
+	public, _ = guests.GetGuest(bg, invitation.Token)
+	guest = public.Guest
+	if guest.AttendPerson == nil || *guest.AttendPerson != 0 {
+		t.Errorf("headcount of a declined rsvp = %v, want 0", guest.AttendPerson)
+	}
+}
+
+func TestGuestUpdateHeadcount(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "u1", "u1@example.com", domain.RoleUser)
+	_, ctx := env.login(t, "u1@example.com")
+	env.createTemplate(t, ctx, "t1")
+	env.createGuest(t, ctx, domain.Guest{ID: "g1", Name: "g1", Person: 2, UserTemplateID: "t1"})
+	guests := env.guests()
+
+	three, four := 3, 4
+	if _, err := guests.Update(ctx, "g1", sql.GuestUpdate{AttendPerson: &three}); !errors.Is(err, ErrRSVPInvalid) {
+		t.Errorf("over the invited persons: err = %v, want ErrRSVPInvalid", err)
+	}
+
+	// raising the invited persons in the same update allows more
+	guest, err := guests.Update(ctx, "g1", sql.GuestUpdate{Person: &four, AttendPerson: &three})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if guest.Person != 4 || guest.AttendPerson == nil || *guest.AttendPerson != 3 {
+		t.Errorf("updated guest = %+v", guest)
+	}
+
+	// lowering the invited persons below the confirmed headcount is refused
+	two := 2
+	if _, err := guests.Update(ctx, "g1", sql.GuestUpdate{Person: &two}); !errors.Is(err, ErrRSVPInvalid) {
+		t.Errorf("below the confirmed headcount: err = %v, want ErrRSVPInvalid", err)
+	}
+}
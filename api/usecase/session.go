@@ -0,0 +1,164 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"basic-service/domain"
+	"basic-service/interface/sql"
+
+	"braces.dev/errtrace"
+)
+
+// sessionTouchInterval limits how often last_seen_at is written for a session
+const sessionTouchInterval = time.Minute
+
+var ErrSessionNotFound = errors.New("session not found")
+
+// Client describes the device a request comes from
+type Client struct {
+	IP        string
+	UserAgent string
+}
+
+// WithClient stores the request client in ctx, sessions started or used with
+// that context record it.
+func WithClient(ctx context.Context, client Client) context.Context {
+	return context.WithValue(ctx, "client", client)
+}
+
+// ClientFromContext returns the request client, empty when none was stored.
+func ClientFromContext(ctx context.Context) Client {
+	client, _ := ctx.Value("client").(Client)
+	return client
+}
+
+// SessionInfo is a session as shown to its user
+type SessionInfo struct {
+	domain.Session
+	// Current marks the session the request was made with
+	Current bool
+}
+
+// touchSession records activity on a session, failures are not fatal for the
+// request so they are ignored.
+func (a *Auth) touchSession(ctx context.Context, sessionID string) {
+	client := ClientFromContext(ctx)
+	_ = a.Tokens.TouchSession(ctx, sessionID, client.IP, client.UserAgent, time.Now().Add(-sessionTouchInterval))
+}
+
+// ListSessions returns the active sessions of the current user.
+func (a *Auth) ListSessions(ctx context.Context) ([]SessionInfo, error) {
+	claims, err := GetClaimFromContext(ctx)
+	if err != nil {
+		return nil, errtrace.Wrap(errors.New("invalid token claims"))
+	}
+
+	sessions, err := a.Tokens.ListSessions(ctx, claims.UserID)
+	if err != nil {
+		return nil, errtrace.Wrap(err)
+	}
+
+	result := make([]SessionInfo, 0, len(sessions))
+	for _, v := range sessions {
+		result = append(result, SessionInfo{
+			Session: v,
+			Current: v.ID == claims.SessionID,
+		})
+	}
+
+	return result, nil
+}
+
+// RevokeSession signs out one session of the current user, access tokens of
+// that session stop working right away.
+func (a *Auth) RevokeSession(ctx context.Context, id string) error {
+	claims, err := GetClaimFromContext(ctx)
+	if err != nil {
+		return errtrace.Wrap(errors.New("invalid token claims"))
+	}
+
+	if _, err := a.Tokens.GetSession(ctx, claims.UserID, id); err != nil {
+		if errors.Is(err, sql.ErrSessionNotFound) {
+			return errtrace.Wrap(ErrSessionNotFound)
+		}
+		return errtrace.Wrap(err)
+	}
+
+	return errtrace.Wrap(a.Tokens.RevokeFamily(ctx, id))
+}
+
+// RevokeAllSessions signs out every session of the current user, keepCurrent
+// leaves the session of the request signed in.
+func (a *Auth) RevokeAllSessions(ctx context.Context, keepCurrent bool) error {
+	claims, err := GetClaimFromContext(ctx)
+	if err != nil {
+		return errtrace.Wrap(errors.New("invalid token claims"))
+	}
+
+	if keepCurrent && claims.SessionID != "" {
+		return errtrace.Wrap(a.Tokens.RevokeUserExcept(ctx, claims.UserID, claims.SessionID))
+	}
+
+	return errtrace.Wrap(a.Tokens.RevokeUser(ctx, claims.UserID))
+}
+
+// ListSessions returns the active sessions of a user.
+func (p *UserUsecase) ListSessions(ctx context.Context, userID string) ([]domain.Session, error) {
+	if err := Authorize(ctx, PermUserManage); err != nil {
+		return nil, err
+	}
+
+	if _, err := p.uc.GetUserByID(ctx, userID); err != nil {
+		return nil, errtrace.Wrap(err)
+	}
+
+	return errtrace.Wrap2(p.tokens.ListSessions(ctx, userID))
+}
+
+// RevokeSessions signs out one session of a user, or all of them when
+// sessionID is empty.
+func (p *UserUsecase) RevokeSessions(ctx context.Context, userID, sessionID string) error {
+	if err := Authorize(ctx, PermUserManage); err != nil {
+		return err
+	}
+
+	if err := AuthorizeDestructive(ctx); err != nil {
+		return err
+	}
+
+	if _, err := p.uc.GetUserByID(ctx, userID); err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	if sessionID == "" {
+		if err := p.tokens.RevokeUser(ctx, userID); err != nil {
+			return errtrace.Wrap(err)
+		}
+	} else {
+		if _, err := p.tokens.GetSession(ctx, userID, sessionID); err != nil {
+			if errors.Is(err, sql.ErrSessionNotFound) {
+				return errtrace.Wrap(ErrSessionNotFound)
+			}
+			return errtrace.Wrap(err)
+		}
+
+		if err := p.tokens.RevokeFamily(ctx, sessionID); err != nil {
+			return errtrace.Wrap(err)
+		}
+	}
+
+	metadata := map[string]any{"all": sessionID == ""}
+	if sessionID != "" {
+		metadata["session_id"] = sessionID
+	}
+
+	return errtrace.Wrap(p.audit.Record(ctx, domain.AuditLog{
+		Action:     AuditSessionRevoke,
+		TargetType: "user",
+		TargetID:   userID,
+		IP:         ClientFromContext(ctx).IP,
+		Metadata:   metadata,
+	}))
+}
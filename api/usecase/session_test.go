@@ -0,0 +1,124 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"basic-service/domain"
+)
+
+func TestListSessions(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "u1", "u1@example.com", domain.RoleUser)
+
+	phone := WithClient(context.Background(), Client{IP: "10.0.0.1", UserAgent: "phone"})
+	if _, err := env.auth.Login(phone, "u1@example.com", "password", "10.0.0.1"); err != nil {
+		t.Fatal(err)
+	}
+	_, ctx := env.login(t, "u1@example.com")
+
+	sessions, err := env.auth.ListSessions(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 2 {
+		t.Fatalf("got %d sessions, want 2", len(sessions))
+	}
+
+	var current, other int
+	for _, v := range sessions {
+		if v.Current {
+			current++
+		} else if v.UserAgent == "phone" && v.IP == "10.0.0.1" {
+			other++
+		}
+	}
+	if current != 1 || other != 1 {
+		t.Errorf("sessions = %+v", sessions)
+	}
+}
+
+func TestRevokeSession(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "u1", "u1@example.com", domain.RoleUser)
+	env.register(t, "u2", "u2@example.com", domain.RoleUser)
+	other, _ := env.login(t, "u1@example.com")
+	_, ctx := env.login(t, "u1@example.com")
+	_, ctx2 := env.login(t, "u2@example.com")
+	bg := context.Background()
+
+	claims, _ := env.auth.ValidateToken(bg, other.AccessToken)
+
+	// sessions of other users look like unknown ones
+	if err := env.auth.RevokeSession(ctx2, claims.SessionID); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("other user: err = %v, want ErrSessionNotFound", err)
+	}
+
+	if err := env.auth.RevokeSession(ctx, claims.SessionID); err != nil {
+		t.Fatalf("revoke: %v", err)
+	}
+	if _, err := env.auth.ValidateToken(bg, other.AccessToken); !errors.Is(err, ErrTokenRevoked) {
+		t.Errorf("revoked session: err = %v, want ErrTokenRevoked", err)
+	}
+	if _, err := env.auth.Refresh(bg, other.RefreshToken); err == nil {
+		t.Error("refresh token of the revoked session still works")
+	}
+}
+
+func TestRevokeAllSessions(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "u1", "u1@example.com", domain.RoleUser)
+	other, _ := env.login(t, "u1@example.com")
+	current, ctx := env.login(t, "u1@example.com")
+	bg := context.Background()
+
+	if err := env.auth.RevokeAllSessions(ctx, true); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := env.auth.ValidateToken(bg, other.AccessToken); !errors.Is(err, ErrTokenRevoked) {
+		t.Errorf("other session: err = %v, want ErrTokenRevoked", err)
+	}
+	if _, err := env.auth.ValidateToken(bg, current.AccessToken); err != nil {
+		t.Errorf("current session: %v", err)
+	}
+
+	if err := env.auth.RevokeAllSessions(ctx, false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := env.auth.ValidateToken(bg, current.AccessToken); !errors.Is(err, ErrTokenRevoked) {
+		t.Errorf("current session: err = %v, want ErrTokenRevoked", err)
+	}
+}
+
+func TestAdminRevokeSessions(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "admin", "admin@example.com", domain.RoleAdmin)
+	env.register(t, "u1", "u1@example.com", domain.RoleUser)
+	pair, _ := env.login(t, "u1@example.com")
+	_, admin := env.login(t, "admin@example.com")
+	_, user := env.login(t, "u1@example.com")
+	users := env.users()
+
+	if _, err := users.ListSessions(user, "u1"); !errors.Is(err, ErrForbidden) {
+		t.Errorf("user: err = %v, want ErrForbidden", err)
+	}
+
+	sessions, err := users.ListSessions(admin, "u1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 2 {
+		t.Fatalf("got %d sessions, want 2", len(sessions))
+	}
+
+	if err := users.RevokeSessions(admin, "u1", "unknown"); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("unknown session: err = %v, want ErrSessionNotFound", err)
+	}
+	if err := users.RevokeSessions(admin, "u1", ""); err != nil {
+		t.Fatalf("revoke all: %v", err)
+	}
+	if _, err := env.auth.ValidateToken(context.Background(), pair.AccessToken); !errors.Is(err, ErrTokenRevoked) {
+		t.Errorf("err = %v, want ErrTokenRevoked", err)
+	}
+}
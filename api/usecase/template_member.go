@@ -0,0 +1,262 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"basic-service/domain"
+	"basic-service/interface/sql"
+	"basic-service/pkg/mailer"
+
+	"braces.dev/errtrace"
+	"github.com/google/uuid"
+)
+
+const defaultInviteTTL = 7 * 24 * time.Hour
+
+var (
+	ErrInvalidTemplateRole = errors.New("invalid template role")
+	ErrAlreadyMember       = errors.New("user is already a member of this template")
+	ErrLastOwner           = errors.New("a template needs at least one owner")
+	ErrInviteEmailMismatch = errors.New("invitation was sent to another email address")
+)
+
+// authorizeTemplate checks that the user in ctx has at least role min on the
+// template. Admins that can read any template get viewer rights on all of
+// them.
+func authorizeTemplate(ctx context.Context, members *sql.TemplateMemberRepository, templateID string, min domain.TemplateRole) (*Claims, error) {
+	claims, err := GetClaimFromContext(ctx)
+	if err != nil {
+		return nil, errtrace.Wrap(errors.New("invalid token claims"))
+	}
+
+	role, err := members.GetRole(ctx, templateID, claims.UserID)
+	if err != nil {
+		if !errors.Is(err, sql.ErrTemplateMemberNotFound) {
+			return nil, errtrace.Wrap(err)
+		}
+		if min == domain.TemplateRoleViewer && claims.Can(PermUserTemplateReadAny) {
+			return claims, nil
+		}
+		return nil, errtrace.Wrap(ErrForbidden)
+	}
+
+	if !role.Allows(min) {
+		return nil, errtrace.Wrap(ErrForbidden)
+	}
+
+	return claims, nil
+}
+
+// TemplateMembers manages the co-hosts of user templates and their
+// invitations.
+type TemplateMembers struct {
+	repo      *sql.TemplateMemberRepository
+	templates *sql.UserTemplateRepository
+	users     *sql.UserRepository
+	mail      mailer.Mailer
+	// AppURL is the web frontend base URL used to build invitation links
+	AppURL    string
+	InviteTTL time.Duration
+}
+
+func NewTemplateMembers(
+	repo *sql.TemplateMemberRepository,
+	templates *sql.UserTemplateRepository,
+	users *sql.UserRepository,
+	mail mailer.Mailer,
+) *TemplateMembers {
+	return &TemplateMembers{
+		repo:      repo,
+		templates: templates,
+		users:     users,
+		mail:      mail,
+		InviteTTL: defaultInviteTTL,
+	}
+}
+
+func (m *TemplateMembers) List(ctx context.Context, templateID string) ([]domain.TemplateMember, error) {
+	if _, err := authorizeTemplate(ctx, m.repo, templateID, domain.TemplateRoleViewer); err != nil {
+		return nil, err
+	}
+
+	return errtrace.Wrap2(m.repo.List(ctx, templateID))
+}
+
+// Invite emails an invitation to join the template with role, only owners
+// can invite.
+func (m *TemplateMembers) Invite(ctx context.Context, templateID, email string, role domain.TemplateRole) (domain.TemplateInvite, error) {
+	if !role.Valid() {
+		return domain.TemplateInvite{}, errtrace.Wrap(ErrInvalidTemplateRole)
+	}
+
+	claims, err := authorizeTemplate(ctx, m.repo, templateID, domain.TemplateRoleOwner)
+	if err != nil {
+		return domain.TemplateInvite{}, err
+	}
+
+	email = strings.ToLower(strings.TrimSpace(email))
+
+	if user, err := m.users.GetEmail(ctx, email); err == nil {
+		if _, err := m.repo.Get(ctx, templateID, user.ID); err == nil {
+			return domain.TemplateInvite{}, errtrace.Wrap(ErrAlreadyMember)
+		}
+	}
+
+	template, err := m.templates.Get(ctx, templateID)
+	if err != nil {
+		return domain.TemplateInvite{}, errtrace.Wrap(err)
+	}
+
+	inviter, err := m.users.GetUserByID(ctx, claims.UserID)
+	if err != nil {
+		return domain.TemplateInvite{}, errtrace.Wrap(err)
+	}
+
+	raw, err := randomToken()
+	if err != nil {
+		return domain.TemplateInvite{}, errtrace.Wrap(err)
+	}
+
+	now := time.Now()
+	invite := domain.TemplateInvite{
+		ID:             uuid.New().String(),
+		UserTemplateID: templateID,
+		Email:          email,
+		Role:           role,
+		TokenHash:      hashToken(raw),
+		InvitedBy:      claims.UserID,
+		ExpiresAt:      now.Add(m.InviteTTL),
+		CreatedAt:      now,
+	}
+
+	if err := m.repo.CreateInvite(ctx, invite); err != nil {
+		return domain.TemplateInvite{}, errtrace.Wrap(err)
+	}
+
+	link := fmt.Sprintf("%s/invites/accept?token=%s", m.AppURL, url.QueryEscape(raw))
+
+	if err := m.mail.Send(ctx, mailer.Message{
+		To:      email,
+		Subject: fmt.Sprintf("%s invited you to manage %s", inviter.Name, template.Name),
+		Body: fmt.Sprintf("Hi,\n\n%s invited you to help manage %q as %s. Sign in or register "+
+			"with this email address and open the link below, it is valid for %s:\n\n%s\n",
+			inviter.Name, template.Name, role, m.InviteTTL, link),
+	}); err != nil {
+		return domain.TemplateInvite{}, errtrace.Wrap(err)
+	}
+
+	return invite, nil
+}
+
+func (m *TemplateMembers) ListInvites(ctx context.Context, templateID string) ([]domain.TemplateInvite, error) {
+	if _, err := authorizeTemplate(ctx, m.repo, templateID, domain.TemplateRoleOwner); err != nil {
+		return nil, err
+	}
+
+	return errtrace.Wrap2(m.repo.ListInvites(ctx, templateID))
+}
+
+func (m *TemplateMembers) RevokeInvite(ctx context.Context, templateID, id string) error {
+	if _, err := authorizeTemplate(ctx, m.repo, templateID, domain.TemplateRoleOwner); err != nil {
+		return err
+	}
+
+	if err := AuthorizeDestructive(ctx); err != nil {
+		return err
+	}
+
+	return errtrace.Wrap(m.repo.DeleteInvite(ctx, templateID, id))
+}
+
+// AcceptInvite adds the current user to the template of the invitation and
+// returns the template ID. The invitation only works for the account with
+// the invited email once that email is verified.
+func (m *TemplateMembers) AcceptInvite(ctx context.Context, token string) (string, error) {
+	claims, err := GetClaimFromContext(ctx)
+	if err != nil {
+		return "", errtrace.Wrap(errors.New("invalid token claims"))
+	}
+
+	invite, err := m.repo.GetInviteByHash(ctx, hashToken(token))
+	if err != nil {
+		return "", errtrace.Wrap(err)
+	}
+
+	user, err := m.users.GetUserByID(ctx, claims.UserID)
+	if err != nil {
+		return "", errtrace.Wrap(err)
+	}
+
+	if !strings.EqualFold(user.Email, invite.Email) {
+		return "", errtrace.Wrap(ErrInviteEmailMismatch)
+	}
+
+	// anyone can register the invited address, only its owner can verify it
+	if user.VerifiedAt == nil {
+		return "", errtrace.Wrap(ErrEmailNotVerified)
+	}
+
+	if err := m.repo.AcceptInvite(ctx, invite.ID, domain.TemplateMember{
+		ID:             uuid.New().String(),
+		UserTemplateID: invite.UserTemplateID,
+		UserID:         user.ID,
+		Role:           invite.Role,
+		CreatedAt:      time.Now(),
+	}); err != nil {
+		return "", errtrace.Wrap(err)
+	}
+
+	return invite.UserTemplateID, nil
+}
+
+// UpdateRole changes the role of a member, only owners can do it and the last
+// owner cannot be demoted.
+func (m *TemplateMembers) UpdateRole(ctx context.Context, templateID, userID string, role domain.TemplateRole) error {
+	if !role.Valid() {
+		return errtrace.Wrap(ErrInvalidTemplateRole)
+	}
+
+	if _, err := authorizeTemplate(ctx, m.repo, templateID, domain.TemplateRoleOwner); err != nil {
+		return err
+	}
+
+	return errtrace.Wrap(lastOwner(m.repo.UpdateRole(ctx, templateID, userID, role)))
+}
+
+// Remove takes a member off the template. Owners can remove anyone, other
+// members can only leave.
+func (m *TemplateMembers) Remove(ctx context.Context, templateID, userID string) error {
+	claims, err := GetClaimFromContext(ctx)
+	if err != nil {
+		return errtrace.Wrap(errors.New("invalid token claims"))
+	}
+
+	min := domain.TemplateRoleOwner
+	if claims.UserID == userID {
+		min = domain.TemplateRoleViewer
+	}
+
+	if _, err := authorizeTemplate(ctx, m.repo, templateID, min); err != nil {
+		return err
+	}
+
+	if err := AuthorizeDestructive(ctx); err != nil {
+		return err
+	}
+
+	return errtrace.Wrap(lastOwner(m.repo.Remove(ctx, templateID, userID)))
+}
+
+// lastOwner returns ErrLastOwner for a change the repository refused because
+// it would leave the template without an owner.
+func lastOwner(err error) error {
+	if errors.Is(err, sql.ErrLastTemplateOwner) {
+		return ErrLastOwner
+	}
+	return err
+}
@@ -0,0 +1,227 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"basic-service/domain"
+	"basic-service/interface/sql"
+)
+
+// addMember invites the user with email to the template of the owner in ctx
+// and accepts the invitation as that user
+func (e *testEnv) addMember(t *testing.T, owner context.Context, templateID, email string, role domain.TemplateRole) context.Context {
+	t.Helper()
+
+	if _, err := e.members().Invite(owner, templateID, email, role); err != nil {
+		t.Fatalf("invite %s: %v", email, err)
+	}
+
+	_, ctx := e.login(t, email)
+	if _, err := e.members().AcceptInvite(ctx, e.mail.lastToken(t, email)); err != nil {
+		t.Fatalf("accept invite of %s: %v", email, err)
+	}
+
+	return ctx
+}
+
+func TestTemplateInvite(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "u1", "u1@example.com", domain.RoleUser)
+	env.register(t, "u2", "u2@example.com", domain.RoleUser)
+	env.register(t, "u3", "u3@example.com", domain.RoleUser)
+	env.registerUnverified(t, "u4", "u4@example.com", domain.RoleUser)
+	_, owner := env.login(t, "u1@example.com")
+	_, u2 := env.login(t, "u2@example.com")
+	_, u3 := env.login(t, "u3@example.com")
+	env.createTemplate(t, owner, "t1")
+	members := env.members()
+
+	// anyone can register the invited address before its owner does
+	if _, err := members.Invite(owner, "t1", "u4@example.com", domain.TemplateRoleEditor); err != nil {
+		t.Fatalf("invite: %v", err)
+	}
+	unverified := withClaims(context.Background(), "u4", domain.RoleUser)
+	if _, err := members.AcceptInvite(unverified, env.mail.lastToken(t, "u4@example.com")); !errors.Is(err, ErrEmailNotVerified) {
+		t.Errorf("unverified email: err = %v, want ErrEmailNotVerified", err)
+	}
+
+	if _, err := members.Invite(owner, "t1", " U2@example.com ", domain.TemplateRoleEditor); err != nil {
+		t.Fatalf("invite: %v", err)
+	}
+	token := env.mail.lastToken(t, "u2@example.com")
+
+	if _, err := members.AcceptInvite(u3, token); !errors.Is(err, ErrInviteEmailMismatch) {
+		t.Errorf("other account: err = %v, want ErrInviteEmailMismatch", err)
+	}
+
+	templateID, err := members.AcceptInvite(u2, token)
+	if err != nil {
+		t.Fatalf("accept: %v", err)
+	}
+	if templateID != "t1" {
+		t.Errorf("accepted template %q, want t1", templateID)
+	}
+	if _, err := members.AcceptInvite(u2, token); err == nil {
+		t.Error("invitation accepted twice")
+	}
+
+	list, err := members.List(u2, "t1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 {
+		t.Errorf("got %d members, want 2", len(list))
+	}
+
+	if _, err := members.Invite(owner, "t1", "u2@example.com", domain.TemplateRoleViewer); !errors.Is(err, ErrAlreadyMember) {
+		t.Errorf("member: err = %v, want ErrAlreadyMember", err)
+	}
+	if _, err := members.Invite(u2, "t1", "u3@example.com", domain.TemplateRoleViewer); !errors.Is(err, ErrForbidden) {
+		t.Errorf("editor inviting: err = %v, want ErrForbidden", err)
+	}
+}
+
+func TestTemplateRoles(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "u1", "u1@example.com", domain.RoleUser)
+	env.register(t, "u2", "u2@example.com", domain.RoleUser)
+	env.register(t, "u3", "u3@example.com", domain.RoleUser)
+	env.register(t, "u4", "u4@example.com", domain.RoleUser)
+	_, owner := env.login(t, "u1@example.com")
+	_, stranger := env.login(t, "u4@example.com")
+	env.createTemplate(t, owner, "t1")
+	editor := env.addMember(t, owner, "t1", "u2@example.com", domain.TemplateRoleEditor)
+	viewer := env.addMember(t, owner, "t1", "u3@example.com", domain.TemplateRoleViewer)
+	guests := env.guests()
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want error
+	}{
+		{"owner", owner, nil},
+		{"editor", editor, nil},
+		{"viewer", viewer, ErrForbidden},
+		{"stranger", stranger, ErrForbidden},
+	}
+
+	for _, tt := range tests {
+		err := guests.Create(tt.ctx, domain.Guest{ID: "g-" + tt.name, Name: tt.name, UserTemplateID: "t1"})
+		if !errors.Is(err, tt.want) {
+			t.Errorf("%s creating a guest: err = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+
+	if _, err := guests.List(viewer, sql.GuestFilter{UserTemplateID: "t1"}, 1, 10); err != nil {
+		t.Errorf("viewer listing guests: %v", err)
+	}
+	if _, err := guests.List(stranger, sql.GuestFilter{UserTemplateID: "t1"}, 1, 10); !errors.Is(err, ErrForbidden) {
+		t.Errorf("stranger listing guests: err = %v, want ErrForbidden", err)
+	}
+}
+
+func TestTemplateLastOwner(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "u1", "u1@example.com", domain.RoleUser)
+	env.register(t, "u2", "u2@example.com", domain.RoleUser)
+	env.register(t, "u3", "u3@example.com", domain.RoleUser)
+	_, owner := env.login(t, "u1@example.com")
+	env.createTemplate(t, owner, "t1")
+	editor := env.addMember(t, owner, "t1", "u2@example.com", domain.TemplateRoleEditor)
+	viewer := env.addMember(t, owner, "t1", "u3@example.com", domain.TemplateRoleViewer)
+	members := env.members()
+
+	if err := members.UpdateRole(owner, "t1", "u1", domain.TemplateRoleEditor); !errors.Is(err, ErrLastOwner) {
+		t.Errorf("demote last owner: err = %v, want ErrLastOwner", err)
+	}
+	if err := members.Remove(owner, "t1", "u1"); !errors.Is(err, ErrLastOwner) {
+		t.Errorf("last owner leaving: err = %v, want ErrLastOwner", err)
+	}
+	if err := members.Remove(editor, "t1", "u3"); !errors.Is(err, ErrForbidden) {
+		t.Errorf("editor removing a member: err = %v, want ErrForbidden", err)
+	}
+
+	// members can always leave
+	if err := members.Remove(viewer, "t1", "u3"); err != nil {
+		t.Errorf("viewer leaving: %v", err)
+	}
+
+	if err := members.UpdateRole(owner, "t1", "u2", domain.TemplateRoleOwner); err != nil {
+		t.Fatalf("promote: %v", err)
+	}
+	if err := members.Remove(owner, "t1", "u1"); err != nil {
+		t.Errorf("owner leaving with another owner left: %v", err)
+	}
+	if err := members.UpdateRole(editor, "t1", "u2", domain.TemplateRoleViewer); !errors.Is(err, ErrLastOwner) {
+		t.Errorf("demote new last owner: err = %v, want ErrLastOwner", err)
+	}
+}
+
+func TestTemplateOwnersStepDownAtOnce(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "u1", "u1@example.com", domain.RoleUser)
+	env.register(t, "u2", "u2@example.com", domain.RoleUser)
+	_, u1 := env.login(t, "u1@example.com")
+	members := env.members()
+
+	tests := []struct {
+		name  string
+		leave func(ctx context.Context, templateID, userID string) error
+	}{
+		{"demote", func(ctx context.Context, templateID, userID string) error {
+			return members.UpdateRole(ctx, templateID, userID, domain.TemplateRoleEditor)
+		}},
+		{"leave", members.Remove},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i := range 10 {
+				templateID := fmt.Sprintf("%s-%d", tt.name, i)
+				env.createTemplate(t, u1, templateID)
+				u2 := env.addMember(t, u1, templateID, "u2@example.com", domain.TemplateRoleOwner)
+
+				var wg sync.WaitGroup
+				errs := make([]error, 2)
+				for j, v := range []struct {
+					ctx    context.Context
+					userID string
+				}{{u1, "u1"}, {u2, "u2"}} {
+					wg.Add(1)
+					go func() {
+						defer wg.Done()
+						errs[j] = tt.leave(v.ctx, templateID, v.userID)
+					}()
+				}
+				wg.Wait()
+
+				list, err := env.members().repo.List(context.Background(), templateID)
+				if err != nil {
+					t.Fatal(err)
+				}
+				owners := 0
+				for _, v := range list {
+					if v.Role == domain.TemplateRoleOwner {
+						owners++
+					}
+				}
+
+				failed := 0
+				for _, err := range errs {
+					if errors.Is(err, ErrLastOwner) {
+						failed++
+					} else if err != nil {
+						t.Fatal(err)
+					}
+				}
+				if owners != 1 || failed != 1 {
+					t.Fatalf("%d owners left and %d refused, want 1 and 1", owners, failed)
+				}
+			}
+		})
+	}
+}
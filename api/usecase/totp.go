@@ -0,0 +1,330 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"strings"
+	"time"
+
+	"basic-service/domain"
+	"basic-service/interface/sql"
+	"basic-service/pkg/totp"
+
+	"braces.dev/errtrace"
+)
+
+const (
+	defaultTOTPIssuer  = "Undangan"
+	mfaChallengeTTL    = 5 * time.Minute
+	recoveryCodeCount  = 10
+	recoveryCodeLength = 10
+	// totpSkew accepts the previous and next code to absorb clock drift
+	totpSkew = 1
+)
+
+var (
+	ErrTOTPAlreadyEnabled  = errors.New("two-factor authentication is already enabled")
+	ErrTOTPNotEnabled      = errors.New("two-factor authentication is not enabled")
+	ErrTOTPRequired        = errors.New("two-factor authentication is required for this account")
+	ErrInvalidMFACode      = errors.New("invalid two-factor code")
+	ErrInvalidMFAChallenge = errors.New("invalid or expired two-factor challenge")
+)
+
+type TOTPSetup struct {
+	Secret string
+	URI    string
+}
+
+type TOTPStatus struct {
+	Enabled       bool
+	Required      bool
+	RecoveryCodes int64
+}
+
+// requiresTOTP reports whether the policy forces a second factor on role.
+func (a *Auth) requiresTOTP(role domain.RoleType) bool {
+	return a.RequireAdminTOTP && role == domain.RoleAdmin
+}
+
+// SetupTOTP starts an enrollment, the secret is only used once the user
+// confirms a code with EnableTOTP.
+func (a *Auth) SetupTOTP(ctx context.Context) (TOTPSetup, error) {
+	user, err := a.currentUser(ctx)
+	if err != nil {
+		return TOTPSetup{}, err
+	}
+
+	enabled, err := a.TOTP.IsEnabled(ctx, user.ID)
+	if err != nil {
+		return TOTPSetup{}, errtrace.Wrap(err)
+	}
+	if enabled {
+		return TOTPSetup{}, errtrace.Wrap(ErrTOTPAlreadyEnabled)
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return TOTPSetup{}, errtrace.Wrap(err)
+	}
+
+	if err := a.TOTP.SavePending(ctx, user.ID, secret); err != nil {
+		return TOTPSetup{}, errtrace.Wrap(err)
+	}
+
+	return TOTPSetup{
+		Secret: secret,
+		URI:    totp.URI(a.TOTPIssuer, user.Email, secret),
+	}, nil
+}
+
+// EnableTOTP confirms the enrollment with a code from the authenticator and
+// returns the recovery codes, they are only shown once. Every session is
+// revoked so that the remaining ones all passed the second factor.
+func (a *Auth) EnableTOTP(ctx context.Context, code string) ([]string, error) {
+	user, err := a.currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := a.TOTP.Get(ctx, user.ID)
+	if err != nil {
+		if errors.Is(err, sql.ErrTOTPNotFound) {
+			return nil, errtrace.Wrap(ErrTOTPNotEnabled)
+		}
+		return nil, errtrace.Wrap(err)
+	}
+	if secret.EnabledAt != nil {
+		return nil, errtrace.Wrap(ErrTOTPAlreadyEnabled)
+	}
+
+	if err := a.checkTOTPCode(ctx, secret, code); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, errtrace.Wrap(err)
+	}
+
+	if err := a.TOTP.Enable(ctx, user.ID, hashes); err != nil {
+		return nil, errtrace.Wrap(err)
+	}
+
+	if err := a.RevokeUserTokens(ctx, user.ID); err != nil {
+		return nil, errtrace.Wrap(err)
+	}
+
+	return codes, nil
+}
+
+// DisableTOTP removes the second factor, it needs the password and a code.
+func (a *Auth) DisableTOTP(ctx context.Context, password, code string) error {
+	user, err := a.currentUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	if a.requiresTOTP(user.Role) {
+		return errtrace.Wrap(ErrTOTPRequired)
+	}
+
+	if !a.checkPasswordHash(password, user.Password) {
+		return errtrace.Wrap(ErrInvalidCredentials)
+	}
+
+	if err := a.checkSecondFactor(ctx, user.ID, code); err != nil {
+		return err
+	}
+
+	return errtrace.Wrap(a.TOTP.Delete(ctx, user.ID))
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes of the current user.
+func (a *Auth) RegenerateRecoveryCodes(ctx context.Context, code string) ([]string, error) {
+	user, err := a.currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := a.checkSecondFactor(ctx, user.ID, code); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, errtrace.Wrap(err)
+	}
+
+	if err := a.TOTP.ReplaceRecoveryCodes(ctx, user.ID, hashes); err != nil {
+		return nil, errtrace.Wrap(err)
+	}
+
+	return codes, nil
+}
+
+func (a *Auth) TOTPStatus(ctx context.Context) (TOTPStatus, error) {
+	user, err := a.currentUser(ctx)
+	if err != nil {
+		return TOTPStatus{}, err
+	}
+
+	enabled, err := a.TOTP.IsEnabled(ctx, user.ID)
+	if err != nil {
+		return TOTPStatus{}, errtrace.Wrap(err)
+	}
+
+	status := TOTPStatus{Enabled: enabled, Required: a.requiresTOTP(user.Role)}
+	if enabled {
+		status.RecoveryCodes, err = a.TOTP.CountRecoveryCodes(ctx, user.ID)
+		if err != nil {
+			return TOTPStatus{}, errtrace.Wrap(err)
+		}
+	}
+
+	return status, nil
+}
+
+// VerifyMFA is the second login step, it exchanges the challenge returned by
+// Login and a TOTP or recovery code for a token pair.
+func (a *Auth) VerifyMFA(ctx context.Context, challenge, code, ip string) (TokenPair, error) {
+	userToken, err := a.UserTokens.Get(ctx, domain.UserTokenMFAChallenge, hashToken(challenge))
+	if err != nil {
+		if errors.Is(err, sql.ErrUserTokenInvalid) {
+			return TokenPair{}, errtrace.Wrap(ErrInvalidMFAChallenge)
+		}
+		return TokenPair{}, errtrace.Wrap(err)
+	}
+
+	user, err := a.UserManager.GetUserByID(ctx, userToken.UserID)
+	if err != nil {
+		return TokenPair{}, errtrace.Wrap(err)
+	}
+
+	if err := a.Guard.Check(ctx, user.Email, ip); err != nil {
+		return TokenPair{}, err
+	}
+
+	if !user.IsActive {
+		return TokenPair{}, errtrace.Wrap(ErrUserNotActive)
+	}
+
+	if err := a.checkSecondFactor(ctx, user.ID, code); err != nil {
+		if errors.Is(err, ErrInvalidMFACode) {
+			if err := a.Guard.Fail(ctx, user.Email, ip, user.ID); err != nil {
+				return TokenPair{}, errtrace.Wrap(err)
+			}
+		}
+		return TokenPair{}, err
+	}
+
+	if _, err := a.UserTokens.Consume(ctx, domain.UserTokenMFAChallenge, hashToken(challenge)); err != nil {
+		if errors.Is(err, sql.ErrUserTokenInvalid) {
+			return TokenPair{}, errtrace.Wrap(ErrInvalidMFAChallenge)
+		}
+		return TokenPair{}, errtrace.Wrap(err)
+	}
+
+	if err := a.Guard.Succeed(ctx, user.Email); err != nil {
+		return TokenPair{}, errtrace.Wrap(err)
+	}
+
+	return a.startSession(ctx, user)
+}
+
+// mfaChallenge returns a token pair that only carries the challenge for the
+// second login step.
+func (a *Auth) mfaChallenge(ctx context.Context, user *domain.User) (TokenPair, error) {
+	raw, err := a.issueUserToken(ctx, user.ID, domain.UserTokenMFAChallenge, mfaChallengeTTL)
+	if err != nil {
+		return TokenPair{}, errtrace.Wrap(err)
+	}
+
+	return TokenPair{
+		MFAChallenge: raw,
+		ExpiresAt:    time.Now().Add(mfaChallengeTTL),
+	}, nil
+}
+
+// checkSecondFactor accepts a TOTP code or an unused recovery code.
+func (a *Auth) checkSecondFactor(ctx context.Context, userID, code string) error {
+	secret, err := a.TOTP.Get(ctx, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrTOTPNotFound) {
+			return errtrace.Wrap(ErrTOTPNotEnabled)
+		}
+		return errtrace.Wrap(err)
+	}
+	if secret.EnabledAt == nil {
+		return errtrace.Wrap(ErrTOTPNotEnabled)
+	}
+
+	code = strings.TrimSpace(code)
+	if len(code) == totp.Digits {
+		return a.checkTOTPCode(ctx, secret, code)
+	}
+
+	if err := a.TOTP.UseRecoveryCode(ctx, userID, hashRecoveryCode(code)); err != nil {
+		if errors.Is(err, sql.ErrRecoveryCodeInvalid) {
+			return errtrace.Wrap(ErrInvalidMFACode)
+		}
+		return errtrace.Wrap(err)
+	}
+
+	return nil
+}
+
+// checkTOTPCode validates code and burns its time step so it cannot be
+// replayed.
+func (a *Auth) checkTOTPCode(ctx context.Context, secret *domain.UserTOTP, code string) error {
+	step, ok := totp.Validate(secret.Secret, code, time.Now(), totpSkew)
+	if !ok {
+		return errtrace.Wrap(ErrInvalidMFACode)
+	}
+
+	if err := a.TOTP.UseStep(ctx, secret.UserID, step); err != nil {
+		if errors.Is(err, sql.ErrTOTPStepUsed) {
+			return errtrace.Wrap(ErrInvalidMFACode)
+		}
+		return errtrace.Wrap(err)
+	}
+
+	return nil
+}
+
+func (a *Auth) currentUser(ctx context.Context) (*domain.User, error) {
+	claims, err := GetClaimFromContext(ctx)
+	if err != nil {
+		return nil, errtrace.Wrap(errors.New("invalid token claims"))
+	}
+
+	return errtrace.Wrap2(a.UserManager.GetUserByID(ctx, claims.UserID))
+}
+
+// newRecoveryCodes returns codes formatted as xxxxx-xxxxx and their hashes.
+func newRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+
+	for range recoveryCodeCount {
+		buf := make([]byte, recoveryCodeLength)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, nil, errtrace.Wrap(err)
+		}
+
+		raw := strings.ToLower(base32.StdEncoding.EncodeToString(buf))[:recoveryCodeLength]
+		code := raw[:recoveryCodeLength/2] + "-" + raw[recoveryCodeLength/2:]
+
+		codes = append(codes, code)
+		hashes = append(hashes, hashRecoveryCode(code))
+	}
+
+	return codes, hashes, nil
+}
+
+// hashRecoveryCode ignores case and dashes so codes can be typed loosely.
+func hashRecoveryCode(code string) string {
+	code = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+	return hashToken(code)
+}
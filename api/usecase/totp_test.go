@@ -0,0 +1,179 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"basic-service/domain"
+	"basic-service/pkg/totp"
+)
+
+// enrollTOTP enables a second factor for the user of ctx, the code of the
+// current time step is used up
+func enrollTOTP(t *testing.T, env *testEnv, ctx context.Context) (string, int64, []string) {
+	t.Helper()
+
+	setup, err := env.auth.SetupTOTP(ctx)
+	if err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+
+	step := totp.Step(time.Now())
+	code, err := totp.Code(setup.Secret, step)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	codes, err := env.auth.EnableTOTP(ctx, code)
+	if err != nil {
+		t.Fatalf("enable: %v", err)
+	}
+
+	return setup.Secret, step, codes
+}
+
+func TestTOTPLogin(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "u1", "u1@example.com", domain.RoleUser)
+	pair, ctx := env.login(t, "u1@example.com")
+	bg := context.Background()
+
+	secret, step, codes := enrollTOTP(t, env, ctx)
+	if len(codes) != recoveryCodeCount {
+		t.Fatalf("got %d recovery codes", len(codes))
+	}
+	if _, err := env.auth.ValidateToken(bg, pair.AccessToken); !errors.Is(err, ErrTokenRevoked) {
+		t.Errorf("session before enrolling: err = %v, want ErrTokenRevoked", err)
+	}
+
+	challenge, err := env.auth.Login(bg, "u1@example.com", "password", "")
+	if err != nil {
+		t.Fatalf("login: %v", err)
+	}
+	if challenge.AccessToken != "" || challenge.MFAChallenge == "" {
+		t.Fatalf("login returned %+v, want only a challenge", challenge)
+	}
+
+	if _, err := env.auth.VerifyMFA(bg, challenge.MFAChallenge, "000000", ""); !errors.Is(err, ErrInvalidMFACode) {
+		t.Errorf("wrong code: err = %v, want ErrInvalidMFACode", err)
+	}
+
+	// the code used to enroll cannot be replayed
+	used, _ := totp.Code(secret, step)
+	if _, err := env.auth.VerifyMFA(bg, challenge.MFAChallenge, used, ""); !errors.Is(err, ErrInvalidMFACode) {
+		t.Errorf("replayed code: err = %v, want ErrInvalidMFACode", err)
+	}
+
+	next, _ := totp.Code(secret, step+1)
+	verified, err := env.auth.VerifyMFA(bg, challenge.MFAChallenge, next, "")
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+
+	claims, err := env.auth.ValidateToken(bg, verified.AccessToken)
+	if err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	if !claims.MFA {
+		t.Error("access token does not carry mfa")
+	}
+
+	if _, err := env.auth.VerifyMFA(bg, challenge.MFAChallenge, codes[0], ""); !errors.Is(err, ErrInvalidMFAChallenge) {
+		t.Errorf("reused challenge: err = %v, want ErrInvalidMFAChallenge", err)
+	}
+}
+
+func TestTOTPRecoveryCode(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "u1", "u1@example.com", domain.RoleUser)
+	_, ctx := env.login(t, "u1@example.com")
+	bg := context.Background()
+
+	_, _, codes := enrollTOTP(t, env, ctx)
+
+	login := func() string {
+		pair, err := env.auth.Login(bg, "u1@example.com", "password", "")
+		if err != nil {
+			t.Fatalf("login: %v", err)
+		}
+		return pair.MFAChallenge
+	}
+
+	// recovery codes are accepted in upper case too
+	if _, err := env.auth.VerifyMFA(bg, login(), " "+strings.ToUpper(codes[0]), ""); err != nil {
+		t.Fatalf("recovery code: %v", err)
+	}
+	if _, err := env.auth.VerifyMFA(bg, login(), codes[0], ""); !errors.Is(err, ErrInvalidMFACode) {
+		t.Errorf("used recovery code: err = %v, want ErrInvalidMFACode", err)
+	}
+
+	_, ctx = env.loginMFA(t, "u1@example.com", codes[1])
+	status, err := env.auth.TOTPStatus(ctx)
+	if err != nil {
+		t.Fatalf("status: %v", err)
+	}
+	if !status.Enabled || status.RecoveryCodes != recoveryCodeCount-2 {
+		t.Errorf("status = %+v", status)
+	}
+
+	fresh, err := env.auth.RegenerateRecoveryCodes(ctx, codes[2])
+	if err != nil {
+		t.Fatalf("regenerate: %v", err)
+	}
+	if _, err := env.auth.VerifyMFA(bg, login(), codes[3], ""); !errors.Is(err, ErrInvalidMFACode) {
+		t.Errorf("replaced recovery code: err = %v, want ErrInvalidMFACode", err)
+	}
+	if _, err := env.auth.VerifyMFA(bg, login(), fresh[0], ""); err != nil {
+		t.Errorf("new recovery code: %v", err)
+	}
+}
+
+func TestDisableTOTP(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "u1", "u1@example.com", domain.RoleUser)
+	_, ctx := env.login(t, "u1@example.com")
+
+	_, _, codes := enrollTOTP(t, env, ctx)
+	_, ctx = env.loginMFA(t, "u1@example.com", codes[0])
+
+	if err := env.auth.DisableTOTP(ctx, "wrong", codes[1]); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("wrong password: err = %v, want ErrInvalidCredentials", err)
+	}
+	if err := env.auth.DisableTOTP(ctx, "password", "wrong-code"); !errors.Is(err, ErrInvalidMFACode) {
+		t.Errorf("wrong code: err = %v, want ErrInvalidMFACode", err)
+	}
+	if err := env.auth.DisableTOTP(ctx, "password", codes[1]); err != nil {
+		t.Fatalf("disable: %v", err)
+	}
+
+	// login no longer asks for a second factor
+	env.login(t, "u1@example.com")
+}
+
+func TestRequireAdminTOTP(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "admin", "admin@example.com", domain.RoleAdmin)
+	env.auth.RequireAdminTOTP = true
+
+	pair, ctx := env.login(t, "admin@example.com")
+	if !pair.MFASetupRequired {
+		t.Error("admin without a second factor is not asked to enroll")
+	}
+
+	// until then the admin only has user rights
+	if err := Authorize(ctx, PermUserList); !errors.Is(err, ErrForbidden) {
+		t.Errorf("admin without 2FA: err = %v, want ErrForbidden", err)
+	}
+
+	_, _, codes := enrollTOTP(t, env, ctx)
+	_, ctx = env.loginMFA(t, "admin@example.com", codes[0])
+	if err := Authorize(ctx, PermUserList); err != nil {
+		t.Errorf("admin with 2FA: %v", err)
+	}
+	if err := env.auth.DisableTOTP(ctx, "password", codes[1]); !errors.Is(err, ErrTOTPRequired) {
+		t.Errorf("disable: err = %v, want ErrTOTPRequired", err)
+	}
+}
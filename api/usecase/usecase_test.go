@@ -0,0 +1,245 @@
+package usecase
+
+import (
+	"context"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	"basic-service/domain"
+	"basic-service/interface/sql"
+	"basic-service/interface/sql/sqltest"
+	"basic-service/pkg/jwtkey"
+	"basic-service/pkg/mailer"
+	"basic-service/pkg/otel"
+)
+
+// withClaims returns ctx carrying the claims of an authenticated user, as
+// AuthMiddleware stores them
+func withClaims(ctx context.Context, userID string, role domain.RoleType) context.Context {
+	return context.WithValue(ctx, "claims", &Claims{UserID: userID, Role: role})
+}
+
+// testMailer keeps the sent messages in memory, it fails with err when set
+// and holds every message until wait is closed
+type testMailer struct {
+	mu   sync.Mutex
+	sent []mailer.Message
+	err  error
+	wait chan struct{}
+}
+
+func (m *testMailer) Send(ctx context.Context, msg mailer.Message) error {
+	if m.wait != nil {
+		<-m.wait
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.err != nil {
+		return m.err
+	}
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+// messages returns the messages sent to an address
+func (m *testMailer) messages(to string) []mailer.Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var messages []mailer.Message
+	for _, v := range m.sent {
+		if v.To == to {
+			messages = append(messages, v)
+		}
+	}
+	return messages
+}
+
+var linkToken = regexp.MustCompile(`token=([^\s]+)`)
+
+// lastToken returns the token of the link in the last message sent to an
+// address
+func (m *testMailer) lastToken(t *testing.T, to string) string {
+	t.Helper()
+
+	messages := m.messages(to)
+	if len(messages) == 0 {
+		t.Fatalf("no mail sent to %s", to)
+	}
+
+	match := linkToken.FindStringSubmatch(messages[len(messages)-1].Body)
+	if match == nil {
+		t.Fatalf("no link in the mail sent to %s", to)
+	}
+
+	token, err := url.QueryUnescape(match[1])
+	if err != nil {
+		t.Fatalf("unescape token: %v", err)
+	}
+	return token
+}
+
+// testEnv is an Auth over a fresh database
+type testEnv struct {
+	db    *sql.SQLite
+	mail  *testMailer
+	audit *Auditor
+	auth  *Auth
+	// dir holds the files written by the usecases
+	dir string
+}
+
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+
+	db := sqltest.New(t)
+	keys, err := jwtkey.Load("test", []jwtkey.KeyOption{
+		{ID: "test", Algorithm: "HS256", Secret: "test-secret-of-at-least-32-bytes"},
+	})
+	if err != nil {
+		t.Fatalf("load keys: %v", err)
+	}
+	audit := NewAuditor(sql.NewAuditRepository(db))
+	guard, err := NewLoginGuard(sql.NewLoginAttemptRepository(db), audit, otel.NewOtel("test", "error"))
+	if err != nil {
+		t.Fatalf("login guard: %v", err)
+	}
+
+	mail := &testMailer{}
+	auth := NewAuth(sql.NewUserRepository(db), sql.NewTokenRepository(db), sql.NewUserTokenRepository(db), sql.NewAPITokenRepository(db), sql.NewTOTPRepository(db), keys, mail, guard, audit)
+
+	return &testEnv{db: db, mail: mail, audit: audit, auth: auth, dir: t.TempDir()}
+}
+
+// register creates an active user with the password "password" and a
+// verified email
+func (e *testEnv) register(t *testing.T, id, email string, role domain.RoleType) {
+	t.Helper()
+
+	e.registerUnverified(t, id, email, role)
+	if err := e.auth.VerifyEmail(context.Background(), e.mail.lastToken(t, email)); err != nil {
+		t.Fatalf("verify %s: %v", email, err)
+	}
+}
+
+// registerUnverified creates an active user with the password "password"
+func (e *testEnv) registerUnverified(t *testing.T, id, email string, role domain.RoleType) {
+	t.Helper()
+
+	err := e.auth.Register(context.Background(), domain.User{
+		ID:       id,
+		Email:    email,
+		Password: "password",
+		Name:     id,
+		Role:     role,
+		IsActive: true,
+	})
+	if err != nil {
+		t.Fatalf("register %s: %v", email, err)
+	}
+}
+
+// login signs a user in and returns the claims of the access token in a
+// request context
+func (e *testEnv) login(t *testing.T, email string) (TokenPair, context.Context) {
+	t.Helper()
+
+	pair, err := e.auth.Login(context.Background(), email, "password", "")
+	if err != nil {
+		t.Fatalf("login %s: %v", email, err)
+	}
+
+	claims, err := e.auth.ValidateToken(context.Background(), pair.AccessToken)
+	if err != nil {
+		t.Fatalf("validate token of %s: %v", email, err)
+	}
+
+	return pair, context.WithValue(context.Background(), "claims", claims)
+}
+
+// loginMFA signs in a user with a second factor using code
+func (e *testEnv) loginMFA(t *testing.T, email, code string) (TokenPair, context.Context) {
+	t.Helper()
+
+	challenge, err := e.auth.Login(context.Background(), email, "password", "")
+	if err != nil {
+		t.Fatalf("login %s: %v", email, err)
+	}
+
+	pair, err := e.auth.VerifyMFA(context.Background(), challenge.MFAChallenge, code, "")
+	if err != nil {
+		t.Fatalf("verify second factor of %s: %v", email, err)
+	}
+
+	claims, err := e.auth.ValidateToken(context.Background(), pair.AccessToken)
+	if err != nil {
+		t.Fatalf("validate token of %s: %v", email, err)
+	}
+
+	return pair, context.WithValue(context.Background(), "claims", claims)
+}
+
+func (e *testEnv) users() *UserUsecase {
+	return NewUserUsecase(e.auth.UserManager, e.auth.Tokens, sql.NewUserTemplateRepository(e.db), sql.NewGuestManager(e.db), e.auth.Guard, e.audit)
+}
+
+func (e *testEnv) templates() *UserTemplate {
+	return NewUserTemplate(sql.NewUserTemplateRepository(e.db), e.auth.UserManager, sql.NewTemplateMemberRepository(e.db))
+}
+
+func (e *testEnv) members() *TemplateMembers {
+	members := NewTemplateMembers(sql.NewTemplateMemberRepository(e.db), sql.NewUserTemplateRepository(e.db), e.auth.UserManager, e.mail)
+	members.AppURL = "http://app.example.com"
+	return members
+}
+
+func (e *testEnv) account() *Account {
+	account := NewAccount(e.auth, sql.NewAccountRepository(e.db), sql.NewUserTemplateRepository(e.db), sql.NewTemplateMemberRepository(e.db), sql.NewGuestManager(e.db))
+	account.UploadDir = filepath.Join(e.dir, "uploads")
+	account.TemplateDir = filepath.Join(e.dir, "template")
+	return account
+}
+
+func (e *testEnv) guestbook() *Guestbook {
+	return NewGuestbook(sql.NewGuestManager(e.db), sql.NewUserTemplateRepository(e.db), sql.NewTemplateMemberRepository(e.db))
+}
+
+func (e *testEnv) guests() *GuestUsecase {
+	guests := NewGuestUsecase(sql.NewGuestManager(e.db), sql.NewTemplateMemberRepository(e.db), sql.NewUserTemplateRepository(e.db), sql.NewRSVPQuestionRepository(e.db))
+	guests.InvitationKey = []byte("test-invitation-key")
+	return guests
+}
+
+// createTemplate creates a published user template owned by the user of ctx
+func (e *testEnv) createTemplate(t *testing.T, ctx context.Context, id string) {
+	t.Helper()
+
+	err := e.templates().Create(ctx, domain.UserTemplate{
+		ID:       id,
+		Name:     id,
+		Slug:     id,
+		ExpireAt: time.Now().Add(30 * 24 * time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("create template %s: %v", id, err)
+	}
+
+	if _, err := e.templates().SetState(ctx, id, domain.TemplatePublished.String()); err != nil {
+		t.Fatalf("publish template %s: %v", id, err)
+	}
+}
+
+// createGuest adds a guest to a template as the user of ctx
+func (e *testEnv) createGuest(t *testing.T, ctx context.Context, guest domain.Guest) {
+	t.Helper()
+
+	if err := e.guests().Create(ctx, guest); err != nil {
+		t.Fatalf("create guest %s: %v", guest.ID, err)
+	}
+}
@@ -2,18 +2,46 @@ package usecase
 
 import (
 	"context"
+	"errors"
 
 	"basic-service/domain"
 	"basic-service/interface/sql"
+
+	"braces.dev/errtrace"
+)
+
+// maxUserDetailTemplates caps how many templates are loaded for a user detail
+const maxUserDetailTemplates = 1000
+
+var (
+	ErrInvalidRole    = errors.New("invalid role")
+	ErrCannotEditSelf = errors.New("admins cannot change their own role or state")
 )
 
 type UserUsecase struct {
-	uc *sql.UserRepository
+	uc        *sql.UserRepository
+	tokens    *sql.TokenRepository
+	templates *sql.UserTemplateRepository
+	guests    *sql.GuestManager
+	guard     *LoginGuard
+	audit     *Auditor
 }
 
-func NewUserUsecase(uc *sql.UserRepository) *UserUsecase {
+func NewUserUsecase(
+	uc *sql.UserRepository,
+	tokens *sql.TokenRepository,
+	templates *sql.UserTemplateRepository,
+	guests *sql.GuestManager,
+	guard *LoginGuard,
+	audit *Auditor,
+) *UserUsecase {
 	return &UserUsecase{
-		uc: uc,
+		uc:        uc,
+		tokens:    tokens,
+		templates: templates,
+		guests:    guests,
+		guard:     guard,
+		audit:     audit,
 	}
 }
 
@@ -22,10 +50,40 @@ type UserListResult struct {
 	Data  []domain.SafeUser
 }
 
-func (p *UserUsecase) List(ctx context.Context, page, limit int) (UserListResult, error) {
+// UserTemplateStat is a user template with its guest count
+type UserTemplateStat struct {
+	Template   domain.UserTemplate
+	GuestCount int64
+}
+
+type UserDetail struct {
+	User       domain.SafeUser
+	Templates  []UserTemplateStat
+	GuestCount int64
+}
+
+func toSafeUser(v domain.User) domain.SafeUser {
+	return domain.SafeUser{
+		ID:         v.ID,
+		Name:       v.Name,
+		Email:      v.Email,
+		Profile:    v.Profile,
+		IsActive:   v.IsActive,
+		Role:       v.Role,
+		VerifiedAt: v.VerifiedAt,
+		CreatedAt:  v.CreatedAt,
+		UpdatedAt:  v.UpdatedAt,
+	}
+}
+
+func (p *UserUsecase) List(ctx context.Context, filter sql.UserFilter, page, limit int) (UserListResult, error) {
 	var result UserListResult
 
-	resp, err := p.uc.ListByRole(ctx, domain.RoleUser, page, limit)
+	if err := Authorize(ctx, PermUserList); err != nil {
+		return result, err
+	}
+
+	resp, err := p.uc.Search(ctx, filter, page, limit)
 	if err != nil {
 		return result, err
 	}
@@ -33,17 +91,121 @@ func (p *UserUsecase) List(ctx context.Context, page, limit int) (UserListResult
 	result.Total = resp.Total
 	result.Data = make([]domain.SafeUser, 0, len(resp.Data))
 	for _, v := range resp.Data {
-		result.Data = append(result.Data, domain.SafeUser{
-			ID:        v.ID,
-			Name:      v.Name,
-			Email:     v.Email,
-			Profile:   v.Profile,
-			IsActive:  v.IsActive,
-			Role:      v.Role,
-			CreatedAt: v.CreatedAt,
-			UpdatedAt: v.UpdatedAt,
+		result.Data = append(result.Data, toSafeUser(v))
+	}
+
+	return result, nil
+}
+
+// Get returns a user with their templates and guest counts
+func (p *UserUsecase) Get(ctx context.Context, id string) (UserDetail, error) {
+	var result UserDetail
+
+	if err := Authorize(ctx, PermUserList); err != nil {
+		return result, err
+	}
+
+	user, err := p.uc.GetUserByID(ctx, id)
+	if err != nil {
+		return result, errtrace.Wrap(err)
+	}
+	result.User = toSafeUser(*user)
+
+	templates, err := p.templates.List(ctx, id, 0, maxUserDetailTemplates)
+	if err != nil {
+		return result, errtrace.Wrap(err)
+	}
+
+	counts, err := p.guests.CountByUser(ctx, id)
+	if err != nil {
+		return result, errtrace.Wrap(err)
+	}
+
+	result.Templates = make([]UserTemplateStat, 0, len(templates.Data))
+	for _, v := range templates.Data {
+		result.Templates = append(result.Templates, UserTemplateStat{
+			Template:   v,
+			GuestCount: counts[v.ID],
 		})
+		result.GuestCount += counts[v.ID]
 	}
 
 	return result, nil
 }
+
+// UpdateState activates or deactivates a user, deactivated users are signed
+// out of every session.
+func (p *UserUsecase) UpdateState(ctx context.Context, id string, isActive bool) error {
+	if err := p.authorizeManage(ctx, id); err != nil {
+		return err
+	}
+
+	if _, err := p.uc.GetUserByID(ctx, id); err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	if err := p.uc.UpdateUserState(ctx, id, isActive); err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	if !isActive {
+		return errtrace.Wrap(p.tokens.RevokeUser(ctx, id))
+	}
+
+	return nil
+}
+
+// ChangeRole promotes or demotes a user. The role is part of the access token
+// so the user's sessions are revoked and they have to log in again.
+func (p *UserUsecase) ChangeRole(ctx context.Context, id string, role domain.RoleType) error {
+	if role != domain.RoleAdmin && role != domain.RoleUser {
+		return errtrace.Wrap(ErrInvalidRole)
+	}
+
+	if err := p.authorizeManage(ctx, id); err != nil {
+		return err
+	}
+
+	if err := p.uc.UpdateRole(ctx, id, role); err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	return errtrace.Wrap(p.tokens.RevokeUser(ctx, id))
+}
+
+// Unlock lifts a login lockout of the user before it expires
+func (p *UserUsecase) Unlock(ctx context.Context, id string) error {
+	if err := Authorize(ctx, PermUserManage); err != nil {
+		return err
+	}
+
+	user, err := p.uc.GetUserByID(ctx, id)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	return errtrace.Wrap(p.guard.Unlock(ctx, toSafeUser(*user)))
+}
+
+// authorizeManage checks the manage permission and prevents admins from
+// locking themselves out, changing another account is destructive.
+func (p *UserUsecase) authorizeManage(ctx context.Context, id string) error {
+	if err := Authorize(ctx, PermUserManage); err != nil {
+		return err
+	}
+
+	if err := AuthorizeDestructive(ctx); err != nil {
+		return err
+	}
+
+	claims, err := GetClaimFromContext(ctx)
+	if err != nil {
+		return errtrace.Wrap(errors.New("invalid token claims"))
+	}
+
+	if claims.UserID == id {
+		return errtrace.Wrap(ErrCannotEditSelf)
+	}
+
+	return nil
+}
@@ -3,21 +3,23 @@ package usecase
 import (
 	"context"
 	"errors"
-	"fmt"
 	"time"
 
 	"basic-service/domain"
 	"basic-service/interface/sql"
 
 	"braces.dev/errtrace"
+	"github.com/google/uuid"
 )
 
 type UserTemplate struct {
-	repo *sql.UserTemplateRepository
+	repo    *sql.UserTemplateRepository
+	users   *sql.UserRepository
+	members *sql.TemplateMemberRepository
 }
 
-func NewUserTemplate(repo *sql.UserTemplateRepository) *UserTemplate {
-	return &UserTemplate{repo: repo}
+func NewUserTemplate(repo *sql.UserTemplateRepository, users *sql.UserRepository, members *sql.TemplateMemberRepository) *UserTemplate {
+	return &UserTemplate{repo: repo, users: users, members: members}
 }
 
 type UserTemplateList struct {
@@ -28,19 +30,23 @@ type UserTemplateList struct {
 func (p *UserTemplate) List(ctx context.Context, page, limit int, userID string) (UserTemplateList, error) {
 	var result UserTemplateList
 
+	claims, err := GetClaimFromContext(ctx)
+	if err != nil {
+		return result, errtrace.Wrap(errors.New("invalid token claims"))
+	}
+
 	if userID == "" {
-		claims, err := GetClaimFromContext(ctx)
-		if err != nil {
-			return result, errtrace.Wrap(errors.New("invalid token claims"))
-		}
 		userID = claims.UserID
 	}
 
-	fmt.Println("User ID:", userID)
+	// listing someone else's templates is an admin capability
+	if userID != claims.UserID && !claims.Can(PermUserTemplateReadAny) {
+		return result, errtrace.Wrap(ErrForbidden)
+	}
 
 	offset := (page - 1) * limit
 	// Get total count of templates
-	total, err := p.repo.Count(ctx)
+	total, err := p.repo.Count(ctx, userID)
 	if err != nil {
 		return result, errtrace.Wrap(err)
 	}
@@ -57,6 +63,10 @@ func (p *UserTemplate) List(ctx context.Context, page, limit int, userID string)
 }
 
 func (p *UserTemplate) Get(ctx context.Context, id string) (domain.UserTemplate, error) {
+	if _, err := authorizeTemplate(ctx, p.members, id, domain.TemplateRoleViewer); err != nil {
+		return domain.UserTemplate{}, err
+	}
+
 	// First check if template exists
 	exists, err := p.repo.Exists(ctx, id)
 	if err != nil {
@@ -75,20 +85,60 @@ func (p *UserTemplate) Get(ctx context.Context, id string) (domain.UserTemplate,
 	return template, nil
 }
 
+// CanCreate returns ErrEmailNotVerified when the current user has not
+// confirmed their email yet, handlers call it before accepting uploads.
+func (p *UserTemplate) CanCreate(ctx context.Context) error {
+	claims, err := GetClaimFromContext(ctx)
+	if err != nil {
+		return errtrace.Wrap(errors.New("invalid token claims"))
+	}
+
+	user, err := p.users.GetUserByID(ctx, claims.UserID)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	if user.VerifiedAt == nil {
+		return errtrace.Wrap(ErrEmailNotVerified)
+	}
+
+	return nil
+}
+
 func (p *UserTemplate) Create(ctx context.Context, data domain.UserTemplate) error {
 	claims, err := GetClaimFromContext(ctx)
 	if err != nil {
 		return errtrace.Wrap(errors.New("invalid token claims"))
 	}
+
+	if err := p.CanCreate(ctx); err != nil {
+		return err
+	}
+
+	if data.RSVPDeadline != nil && !data.ExpireAt.IsZero() && data.RSVPDeadline.After(data.ExpireAt) {
+		return errtrace.Wrap(ErrRSVPDeadlineAfterEnd)
+	}
+
 	now := time.Now()
 	data.UserID = claims.UserID
 	data.CreatedAt = now
 	data.UpdatedAt = now
 
-	return errtrace.Wrap(p.repo.Create(ctx, data))
+	// the creator is the first owner, co-hosts join by invitation
+	return errtrace.Wrap(p.repo.Create(ctx, data, domain.TemplateMember{
+		ID:             uuid.New().String(),
+		UserTemplateID: data.ID,
+		UserID:         claims.UserID,
+		Role:           domain.TemplateRoleOwner,
+		CreatedAt:      now,
+	}))
 }
 
 func (p *UserTemplate) Update(ctx context.Context, id string, data domain.UserTemplate) error {
+	if _, err := authorizeTemplate(ctx, p.members, id, domain.TemplateRoleEditor); err != nil {
+		return err
+	}
+
 	// First check if template exists
 	exists, err := p.repo.Exists(ctx, id)
 	if err != nil {
@@ -105,6 +155,14 @@ func (p *UserTemplate) Update(ctx context.Context, id string, data domain.UserTe
 }
 
 func (p *UserTemplate) Delete(ctx context.Context, id string) error {
+	if _, err := authorizeTemplate(ctx, p.members, id, domain.TemplateRoleOwner); err != nil {
+		return err
+	}
+
+	if err := AuthorizeDestructive(ctx); err != nil {
+		return err
+	}
+
 	// First check if template exists
 	exists, err := p.repo.Exists(ctx, id)
 	if err != nil {
@@ -0,0 +1,125 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"basic-service/domain"
+	"basic-service/interface/sql"
+)
+
+func TestUserSearch(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "admin", "admin@example.com", domain.RoleAdmin)
+	env.register(t, "budi", "budi@example.com", domain.RoleUser)
+	env.register(t, "siti", "siti@example.org", domain.RoleUser)
+	env.register(t, "percent", "100%@example.org", domain.RoleUser)
+	_, adminCtx := env.login(t, "admin@example.com")
+
+	if err := env.users().UpdateState(adminCtx, "siti", false); err != nil {
+		t.Fatalf("deactivate: %v", err)
+	}
+
+	active := true
+	tests := []struct {
+		name   string
+		filter sql.UserFilter
+		want   []string
+	}{
+		{"all by email", sql.UserFilter{SortBy: "email"}, []string{"percent", "admin", "budi", "siti"}},
+		{"query", sql.UserFilter{Query: "example.org", SortBy: "email"}, []string{"percent", "siti"}},
+		{"role", sql.UserFilter{Role: domain.RoleAdmin}, []string{"admin"}},
+		{"active", sql.UserFilter{IsActive: &active, Query: "example.org"}, []string{"percent"}},
+		{"query is not a pattern", sql.UserFilter{Query: "%"}, []string{"percent"}},
+		{"query underscore is not a wildcard", sql.UserFilter{Query: "b_di"}, nil},
+		{"descending", sql.UserFilter{SortBy: "name", Desc: true}, []string{"siti", "percent", "budi", "admin"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := env.users().List(adminCtx, tt.filter, 1, 10)
+			if err != nil {
+				t.Fatalf("list: %v", err)
+			}
+
+			got := make([]string, 0, len(result.Data))
+			for _, v := range result.Data {
+				got = append(got, v.ID)
+			}
+			if !slices.Equal(got, tt.want) || result.Total != len(tt.want) {
+				t.Errorf("got %v (total %d), want %v", got, result.Total, tt.want)
+			}
+		})
+	}
+}
+
+func TestChangeRole(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "admin", "admin@example.com", domain.RoleAdmin)
+	env.register(t, "u1", "u1@example.com", domain.RoleUser)
+	ctx := context.Background()
+
+	pair, userCtx := env.login(t, "u1@example.com")
+	_, adminCtx := env.login(t, "admin@example.com")
+
+	if err := env.users().ChangeRole(adminCtx, "u1", 7); !errors.Is(err, ErrInvalidRole) {
+		t.Errorf("unknown role: err = %v, want ErrInvalidRole", err)
+	}
+	if err := env.users().ChangeRole(userCtx, "u1", domain.RoleAdmin); !errors.Is(err, ErrForbidden) {
+		t.Errorf("as user: err = %v, want ErrForbidden", err)
+	}
+	if err := env.users().ChangeRole(adminCtx, "admin", domain.RoleUser); !errors.Is(err, ErrCannotEditSelf) {
+		t.Errorf("own role: err = %v, want ErrCannotEditSelf", err)
+	}
+	if err := env.users().UpdateState(adminCtx, "admin", false); !errors.Is(err, ErrCannotEditSelf) {
+		t.Errorf("own state: err = %v, want ErrCannotEditSelf", err)
+	}
+
+	if err := env.users().ChangeRole(adminCtx, "u1", domain.RoleAdmin); err != nil {
+		t.Fatalf("promote: %v", err)
+	}
+
+	// the role is in the token, the user signs in again to get it
+	if _, err := env.auth.ValidateToken(ctx, pair.AccessToken); !errors.Is(err, ErrTokenRevoked) {
+		t.Errorf("old token: err = %v, want ErrTokenRevoked", err)
+	}
+	_, promotedCtx := env.login(t, "u1@example.com")
+	if err := Authorize(promotedCtx, PermUserManage); err != nil {
+		t.Errorf("promoted user: %v", err)
+	}
+}
+
+func TestUserDetail(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "admin", "admin@example.com", domain.RoleAdmin)
+	env.register(t, "u1", "u1@example.com", domain.RoleUser)
+	_, adminCtx := env.login(t, "admin@example.com")
+	_, userCtx := env.login(t, "u1@example.com")
+
+	env.createTemplate(t, userCtx, "t1")
+	env.createTemplate(t, userCtx, "t2")
+	env.createGuest(t, userCtx, domain.Guest{ID: "g1", UserTemplateID: "t1", Name: "Budi"})
+	env.createGuest(t, userCtx, domain.Guest{ID: "g2", UserTemplateID: "t1", Name: "Siti"})
+	env.createGuest(t, userCtx, domain.Guest{ID: "g3", UserTemplateID: "t2", Name: "Ani"})
+
+	if _, err := env.users().Get(userCtx, "u1"); !errors.Is(err, ErrForbidden) {
+		t.Errorf("as user: err = %v, want ErrForbidden", err)
+	}
+
+	detail, err := env.users().Get(adminCtx, "u1")
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+
+	if detail.User.Email != "u1@example.com" || detail.GuestCount != 3 || len(detail.Templates) != 2 {
+		t.Fatalf("detail = %+v, want u1 with 2 templates and 3 guests", detail)
+	}
+	for _, v := range detail.Templates {
+		want := map[string]int64{"t1": 2, "t2": 1}[v.Template.ID]
+		if v.GuestCount != want {
+			t.Errorf("template %s: %d guests, want %d", v.Template.ID, v.GuestCount, want)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"basic-service/domain"
+	"basic-service/interface/sql"
+	"basic-service/pkg/mailer"
+
+	"braces.dev/errtrace"
+)
+
+// verificationResendInterval is the minimum time between two verification
+// emails to the same user.
+const verificationResendInterval = time.Minute
+
+var (
+	ErrEmailNotVerified       = errors.New("email address is not verified")
+	ErrEmailAlreadyVerified   = errors.New("email address is already verified")
+	ErrInvalidVerifyToken     = errors.New("verification token is invalid or expired")
+	ErrVerificationResendWait = errors.New("verification email was sent recently, try again later")
+)
+
+// SendVerification emails a verification link to the user.
+func (a *Auth) SendVerification(ctx context.Context, user *domain.User) error {
+	raw, err := a.issueUserToken(ctx, user.ID, domain.UserTokenEmailVerification, a.VerifyTokenTTL)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	link := fmt.Sprintf("%s/verify-email?token=%s", a.AppURL, url.QueryEscape(raw))
+
+	return errtrace.Wrap(a.Mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Confirm your email address",
+		Body: fmt.Sprintf("Hi %s,\n\nThanks for registering. Open the link below to confirm your "+
+			"email address, it is valid for %s:\n\n%s\n",
+			user.Name, a.VerifyTokenTTL, link),
+	}))
+}
+
+// VerifyEmail marks the owner of token as verified.
+func (a *Auth) VerifyEmail(ctx context.Context, token string) error {
+	userToken, err := a.UserTokens.Consume(ctx, domain.UserTokenEmailVerification, hashToken(token))
+	if err != nil {
+		if errors.Is(err, sql.ErrUserTokenInvalid) {
+			return errtrace.Wrap(ErrInvalidVerifyToken)
+		}
+		return errtrace.Wrap(err)
+	}
+
+	return errtrace.Wrap(a.UserManager.MarkVerified(ctx, userToken.UserID))
+}
+
+// ResendVerification sends a fresh verification link to the current user,
+// the previous link stops working.
+func (a *Auth) ResendVerification(ctx context.Context) error {
+	claims, err := GetClaimFromContext(ctx)
+	if err != nil {
+		return errtrace.Wrap(errors.New("invalid token claims"))
+	}
+
+	user, err := a.UserManager.GetUserByID(ctx, claims.UserID)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	if user.VerifiedAt != nil {
+		return errtrace.Wrap(ErrEmailAlreadyVerified)
+	}
+
+	last, err := a.UserTokens.LastIssuedAt(ctx, user.ID, domain.UserTokenEmailVerification)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	if last != nil && time.Since(*last) < verificationResendInterval {
+		return errtrace.Wrap(ErrVerificationResendWait)
+	}
+
+	return errtrace.Wrap(a.SendVerification(ctx, user))
+}
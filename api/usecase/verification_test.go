@@ -0,0 +1,56 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"basic-service/domain"
+)
+
+func TestRegisterRequiresVerification(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerUnverified(t, "u1", "u1@example.com", domain.RoleUser)
+	ctx := withClaims(context.Background(), "u1", domain.RoleUser)
+
+	templates := env.templates()
+	if err := templates.CanCreate(ctx); !errors.Is(err, ErrEmailNotVerified) {
+		t.Fatalf("before verification: err = %v, want ErrEmailNotVerified", err)
+	}
+
+	token := env.mail.lastToken(t, "u1@example.com")
+	if err := env.auth.VerifyEmail(ctx, token); err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+
+	if err := templates.CanCreate(ctx); err != nil {
+		t.Errorf("after verification: %v", err)
+	}
+	if err := env.auth.VerifyEmail(ctx, token); !errors.Is(err, ErrInvalidVerifyToken) {
+		t.Errorf("second use: err = %v, want ErrInvalidVerifyToken", err)
+	}
+}
+
+func TestResendVerification(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerUnverified(t, "u1", "u1@example.com", domain.RoleUser)
+	env.register(t, "u2", "u2@example.com", domain.RoleUser)
+
+	if err := env.auth.ResendVerification(withClaims(context.Background(), "u1", domain.RoleUser)); !errors.Is(err, ErrVerificationResendWait) {
+		t.Errorf("right after registering: err = %v, want ErrVerificationResendWait", err)
+	}
+	if err := env.auth.ResendVerification(withClaims(context.Background(), "u2", domain.RoleUser)); !errors.Is(err, ErrEmailAlreadyVerified) {
+		t.Errorf("verified user: err = %v, want ErrEmailAlreadyVerified", err)
+	}
+}
+
+func TestRegisterMailFailure(t *testing.T) {
+	env := newTestEnv(t)
+	env.mail.err = errors.New("smtp down")
+
+	// the account is created, the link can be sent again later
+	env.registerUnverified(t, "u1", "u1@example.com", domain.RoleUser)
+	if _, err := env.auth.UserManager.GetEmail(context.Background(), "u1@example.com"); err != nil {
+		t.Errorf("registered user: %v", err)
+	}
+}
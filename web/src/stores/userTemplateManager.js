@@ -103,5 +103,27 @@ export const useUserTemplateManagerStore = defineStore('userTemplateManager', {
         throw error
       }
     },
+    async setState(id, state) {
+      const authStore = useAuthUser()
+
+      const response = await fetch(`http://localhost:8085/private/user-templates/${id}/state`, {
+        method: 'PUT',
+        headers: {
+          'Authorization': `Bearer ${authStore.token}`,
+          'Content-Type': 'application/json',
+        },
+        body: JSON.stringify({ state }),
+      })
+
+      if (!response.ok) {
+        const errorData = await response.json().catch(() => ({}));
+        throw new Error(errorData.message || `HTTP error! status: ${response.status}`);
+      }
+
+      const data = await response.json()
+      const index = this.list.data.findIndex(e => e.id == id)
+      if (index >= 0) this.list.data[index] = { ...this.list.data[index], ...data }
+      return data
+    },
   }
 })